@@ -0,0 +1,100 @@
+package applications
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// HashRing assigns keys to nodes by consistent hashing. Each node is placed on
+// the ring at several virtual points so keys spread evenly and adding or
+// removing a node only moves the keys next to its points.
+type HashRing struct {
+	vnodes int
+	points []ringPoint // sorted by hash
+	nodes  map[string]struct{}
+}
+
+type ringPoint struct {
+	hash uint64
+	node string
+}
+
+// NewHashRing creates an empty ring that places each node at vnodes points.
+func NewHashRing(vnodes int) *HashRing {
+	if vnodes < 1 {
+		vnodes = 1
+	}
+	return &HashRing{
+		vnodes: vnodes,
+		nodes:  make(map[string]struct{}),
+	}
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	// fnv alone clusters similar keys such as "node#1" and "node#2", so finish with a mixer
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Add places a node on the ring. Adding a node twice has no effect.
+func (r *HashRing) Add(node string) {
+	if _, ok := r.nodes[node]; ok {
+		return
+	}
+	r.nodes[node] = struct{}{}
+	for i := 0; i < r.vnodes; i++ {
+		r.points = append(r.points, ringPoint{hash: hashKey(fmt.Sprintf("%s#%d", node, i)), node: node})
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash == r.points[j].hash {
+			return r.points[i].node < r.points[j].node
+		}
+		return r.points[i].hash < r.points[j].hash
+	})
+}
+
+// Remove takes a node off the ring.
+func (r *HashRing) Remove(node string) {
+	if _, ok := r.nodes[node]; !ok {
+		return
+	}
+	delete(r.nodes, node)
+	points := r.points[:0]
+	for _, point := range r.points {
+		if point.node != node {
+			points = append(points, point)
+		}
+	}
+	r.points = points
+}
+
+// Get returns the node that owns key, or "" if the ring is empty.
+func (r *HashRing) Get(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	hash := hashKey(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= hash })
+	if i == len(r.points) {
+		i = 0 // wrap around
+	}
+	return r.points[i].node
+}
+
+// Nodes returns the nodes on the ring in sorted order.
+func (r *HashRing) Nodes() []string {
+	nodes := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
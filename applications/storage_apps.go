@@ -1,30 +1,145 @@
 package applications
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"fmt"
+	"hash/crc32"
 	"log"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"cse190-welp/proto/mydatabase"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
 	ErrRecordNotFound    = errors.New("storage: item not found")
 	ErrInvalidDeviceType = errors.New("invalid device type")
+	ErrSnapshotTooOld    = errors.New("storage: snapshot version is older than retained history")
+	ErrChangesTruncated  = errors.New("storage: change is older than the retained change log")
+	ErrRecordCorrupted   = errors.New("storage: record failed its checksum")
+	ErrVersionConflict   = errors.New("storage: record is not at the expected version")
 )
 
-// EmulatedStorageApp is an in-memory emulated storage layer.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Checksum returns the CRC-32C of a record's key, deleted flag and value.
+func Checksum(record *mydatabase.DatabaseRecord) uint32 {
+	h := crc32.New(castagnoli)
+	var header [5]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(record.GetKey())))
+	if record.GetDeleted() {
+		header[4] = 1
+	}
+	h.Write(header[:])
+	h.Write([]byte(record.GetKey()))
+	h.Write(record.GetValue())
+	return h.Sum32()
+}
+
+// VerifyChecksum returns ErrRecordCorrupted if a record doesn't match its checksum.
+func VerifyChecksum(record *mydatabase.DatabaseRecord) error {
+	if Checksum(record) != record.GetChecksum() {
+		return ErrRecordCorrupted
+	}
+	return nil
+}
+
+// EmulatedStorageApp is an in-memory emulated storage layer. Every write is
+// assigned a commit version, and a bounded history of versions is kept per key
+// so reads can be served as of an earlier snapshot.
 type EmulatedStorageApp struct {
-	data    map[string]*mydatabase.DatabaseRecord
-	mu      sync.Mutex
-	dist    string
-	latency time.Duration
+	data         map[string]*versionChain
+	keys         []string // keys of data in sorted order, for scans
+	mu           sync.Mutex
+	dist         string
+	latency      time.Duration
+	version      uint64 // latest commit version
+	horizon      uint64 // oldest snapshot version that garbage collection still guarantees
+	historyLimit int    // maximum number of versions kept per key
+
+	changes       []*mydatabase.ChangeEvent // most recent changes, oldest first
+	changesWaiter chan struct{}             // closed and replaced when a change is committed
+}
+
+// versionChain holds the retained versions of one key, oldest first.
+type versionChain struct {
+	versions    []*mydatabase.DatabaseRecord
+	lostLive    bool                         // the newest dropped version held a value, so older snapshots can't be served
+	quarantined []*mydatabase.DatabaseRecord // versions taken out of service by Scrub, oldest first
+}
+
+// latest returns the newest version in the chain.
+func (c *versionChain) latest() *mydatabase.DatabaseRecord {
+	return c.versions[len(c.versions)-1]
+}
+
+// damaged reports whether the newest committed version is quarantined.
+func (c *versionChain) damaged() bool {
+	n := len(c.quarantined)
+	return n > 0 && (len(c.versions) == 0 || c.quarantined[n-1].Version > c.latest().Version)
+}
+
+// live reports whether the key currently holds a value. A damaged key counts
+// as live, since its newest version can't be trusted to be a tombstone.
+func (c *versionChain) live() bool {
+	return c.damaged() || len(c.versions) > 0 && !c.latest().Deleted
+}
+
+// current returns the version of the key's latest write, or zero if the key
+// doesn't hold a value.
+func (c *versionChain) current() uint64 {
+	if !c.live() {
+		return 0
+	}
+	if c.damaged() {
+		return c.quarantined[len(c.quarantined)-1].Version
+	}
+	return c.latest().Version
+}
+
+// at returns the value of the key as of snapshot. It returns
+// ErrRecordCorrupted if that version fails its checksum or is quarantined.
+func (c *versionChain) at(snapshot uint64) (*mydatabase.DatabaseRecord, error) {
+	var record *mydatabase.DatabaseRecord
+	for i := len(c.versions) - 1; i >= 0; i-- {
+		if c.versions[i].Version <= snapshot {
+			record = c.versions[i]
+			break
+		}
+	}
+	for _, bad := range c.quarantined {
+		if bad.Version <= snapshot && (record == nil || bad.Version > record.Version) {
+			return nil, ErrRecordCorrupted
+		}
+	}
+	switch {
+	case record == nil && c.lostLive:
+		return nil, ErrSnapshotTooOld
+	case record == nil || record.Deleted:
+		return nil, ErrRecordNotFound
+	}
+	if err := VerifyChecksum(record); err != nil {
+		return nil, err
+	}
+	return record, nil
 }
 
+const (
+	// DefaultHistoryLimit is the number of versions kept per key when none is configured.
+	DefaultHistoryLimit = 10
+
+	// ChangeLogLimit is the number of recent changes kept for change streams.
+	ChangeLogLimit = 10000
+)
+
 // NewEmulatedStorageApp creates a new instance of EmulatedStorage. DeviceType must be 'disk' or 'ssd'
 func NewEmulatedStorageApp(deviceType string) (*EmulatedStorageApp, error) {
 	log.Printf("device type: %v", deviceType)
@@ -33,6 +148,7 @@ func NewEmulatedStorageApp(deviceType string) (*EmulatedStorageApp, error) {
 		"ssd":   500,   // order of magnitude latency for consumer grade SSD
 		"disk":  5000,  // order of magnitude latency for commodity disk
 		"cloud": 50000, // order of magnitude latency for cloud storage service
+		"none":  0,     // no emulated latency, used by tests and tools
 	}
 	// TODO: add dist options
 
@@ -42,45 +158,460 @@ func NewEmulatedStorageApp(deviceType string) (*EmulatedStorageApp, error) {
 		return nil, ErrInvalidDeviceType
 	}
 	return &EmulatedStorageApp{
-		data:    make(map[string]*mydatabase.DatabaseRecord),
-		dist:    "uniform",
-		latency: time.Duration(latency) * time.Microsecond,
+		data:          make(map[string]*versionChain),
+		dist:          "uniform",
+		latency:       time.Duration(latency) * time.Microsecond,
+		historyLimit:  DefaultHistoryLimit,
+		changesWaiter: make(chan struct{}),
 	}, nil
 }
 
+// SetHistoryLimit sets the maximum number of versions kept per key. Values below one are ignored.
+func (s *EmulatedStorageApp) SetHistoryLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit > 0 {
+		s.historyLimit = limit
+	}
+}
+
 func (s *EmulatedStorageApp) sleep() {
 	time.Sleep(s.latency)
 }
 
+// Version returns the latest commit version.
+func (s *EmulatedStorageApp) Version() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.version
+}
+
+// Get returns the latest version of a record.
 func (s *EmulatedStorageApp) Get(key string) (*mydatabase.DatabaseRecord, bool) {
 	s.sleep()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	value, ok := s.data[key]
-	return value, ok
+	chain, ok := s.data[key]
+	if !ok {
+		return nil, false
+	}
+	record, err := chain.at(s.version)
+	return record, err == nil
 }
 
-func (s *EmulatedStorageApp) Set(record *mydatabase.DatabaseRecord) {
+// GetAt returns the record as it was at the given snapshot version, along with
+// the snapshot the read was served at. A zero snapshot reads the latest version.
+func (s *EmulatedStorageApp) GetAt(key string, snapshot uint64) (*mydatabase.DatabaseRecord, uint64, error) {
 	s.sleep()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.data[record.Key] = record
+	if snapshot == 0 || snapshot > s.version {
+		snapshot = s.version
+	}
+	if snapshot < s.horizon {
+		return nil, snapshot, ErrSnapshotTooOld
+	}
+
+	chain, ok := s.data[key]
+	if !ok {
+		return nil, snapshot, ErrRecordNotFound
+	}
+	record, err := chain.at(snapshot)
+	return record, snapshot, err
 }
 
-func (s *EmulatedStorageApp) Delete(key string) {
+// Set writes a new version of a record and returns its commit version.
+func (s *EmulatedStorageApp) Set(record *mydatabase.DatabaseRecord) uint64 {
+	return s.SetAt(record, time.Now())
+}
+
+// SetAt is Set with an explicit commit time, used when replicas must agree on it.
+func (s *EmulatedStorageApp) SetAt(record *mydatabase.DatabaseRecord, timestamp time.Time) uint64 {
 	s.sleep()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	delete(s.data, key)
+	return s.commit(record.Key, record.Value, false, timestamp)
 }
 
+// Delete writes a tombstone version for a key and returns its commit version.
+func (s *EmulatedStorageApp) Delete(key string) uint64 {
+	return s.DeleteAt(key, time.Now())
+}
+
+// DeleteAt is Delete with an explicit commit time, used when replicas must agree on it.
+func (s *EmulatedStorageApp) DeleteAt(key string, timestamp time.Time) uint64 {
+	s.sleep()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if chain, ok := s.data[key]; !ok || !chain.live() {
+		// nothing to delete, don't grow the history
+		return s.version
+	}
+	return s.commit(key, nil, true, timestamp)
+}
+
+// Batch applies several writes atomically and returns the commit version of
+// the last one. A write with an expected version only applies if the key's
+// latest version is that one, zero meaning the key must not exist; if any
+// expectation fails, nothing is written and an error wrapping
+// ErrVersionConflict is returned. Each write gets its own commit version, but
+// reads are never served at a version in the middle of a batch.
+func (s *EmulatedStorageApp) Batch(ops []*mydatabase.WriteOperation, timestamp time.Time) (uint64, error) {
+	s.sleep()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, op := range ops {
+		if op.ExpectedVersion == nil {
+			continue
+		}
+		key := op.GetRecord().GetKey()
+		var current uint64
+		if chain, ok := s.data[key]; ok {
+			current = chain.current()
+		}
+		if current != op.GetExpectedVersion() {
+			return s.version, fmt.Errorf("%w: operation %d expected %q at version %d, found %d", ErrVersionConflict, i, key, op.GetExpectedVersion(), current)
+		}
+	}
+	for _, op := range ops {
+		record := op.GetRecord()
+		if !record.GetDeleted() {
+			s.commit(record.GetKey(), record.GetValue(), false, timestamp)
+		} else if chain, ok := s.data[record.GetKey()]; ok && chain.live() {
+			s.commit(record.GetKey(), nil, true, timestamp)
+		}
+	}
+	return s.version, nil
+}
+
+// commit appends a new version to a key's chain. Caller must hold s.mu.
+func (s *EmulatedStorageApp) commit(key string, value []byte, deleted bool, timestamp time.Time) uint64 {
+	s.version++
+	version := &mydatabase.DatabaseRecord{
+		Key:       key,
+		Value:     value,
+		Version:   s.version,
+		Timestamp: timestamp.UnixNano(),
+		Deleted:   deleted,
+	}
+	version.Checksum = Checksum(version)
+
+	chain, ok := s.data[key]
+	if !ok {
+		chain = &versionChain{}
+		s.data[key] = chain
+		s.insertKey(key)
+	}
+	s.recordChange(chain, version)
+	chain.versions = append(chain.versions, version)
+	if drop := len(chain.versions) - s.historyLimit; drop > 0 {
+		chain.lostLive = !chain.versions[drop-1].Deleted
+		chain.versions = append([]*mydatabase.DatabaseRecord(nil), chain.versions[drop:]...)
+	}
+	return s.version
+}
+
+// recordChange appends a committed version to the change log and wakes
+// change stream readers. Caller must hold s.mu.
+func (s *EmulatedStorageApp) recordChange(chain *versionChain, version *mydatabase.DatabaseRecord) {
+	event := &mydatabase.ChangeEvent{
+		Sequence: version.Version,
+		Record:   version,
+	}
+	if version.Deleted {
+		event.Operation = mydatabase.ChangeEvent_DELETE
+	}
+	if previous, err := chain.at(version.Version - 1); err == nil {
+		event.Previous = previous
+	}
+	if len(s.changes) == ChangeLogLimit {
+		s.changes = s.changes[1:]
+	}
+	s.changes = append(s.changes, event)
+
+	close(s.changesWaiter)
+	s.changesWaiter = make(chan struct{})
+}
+
+// Changes returns up to limit retained changes starting at sequence from, and
+// a channel that is closed when further changes are committed. It returns
+// ErrChangesTruncated if changes starting at from are no longer retained.
+func (s *EmulatedStorageApp) Changes(from uint64, limit int) ([]*mydatabase.ChangeEvent, <-chan struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if from > s.version {
+		return nil, s.changesWaiter, nil
+	}
+	// Sequence numbers are contiguous, so the log is indexed by offset.
+	oldest := s.version + 1 - uint64(len(s.changes))
+	if from < oldest {
+		return nil, s.changesWaiter, ErrChangesTruncated
+	}
+	start := int(from - oldest)
+	end := len(s.changes)
+	if limit > 0 && end-start > limit {
+		end = start + limit
+	}
+	return append([]*mydatabase.ChangeEvent(nil), s.changes[start:end]...), s.changesWaiter, nil
+}
+
+// insertKey adds a new key to the sorted key index. Caller must hold s.mu.
+func (s *EmulatedStorageApp) insertKey(key string) {
+	i := sort.SearchStrings(s.keys, key)
+	s.keys = append(s.keys, "")
+	copy(s.keys[i+1:], s.keys[i:])
+	s.keys[i] = key
+}
+
+// removeKey drops a key from the sorted key index. Caller must hold s.mu.
+func (s *EmulatedStorageApp) removeKey(key string) {
+	i := sort.SearchStrings(s.keys, key)
+	if i < len(s.keys) && s.keys[i] == key {
+		s.keys = append(s.keys[:i], s.keys[i+1:]...)
+	}
+}
+
+// Scan returns up to limit records (all if limit is not positive) whose keys start with prefix and sort after
+// startAfter, as of the given snapshot version (zero scans the latest). It also
+// returns the key to resume after, which is empty once the scan is complete,
+// and the snapshot the scan was served at. Corrupt records are skipped.
+func (s *EmulatedStorageApp) Scan(prefix, startAfter string, limit int, snapshot uint64) ([]*mydatabase.DatabaseRecord, string, uint64, error) {
+	s.sleep()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snapshot == 0 || snapshot > s.version {
+		snapshot = s.version
+	}
+	if snapshot < s.horizon {
+		return nil, "", snapshot, ErrSnapshotTooOld
+	}
+
+	start := startAfter
+	if prefix > start {
+		start = prefix
+	}
+	var records []*mydatabase.DatabaseRecord
+	for i := sort.SearchStrings(s.keys, start); i < len(s.keys); i++ {
+		key := s.keys[i]
+		if key == startAfter {
+			continue
+		}
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+		if limit > 0 && len(records) == limit {
+			return records, records[len(records)-1].Key, snapshot, nil
+		}
+		record, err := s.data[key].at(snapshot)
+		switch err {
+		case nil:
+			records = append(records, record)
+		case ErrSnapshotTooOld:
+			return nil, "", snapshot, err
+		case ErrRecordCorrupted:
+			log.Printf("storage: skipping corrupt record %q in scan", key)
+		}
+	}
+	return records, "", snapshot, nil
+}
+
+// History returns up to limit retained versions of a key, newest first.
+// A limit of zero returns every retained version.
+func (s *EmulatedStorageApp) History(key string, limit int) []*mydatabase.DatabaseRecord {
+	s.sleep()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chain, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	history := make([]*mydatabase.DatabaseRecord, 0, len(chain.versions))
+	for i := len(chain.versions) - 1; i >= 0; i-- {
+		if limit > 0 && len(history) == limit {
+			break
+		}
+		history = append(history, chain.versions[i])
+	}
+	return history
+}
+
+// CollectGarbage drops versions that were superseded before cutoff. For every
+// key the newest version committed before cutoff is kept so that snapshots
+// taken after cutoff can still be read; keys whose only old version is a
+// tombstone are removed entirely. It returns the number of versions dropped.
+func (s *EmulatedStorageApp) CollectGarbage(cutoff time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoffNanos := cutoff.UnixNano()
+	dropped := 0
+	for key, chain := range s.data {
+		// index of the newest version committed before cutoff
+		base := -1
+		for i, version := range chain.versions {
+			if version.Timestamp >= cutoffNanos {
+				break
+			}
+			base = i
+			if version.Version > s.horizon {
+				s.horizon = version.Version
+			}
+		}
+		if base < 0 {
+			continue
+		}
+		if chain.versions[base].Deleted {
+			base++
+		}
+		if base == 0 {
+			continue
+		}
+		dropped += base
+		if base == len(chain.versions) {
+			delete(s.data, key)
+			s.removeKey(key)
+			continue
+		}
+		chain.lostLive = !chain.versions[base-1].Deleted
+		chain.versions = append([]*mydatabase.DatabaseRecord(nil), chain.versions[base:]...)
+
+		// Snapshots that would have read a quarantined version before the
+		// oldest one retained are now too old anyway.
+		for len(chain.quarantined) > 0 && chain.quarantined[0].Version < chain.versions[0].Version {
+			chain.quarantined = chain.quarantined[1:]
+		}
+	}
+	return dropped
+}
+
+// Scrub verifies the checksum of every retained version and quarantines the
+// versions that fail. Quarantined versions are never served again: reads that
+// would have returned one fail with ErrRecordCorrupted until the key is
+// written again. Scrub returns the number of versions checked, the versions it
+// quarantined, and the keys whose latest version is quarantined.
+func (s *EmulatedStorageApp) Scrub() (int, []*mydatabase.DatabaseRecord, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checked := 0
+	var quarantined []*mydatabase.DatabaseRecord
+	var damaged []string
+	for _, key := range s.keys {
+		chain := s.data[key]
+		good := chain.versions[:0:0]
+		for _, version := range chain.versions {
+			checked++
+			if VerifyChecksum(version) == nil && version.Key == key {
+				good = append(good, version)
+				continue
+			}
+			if version.Key != key {
+				// keep it filed under the key it was stored at
+				version = proto.Clone(version).(*mydatabase.DatabaseRecord)
+				version.Key = key
+			}
+			quarantined = append(quarantined, version)
+			chain.quarantined = append(chain.quarantined, version)
+		}
+		if len(good) < len(chain.versions) {
+			chain.versions = good
+			sort.Slice(chain.quarantined, func(i, j int) bool {
+				return chain.quarantined[i].Version < chain.quarantined[j].Version
+			})
+		}
+		if chain.damaged() {
+			damaged = append(damaged, key)
+		}
+	}
+	return checked, quarantined, damaged
+}
+
+// Dump returns a copy of every retained version, for replica snapshots.
+func (s *EmulatedStorageApp) Dump() *mydatabase.StorageSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := &mydatabase.StorageSnapshot{
+		Version: s.version,
+		Horizon: s.horizon,
+	}
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		chain := s.data[key]
+		snapshot.Versions = append(snapshot.Versions, chain.versions...)
+		snapshot.Quarantined = append(snapshot.Quarantined, chain.quarantined...)
+		if chain.lostLive {
+			snapshot.LostLiveKeys = append(snapshot.LostLiveKeys, key)
+		}
+	}
+	return snapshot
+}
+
+// Load replaces the contents of the store with a snapshot taken by Dump.
+func (s *EmulatedStorageApp) Load(snapshot *mydatabase.StorageSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = make(map[string]*versionChain)
+	s.keys = nil
+	for _, version := range snapshot.Versions {
+		chain, ok := s.data[version.Key]
+		if !ok {
+			chain = &versionChain{}
+			s.data[version.Key] = chain
+			s.keys = append(s.keys, version.Key) // Dump emits keys in order
+		}
+		chain.versions = append(chain.versions, version)
+	}
+	for _, version := range snapshot.Quarantined {
+		chain, ok := s.data[version.Key]
+		if !ok {
+			chain = &versionChain{}
+			s.data[version.Key] = chain
+			s.insertKey(version.Key)
+		}
+		chain.quarantined = append(chain.quarantined, version)
+	}
+	for _, key := range snapshot.LostLiveKeys {
+		if chain, ok := s.data[key]; ok {
+			chain.lostLive = true
+		}
+	}
+	s.version = snapshot.Version
+	s.horizon = snapshot.Horizon
+
+	// Changes before the snapshot are gone; readers behind it must resync.
+	s.changes = nil
+	close(s.changesWaiter)
+	s.changesWaiter = make(chan struct{})
+}
+
+// PersistentStorageApp keeps the latest version of each record in memory and
+// in a JSON Lines file, one record per line. Writes append a line, so the last
+// line for a key wins; the file is compacted when it is loaded.
+//
+// Lines that can't be decoded or fail their checksum, such as a line cut short
+// by a crash, are moved to a quarantine file next to the store instead of
+// failing the whole load.
 type PersistentStorageApp struct {
-	data      map[string]*mydatabase.DatabaseRecord
-	dataMutex sync.RWMutex
-	filePath  string
+	data        map[string]*mydatabase.DatabaseRecord
+	dataMutex   sync.RWMutex
+	filePath    string
+	quarantined int // corrupt lines found by the last load
 }
 
 func NewPersistentStorageApp(filePath string) (*PersistentStorageApp, error) {
@@ -97,6 +628,11 @@ func NewPersistentStorageApp(filePath string) (*PersistentStorageApp, error) {
 	return kvs, nil
 }
 
+// QuarantinePath returns the file corrupt lines of the store at filePath are moved to.
+func QuarantinePath(filePath string) string {
+	return filePath + ".quarantine"
+}
+
 func (s *PersistentStorageApp) Get(key string) (*mydatabase.DatabaseRecord, bool) {
 	s.dataMutex.RLock()
 	defer s.dataMutex.RUnlock()
@@ -105,57 +641,139 @@ func (s *PersistentStorageApp) Get(key string) (*mydatabase.DatabaseRecord, bool
 	return record, ok
 }
 
+// Records returns every record in key order.
+func (s *PersistentStorageApp) Records() []*mydatabase.DatabaseRecord {
+	s.dataMutex.RLock()
+	defer s.dataMutex.RUnlock()
+
+	records := make([]*mydatabase.DatabaseRecord, 0, len(s.data))
+	for _, key := range sortedKeys(s.data) {
+		records = append(records, s.data[key])
+	}
+	return records
+}
+
+// Quarantined returns the number of corrupt lines moved aside when the store was loaded.
+func (s *PersistentStorageApp) Quarantined() int {
+	s.dataMutex.RLock()
+	defer s.dataMutex.RUnlock()
+
+	return s.quarantined
+}
+
 func (kvs *PersistentStorageApp) Set(record *mydatabase.DatabaseRecord) {
 	kvs.dataMutex.Lock()
 	defer kvs.dataMutex.Unlock()
 
+	record = proto.Clone(record).(*mydatabase.DatabaseRecord)
+	record.Checksum = Checksum(record)
 	kvs.data[record.Key] = record
 
-	err := kvs.saveToFile()
+	err := kvs.appendToFile(record)
 	if err != nil {
 		log.Println("Error saving key-value store to file:", err)
 	}
 }
 
+// loadFromFile reads the store and compacts it. A store written before
+// records were kept one per line, as a single JSON object mapping keys to
+// records, is migrated, and its records, written before records had
+// checksums, are given one. A line without a checksum is quarantined.
 func (kvs *PersistentStorageApp) loadFromFile() error {
-	_, err := os.Stat(kvs.filePath)
-	if os.IsNotExist(err) {
-		// Create the file if it doesn't exist
-		_, err := os.Create(kvs.filePath)
-		if err != nil {
-			return err
+	data, err := os.ReadFile(kvs.filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	legacy := make(map[string]*mydatabase.DatabaseRecord)
+	if len(bytes.TrimSpace(data)) > 0 && json.Unmarshal(data, &legacy) == nil {
+		log.Printf("migrating %d records of %s to one record per line", len(legacy), kvs.filePath)
+		for key, record := range legacy {
+			if record == nil {
+				continue
+			}
+			record.Key = key
+			record.Checksum = Checksum(record)
+			kvs.data[key] = record
 		}
+		return kvs.saveToFile()
 	}
 
-	data, err := ioutil.ReadFile(kvs.filePath)
-	if err != nil {
+	var corrupt [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		record := &mydatabase.DatabaseRecord{}
+		if err := json.Unmarshal(line, record); err != nil || VerifyChecksum(record) != nil {
+			corrupt = append(corrupt, append([]byte(nil), line...))
+			continue
+		}
+		kvs.data[record.Key] = record
+	}
+	if err := scanner.Err(); err != nil {
 		return err
 	}
 
-	if len(data) == 0 {
-		// File is empty, initialize kvs.data as an empty map
-		kvs.data = make(map[string]*mydatabase.DatabaseRecord)
-		return nil
+	kvs.quarantined = len(corrupt)
+	if len(corrupt) > 0 {
+		log.Printf("quarantining %d corrupt records from %s", len(corrupt), kvs.filePath)
+		if err := appendLines(QuarantinePath(kvs.filePath), corrupt); err != nil {
+			return err
+		}
 	}
+	return kvs.saveToFile()
+}
 
-	err = json.Unmarshal(data, &kvs.data)
-	if err != nil {
-		return err
+// saveToFile rewrites the file with one line per record, replacing it atomically.
+func (kvs *PersistentStorageApp) saveToFile() error {
+	var buf bytes.Buffer
+	for _, key := range sortedKeys(kvs.data) {
+		data, err := json.Marshal(kvs.data[key])
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
 	}
 
-	return nil
+	tmp := kvs.filePath + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, kvs.filePath)
 }
 
-func (kvs *PersistentStorageApp) saveToFile() error {
-	data, err := json.Marshal(kvs.data)
+func (kvs *PersistentStorageApp) appendToFile(record *mydatabase.DatabaseRecord) error {
+	data, err := json.Marshal(record)
 	if err != nil {
 		return err
 	}
+	return appendLines(kvs.filePath, [][]byte{data})
+}
 
-	err = ioutil.WriteFile(kvs.filePath, data, 0644)
+func appendLines(path string, lines [][]byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
 	if err != nil {
 		return err
 	}
+	for _, line := range lines {
+		if _, err := file.Write(append(line, '\n')); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
 
-	return nil
+func sortedKeys(data map[string]*mydatabase.DatabaseRecord) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
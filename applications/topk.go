@@ -0,0 +1,340 @@
+package applications
+
+import (
+	"container/heap"
+	"log"
+	"sync"
+)
+
+// RankedCount is one entry of a top-K answer.
+type RankedCount struct {
+	Name  string
+	Count int64
+	// Error is how much Count may exceed the true count; always 0 when exact.
+	Error int64
+}
+
+// TopK tracks a count per name and answers top-K queries in O(K log K)
+// without sorting every name. Ties are broken by name, so equal inputs give equal answers.
+type TopK interface {
+	// Add adds delta, which may be negative, to the count of name.
+	Add(name string, delta int64)
+
+	// Top returns up to k names by descending count.
+	Top(k int) []RankedCount
+
+	// ErrorBound returns how much any count reported by Top may exceed the
+	// true count, and how high the true count of an unreported name may be.
+	ErrorBound() int64
+
+	// Reset forgets every count.
+	Reset()
+}
+
+// NewTopKApp returns an exact TopK when capacity is 0, and otherwise an
+// approximate one that tracks at most capacity names.
+func NewTopKApp(capacity int) TopK {
+	if capacity <= 0 {
+		log.Println("top-k: exact")
+		return NewExactTopKApp()
+	}
+	log.Printf("top-k: space-saving with capacity %d", capacity)
+	return NewSpaceSavingTopKApp(capacity)
+}
+
+// ranking keeps entries in two heaps with the position of each entry in
+// both: one ranks by descending count, then ascending name, and the other
+// the reverse. Changing a count, adding or removing an entry takes O(log n);
+// the top K are found in O(K log K) and the lowest entry in O(1).
+type ranking struct {
+	highest rankHeap
+	lowest  rankHeap
+	index   map[string]*rankedEntry
+}
+
+// rankedEntry is a count and its position in each heap of a ranking.
+type rankedEntry struct {
+	RankedCount
+	position [2]int // indexed by rankHeap.side
+}
+
+// Sides of a ranking, as rankHeap.side.
+const (
+	highestFirst = iota
+	lowestFirst
+)
+
+// rankHeap is one heap of a ranking. It implements heap.Interface, keeping
+// each entry's position for its side up to date.
+type rankHeap struct {
+	entries []*rankedEntry
+	side    int
+}
+
+func (h *rankHeap) Len() int { return len(h.entries) }
+
+func (h *rankHeap) Less(i, j int) bool {
+	if h.side == lowestFirst {
+		i, j = j, i
+	}
+	return before(&h.entries[i].RankedCount, &h.entries[j].RankedCount)
+}
+
+func (h *rankHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.entries[i].position[h.side] = i
+	h.entries[j].position[h.side] = j
+}
+
+func (h *rankHeap) Push(x any) {
+	entry := x.(*rankedEntry)
+	entry.position[h.side] = len(h.entries)
+	h.entries = append(h.entries, entry)
+}
+
+func (h *rankHeap) Pop() any {
+	last := len(h.entries) - 1
+	entry := h.entries[last]
+	h.entries[last] = nil
+	h.entries = h.entries[:last]
+	return entry
+}
+
+func newRanking() ranking {
+	return ranking{
+		highest: rankHeap{side: highestFirst},
+		lowest:  rankHeap{side: lowestFirst},
+		index:   make(map[string]*rankedEntry),
+	}
+}
+
+// before reports whether a ranks ahead of b.
+func before(a, b *RankedCount) bool {
+	if a.Count != b.Count {
+		return a.Count > b.Count
+	}
+	return a.Name < b.Name
+}
+
+// len returns the number of entries.
+func (r *ranking) len() int {
+	return len(r.index)
+}
+
+// get returns the entry for name, or nil.
+func (r *ranking) get(name string) *rankedEntry {
+	return r.index[name]
+}
+
+// insert adds a new entry.
+func (r *ranking) insert(count RankedCount) {
+	entry := &rankedEntry{RankedCount: count}
+	r.index[entry.Name] = entry
+	heap.Push(&r.highest, entry)
+	heap.Push(&r.lowest, entry)
+}
+
+// remove deletes the entry for name.
+func (r *ranking) remove(name string) {
+	entry, ok := r.index[name]
+	if !ok {
+		return
+	}
+	delete(r.index, name)
+	heap.Remove(&r.highest, entry.position[highestFirst])
+	heap.Remove(&r.lowest, entry.position[lowestFirst])
+}
+
+// fix moves an entry whose count changed back into order.
+func (r *ranking) fix(entry *rankedEntry) {
+	heap.Fix(&r.highest, entry.position[highestFirst])
+	heap.Fix(&r.lowest, entry.position[lowestFirst])
+}
+
+// last returns the entry ranked last, or nil if there is none.
+func (r *ranking) last() *rankedEntry {
+	if len(r.lowest.entries) == 0 {
+		return nil
+	}
+	return r.lowest.entries[0]
+}
+
+// frontier holds the positions in a highest-first heap of the entries that
+// may be ranked next while top walks the heap: the children of those already
+// taken. It is itself a heap, ordered as the entries are.
+type frontier struct {
+	heap      *rankHeap
+	positions []int
+}
+
+func (f *frontier) Len() int { return len(f.positions) }
+
+func (f *frontier) Less(i, j int) bool {
+	return f.heap.Less(f.positions[i], f.positions[j])
+}
+
+func (f *frontier) Swap(i, j int) { f.positions[i], f.positions[j] = f.positions[j], f.positions[i] }
+
+func (f *frontier) Push(x any) { f.positions = append(f.positions, x.(int)) }
+
+func (f *frontier) Pop() any {
+	last := len(f.positions) - 1
+	position := f.positions[last]
+	f.positions = f.positions[:last]
+	return position
+}
+
+// top returns copies of the first k entries, taking each from those whose
+// parent in the heap has already been taken.
+func (r *ranking) top(k int) []RankedCount {
+	if k > r.len() {
+		k = r.len()
+	}
+	if k <= 0 {
+		return []RankedCount{}
+	}
+	result := make([]RankedCount, 0, k)
+	next := &frontier{heap: &r.highest, positions: []int{0}}
+	for len(result) < k {
+		i := heap.Pop(next).(int)
+		result = append(result, r.highest.entries[i].RankedCount)
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(r.highest.entries) {
+				heap.Push(next, child)
+			}
+		}
+	}
+	return result
+}
+
+// ExactTopKApp counts every name exactly.
+type ExactTopKApp struct {
+	ranking
+	lock sync.Mutex
+}
+
+// NewExactTopKApp returns an empty exact TopK.
+func NewExactTopKApp() *ExactTopKApp {
+	return &ExactTopKApp{ranking: newRanking()}
+}
+
+// Add adds delta to the count of name. Names whose count drops to zero or
+// below are forgotten.
+func (t *ExactTopKApp) Add(name string, delta int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	entry := t.get(name)
+	switch {
+	case entry == nil && delta > 0:
+		t.insert(RankedCount{Name: name, Count: delta})
+	case entry == nil:
+	case entry.Count+delta <= 0:
+		t.remove(name)
+	default:
+		entry.Count += delta
+		t.fix(entry)
+	}
+}
+
+// Top returns up to k names by descending count.
+func (t *ExactTopKApp) Top(k int) []RankedCount {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.top(k)
+}
+
+// ErrorBound is always 0.
+func (t *ExactTopKApp) ErrorBound() int64 {
+	return 0
+}
+
+// Reset forgets every count.
+func (t *ExactTopKApp) Reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.ranking = newRanking()
+}
+
+// SpaceSavingTopKApp approximates the counts with the Space-Saving algorithm
+// (Metwally et al.), tracking at most capacity names in bounded memory. When
+// an untracked name arrives and every slot is taken, it replaces the name
+// with the lowest count and inherits that count as its possible error.
+//
+// For increments totalling N, every reported count is at most N/capacity
+// above the true count, and every name whose true count exceeds
+// N/capacity is tracked. Decrements are applied only to tracked names, so
+// the bound is looser for streams that contain them.
+type SpaceSavingTopKApp struct {
+	ranking
+	capacity int
+	maxError int64 // largest error any name was given
+	lock     sync.Mutex
+}
+
+// NewSpaceSavingTopKApp returns an empty approximate TopK tracking at most
+// capacity names.
+func NewSpaceSavingTopKApp(capacity int) *SpaceSavingTopKApp {
+	return &SpaceSavingTopKApp{ranking: newRanking(), capacity: capacity}
+}
+
+// Add adds delta to the count of name, evicting the lowest-counted name if
+// name is new and there is no room for it.
+func (t *SpaceSavingTopKApp) Add(name string, delta int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	entry := t.get(name)
+	switch {
+	case entry != nil:
+		entry.Count += delta
+		if entry.Count <= 0 {
+			t.remove(name)
+			return
+		}
+		if entry.Error > entry.Count {
+			entry.Error = entry.Count
+		}
+		t.fix(entry)
+	case delta <= 0:
+	case t.len() < t.capacity:
+		t.insert(RankedCount{Name: name, Count: delta})
+	default:
+		min := t.last()
+		t.remove(min.Name)
+		t.insert(RankedCount{Name: name, Count: min.Count + delta, Error: min.Count})
+		if min.Count > t.maxError {
+			t.maxError = min.Count
+		}
+	}
+}
+
+// Top returns up to k names by descending estimated count.
+func (t *SpaceSavingTopKApp) Top(k int) []RankedCount {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.top(k)
+}
+
+// ErrorBound returns the largest error given out, or, once every slot is
+// taken, the lowest tracked count if that is larger. It bounds both the
+// overestimate of any tracked name and the true count of any untracked one,
+// including names evicted before a decrement freed their slot.
+func (t *SpaceSavingTopKApp) ErrorBound() int64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	bound := t.maxError
+	if t.len() < t.capacity {
+		return bound
+	}
+	if min := t.last().Count; min > bound {
+		bound = min
+	}
+	return bound
+}
+
+// Reset forgets every count.
+func (t *SpaceSavingTopKApp) Reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.ranking = newRanking()
+	t.maxError = 0
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"runtime"
+	"strings"
+	"time"
 
 	services "cse190-welp/services"
 )
@@ -36,9 +38,19 @@ func main() {
 
 		databasePort            = flag.Int("databaseport", 27017, "port used by all databases")
 		storageDeviceType       = flag.String("storage_device_type", "cloud", "specifies emulated storage device type, e.g. option `ssd`, `disk`, or `cloud`")
-		detailDatabaseAddr      = flag.String("detail_mydatabase_addr", "mydatabase-detail:27017", "details mydatabase address")
-		reviewDatabaseAddr      = flag.String("review_mydatabase_addr", "mydatabase-review:27017", "review mydatabase address")
-		reservationDatabaseAddr = flag.String("reservation_mydatabase_addr", "mydatabase-reservation:27017", "reservation mydatabase address")
+		detailDatabaseAddr      = flag.String("detail_mydatabase_addr", "mydatabase-detail:27017", "details mydatabase address; comma-separated replica addresses, or semicolon-separated shards")
+		reviewDatabaseAddr      = flag.String("review_mydatabase_addr", "mydatabase-review:27017", "review mydatabase address; comma-separated replica addresses, or semicolon-separated shards")
+		reservationDatabaseAddr = flag.String("reservation_mydatabase_addr", "mydatabase-reservation:27017", "reservation mydatabase address; comma-separated replica addresses, or semicolon-separated shards")
+		databaseHistoryLimit    = flag.Int("database_history_limit", 10, "maximum number of versions kept per key by every database")
+		databaseRetention       = flag.Duration("database_retention", 24*time.Hour, "how long superseded record versions are kept before garbage collection")
+		databaseRaftAddr        = flag.String("database_raft_addr", "", "address other replicas use to reach this database replica; enables Raft replication")
+		databaseRaftPeers       = flag.String("database_raft_peers", "", "comma-separated addresses of every replica in the initial database cluster; empty when joining an existing cluster")
+		databaseRaftDir         = flag.String("database_raft_dir", "", "directory a database replica keeps its Raft term, vote and log in across restarts; empty keeps them in memory")
+
+		scrubRepairFrom    = flag.String("scrub_repair_from", "", "backup file or database address that `scrub` copies damaged records from; empty only reports them")
+		popularityCapacity = flag.Int("reservation_popularity_capacity", 0, "number of restaurants the reservation service ranks by popularity in bounded memory, with approximate counts; 0 ranks every restaurant exactly")
+		invalidatorMode    = flag.String("invalidator_mode", "delete", "how invalidators handle a changed key: option `delete` or `refresh` (only keys already cached)")
+		reviewModeration   = flag.String("review_moderation", "length,banned-words,rate-limit,near-duplicate", "comma-separated checks that hold submitted reviews for moderation: options `length`, `banned-words`, `rate-limit` and `near-duplicate`; empty publishes every review")
 	)
 
 	// Limit to 1 thread
@@ -47,8 +59,33 @@ func main() {
 	// Parse the flags
 	flag.Parse()
 
+	// Flags come before the subcommands, e.g. `--storage_device_type=ssd review database`
 	var srv server
-	var cmd = os.Args[1]
+	var args = flag.Args()
+	if len(args) == 0 {
+		log.Fatalf("usage: %s [flags] <cmd> [subcmd]", os.Args[0])
+	}
+	var cmd = args[0]
+
+	// newDatabase creates a standalone database, or a Raft replica when replication is configured
+	newDatabase := func(serverName string) server {
+		if *databaseRaftAddr == "" {
+			return services.NewMyDatabase(serverName, *databasePort, *storageDeviceType, *databaseHistoryLimit, *databaseRetention)
+		}
+		var peers []string
+		if *databaseRaftPeers != "" {
+			peers = strings.Split(*databaseRaftPeers, ",")
+		}
+		return services.NewReplicatedMyDatabase(serverName, *databasePort, *storageDeviceType, *databaseHistoryLimit, *databaseRetention, *databaseRaftAddr, peers, *databaseRaftDir)
+	}
+
+	// newInvalidator creates an invalidator that keeps a cache in line with its database
+	newInvalidator := func(name string, cacheAddr string, databaseAddr string) server {
+		if *invalidatorMode != "delete" && *invalidatorMode != "refresh" {
+			log.Fatalf("unknown invalidator mode: %s", *invalidatorMode)
+		}
+		return services.NewInvalidator(name, cacheAddr, databaseAddr, *invalidatorMode == "refresh")
+	}
 
 	// Switch statement to create the correct service based on the command
 	switch cmd {
@@ -62,79 +99,89 @@ func main() {
 		)
 	case "detail":
 		switch {
-		case len(os.Args) < 3:
+		case len(args) < 2:
 			// Create a new detail service with the specified port
 			srv = services.NewDetail(
 				"detail",
 				*detailPort,
 				*detailCacheAddr,
 				*detailDatabaseAddr,
+				*reviewAddr,
+				*reservationAddr,
 			)
-		case os.Args[2] == "cache":
+		case args[1] == "cache":
 			srv = services.NewMyCache(
 				"detail-cache",
 				*cachePort,
 				*detailCacheCapacity,
 			)
-		case os.Args[2] == "database":
-			srv = services.NewMyDatabase(
-				"detail-database",
-				*databasePort,
-				*storageDeviceType,
-			)
+		case args[1] == "database":
+			srv = newDatabase("detail-database")
+		case args[1] == "invalidator":
+			srv = newInvalidator("detail-invalidator", *detailCacheAddr, *detailDatabaseAddr)
 		default:
-			log.Fatalf("unknown subcmd for detail service: %s", os.Args[2])
+			log.Fatalf("unknown subcmd for detail service: %s", args[1])
 		}
 	case "reservation":
 		switch {
-		case len(os.Args) < 3:
+		case len(args) < 2:
 			// Create a new reservation service with the specified port
 			srv = services.NewReservation(
 				"reservation",
 				*reservationsPort,
 				*reservationCacheAddr,
 				*reservationDatabaseAddr,
+				*detailAddr,
+				*popularityCapacity,
 			)
-		case os.Args[2] == "cache":
+		case args[1] == "cache":
 			srv = services.NewMyCache(
 				"reservation-cache",
 				*cachePort,
 				*reservationCacheCapacity,
 			)
-		case os.Args[2] == "database":
-			srv = services.NewMyDatabase(
-				"reservation-database",
-				*databasePort,
-				*storageDeviceType,
-			)
+		case args[1] == "database":
+			srv = newDatabase("reservation-database")
+		case args[1] == "invalidator":
+			srv = newInvalidator("reservation-invalidator", *reservationCacheAddr, *reservationDatabaseAddr)
 		default:
-			log.Fatalf("unknown subcmd for reservation service: %s", os.Args[2])
+			log.Fatalf("unknown subcmd for reservation service: %s", args[1])
 		}
 	case "review":
 		switch {
-		case len(os.Args) < 3:
+		case len(args) < 2:
 			// Create a new review service with the specified port
 			srv = services.NewReview(
 				"review",
 				*reviewPort,
 				*reviewCacheAddr,
 				*reviewDatabaseAddr,
+				*reviewModeration,
 			)
-		case os.Args[2] == "cache":
+		case args[1] == "cache":
 			srv = services.NewMyCache(
 				"review-cache",
 				*cachePort,
 				*reviewCacheCapacity,
 			)
-		case os.Args[2] == "database":
-			srv = services.NewMyDatabase(
-				"review-database",
-				*databasePort,
-				*storageDeviceType,
-			)
+		case args[1] == "database":
+			srv = newDatabase("review-database")
+		case args[1] == "invalidator":
+			srv = newInvalidator("review-invalidator", *reviewCacheAddr, *reviewDatabaseAddr)
 		default:
-			log.Fatalf("unknown subcmd for review service: %s", os.Args[2])
+			log.Fatalf("unknown subcmd for review service: %s", args[1])
+		}
+	case "scrub":
+		// Scrub a service's database once, e.g. `--scrub_repair_from=backup.jsonl scrub review`
+		databaseAddrs := map[string]string{
+			"detail":      *detailDatabaseAddr,
+			"reservation": *reservationDatabaseAddr,
+			"review":      *reviewDatabaseAddr,
+		}
+		if len(args) < 2 || databaseAddrs[args[1]] == "" {
+			log.Fatalf("usage: %s [flags] scrub detail|reservation|review", os.Args[0])
 		}
+		srv = services.NewScrubber(args[1]+"-scrubber", databaseAddrs[args[1]], *scrubRepairFrom)
 	default:
 		// If an unknown command is provided, log an error and exit
 		log.Fatalf("unknown cmd: %s", cmd)
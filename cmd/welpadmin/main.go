@@ -0,0 +1,96 @@
+// Command welpadmin exports and imports the data of the welp services.
+//
+//	welpadmin [flags] export detail|review|reservation
+//	welpadmin [flags] import detail|review|reservation
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	services "cse190-welp/services"
+)
+
+func main() {
+	var (
+		detailDatabaseAddr      = flag.String("detail_mydatabase_addr", "mydatabase-detail:27017", "details mydatabase address; comma-separated replica addresses, or semicolon-separated shards")
+		reviewDatabaseAddr      = flag.String("review_mydatabase_addr", "mydatabase-review:27017", "review mydatabase address; comma-separated replica addresses, or semicolon-separated shards")
+		reservationDatabaseAddr = flag.String("reservation_mydatabase_addr", "mydatabase-reservation:27017", "reservation mydatabase address; comma-separated replica addresses, or semicolon-separated shards")
+
+		reviewAddr      = flag.String("reviewaddr", "review:8082", "review service addr, rebuilt after an import")
+		reservationAddr = flag.String("reservationaddr", "reservation:8083", "reservation service addr, rebuilt after an import")
+
+		format  = flag.String("format", "jsonl", "file format: option `jsonl` or `csv`")
+		file    = flag.String("file", "-", "file to export to or import from; - for stdout or stdin")
+		workers = flag.Int("workers", 8, "number of parallel writers used by import")
+		rebuild = flag.Bool("rebuild", true, "rebuild the service's derived data after an import")
+	)
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) != 2 {
+		log.Fatalf("usage: %s [flags] export|import detail|review|reservation", os.Args[0])
+	}
+	cmd, store := args[0], args[1]
+
+	databaseAddrs := map[string]string{
+		"detail":      *detailDatabaseAddr,
+		"review":      *reviewDatabaseAddr,
+		"reservation": *reservationDatabaseAddr,
+	}
+	serviceAddrs := map[string]string{
+		"review":      *reviewAddr,
+		"reservation": *reservationAddr,
+	}
+	databaseAddr, ok := databaseAddrs[store]
+	if !ok {
+		log.Fatalf("unknown store: %s", store)
+	}
+
+	ctx := context.Background()
+	switch cmd {
+	case "export":
+		var w io.Writer = os.Stdout
+		if *file != "-" {
+			f, err := os.Create(*file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		count, err := services.ExportRecords(ctx, databaseAddr, store, *format, w)
+		if err != nil {
+			log.Fatalf("export %s failed after %d records: %v", store, count, err)
+		}
+		log.Printf("exported %d %s records", count, store)
+	case "import":
+		var r io.Reader = os.Stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			r = f
+		}
+		count, err := services.ImportRecords(ctx, databaseAddr, store, *format, r, *workers)
+		if err != nil {
+			log.Fatalf("import %s failed after %d records: %v", store, count, err)
+		}
+		log.Printf("imported %d %s records", count, store)
+
+		if addr, ok := serviceAddrs[store]; ok && *rebuild {
+			indexed, err := services.RebuildDerivedData(ctx, store, addr)
+			if err != nil {
+				log.Fatalf("rebuilding %s service at %s: %v", store, addr, err)
+			}
+			log.Printf("rebuilt %s service from %d records", store, indexed)
+		}
+	default:
+		log.Fatalf("unknown cmd: %s", cmd)
+	}
+}
@@ -13,6 +13,7 @@ package detail
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -30,10 +31,12 @@ type PostDetailRequest struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
-	Location       string `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
-	Style          string `protobuf:"bytes,3,opt,name=style,proto3" json:"style,omitempty"`
-	Capacity       int32  `protobuf:"varint,4,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	RestaurantName string          `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	Location       string          `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	Style          string          `protobuf:"bytes,3,opt,name=style,proto3" json:"style,omitempty"`
+	Capacity       int32           `protobuf:"varint,4,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	OpeningHours   []*OpeningHours `protobuf:"bytes,5,rep,name=opening_hours,json=openingHours,proto3" json:"opening_hours,omitempty"` // Open all day, every day, if empty
+	Coordinates    *Coordinates    `protobuf:"bytes,6,opt,name=coordinates,proto3" json:"coordinates,omitempty"`                       // Where the restaurant is, if known
 }
 
 func (x *PostDetailRequest) Reset() {
@@ -94,6 +97,136 @@ func (x *PostDetailRequest) GetCapacity() int32 {
 	return 0
 }
 
+func (x *PostDetailRequest) GetOpeningHours() []*OpeningHours {
+	if x != nil {
+		return x.OpeningHours
+	}
+	return nil
+}
+
+func (x *PostDetailRequest) GetCoordinates() *Coordinates {
+	if x != nil {
+		return x.Coordinates
+	}
+	return nil
+}
+
+// Coordinates is a point on the earth, in degrees.
+type Coordinates struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Latitude  float64 `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`   // -90 to 90
+	Longitude float64 `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"` // -180 to 180
+}
+
+func (x *Coordinates) Reset() {
+	*x = Coordinates{}
+	mi := &file_proto_detail_detail_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Coordinates) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Coordinates) ProtoMessage() {}
+
+func (x *Coordinates) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Coordinates.ProtoReflect.Descriptor instead.
+func (*Coordinates) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Coordinates) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *Coordinates) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+// OpeningHours is when a restaurant is open on one day of the week.
+type OpeningHours struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DayOfWeek int32 `protobuf:"varint,1,opt,name=day_of_week,json=dayOfWeek,proto3" json:"day_of_week,omitempty"` // 0 is Sunday, 6 is Saturday
+	OpensAt   int32 `protobuf:"varint,2,opt,name=opens_at,json=opensAt,proto3" json:"opens_at,omitempty"`         // Minutes after midnight
+	ClosesAt  int32 `protobuf:"varint,3,opt,name=closes_at,json=closesAt,proto3" json:"closes_at,omitempty"`      // Minutes after midnight, at most 1440
+}
+
+func (x *OpeningHours) Reset() {
+	*x = OpeningHours{}
+	mi := &file_proto_detail_detail_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpeningHours) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpeningHours) ProtoMessage() {}
+
+func (x *OpeningHours) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpeningHours.ProtoReflect.Descriptor instead.
+func (*OpeningHours) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OpeningHours) GetDayOfWeek() int32 {
+	if x != nil {
+		return x.DayOfWeek
+	}
+	return 0
+}
+
+func (x *OpeningHours) GetOpensAt() int32 {
+	if x != nil {
+		return x.OpensAt
+	}
+	return 0
+}
+
+func (x *OpeningHours) GetClosesAt() int32 {
+	if x != nil {
+		return x.ClosesAt
+	}
+	return 0
+}
+
 // PostDetailResponse is the response message for the PostDetail RPC method.
 // It indicates whether the operation was successful.
 type PostDetailResponse struct {
@@ -106,7 +239,7 @@ type PostDetailResponse struct {
 
 func (x *PostDetailResponse) Reset() {
 	*x = PostDetailResponse{}
-	mi := &file_proto_detail_detail_proto_msgTypes[1]
+	mi := &file_proto_detail_detail_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -118,7 +251,7 @@ func (x *PostDetailResponse) String() string {
 func (*PostDetailResponse) ProtoMessage() {}
 
 func (x *PostDetailResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_detail_detail_proto_msgTypes[1]
+	mi := &file_proto_detail_detail_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -131,7 +264,7 @@ func (x *PostDetailResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PostDetailResponse.ProtoReflect.Descriptor instead.
 func (*PostDetailResponse) Descriptor() ([]byte, []int) {
-	return file_proto_detail_detail_proto_rawDescGZIP(), []int{1}
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *PostDetailResponse) GetStatus() bool {
@@ -153,7 +286,7 @@ type GetDetailRequest struct {
 
 func (x *GetDetailRequest) Reset() {
 	*x = GetDetailRequest{}
-	mi := &file_proto_detail_detail_proto_msgTypes[2]
+	mi := &file_proto_detail_detail_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -165,7 +298,7 @@ func (x *GetDetailRequest) String() string {
 func (*GetDetailRequest) ProtoMessage() {}
 
 func (x *GetDetailRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_detail_detail_proto_msgTypes[2]
+	mi := &file_proto_detail_detail_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -178,7 +311,7 @@ func (x *GetDetailRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetDetailRequest.ProtoReflect.Descriptor instead.
 func (*GetDetailRequest) Descriptor() ([]byte, []int) {
-	return file_proto_detail_detail_proto_rawDescGZIP(), []int{2}
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetDetailRequest) GetRestaurantName() string {
@@ -195,15 +328,17 @@ type GetDetailResponse struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
-	Location       string `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
-	Style          string `protobuf:"bytes,3,opt,name=style,proto3" json:"style,omitempty"`
-	Capacity       int32  `protobuf:"varint,4,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	RestaurantName string          `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	Location       string          `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	Style          string          `protobuf:"bytes,3,opt,name=style,proto3" json:"style,omitempty"`
+	Capacity       int32           `protobuf:"varint,4,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	OpeningHours   []*OpeningHours `protobuf:"bytes,5,rep,name=opening_hours,json=openingHours,proto3" json:"opening_hours,omitempty"`
+	Coordinates    *Coordinates    `protobuf:"bytes,6,opt,name=coordinates,proto3" json:"coordinates,omitempty"`
 }
 
 func (x *GetDetailResponse) Reset() {
 	*x = GetDetailResponse{}
-	mi := &file_proto_detail_detail_proto_msgTypes[3]
+	mi := &file_proto_detail_detail_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -215,7 +350,7 @@ func (x *GetDetailResponse) String() string {
 func (*GetDetailResponse) ProtoMessage() {}
 
 func (x *GetDetailResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_detail_detail_proto_msgTypes[3]
+	mi := &file_proto_detail_detail_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -228,7 +363,7 @@ func (x *GetDetailResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetDetailResponse.ProtoReflect.Descriptor instead.
 func (*GetDetailResponse) Descriptor() ([]byte, []int) {
-	return file_proto_detail_detail_proto_rawDescGZIP(), []int{3}
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetDetailResponse) GetRestaurantName() string {
@@ -259,47 +394,698 @@ func (x *GetDetailResponse) GetCapacity() int32 {
 	return 0
 }
 
+func (x *GetDetailResponse) GetOpeningHours() []*OpeningHours {
+	if x != nil {
+		return x.OpeningHours
+	}
+	return nil
+}
+
+func (x *GetDetailResponse) GetCoordinates() *Coordinates {
+	if x != nil {
+		return x.Coordinates
+	}
+	return nil
+}
+
+// UpdateDetailRequest is the request message for changing restaurant details.
+type UpdateDetailRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The restaurant to update, by restaurant_name, and the new values of the fields to update
+	Detail *GetDetailResponse `protobuf:"bytes,1,opt,name=detail,proto3" json:"detail,omitempty"`
+	// Fields to update, e.g. "location" or "opening_hours"; without one, every field set in
+	// detail is updated. The restaurant_name can't be updated.
+	UpdateMask *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+}
+
+func (x *UpdateDetailRequest) Reset() {
+	*x = UpdateDetailRequest{}
+	mi := &file_proto_detail_detail_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateDetailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDetailRequest) ProtoMessage() {}
+
+func (x *UpdateDetailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDetailRequest.ProtoReflect.Descriptor instead.
+func (*UpdateDetailRequest) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdateDetailRequest) GetDetail() *GetDetailResponse {
+	if x != nil {
+		return x.Detail
+	}
+	return nil
+}
+
+func (x *UpdateDetailRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+// UpdateDetailResponse is the response message for the UpdateDetail RPC method.
+type UpdateDetailResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Detail *GetDetailResponse `protobuf:"bytes,1,opt,name=detail,proto3" json:"detail,omitempty"` // The details after the update
+}
+
+func (x *UpdateDetailResponse) Reset() {
+	*x = UpdateDetailResponse{}
+	mi := &file_proto_detail_detail_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateDetailResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDetailResponse) ProtoMessage() {}
+
+func (x *UpdateDetailResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDetailResponse.ProtoReflect.Descriptor instead.
+func (*UpdateDetailResponse) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateDetailResponse) GetDetail() *GetDetailResponse {
+	if x != nil {
+		return x.Detail
+	}
+	return nil
+}
+
+// DeleteDetailRequest is the request message for deleting a restaurant.
+type DeleteDetailRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	Cascade        bool   `protobuf:"varint,2,opt,name=cascade,proto3" json:"cascade,omitempty"` // Also delete the restaurant's reviews and reservations
+}
+
+func (x *DeleteDetailRequest) Reset() {
+	*x = DeleteDetailRequest{}
+	mi := &file_proto_detail_detail_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDetailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDetailRequest) ProtoMessage() {}
+
+func (x *DeleteDetailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDetailRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDetailRequest) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteDetailRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+func (x *DeleteDetailRequest) GetCascade() bool {
+	if x != nil {
+		return x.Cascade
+	}
+	return false
+}
+
+// DeleteDetailResponse is the response message for the DeleteDetail RPC method.
+type DeleteDetailResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status              bool  `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	ReviewsDeleted      int32 `protobuf:"varint,2,opt,name=reviews_deleted,json=reviewsDeleted,proto3" json:"reviews_deleted,omitempty"`
+	ReservationsDeleted int32 `protobuf:"varint,3,opt,name=reservations_deleted,json=reservationsDeleted,proto3" json:"reservations_deleted,omitempty"`
+}
+
+func (x *DeleteDetailResponse) Reset() {
+	*x = DeleteDetailResponse{}
+	mi := &file_proto_detail_detail_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDetailResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDetailResponse) ProtoMessage() {}
+
+func (x *DeleteDetailResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDetailResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDetailResponse) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteDetailResponse) GetStatus() bool {
+	if x != nil {
+		return x.Status
+	}
+	return false
+}
+
+func (x *DeleteDetailResponse) GetReviewsDeleted() int32 {
+	if x != nil {
+		return x.ReviewsDeleted
+	}
+	return 0
+}
+
+func (x *DeleteDetailResponse) GetReservationsDeleted() int32 {
+	if x != nil {
+		return x.ReservationsDeleted
+	}
+	return 0
+}
+
+// SearchDetailsRequest is the request message for finding restaurants. Text is
+// matched ignoring case; filters left empty match every restaurant.
+type SearchDetailsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Location    string `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	Style       string `protobuf:"bytes,2,opt,name=style,proto3" json:"style,omitempty"`
+	MinCapacity int32  `protobuf:"varint,3,opt,name=min_capacity,json=minCapacity,proto3" json:"min_capacity,omitempty"` // Restaurants with unlimited capacity always match
+	PageSize    int32  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`          // At most 100; 10 if zero
+	PageToken   string `protobuf:"bytes,5,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`        // From the previous page, to get the next one
+}
+
+func (x *SearchDetailsRequest) Reset() {
+	*x = SearchDetailsRequest{}
+	mi := &file_proto_detail_detail_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchDetailsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchDetailsRequest) ProtoMessage() {}
+
+func (x *SearchDetailsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchDetailsRequest.ProtoReflect.Descriptor instead.
+func (*SearchDetailsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SearchDetailsRequest) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *SearchDetailsRequest) GetStyle() string {
+	if x != nil {
+		return x.Style
+	}
+	return ""
+}
+
+func (x *SearchDetailsRequest) GetMinCapacity() int32 {
+	if x != nil {
+		return x.MinCapacity
+	}
+	return 0
+}
+
+func (x *SearchDetailsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *SearchDetailsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// SearchDetailsResponse is the response message for the SearchDetails RPC method.
+type SearchDetailsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Details       []*GetDetailResponse `protobuf:"bytes,1,rep,name=details,proto3" json:"details,omitempty"`                                    // Ordered by restaurant name
+	NextPageToken string               `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // Empty on the last page
+}
+
+func (x *SearchDetailsResponse) Reset() {
+	*x = SearchDetailsResponse{}
+	mi := &file_proto_detail_detail_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchDetailsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchDetailsResponse) ProtoMessage() {}
+
+func (x *SearchDetailsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchDetailsResponse.ProtoReflect.Descriptor instead.
+func (*SearchDetailsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SearchDetailsResponse) GetDetails() []*GetDetailResponse {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+func (x *SearchDetailsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// NearbyRestaurantsRequest is the request message for finding the restaurants
+// near a point, optionally of a style.
+type NearbyRestaurantsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Center   *Coordinates `protobuf:"bytes,1,opt,name=center,proto3" json:"center,omitempty"`
+	RadiusKm float64      `protobuf:"fixed64,2,opt,name=radius_km,json=radiusKm,proto3" json:"radius_km,omitempty"` // At most 500
+	Style    string       `protobuf:"bytes,3,opt,name=style,proto3" json:"style,omitempty"`                         // Matched ignoring case; any if empty
+	Limit    int32        `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`                        // At most 100; 10 if zero
+}
+
+func (x *NearbyRestaurantsRequest) Reset() {
+	*x = NearbyRestaurantsRequest{}
+	mi := &file_proto_detail_detail_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NearbyRestaurantsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NearbyRestaurantsRequest) ProtoMessage() {}
+
+func (x *NearbyRestaurantsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NearbyRestaurantsRequest.ProtoReflect.Descriptor instead.
+func (*NearbyRestaurantsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *NearbyRestaurantsRequest) GetCenter() *Coordinates {
+	if x != nil {
+		return x.Center
+	}
+	return nil
+}
+
+func (x *NearbyRestaurantsRequest) GetRadiusKm() float64 {
+	if x != nil {
+		return x.RadiusKm
+	}
+	return 0
+}
+
+func (x *NearbyRestaurantsRequest) GetStyle() string {
+	if x != nil {
+		return x.Style
+	}
+	return ""
+}
+
+func (x *NearbyRestaurantsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// NearbyRestaurant is a restaurant found by NearbyRestaurants.
+type NearbyRestaurant struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Detail     *GetDetailResponse `protobuf:"bytes,1,opt,name=detail,proto3" json:"detail,omitempty"`
+	DistanceKm float64            `protobuf:"fixed64,2,opt,name=distance_km,json=distanceKm,proto3" json:"distance_km,omitempty"`
+}
+
+func (x *NearbyRestaurant) Reset() {
+	*x = NearbyRestaurant{}
+	mi := &file_proto_detail_detail_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NearbyRestaurant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NearbyRestaurant) ProtoMessage() {}
+
+func (x *NearbyRestaurant) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NearbyRestaurant.ProtoReflect.Descriptor instead.
+func (*NearbyRestaurant) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *NearbyRestaurant) GetDetail() *GetDetailResponse {
+	if x != nil {
+		return x.Detail
+	}
+	return nil
+}
+
+func (x *NearbyRestaurant) GetDistanceKm() float64 {
+	if x != nil {
+		return x.DistanceKm
+	}
+	return 0
+}
+
+// NearbyRestaurantsResponse is the response message for the NearbyRestaurants RPC method.
+type NearbyRestaurantsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Restaurants []*NearbyRestaurant `protobuf:"bytes,1,rep,name=restaurants,proto3" json:"restaurants,omitempty"` // Nearest first
+}
+
+func (x *NearbyRestaurantsResponse) Reset() {
+	*x = NearbyRestaurantsResponse{}
+	mi := &file_proto_detail_detail_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NearbyRestaurantsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NearbyRestaurantsResponse) ProtoMessage() {}
+
+func (x *NearbyRestaurantsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_detail_detail_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NearbyRestaurantsResponse.ProtoReflect.Descriptor instead.
+func (*NearbyRestaurantsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_detail_detail_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *NearbyRestaurantsResponse) GetRestaurants() []*NearbyRestaurant {
+	if x != nil {
+		return x.Restaurants
+	}
+	return nil
+}
+
 var File_proto_detail_detail_proto protoreflect.FileDescriptor
 
 var file_proto_detail_detail_proto_rawDesc = []byte{
 	0x0a, 0x19, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2f, 0x64,
 	0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x64, 0x65, 0x74,
-	0x61, 0x69, 0x6c, 0x22, 0x8a, 0x01, 0x0a, 0x11, 0x50, 0x6f, 0x73, 0x74, 0x44, 0x65, 0x74, 0x61,
+	0x61, 0x69, 0x6c, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x5f, 0x6d, 0x61, 0x73, 0x6b, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xfc, 0x01, 0x0a, 0x11, 0x50, 0x6f, 0x73, 0x74, 0x44, 0x65,
+	0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72,
+	0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69,
+	0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69,
+	0x74, 0x79, 0x12, 0x39, 0x0a, 0x0d, 0x6f, 0x70, 0x65, 0x6e, 0x69, 0x6e, 0x67, 0x5f, 0x68, 0x6f,
+	0x75, 0x72, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x64, 0x65, 0x74, 0x61,
+	0x69, 0x6c, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x69, 0x6e, 0x67, 0x48, 0x6f, 0x75, 0x72, 0x73, 0x52,
+	0x0c, 0x6f, 0x70, 0x65, 0x6e, 0x69, 0x6e, 0x67, 0x48, 0x6f, 0x75, 0x72, 0x73, 0x12, 0x35, 0x0a,
+	0x0b, 0x63, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x43, 0x6f, 0x6f, 0x72,
+	0x64, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x73, 0x52, 0x0b, 0x63, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e,
+	0x61, 0x74, 0x65, 0x73, 0x22, 0x47, 0x0a, 0x0b, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61,
+	0x74, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12,
+	0x1c, 0x0a, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x22, 0x66, 0x0a,
+	0x0c, 0x4f, 0x70, 0x65, 0x6e, 0x69, 0x6e, 0x67, 0x48, 0x6f, 0x75, 0x72, 0x73, 0x12, 0x1e, 0x0a,
+	0x0b, 0x64, 0x61, 0x79, 0x5f, 0x6f, 0x66, 0x5f, 0x77, 0x65, 0x65, 0x6b, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x09, 0x64, 0x61, 0x79, 0x4f, 0x66, 0x57, 0x65, 0x65, 0x6b, 0x12, 0x19, 0x0a,
+	0x08, 0x6f, 0x70, 0x65, 0x6e, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x07, 0x6f, 0x70, 0x65, 0x6e, 0x73, 0x41, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6c, 0x6f, 0x73,
+	0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x6c, 0x6f,
+	0x73, 0x65, 0x73, 0x41, 0x74, 0x22, 0x2c, 0x0a, 0x12, 0x50, 0x6f, 0x73, 0x74, 0x44, 0x65, 0x74,
+	0x61, 0x69, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x22, 0x3b, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61,
+	0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65,
+	0x22, 0xfc, 0x01, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75,
+	0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x74, 0x79, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x79, 0x6c,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x12, 0x39, 0x0a,
+	0x0d, 0x6f, 0x70, 0x65, 0x6e, 0x69, 0x6e, 0x67, 0x5f, 0x68, 0x6f, 0x75, 0x72, 0x73, 0x18, 0x05,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x4f, 0x70,
+	0x65, 0x6e, 0x69, 0x6e, 0x67, 0x48, 0x6f, 0x75, 0x72, 0x73, 0x52, 0x0c, 0x6f, 0x70, 0x65, 0x6e,
+	0x69, 0x6e, 0x67, 0x48, 0x6f, 0x75, 0x72, 0x73, 0x12, 0x35, 0x0a, 0x0b, 0x63, 0x6f, 0x6f, 0x72,
+	0x64, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e,
+	0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61, 0x74,
+	0x65, 0x73, 0x52, 0x0b, 0x63, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x73, 0x22,
+	0x85, 0x01, 0x0a, 0x13, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x31, 0x0a, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69,
+	0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c,
+	0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x52, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x3b, 0x0a, 0x0b, 0x75, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x5f, 0x6d, 0x61, 0x73, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4d, 0x61, 0x73, 0x6b, 0x52, 0x0a, 0x75, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x4d, 0x61, 0x73, 0x6b, 0x22, 0x49, 0x0a, 0x14, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x31, 0x0a, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61,
+	0x69, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x06, 0x64, 0x65, 0x74, 0x61,
+	0x69, 0x6c, 0x22, 0x58, 0x0a, 0x13, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x65, 0x74, 0x61,
 	0x69, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73,
 	0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
 	0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61,
-	0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x61, 0x73, 0x63, 0x61, 0x64, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x07, 0x63, 0x61, 0x73, 0x63, 0x61, 0x64, 0x65, 0x22, 0x8a, 0x01, 0x0a,
+	0x14, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x27, 0x0a,
+	0x0f, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x44,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x31, 0x0a, 0x14, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x13, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x22, 0xa7, 0x01, 0x0a, 0x14, 0x53, 0x65,
+	0x61, 0x72, 0x63, 0x68, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01,
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14,
-	0x0a, 0x05, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73,
-	0x74, 0x79, 0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79,
-	0x22, 0x2c, 0x0a, 0x12, 0x50, 0x6f, 0x73, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x3b,
-	0x0a, 0x10, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74,
-	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73,
-	0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x8a, 0x01, 0x0a, 0x11,
-	0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74,
-	0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x6f,
-	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x6f,
-	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08,
-	0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08,
-	0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x32, 0x96, 0x01, 0x0a, 0x0d, 0x44, 0x65, 0x74,
-	0x61, 0x69, 0x6c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x43, 0x0a, 0x0a, 0x50, 0x6f,
-	0x73, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x19, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69,
-	0x6c, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x50, 0x6f, 0x73,
-	0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x40, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x18, 0x2e, 0x64,
-	0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e,
-	0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x42, 0x10, 0x5a, 0x0e, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x64, 0x65, 0x74,
-	0x61, 0x69, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x0a, 0x05, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73,
+	0x74, 0x79, 0x6c, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x69, 0x6e, 0x5f, 0x63, 0x61, 0x70, 0x61,
+	0x63, 0x69, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6d, 0x69, 0x6e, 0x43,
+	0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f,
+	0x73, 0x69, 0x7a, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65,
+	0x53, 0x69, 0x7a, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b,
+	0x65, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f,
+	0x6b, 0x65, 0x6e, 0x22, 0x74, 0x0a, 0x15, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x44, 0x65, 0x74,
+	0x61, 0x69, 0x6c, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x33, 0x0a, 0x07,
+	0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x07, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c,
+	0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74,
+	0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x90, 0x01, 0x0a, 0x18, 0x4e, 0x65,
+	0x61, 0x72, 0x62, 0x79, 0x52, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2b, 0x0a, 0x06, 0x63, 0x65, 0x6e, 0x74, 0x65, 0x72,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e,
+	0x43, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x73, 0x52, 0x06, 0x63, 0x65, 0x6e,
+	0x74, 0x65, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x61, 0x64, 0x69, 0x75, 0x73, 0x5f, 0x6b, 0x6d,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x72, 0x61, 0x64, 0x69, 0x75, 0x73, 0x4b, 0x6d,
+	0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x66, 0x0a, 0x10,
+	0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x52, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74,
+	0x12, 0x31, 0x0a, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74,
+	0x61, 0x69, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x06, 0x64, 0x65, 0x74,
+	0x61, 0x69, 0x6c, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x5f,
+	0x6b, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x4b, 0x6d, 0x22, 0x57, 0x0a, 0x19, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x52, 0x65,
+	0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x3a, 0x0a, 0x0b, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e,
+	0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x52, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74,
+	0x52, 0x0b, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x73, 0x32, 0xd4, 0x03,
+	0x0a, 0x0d, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
+	0x43, 0x0a, 0x0a, 0x50, 0x6f, 0x73, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x19, 0x2e,
+	0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69,
+	0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69,
+	0x6c, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69,
+	0x6c, 0x12, 0x18, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x65,
+	0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x64, 0x65,
+	0x74, 0x61, 0x69, 0x6c, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x49, 0x0a, 0x0c, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x1b, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x55, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x49, 0x0a, 0x0c, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x65, 0x74, 0x61, 0x69,
+	0x6c, 0x12, 0x1b, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c,
+	0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x65,
+	0x74, 0x61, 0x69, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x0d,
+	0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x12, 0x1c, 0x2e,
+	0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x44, 0x65, 0x74,
+	0x61, 0x69, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x64, 0x65,
+	0x74, 0x61, 0x69, 0x6c, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x44, 0x65, 0x74, 0x61, 0x69,
+	0x6c, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x58, 0x0a, 0x11, 0x4e, 0x65,
+	0x61, 0x72, 0x62, 0x79, 0x52, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x73, 0x12,
+	0x20, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x4e, 0x65, 0x61, 0x72, 0x62, 0x79, 0x52,
+	0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x21, 0x2e, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x2e, 0x4e, 0x65, 0x61, 0x72, 0x62,
+	0x79, 0x52, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x42, 0x10, 0x5a, 0x0e, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f,
+	0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -314,23 +1100,54 @@ func file_proto_detail_detail_proto_rawDescGZIP() []byte {
 	return file_proto_detail_detail_proto_rawDescData
 }
 
-var file_proto_detail_detail_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_detail_detail_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_proto_detail_detail_proto_goTypes = []any{
-	(*PostDetailRequest)(nil),  // 0: detail.PostDetailRequest
-	(*PostDetailResponse)(nil), // 1: detail.PostDetailResponse
-	(*GetDetailRequest)(nil),   // 2: detail.GetDetailRequest
-	(*GetDetailResponse)(nil),  // 3: detail.GetDetailResponse
+	(*PostDetailRequest)(nil),         // 0: detail.PostDetailRequest
+	(*Coordinates)(nil),               // 1: detail.Coordinates
+	(*OpeningHours)(nil),              // 2: detail.OpeningHours
+	(*PostDetailResponse)(nil),        // 3: detail.PostDetailResponse
+	(*GetDetailRequest)(nil),          // 4: detail.GetDetailRequest
+	(*GetDetailResponse)(nil),         // 5: detail.GetDetailResponse
+	(*UpdateDetailRequest)(nil),       // 6: detail.UpdateDetailRequest
+	(*UpdateDetailResponse)(nil),      // 7: detail.UpdateDetailResponse
+	(*DeleteDetailRequest)(nil),       // 8: detail.DeleteDetailRequest
+	(*DeleteDetailResponse)(nil),      // 9: detail.DeleteDetailResponse
+	(*SearchDetailsRequest)(nil),      // 10: detail.SearchDetailsRequest
+	(*SearchDetailsResponse)(nil),     // 11: detail.SearchDetailsResponse
+	(*NearbyRestaurantsRequest)(nil),  // 12: detail.NearbyRestaurantsRequest
+	(*NearbyRestaurant)(nil),          // 13: detail.NearbyRestaurant
+	(*NearbyRestaurantsResponse)(nil), // 14: detail.NearbyRestaurantsResponse
+	(*fieldmaskpb.FieldMask)(nil),     // 15: google.protobuf.FieldMask
 }
 var file_proto_detail_detail_proto_depIdxs = []int32{
-	0, // 0: detail.DetailService.PostDetail:input_type -> detail.PostDetailRequest
-	2, // 1: detail.DetailService.GetDetail:input_type -> detail.GetDetailRequest
-	1, // 2: detail.DetailService.PostDetail:output_type -> detail.PostDetailResponse
-	3, // 3: detail.DetailService.GetDetail:output_type -> detail.GetDetailResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	2,  // 0: detail.PostDetailRequest.opening_hours:type_name -> detail.OpeningHours
+	1,  // 1: detail.PostDetailRequest.coordinates:type_name -> detail.Coordinates
+	2,  // 2: detail.GetDetailResponse.opening_hours:type_name -> detail.OpeningHours
+	1,  // 3: detail.GetDetailResponse.coordinates:type_name -> detail.Coordinates
+	5,  // 4: detail.UpdateDetailRequest.detail:type_name -> detail.GetDetailResponse
+	15, // 5: detail.UpdateDetailRequest.update_mask:type_name -> google.protobuf.FieldMask
+	5,  // 6: detail.UpdateDetailResponse.detail:type_name -> detail.GetDetailResponse
+	5,  // 7: detail.SearchDetailsResponse.details:type_name -> detail.GetDetailResponse
+	1,  // 8: detail.NearbyRestaurantsRequest.center:type_name -> detail.Coordinates
+	5,  // 9: detail.NearbyRestaurant.detail:type_name -> detail.GetDetailResponse
+	13, // 10: detail.NearbyRestaurantsResponse.restaurants:type_name -> detail.NearbyRestaurant
+	0,  // 11: detail.DetailService.PostDetail:input_type -> detail.PostDetailRequest
+	4,  // 12: detail.DetailService.GetDetail:input_type -> detail.GetDetailRequest
+	6,  // 13: detail.DetailService.UpdateDetail:input_type -> detail.UpdateDetailRequest
+	8,  // 14: detail.DetailService.DeleteDetail:input_type -> detail.DeleteDetailRequest
+	10, // 15: detail.DetailService.SearchDetails:input_type -> detail.SearchDetailsRequest
+	12, // 16: detail.DetailService.NearbyRestaurants:input_type -> detail.NearbyRestaurantsRequest
+	3,  // 17: detail.DetailService.PostDetail:output_type -> detail.PostDetailResponse
+	5,  // 18: detail.DetailService.GetDetail:output_type -> detail.GetDetailResponse
+	7,  // 19: detail.DetailService.UpdateDetail:output_type -> detail.UpdateDetailResponse
+	9,  // 20: detail.DetailService.DeleteDetail:output_type -> detail.DeleteDetailResponse
+	11, // 21: detail.DetailService.SearchDetails:output_type -> detail.SearchDetailsResponse
+	14, // 22: detail.DetailService.NearbyRestaurants:output_type -> detail.NearbyRestaurantsResponse
+	17, // [17:23] is the sub-list for method output_type
+	11, // [11:17] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_proto_detail_detail_proto_init() }
@@ -344,7 +1161,7 @@ func file_proto_detail_detail_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_detail_detail_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -23,8 +23,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	DetailService_PostDetail_FullMethodName = "/detail.DetailService/PostDetail"
-	DetailService_GetDetail_FullMethodName  = "/detail.DetailService/GetDetail"
+	DetailService_PostDetail_FullMethodName        = "/detail.DetailService/PostDetail"
+	DetailService_GetDetail_FullMethodName         = "/detail.DetailService/GetDetail"
+	DetailService_UpdateDetail_FullMethodName      = "/detail.DetailService/UpdateDetail"
+	DetailService_DeleteDetail_FullMethodName      = "/detail.DetailService/DeleteDetail"
+	DetailService_SearchDetails_FullMethodName     = "/detail.DetailService/SearchDetails"
+	DetailService_NearbyRestaurants_FullMethodName = "/detail.DetailService/NearbyRestaurants"
 )
 
 // DetailServiceClient is the client API for DetailService service.
@@ -37,6 +41,14 @@ type DetailServiceClient interface {
 	PostDetail(ctx context.Context, in *PostDetailRequest, opts ...grpc.CallOption) (*PostDetailResponse, error)
 	// GetDetail is an RPC method for retrieving details of a restaurant based on its name.
 	GetDetail(ctx context.Context, in *GetDetailRequest, opts ...grpc.CallOption) (*GetDetailResponse, error)
+	// UpdateDetail is an RPC method for changing some of the details of a restaurant.
+	UpdateDetail(ctx context.Context, in *UpdateDetailRequest, opts ...grpc.CallOption) (*UpdateDetailResponse, error)
+	// DeleteDetail is an RPC method for removing a restaurant, optionally with its reviews and reservations.
+	DeleteDetail(ctx context.Context, in *DeleteDetailRequest, opts ...grpc.CallOption) (*DeleteDetailResponse, error)
+	// SearchDetails is an RPC method for finding restaurants by location, style and capacity.
+	SearchDetails(ctx context.Context, in *SearchDetailsRequest, opts ...grpc.CallOption) (*SearchDetailsResponse, error)
+	// NearbyRestaurants is an RPC method for finding the restaurants within a distance of a point.
+	NearbyRestaurants(ctx context.Context, in *NearbyRestaurantsRequest, opts ...grpc.CallOption) (*NearbyRestaurantsResponse, error)
 }
 
 type detailServiceClient struct {
@@ -67,6 +79,46 @@ func (c *detailServiceClient) GetDetail(ctx context.Context, in *GetDetailReques
 	return out, nil
 }
 
+func (c *detailServiceClient) UpdateDetail(ctx context.Context, in *UpdateDetailRequest, opts ...grpc.CallOption) (*UpdateDetailResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateDetailResponse)
+	err := c.cc.Invoke(ctx, DetailService_UpdateDetail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detailServiceClient) DeleteDetail(ctx context.Context, in *DeleteDetailRequest, opts ...grpc.CallOption) (*DeleteDetailResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteDetailResponse)
+	err := c.cc.Invoke(ctx, DetailService_DeleteDetail_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detailServiceClient) SearchDetails(ctx context.Context, in *SearchDetailsRequest, opts ...grpc.CallOption) (*SearchDetailsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchDetailsResponse)
+	err := c.cc.Invoke(ctx, DetailService_SearchDetails_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *detailServiceClient) NearbyRestaurants(ctx context.Context, in *NearbyRestaurantsRequest, opts ...grpc.CallOption) (*NearbyRestaurantsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NearbyRestaurantsResponse)
+	err := c.cc.Invoke(ctx, DetailService_NearbyRestaurants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DetailServiceServer is the server API for DetailService service.
 // All implementations must embed UnimplementedDetailServiceServer
 // for forward compatibility.
@@ -77,6 +129,14 @@ type DetailServiceServer interface {
 	PostDetail(context.Context, *PostDetailRequest) (*PostDetailResponse, error)
 	// GetDetail is an RPC method for retrieving details of a restaurant based on its name.
 	GetDetail(context.Context, *GetDetailRequest) (*GetDetailResponse, error)
+	// UpdateDetail is an RPC method for changing some of the details of a restaurant.
+	UpdateDetail(context.Context, *UpdateDetailRequest) (*UpdateDetailResponse, error)
+	// DeleteDetail is an RPC method for removing a restaurant, optionally with its reviews and reservations.
+	DeleteDetail(context.Context, *DeleteDetailRequest) (*DeleteDetailResponse, error)
+	// SearchDetails is an RPC method for finding restaurants by location, style and capacity.
+	SearchDetails(context.Context, *SearchDetailsRequest) (*SearchDetailsResponse, error)
+	// NearbyRestaurants is an RPC method for finding the restaurants within a distance of a point.
+	NearbyRestaurants(context.Context, *NearbyRestaurantsRequest) (*NearbyRestaurantsResponse, error)
 	mustEmbedUnimplementedDetailServiceServer()
 }
 
@@ -93,6 +153,18 @@ func (UnimplementedDetailServiceServer) PostDetail(context.Context, *PostDetailR
 func (UnimplementedDetailServiceServer) GetDetail(context.Context, *GetDetailRequest) (*GetDetailResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetDetail not implemented")
 }
+func (UnimplementedDetailServiceServer) UpdateDetail(context.Context, *UpdateDetailRequest) (*UpdateDetailResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDetail not implemented")
+}
+func (UnimplementedDetailServiceServer) DeleteDetail(context.Context, *DeleteDetailRequest) (*DeleteDetailResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDetail not implemented")
+}
+func (UnimplementedDetailServiceServer) SearchDetails(context.Context, *SearchDetailsRequest) (*SearchDetailsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchDetails not implemented")
+}
+func (UnimplementedDetailServiceServer) NearbyRestaurants(context.Context, *NearbyRestaurantsRequest) (*NearbyRestaurantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NearbyRestaurants not implemented")
+}
 func (UnimplementedDetailServiceServer) mustEmbedUnimplementedDetailServiceServer() {}
 func (UnimplementedDetailServiceServer) testEmbeddedByValue()                       {}
 
@@ -150,6 +222,78 @@ func _DetailService_GetDetail_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DetailService_UpdateDetail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateDetailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).UpdateDetail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DetailService_UpdateDetail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).UpdateDetail(ctx, req.(*UpdateDetailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DetailService_DeleteDetail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDetailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).DeleteDetail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DetailService_DeleteDetail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).DeleteDetail(ctx, req.(*DeleteDetailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DetailService_SearchDetails_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchDetailsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).SearchDetails(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DetailService_SearchDetails_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).SearchDetails(ctx, req.(*SearchDetailsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DetailService_NearbyRestaurants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NearbyRestaurantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DetailServiceServer).NearbyRestaurants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DetailService_NearbyRestaurants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DetailServiceServer).NearbyRestaurants(ctx, req.(*NearbyRestaurantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DetailService_ServiceDesc is the grpc.ServiceDesc for DetailService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -165,6 +309,22 @@ var DetailService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetDetail",
 			Handler:    _DetailService_GetDetail_Handler,
 		},
+		{
+			MethodName: "UpdateDetail",
+			Handler:    _DetailService_UpdateDetail_Handler,
+		},
+		{
+			MethodName: "DeleteDetail",
+			Handler:    _DetailService_DeleteDetail_Handler,
+		},
+		{
+			MethodName: "SearchDetails",
+			Handler:    _DetailService_SearchDetails_Handler,
+		},
+		{
+			MethodName: "NearbyRestaurants",
+			Handler:    _DetailService_NearbyRestaurants_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/detail/detail.proto",
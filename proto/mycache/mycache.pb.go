@@ -344,6 +344,87 @@ func (x *DeleteItemResponse) GetSuccess() bool {
 	return false
 }
 
+type ClearItemsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ClearItemsRequest) Reset() {
+	*x = ClearItemsRequest{}
+	mi := &file_proto_mycache_mycache_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearItemsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearItemsRequest) ProtoMessage() {}
+
+func (x *ClearItemsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mycache_mycache_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearItemsRequest.ProtoReflect.Descriptor instead.
+func (*ClearItemsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_mycache_mycache_proto_rawDescGZIP(), []int{7}
+}
+
+type ClearItemsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+}
+
+func (x *ClearItemsResponse) Reset() {
+	*x = ClearItemsResponse{}
+	mi := &file_proto_mycache_mycache_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearItemsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearItemsResponse) ProtoMessage() {}
+
+func (x *ClearItemsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mycache_mycache_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearItemsResponse.ProtoReflect.Descriptor instead.
+func (*ClearItemsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_mycache_mycache_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ClearItemsResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
 var File_proto_mycache_mycache_proto protoreflect.FileDescriptor
 
 var file_proto_mycache_mycache_proto_rawDesc = []byte{
@@ -370,22 +451,31 @@ var file_proto_mycache_mycache_proto_rawDesc = []byte{
 	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x2e, 0x0a, 0x12, 0x44, 0x65, 0x6c, 0x65,
 	0x74, 0x65, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18,
 	0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52,
-	0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x32, 0xd7, 0x01, 0x0a, 0x0c, 0x43, 0x61, 0x63,
-	0x68, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3e, 0x0a, 0x07, 0x47, 0x65, 0x74,
-	0x49, 0x74, 0x65, 0x6d, 0x12, 0x17, 0x2e, 0x6d, 0x79, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x47,
-	0x65, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e,
-	0x6d, 0x79, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x07, 0x53, 0x65, 0x74,
-	0x49, 0x74, 0x65, 0x6d, 0x12, 0x17, 0x2e, 0x6d, 0x79, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x53,
-	0x65, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e,
-	0x6d, 0x79, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x53, 0x65, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x0a, 0x44, 0x65, 0x6c,
-	0x65, 0x74, 0x65, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x1a, 0x2e, 0x6d, 0x79, 0x63, 0x61, 0x63, 0x68,
-	0x65, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6d, 0x79, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x44, 0x65,
-	0x6c, 0x65, 0x74, 0x65, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x22, 0x00, 0x42, 0x11, 0x5a, 0x0f, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x79,
-	0x63, 0x61, 0x63, 0x68, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x13, 0x0a, 0x11, 0x43, 0x6c, 0x65, 0x61,
+	0x72, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x2e, 0x0a,
+	0x12, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x32, 0xa0, 0x02,
+	0x0a, 0x0c, 0x43, 0x61, 0x63, 0x68, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3e,
+	0x0a, 0x07, 0x47, 0x65, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x17, 0x2e, 0x6d, 0x79, 0x63, 0x61,
+	0x63, 0x68, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x18, 0x2e, 0x6d, 0x79, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x47, 0x65, 0x74,
+	0x49, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x3e,
+	0x0a, 0x07, 0x53, 0x65, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x17, 0x2e, 0x6d, 0x79, 0x63, 0x61,
+	0x63, 0x68, 0x65, 0x2e, 0x53, 0x65, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x18, 0x2e, 0x6d, 0x79, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x53, 0x65, 0x74,
+	0x49, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x47,
+	0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x1a, 0x2e, 0x6d,
+	0x79, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x49, 0x74, 0x65,
+	0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6d, 0x79, 0x63, 0x61, 0x63,
+	0x68, 0x65, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x49, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x0a, 0x43, 0x6c, 0x65, 0x61, 0x72,
+	0x49, 0x74, 0x65, 0x6d, 0x73, 0x12, 0x1a, 0x2e, 0x6d, 0x79, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e,
+	0x43, 0x6c, 0x65, 0x61, 0x72, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1b, 0x2e, 0x6d, 0x79, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x43, 0x6c, 0x65, 0x61,
+	0x72, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
+	0x42, 0x11, 0x5a, 0x0f, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x79, 0x63, 0x61,
+	0x63, 0x68, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -400,7 +490,7 @@ func file_proto_mycache_mycache_proto_rawDescGZIP() []byte {
 	return file_proto_mycache_mycache_proto_rawDescData
 }
 
-var file_proto_mycache_mycache_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_mycache_mycache_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_proto_mycache_mycache_proto_goTypes = []any{
 	(*CacheItem)(nil),          // 0: mycache.CacheItem
 	(*GetItemRequest)(nil),     // 1: mycache.GetItemRequest
@@ -409,6 +499,8 @@ var file_proto_mycache_mycache_proto_goTypes = []any{
 	(*SetItemResponse)(nil),    // 4: mycache.SetItemResponse
 	(*DeleteItemRequest)(nil),  // 5: mycache.DeleteItemRequest
 	(*DeleteItemResponse)(nil), // 6: mycache.DeleteItemResponse
+	(*ClearItemsRequest)(nil),  // 7: mycache.ClearItemsRequest
+	(*ClearItemsResponse)(nil), // 8: mycache.ClearItemsResponse
 }
 var file_proto_mycache_mycache_proto_depIdxs = []int32{
 	0, // 0: mycache.GetItemResponse.item:type_name -> mycache.CacheItem
@@ -416,11 +508,13 @@ var file_proto_mycache_mycache_proto_depIdxs = []int32{
 	1, // 2: mycache.CacheService.GetItem:input_type -> mycache.GetItemRequest
 	3, // 3: mycache.CacheService.SetItem:input_type -> mycache.SetItemRequest
 	5, // 4: mycache.CacheService.DeleteItem:input_type -> mycache.DeleteItemRequest
-	2, // 5: mycache.CacheService.GetItem:output_type -> mycache.GetItemResponse
-	4, // 6: mycache.CacheService.SetItem:output_type -> mycache.SetItemResponse
-	6, // 7: mycache.CacheService.DeleteItem:output_type -> mycache.DeleteItemResponse
-	5, // [5:8] is the sub-list for method output_type
-	2, // [2:5] is the sub-list for method input_type
+	7, // 5: mycache.CacheService.ClearItems:input_type -> mycache.ClearItemsRequest
+	2, // 6: mycache.CacheService.GetItem:output_type -> mycache.GetItemResponse
+	4, // 7: mycache.CacheService.SetItem:output_type -> mycache.SetItemResponse
+	6, // 8: mycache.CacheService.DeleteItem:output_type -> mycache.DeleteItemResponse
+	8, // 9: mycache.CacheService.ClearItems:output_type -> mycache.ClearItemsResponse
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
 	2, // [2:2] is the sub-list for extension type_name
 	2, // [2:2] is the sub-list for extension extendee
 	0, // [0:2] is the sub-list for field type_name
@@ -437,7 +531,7 @@ func file_proto_mycache_mycache_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_mycache_mycache_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
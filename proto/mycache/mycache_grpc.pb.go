@@ -22,6 +22,7 @@ const (
 	CacheService_GetItem_FullMethodName    = "/mycache.CacheService/GetItem"
 	CacheService_SetItem_FullMethodName    = "/mycache.CacheService/SetItem"
 	CacheService_DeleteItem_FullMethodName = "/mycache.CacheService/DeleteItem"
+	CacheService_ClearItems_FullMethodName = "/mycache.CacheService/ClearItems"
 )
 
 // CacheServiceClient is the client API for CacheService service.
@@ -33,6 +34,7 @@ type CacheServiceClient interface {
 	GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*GetItemResponse, error)
 	SetItem(ctx context.Context, in *SetItemRequest, opts ...grpc.CallOption) (*SetItemResponse, error)
 	DeleteItem(ctx context.Context, in *DeleteItemRequest, opts ...grpc.CallOption) (*DeleteItemResponse, error)
+	ClearItems(ctx context.Context, in *ClearItemsRequest, opts ...grpc.CallOption) (*ClearItemsResponse, error)
 }
 
 type cacheServiceClient struct {
@@ -73,6 +75,16 @@ func (c *cacheServiceClient) DeleteItem(ctx context.Context, in *DeleteItemReque
 	return out, nil
 }
 
+func (c *cacheServiceClient) ClearItems(ctx context.Context, in *ClearItemsRequest, opts ...grpc.CallOption) (*ClearItemsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClearItemsResponse)
+	err := c.cc.Invoke(ctx, CacheService_ClearItems_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CacheServiceServer is the server API for CacheService service.
 // All implementations must embed UnimplementedCacheServiceServer
 // for forward compatibility.
@@ -82,6 +94,7 @@ type CacheServiceServer interface {
 	GetItem(context.Context, *GetItemRequest) (*GetItemResponse, error)
 	SetItem(context.Context, *SetItemRequest) (*SetItemResponse, error)
 	DeleteItem(context.Context, *DeleteItemRequest) (*DeleteItemResponse, error)
+	ClearItems(context.Context, *ClearItemsRequest) (*ClearItemsResponse, error)
 	mustEmbedUnimplementedCacheServiceServer()
 }
 
@@ -101,6 +114,9 @@ func (UnimplementedCacheServiceServer) SetItem(context.Context, *SetItemRequest)
 func (UnimplementedCacheServiceServer) DeleteItem(context.Context, *DeleteItemRequest) (*DeleteItemResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteItem not implemented")
 }
+func (UnimplementedCacheServiceServer) ClearItems(context.Context, *ClearItemsRequest) (*ClearItemsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearItems not implemented")
+}
 func (UnimplementedCacheServiceServer) mustEmbedUnimplementedCacheServiceServer() {}
 func (UnimplementedCacheServiceServer) testEmbeddedByValue()                      {}
 
@@ -176,6 +192,24 @@ func _CacheService_DeleteItem_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CacheService_ClearItems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearItemsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).ClearItems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_ClearItems_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).ClearItems(ctx, req.(*ClearItemsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CacheService_ServiceDesc is the grpc.ServiceDesc for CacheService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -195,6 +229,10 @@ var CacheService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteItem",
 			Handler:    _CacheService_DeleteItem_Handler,
 		},
+		{
+			MethodName: "ClearItems",
+			Handler:    _CacheService_ClearItems_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/mycache/mycache.proto",
@@ -20,6 +20,52 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ChangeEvent_Operation int32
+
+const (
+	ChangeEvent_PUT    ChangeEvent_Operation = 0
+	ChangeEvent_DELETE ChangeEvent_Operation = 1
+)
+
+// Enum value maps for ChangeEvent_Operation.
+var (
+	ChangeEvent_Operation_name = map[int32]string{
+		0: "PUT",
+		1: "DELETE",
+	}
+	ChangeEvent_Operation_value = map[string]int32{
+		"PUT":    0,
+		"DELETE": 1,
+	}
+)
+
+func (x ChangeEvent_Operation) Enum() *ChangeEvent_Operation {
+	p := new(ChangeEvent_Operation)
+	*p = x
+	return p
+}
+
+func (x ChangeEvent_Operation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChangeEvent_Operation) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_mydatabase_mydatabase_proto_enumTypes[0].Descriptor()
+}
+
+func (ChangeEvent_Operation) Type() protoreflect.EnumType {
+	return &file_proto_mydatabase_mydatabase_proto_enumTypes[0]
+}
+
+func (x ChangeEvent_Operation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChangeEvent_Operation.Descriptor instead.
+func (ChangeEvent_Operation) EnumDescriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{12, 0}
+}
+
 type DatabaseRecord struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -27,6 +73,16 @@ type DatabaseRecord struct {
 
 	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// Commit version assigned by the database when the record was written.
+	// Versions are global to a database instance and strictly increasing.
+	Version uint64 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	// Commit time of this version in Unix nanoseconds.
+	Timestamp int64 `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Set on the tombstone version written by a delete (only visible in history).
+	Deleted bool `protobuf:"varint,5,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// CRC-32C of the key, deleted flag and value, set by the database on write.
+	// Clients may set it on writes to detect corruption in transit.
+	Checksum uint32 `protobuf:"varint,6,opt,name=checksum,proto3" json:"checksum,omitempty"`
 }
 
 func (x *DatabaseRecord) Reset() {
@@ -73,13 +129,44 @@ func (x *DatabaseRecord) GetValue() []byte {
 	return nil
 }
 
+func (x *DatabaseRecord) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *DatabaseRecord) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *DatabaseRecord) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *DatabaseRecord) GetChecksum() uint32 {
+	if x != nil {
+		return x.Checksum
+	}
+	return 0
+}
+
 type SetRecordRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	// Fields for setting a new record
-	Record *DatabaseRecord `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"` // ... add more fields as needed
+	Record *DatabaseRecord `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	// Only write if the key's latest version is this one; zero means the key
+	// must not exist. Fails with ABORTED otherwise. Unset writes unconditionally.
+	ExpectedVersion *uint64 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
 }
 
 func (x *SetRecordRequest) Reset() {
@@ -119,6 +206,13 @@ func (x *SetRecordRequest) GetRecord() *DatabaseRecord {
 	return nil
 }
 
+func (x *SetRecordRequest) GetExpectedVersion() uint64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
 type SetRecordResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -126,6 +220,8 @@ type SetRecordResponse struct {
 
 	// Response message for setting a record
 	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// Commit version assigned to the write
+	Version uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // ... add more fields as needed
 }
 
 func (x *SetRecordResponse) Reset() {
@@ -165,6 +261,13 @@ func (x *SetRecordResponse) GetSuccess() bool {
 	return false
 }
 
+func (x *SetRecordResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type GetRecordRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -172,6 +275,8 @@ type GetRecordRequest struct {
 
 	// Field for specifying the record to get
 	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Read the record as of this commit version. Zero reads the latest version.
+	SnapshotVersion uint64 `protobuf:"varint,2,opt,name=snapshot_version,json=snapshotVersion,proto3" json:"snapshot_version,omitempty"`
 }
 
 func (x *GetRecordRequest) Reset() {
@@ -211,13 +316,23 @@ func (x *GetRecordRequest) GetKey() string {
 	return ""
 }
 
+func (x *GetRecordRequest) GetSnapshotVersion() uint64 {
+	if x != nil {
+		return x.SnapshotVersion
+	}
+	return 0
+}
+
 type GetRecordResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	// Response message for getting a record
-	Record *DatabaseRecord `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"` //   bool success = 2;
+	Record *DatabaseRecord `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	// Commit version the read was served at. Pass it back as snapshot_version
+	// to read further keys at the same point in time.
+	SnapshotVersion uint64 `protobuf:"varint,2,opt,name=snapshot_version,json=snapshotVersion,proto3" json:"snapshot_version,omitempty"`
 }
 
 func (x *GetRecordResponse) Reset() {
@@ -257,6 +372,13 @@ func (x *GetRecordResponse) GetRecord() *DatabaseRecord {
 	return nil
 }
 
+func (x *GetRecordResponse) GetSnapshotVersion() uint64 {
+	if x != nil {
+		return x.SnapshotVersion
+	}
+	return 0
+}
+
 type DeleteRecordRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -264,6 +386,8 @@ type DeleteRecordRequest struct {
 
 	// Field for specifying the record to delete
 	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Only delete if the key's latest version is this one, as in SetRecordRequest
+	ExpectedVersion *uint64 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
 }
 
 func (x *DeleteRecordRequest) Reset() {
@@ -303,6 +427,13 @@ func (x *DeleteRecordRequest) GetKey() string {
 	return ""
 }
 
+func (x *DeleteRecordRequest) GetExpectedVersion() uint64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
 type DeleteRecordResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -310,6 +441,8 @@ type DeleteRecordResponse struct {
 
 	// Response message for deleting a record
 	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// Commit version of the tombstone written by the delete
+	Version uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 }
 
 func (x *DeleteRecordResponse) Reset() {
@@ -349,111 +482,1228 @@ func (x *DeleteRecordResponse) GetSuccess() bool {
 	return false
 }
 
-var File_proto_mydatabase_mydatabase_proto protoreflect.FileDescriptor
+func (x *DeleteRecordResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
 
-var file_proto_mydatabase_mydatabase_proto_rawDesc = []byte{
-	0x0a, 0x21, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61,
-	0x73, 0x65, 0x2f, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x22,
-	0x38, 0x0a, 0x0e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72,
-	0x64, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x46, 0x0a, 0x10, 0x53, 0x65, 0x74,
-	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x32, 0x0a,
-	0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
-	0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62,
-	0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72,
-	0x64, 0x22, 0x2d, 0x0a, 0x11, 0x53, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73,
-	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73,
-	0x22, 0x24, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x47, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63,
-	0x6f, 0x72, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x06, 0x72,
-	0x65, 0x63, 0x6f, 0x72, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6d, 0x79,
-	0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
-	0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22,
-	0x27, 0x0a, 0x13, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x30, 0x0a, 0x14, 0x44, 0x65, 0x6c, 0x65,
-	0x74, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x08, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x32, 0xf8, 0x01, 0x0a, 0x0f, 0x44,
-	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x48,
-	0x0a, 0x09, 0x53, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x1c, 0x2e, 0x6d, 0x79,
-	0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x53, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f,
-	0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6d, 0x79, 0x64, 0x61,
-	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x53, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x52,
-	0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x1c, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61,
-	0x73, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
-	0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x51, 0x0a, 0x0c, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x63, 0x6f,
-	0x72, 0x64, 0x12, 0x1f, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e,
-	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
-	0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x14, 0x5a, 0x12, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x2f, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
+type GetRecordHistoryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Field for specifying the record whose history to get
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Maximum number of versions to return. Zero returns every retained version.
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
 }
 
-var (
-	file_proto_mydatabase_mydatabase_proto_rawDescOnce sync.Once
-	file_proto_mydatabase_mydatabase_proto_rawDescData = file_proto_mydatabase_mydatabase_proto_rawDesc
-)
+func (x *GetRecordHistoryRequest) Reset() {
+	*x = GetRecordHistoryRequest{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
 
-func file_proto_mydatabase_mydatabase_proto_rawDescGZIP() []byte {
-	file_proto_mydatabase_mydatabase_proto_rawDescOnce.Do(func() {
-		file_proto_mydatabase_mydatabase_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_mydatabase_mydatabase_proto_rawDescData)
-	})
-	return file_proto_mydatabase_mydatabase_proto_rawDescData
+func (x *GetRecordHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var file_proto_mydatabase_mydatabase_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
-var file_proto_mydatabase_mydatabase_proto_goTypes = []any{
-	(*DatabaseRecord)(nil),       // 0: mydatabase.DatabaseRecord
-	(*SetRecordRequest)(nil),     // 1: mydatabase.SetRecordRequest
-	(*SetRecordResponse)(nil),    // 2: mydatabase.SetRecordResponse
-	(*GetRecordRequest)(nil),     // 3: mydatabase.GetRecordRequest
-	(*GetRecordResponse)(nil),    // 4: mydatabase.GetRecordResponse
-	(*DeleteRecordRequest)(nil),  // 5: mydatabase.DeleteRecordRequest
-	(*DeleteRecordResponse)(nil), // 6: mydatabase.DeleteRecordResponse
+func (*GetRecordHistoryRequest) ProtoMessage() {}
+
+func (x *GetRecordHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-var file_proto_mydatabase_mydatabase_proto_depIdxs = []int32{
-	0, // 0: mydatabase.SetRecordRequest.record:type_name -> mydatabase.DatabaseRecord
-	0, // 1: mydatabase.GetRecordResponse.record:type_name -> mydatabase.DatabaseRecord
-	1, // 2: mydatabase.DatabaseService.SetRecord:input_type -> mydatabase.SetRecordRequest
-	3, // 3: mydatabase.DatabaseService.GetRecord:input_type -> mydatabase.GetRecordRequest
-	5, // 4: mydatabase.DatabaseService.DeleteRecord:input_type -> mydatabase.DeleteRecordRequest
-	2, // 5: mydatabase.DatabaseService.SetRecord:output_type -> mydatabase.SetRecordResponse
-	4, // 6: mydatabase.DatabaseService.GetRecord:output_type -> mydatabase.GetRecordResponse
-	6, // 7: mydatabase.DatabaseService.DeleteRecord:output_type -> mydatabase.DeleteRecordResponse
-	5, // [5:8] is the sub-list for method output_type
-	2, // [2:5] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+
+// Deprecated: Use GetRecordHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetRecordHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{7}
 }
 
-func init() { file_proto_mydatabase_mydatabase_proto_init() }
-func file_proto_mydatabase_mydatabase_proto_init() {
-	if File_proto_mydatabase_mydatabase_proto != nil {
-		return
+func (x *GetRecordHistoryRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *GetRecordHistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type GetRecordHistoryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Retained versions of the record, newest first. Deletes appear as
+	// records with deleted set and an empty value.
+	Records []*DatabaseRecord `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+}
+
+func (x *GetRecordHistoryResponse) Reset() {
+	*x = GetRecordHistoryResponse{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRecordHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRecordHistoryResponse) ProtoMessage() {}
+
+func (x *GetRecordHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRecordHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetRecordHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetRecordHistoryResponse) GetRecords() []*DatabaseRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type ScanRecordsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Only return keys starting with this prefix
+	Prefix string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// Resume after this key. Empty starts from the first key.
+	StartAfter string `protobuf:"bytes,2,opt,name=start_after,json=startAfter,proto3" json:"start_after,omitempty"`
+	// Maximum number of records to return. Zero uses the server default.
+	Limit int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Scan as of this commit version. Zero scans the latest version.
+	SnapshotVersion uint64 `protobuf:"varint,4,opt,name=snapshot_version,json=snapshotVersion,proto3" json:"snapshot_version,omitempty"`
+}
+
+func (x *ScanRecordsRequest) Reset() {
+	*x = ScanRecordsRequest{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRecordsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRecordsRequest) ProtoMessage() {}
+
+func (x *ScanRecordsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRecordsRequest.ProtoReflect.Descriptor instead.
+func (*ScanRecordsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ScanRecordsRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ScanRecordsRequest) GetStartAfter() string {
+	if x != nil {
+		return x.StartAfter
+	}
+	return ""
+}
+
+func (x *ScanRecordsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ScanRecordsRequest) GetSnapshotVersion() uint64 {
+	if x != nil {
+		return x.SnapshotVersion
+	}
+	return 0
+}
+
+type ScanRecordsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Records in key order
+	Records []*DatabaseRecord `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	// Pass as start_after to get the next page. Empty when the scan is complete.
+	NextStartAfter string `protobuf:"bytes,2,opt,name=next_start_after,json=nextStartAfter,proto3" json:"next_start_after,omitempty"`
+	// Commit version the scan was served at
+	SnapshotVersion uint64 `protobuf:"varint,3,opt,name=snapshot_version,json=snapshotVersion,proto3" json:"snapshot_version,omitempty"`
+}
+
+func (x *ScanRecordsResponse) Reset() {
+	*x = ScanRecordsResponse{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRecordsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRecordsResponse) ProtoMessage() {}
+
+func (x *ScanRecordsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRecordsResponse.ProtoReflect.Descriptor instead.
+func (*ScanRecordsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ScanRecordsResponse) GetRecords() []*DatabaseRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *ScanRecordsResponse) GetNextStartAfter() string {
+	if x != nil {
+		return x.NextStartAfter
+	}
+	return ""
+}
+
+func (x *ScanRecordsResponse) GetSnapshotVersion() uint64 {
+	if x != nil {
+		return x.SnapshotVersion
+	}
+	return 0
+}
+
+type WatchChangesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// First sequence number to send, used to resume a stream. Zero starts with
+	// the next change. Fails with OUT_OF_RANGE if the change is no longer retained.
+	StartSequence uint64 `protobuf:"varint,1,opt,name=start_sequence,json=startSequence,proto3" json:"start_sequence,omitempty"`
+	// Only send changes to keys starting with this prefix
+	Prefix string `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+}
+
+func (x *WatchChangesRequest) Reset() {
+	*x = WatchChangesRequest{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchChangesRequest) ProtoMessage() {}
+
+func (x *WatchChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchChangesRequest.ProtoReflect.Descriptor instead.
+func (*WatchChangesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WatchChangesRequest) GetStartSequence() uint64 {
+	if x != nil {
+		return x.StartSequence
+	}
+	return 0
+}
+
+func (x *WatchChangesRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+type ChangeEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Commit version of the change. Sequence numbers increase by one per write.
+	Sequence  uint64                `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Operation ChangeEvent_Operation `protobuf:"varint,2,opt,name=operation,proto3,enum=mydatabase.ChangeEvent_Operation" json:"operation,omitempty"`
+	// The version written by the change; a tombstone for deletes
+	Record *DatabaseRecord `protobuf:"bytes,3,opt,name=record,proto3" json:"record,omitempty"`
+	// The version the change replaced, if the key existed
+	Previous *DatabaseRecord `protobuf:"bytes,4,opt,name=previous,proto3" json:"previous,omitempty"`
+}
+
+func (x *ChangeEvent) Reset() {
+	*x = ChangeEvent{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeEvent) ProtoMessage() {}
+
+func (x *ChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeEvent.ProtoReflect.Descriptor instead.
+func (*ChangeEvent) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ChangeEvent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ChangeEvent) GetOperation() ChangeEvent_Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return ChangeEvent_PUT
+}
+
+func (x *ChangeEvent) GetRecord() *DatabaseRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetPrevious() *DatabaseRecord {
+	if x != nil {
+		return x.Previous
+	}
+	return nil
+}
+
+// WriteOperation is one write of a batch.
+type WriteOperation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The record to write; a record with deleted set deletes the key
+	Record *DatabaseRecord `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	// Only write if the key's latest version is this one, as in SetRecordRequest
+	ExpectedVersion *uint64 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+}
+
+func (x *WriteOperation) Reset() {
+	*x = WriteOperation{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteOperation) ProtoMessage() {}
+
+func (x *WriteOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteOperation.ProtoReflect.Descriptor instead.
+func (*WriteOperation) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WriteOperation) GetRecord() *DatabaseRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *WriteOperation) GetExpectedVersion() uint64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type WriteBatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Operations []*WriteOperation `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"`
+}
+
+func (x *WriteBatchRequest) Reset() {
+	*x = WriteBatchRequest{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteBatchRequest) ProtoMessage() {}
+
+func (x *WriteBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteBatchRequest.ProtoReflect.Descriptor instead.
+func (*WriteBatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WriteBatchRequest) GetOperations() []*WriteOperation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+type WriteBatchResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Success bool `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// Commit version of the last write; the batch is visible to snapshots from here
+	Version uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *WriteBatchResponse) Reset() {
+	*x = WriteBatchResponse{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteBatchResponse) ProtoMessage() {}
+
+func (x *WriteBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteBatchResponse.ProtoReflect.Descriptor instead.
+func (*WriteBatchResponse) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WriteBatchResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *WriteBatchResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ScrubRecordsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ScrubRecordsRequest) Reset() {
+	*x = ScrubRecordsRequest{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScrubRecordsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScrubRecordsRequest) ProtoMessage() {}
+
+func (x *ScrubRecordsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScrubRecordsRequest.ProtoReflect.Descriptor instead.
+func (*ScrubRecordsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{16}
+}
+
+type ScrubRecordsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Number of versions verified
+	Checked uint64 `protobuf:"varint,1,opt,name=checked,proto3" json:"checked,omitempty"`
+	// Versions quarantined by this scrub
+	Quarantined []*DatabaseRecord `protobuf:"bytes,2,rep,name=quarantined,proto3" json:"quarantined,omitempty"`
+	// Keys whose latest version is quarantined and that must be rewritten to be
+	// readable again, including ones found by earlier scrubs
+	DamagedKeys []string `protobuf:"bytes,3,rep,name=damaged_keys,json=damagedKeys,proto3" json:"damaged_keys,omitempty"`
+}
+
+func (x *ScrubRecordsResponse) Reset() {
+	*x = ScrubRecordsResponse{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScrubRecordsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScrubRecordsResponse) ProtoMessage() {}
+
+func (x *ScrubRecordsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScrubRecordsResponse.ProtoReflect.Descriptor instead.
+func (*ScrubRecordsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ScrubRecordsResponse) GetChecked() uint64 {
+	if x != nil {
+		return x.Checked
+	}
+	return 0
+}
+
+func (x *ScrubRecordsResponse) GetQuarantined() []*DatabaseRecord {
+	if x != nil {
+		return x.Quarantined
+	}
+	return nil
+}
+
+func (x *ScrubRecordsResponse) GetDamagedKeys() []string {
+	if x != nil {
+		return x.DamagedKeys
+	}
+	return nil
+}
+
+// DatabaseCommand is a write replicated between the replicas of a database.
+type DatabaseCommand struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Commit time chosen by the leader, so every replica stores the same timestamps
+	Timestamp int64 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Types that are assignable to Op:
+	//	*DatabaseCommand_Set
+	//	*DatabaseCommand_Delete
+	//	*DatabaseCommand_CollectGarbageBefore
+	//	*DatabaseCommand_Batch
+	Op isDatabaseCommand_Op `protobuf_oneof:"op"`
+}
+
+func (x *DatabaseCommand) Reset() {
+	*x = DatabaseCommand{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DatabaseCommand) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DatabaseCommand) ProtoMessage() {}
+
+func (x *DatabaseCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DatabaseCommand.ProtoReflect.Descriptor instead.
+func (*DatabaseCommand) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *DatabaseCommand) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (m *DatabaseCommand) GetOp() isDatabaseCommand_Op {
+	if m != nil {
+		return m.Op
+	}
+	return nil
+}
+
+func (x *DatabaseCommand) GetSet() *DatabaseRecord {
+	if x, ok := x.GetOp().(*DatabaseCommand_Set); ok {
+		return x.Set
+	}
+	return nil
+}
+
+func (x *DatabaseCommand) GetDelete() string {
+	if x, ok := x.GetOp().(*DatabaseCommand_Delete); ok {
+		return x.Delete
+	}
+	return ""
+}
+
+func (x *DatabaseCommand) GetCollectGarbageBefore() int64 {
+	if x, ok := x.GetOp().(*DatabaseCommand_CollectGarbageBefore); ok {
+		return x.CollectGarbageBefore
+	}
+	return 0
+}
+
+func (x *DatabaseCommand) GetBatch() *WriteBatchRequest {
+	if x, ok := x.GetOp().(*DatabaseCommand_Batch); ok {
+		return x.Batch
+	}
+	return nil
+}
+
+type isDatabaseCommand_Op interface {
+	isDatabaseCommand_Op()
+}
+
+type DatabaseCommand_Set struct {
+	Set *DatabaseRecord `protobuf:"bytes,2,opt,name=set,proto3,oneof"`
+}
+
+type DatabaseCommand_Delete struct {
+	Delete string `protobuf:"bytes,3,opt,name=delete,proto3,oneof"`
+}
+
+type DatabaseCommand_CollectGarbageBefore struct {
+	// Drop versions superseded before this Unix nanosecond time
+	CollectGarbageBefore int64 `protobuf:"varint,4,opt,name=collect_garbage_before,json=collectGarbageBefore,proto3,oneof"`
+}
+
+type DatabaseCommand_Batch struct {
+	Batch *WriteBatchRequest `protobuf:"bytes,5,opt,name=batch,proto3,oneof"`
+}
+
+func (*DatabaseCommand_Set) isDatabaseCommand_Op() {}
+
+func (*DatabaseCommand_Delete) isDatabaseCommand_Op() {}
+
+func (*DatabaseCommand_CollectGarbageBefore) isDatabaseCommand_Op() {}
+
+func (*DatabaseCommand_Batch) isDatabaseCommand_Op() {}
+
+// StorageSnapshot is a full copy of a database replica's versioned state.
+type StorageSnapshot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Every retained version, grouped by key and oldest first within a key
+	Versions []*DatabaseRecord `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	// Keys whose older snapshots can no longer be served
+	LostLiveKeys []string `protobuf:"bytes,2,rep,name=lost_live_keys,json=lostLiveKeys,proto3" json:"lost_live_keys,omitempty"`
+	Version      uint64   `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	Horizon      uint64   `protobuf:"varint,4,opt,name=horizon,proto3" json:"horizon,omitempty"`
+	// Versions that failed their checksum and were taken out of service
+	Quarantined []*DatabaseRecord `protobuf:"bytes,5,rep,name=quarantined,proto3" json:"quarantined,omitempty"`
+}
+
+func (x *StorageSnapshot) Reset() {
+	*x = StorageSnapshot{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StorageSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageSnapshot) ProtoMessage() {}
+
+func (x *StorageSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageSnapshot.ProtoReflect.Descriptor instead.
+func (*StorageSnapshot) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StorageSnapshot) GetVersions() []*DatabaseRecord {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+func (x *StorageSnapshot) GetLostLiveKeys() []string {
+	if x != nil {
+		return x.LostLiveKeys
+	}
+	return nil
+}
+
+func (x *StorageSnapshot) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *StorageSnapshot) GetHorizon() uint64 {
+	if x != nil {
+		return x.Horizon
+	}
+	return 0
+}
+
+func (x *StorageSnapshot) GetQuarantined() []*DatabaseRecord {
+	if x != nil {
+		return x.Quarantined
+	}
+	return nil
+}
+
+// IdempotentResponse is a service's stored outcome of a request made with an
+// idempotency key, replayed when the request is retried.
+type IdempotentResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// SHA-256 of the request, so a key reused for another request is refused
+	RequestHash []byte `protobuf:"bytes,1,opt,name=request_hash,json=requestHash,proto3" json:"request_hash,omitempty"`
+	// Full name of the response message type; empty while the request is in progress
+	ResponseType string `protobuf:"bytes,2,opt,name=response_type,json=responseType,proto3" json:"response_type,omitempty"`
+	Response     []byte `protobuf:"bytes,3,opt,name=response,proto3" json:"response,omitempty"`
+	// Unix nanosecond time after which the key is forgotten
+	ExpiresAt int64 `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+}
+
+func (x *IdempotentResponse) Reset() {
+	*x = IdempotentResponse{}
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IdempotentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdempotentResponse) ProtoMessage() {}
+
+func (x *IdempotentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_mydatabase_mydatabase_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdempotentResponse.ProtoReflect.Descriptor instead.
+func (*IdempotentResponse) Descriptor() ([]byte, []int) {
+	return file_proto_mydatabase_mydatabase_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *IdempotentResponse) GetRequestHash() []byte {
+	if x != nil {
+		return x.RequestHash
+	}
+	return nil
+}
+
+func (x *IdempotentResponse) GetResponseType() string {
+	if x != nil {
+		return x.ResponseType
+	}
+	return ""
+}
+
+func (x *IdempotentResponse) GetResponse() []byte {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *IdempotentResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+var File_proto_mydatabase_mydatabase_proto protoreflect.FileDescriptor
+
+var file_proto_mydatabase_mydatabase_proto_rawDesc = []byte{
+	0x0a, 0x21, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61,
+	0x73, 0x65, 0x2f, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x22,
+	0xa6, 0x01, 0x0a, 0x0e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x22, 0x8b, 0x01, 0x0a, 0x10, 0x53, 0x65, 0x74,
+	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x32, 0x0a,
+	0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x12, 0x2e, 0x0a, 0x10, 0x65, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x0f, 0x65,
+	0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x88, 0x01,
+	0x01, 0x42, 0x13, 0x0a, 0x11, 0x5f, 0x65, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x47, 0x0a, 0x11, 0x53, 0x65, 0x74, 0x52, 0x65, 0x63,
+	0x6f, 0x72, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73,
+	0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22,
+	0x4f, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f,
+	0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x0f, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x22, 0x72, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61,
+	0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x52, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x6e, 0x61,
+	0x70, 0x73, 0x68, 0x6f, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0f, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x22, 0x6c, 0x0a, 0x13, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2e, 0x0a,
+	0x10, 0x65, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x0f, 0x65, 0x78, 0x70, 0x65, 0x63,
+	0x74, 0x65, 0x64, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x42, 0x13, 0x0a,
+	0x11, 0x5f, 0x65, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x22, 0x4a, 0x0a, 0x14, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x41,
+	0x0a, 0x17, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x48, 0x69, 0x73, 0x74, 0x6f,
+	0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x6c,
+	0x69, 0x6d, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69,
+	0x74, 0x22, 0x50, 0x0a, 0x18, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x48, 0x69,
+	0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x34, 0x0a,
+	0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61,
+	0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x07, 0x72, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x73, 0x22, 0x8e, 0x01, 0x0a, 0x12, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72,
+	0x65, 0x66, 0x69, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66,
+	0x69, 0x78, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x61, 0x66, 0x74, 0x65,
+	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x41, 0x66,
+	0x74, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x6e, 0x61,
+	0x70, 0x73, 0x68, 0x6f, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0f, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x22, 0xa0, 0x01, 0x0a, 0x13, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x63,
+	0x6f, 0x72, 0x64, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x34, 0x0a, 0x07,
+	0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x6e, 0x65,
+	0x78, 0x74, 0x53, 0x74, 0x61, 0x72, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x12, 0x29, 0x0a, 0x10,
+	0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0f, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x54, 0x0a, 0x13, 0x57, 0x61, 0x74, 0x63, 0x68,
+	0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x25,
+	0x0a, 0x0e, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x73, 0x74, 0x61, 0x72, 0x74, 0x53, 0x65, 0x71,
+	0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x22, 0xf8, 0x01,
+	0x0a, 0x0b, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a,
+	0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x3f, 0x0a, 0x09, 0x6f, 0x70, 0x65,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x21, 0x2e, 0x6d,
+	0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x32, 0x0a, 0x06, 0x72, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6d, 0x79, 0x64,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
+	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x36,
+	0x0a, 0x08, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44, 0x61,
+	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x08, 0x70, 0x72,
+	0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x22, 0x20, 0x0a, 0x09, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x07, 0x0a, 0x03, 0x50, 0x55, 0x54, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06,
+	0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x01, 0x22, 0x89, 0x01, 0x0a, 0x0e, 0x57, 0x72, 0x69,
+	0x74, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x32, 0x0a, 0x06, 0x72,
+	0x65, 0x63, 0x6f, 0x72, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6d, 0x79,
+	0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
+	0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12,
+	0x2e, 0x0a, 0x10, 0x65, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x0f, 0x65, 0x78, 0x70,
+	0x65, 0x63, 0x74, 0x65, 0x64, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x42,
+	0x13, 0x0a, 0x11, 0x5f, 0x65, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x22, 0x4f, 0x0a, 0x11, 0x57, 0x72, 0x69, 0x74, 0x65, 0x42, 0x61, 0x74,
+	0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x3a, 0x0a, 0x0a, 0x6f, 0x70, 0x65,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x57, 0x72, 0x69, 0x74, 0x65,
+	0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x6f, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x48, 0x0a, 0x12, 0x57, 0x72, 0x69, 0x74, 0x65, 0x42, 0x61,
+	0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73,
+	0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22,
+	0x15, 0x0a, 0x13, 0x53, 0x63, 0x72, 0x75, 0x62, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x91, 0x01, 0x0a, 0x14, 0x53, 0x63, 0x72, 0x75, 0x62,
+	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x07, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x64, 0x12, 0x3c, 0x0a, 0x0b, 0x71, 0x75, 0x61,
+	0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61,
+	0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x0b, 0x71, 0x75, 0x61, 0x72,
+	0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x61, 0x6d, 0x61, 0x67,
+	0x65, 0x64, 0x5f, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x64,
+	0x61, 0x6d, 0x61, 0x67, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x73, 0x22, 0xee, 0x01, 0x0a, 0x0f, 0x44,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x1c,
+	0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x2e, 0x0a, 0x03,
+	0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6d, 0x79, 0x64, 0x61,
+	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52,
+	0x65, 0x63, 0x6f, 0x72, 0x64, 0x48, 0x00, 0x52, 0x03, 0x73, 0x65, 0x74, 0x12, 0x18, 0x0a, 0x06,
+	0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x06,
+	0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x36, 0x0a, 0x16, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
+	0x74, 0x5f, 0x67, 0x61, 0x72, 0x62, 0x61, 0x67, 0x65, 0x5f, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x48, 0x00, 0x52, 0x14, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
+	0x74, 0x47, 0x61, 0x72, 0x62, 0x61, 0x67, 0x65, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x35,
+	0x0a, 0x05, 0x62, 0x61, 0x74, 0x63, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e,
+	0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x57, 0x72, 0x69, 0x74, 0x65,
+	0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x05,
+	0x62, 0x61, 0x74, 0x63, 0x68, 0x42, 0x04, 0x0a, 0x02, 0x6f, 0x70, 0x22, 0xe1, 0x01, 0x0a, 0x0f,
+	0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12,
+	0x36, 0x0a, 0x08, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x08, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x24, 0x0a, 0x0e, 0x6c, 0x6f, 0x73, 0x74, 0x5f,
+	0x6c, 0x69, 0x76, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0c, 0x6c, 0x6f, 0x73, 0x74, 0x4c, 0x69, 0x76, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x68, 0x6f, 0x72, 0x69, 0x7a,
+	0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x6f,
+	0x6e, 0x12, 0x3c, 0x0a, 0x0b, 0x71, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64,
+	0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x52, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x52, 0x0b, 0x71, 0x75, 0x61, 0x72, 0x61, 0x6e, 0x74, 0x69, 0x6e, 0x65, 0x64, 0x22,
+	0x97, 0x01, 0x0a, 0x12, 0x49, 0x64, 0x65, 0x6d, 0x70, 0x6f, 0x74, 0x65, 0x6e, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x61, 0x73, 0x68, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0c, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x78,
+	0x70, 0x69, 0x72, 0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x32, 0x93, 0x05, 0x0a, 0x0f, 0x44, 0x61,
+	0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x48, 0x0a,
+	0x09, 0x53, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x1c, 0x2e, 0x6d, 0x79, 0x64,
+	0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x53, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74,
+	0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x53, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x12, 0x1c, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
+	0x65, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e,
+	0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x51, 0x0a, 0x0c, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x12, 0x1f, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x44,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x20, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5d, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x23, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74,
+	0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x48,
+	0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e,
+	0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0b, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x73, 0x12, 0x1e, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e,
+	0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e,
+	0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x4a, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x43, 0x68, 0x61, 0x6e,
+	0x67, 0x65, 0x73, 0x12, 0x1f, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
+	0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73,
+	0x65, 0x2e, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x30, 0x01, 0x12,
+	0x51, 0x0a, 0x0c, 0x53, 0x63, 0x72, 0x75, 0x62, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12,
+	0x1f, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x53, 0x63, 0x72,
+	0x75, 0x62, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x20, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x53, 0x63,
+	0x72, 0x75, 0x62, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x4b, 0x0a, 0x0a, 0x57, 0x72, 0x69, 0x74, 0x65, 0x42, 0x61, 0x74, 0x63, 0x68,
+	0x12, 0x1d, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x57, 0x72,
+	0x69, 0x74, 0x65, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1e, 0x2e, 0x6d, 0x79, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x57, 0x72, 0x69,
+	0x74, 0x65, 0x42, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42,
+	0x14, 0x5a, 0x12, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x79, 0x64, 0x61, 0x74,
+	0x61, 0x62, 0x61, 0x73, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_mydatabase_mydatabase_proto_rawDescOnce sync.Once
+	file_proto_mydatabase_mydatabase_proto_rawDescData = file_proto_mydatabase_mydatabase_proto_rawDesc
+)
+
+func file_proto_mydatabase_mydatabase_proto_rawDescGZIP() []byte {
+	file_proto_mydatabase_mydatabase_proto_rawDescOnce.Do(func() {
+		file_proto_mydatabase_mydatabase_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_mydatabase_mydatabase_proto_rawDescData)
+	})
+	return file_proto_mydatabase_mydatabase_proto_rawDescData
+}
+
+var file_proto_mydatabase_mydatabase_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_mydatabase_mydatabase_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_proto_mydatabase_mydatabase_proto_goTypes = []any{
+	(ChangeEvent_Operation)(0),       // 0: mydatabase.ChangeEvent.Operation
+	(*DatabaseRecord)(nil),           // 1: mydatabase.DatabaseRecord
+	(*SetRecordRequest)(nil),         // 2: mydatabase.SetRecordRequest
+	(*SetRecordResponse)(nil),        // 3: mydatabase.SetRecordResponse
+	(*GetRecordRequest)(nil),         // 4: mydatabase.GetRecordRequest
+	(*GetRecordResponse)(nil),        // 5: mydatabase.GetRecordResponse
+	(*DeleteRecordRequest)(nil),      // 6: mydatabase.DeleteRecordRequest
+	(*DeleteRecordResponse)(nil),     // 7: mydatabase.DeleteRecordResponse
+	(*GetRecordHistoryRequest)(nil),  // 8: mydatabase.GetRecordHistoryRequest
+	(*GetRecordHistoryResponse)(nil), // 9: mydatabase.GetRecordHistoryResponse
+	(*ScanRecordsRequest)(nil),       // 10: mydatabase.ScanRecordsRequest
+	(*ScanRecordsResponse)(nil),      // 11: mydatabase.ScanRecordsResponse
+	(*WatchChangesRequest)(nil),      // 12: mydatabase.WatchChangesRequest
+	(*ChangeEvent)(nil),              // 13: mydatabase.ChangeEvent
+	(*WriteOperation)(nil),           // 14: mydatabase.WriteOperation
+	(*WriteBatchRequest)(nil),        // 15: mydatabase.WriteBatchRequest
+	(*WriteBatchResponse)(nil),       // 16: mydatabase.WriteBatchResponse
+	(*ScrubRecordsRequest)(nil),      // 17: mydatabase.ScrubRecordsRequest
+	(*ScrubRecordsResponse)(nil),     // 18: mydatabase.ScrubRecordsResponse
+	(*DatabaseCommand)(nil),          // 19: mydatabase.DatabaseCommand
+	(*StorageSnapshot)(nil),          // 20: mydatabase.StorageSnapshot
+	(*IdempotentResponse)(nil),       // 21: mydatabase.IdempotentResponse
+}
+var file_proto_mydatabase_mydatabase_proto_depIdxs = []int32{
+	1,  // 0: mydatabase.SetRecordRequest.record:type_name -> mydatabase.DatabaseRecord
+	1,  // 1: mydatabase.GetRecordResponse.record:type_name -> mydatabase.DatabaseRecord
+	1,  // 2: mydatabase.GetRecordHistoryResponse.records:type_name -> mydatabase.DatabaseRecord
+	1,  // 3: mydatabase.ScanRecordsResponse.records:type_name -> mydatabase.DatabaseRecord
+	0,  // 4: mydatabase.ChangeEvent.operation:type_name -> mydatabase.ChangeEvent.Operation
+	1,  // 5: mydatabase.ChangeEvent.record:type_name -> mydatabase.DatabaseRecord
+	1,  // 6: mydatabase.ChangeEvent.previous:type_name -> mydatabase.DatabaseRecord
+	1,  // 7: mydatabase.WriteOperation.record:type_name -> mydatabase.DatabaseRecord
+	14, // 8: mydatabase.WriteBatchRequest.operations:type_name -> mydatabase.WriteOperation
+	1,  // 9: mydatabase.ScrubRecordsResponse.quarantined:type_name -> mydatabase.DatabaseRecord
+	1,  // 10: mydatabase.DatabaseCommand.set:type_name -> mydatabase.DatabaseRecord
+	15, // 11: mydatabase.DatabaseCommand.batch:type_name -> mydatabase.WriteBatchRequest
+	1,  // 12: mydatabase.StorageSnapshot.versions:type_name -> mydatabase.DatabaseRecord
+	1,  // 13: mydatabase.StorageSnapshot.quarantined:type_name -> mydatabase.DatabaseRecord
+	2,  // 14: mydatabase.DatabaseService.SetRecord:input_type -> mydatabase.SetRecordRequest
+	4,  // 15: mydatabase.DatabaseService.GetRecord:input_type -> mydatabase.GetRecordRequest
+	6,  // 16: mydatabase.DatabaseService.DeleteRecord:input_type -> mydatabase.DeleteRecordRequest
+	8,  // 17: mydatabase.DatabaseService.GetRecordHistory:input_type -> mydatabase.GetRecordHistoryRequest
+	10, // 18: mydatabase.DatabaseService.ScanRecords:input_type -> mydatabase.ScanRecordsRequest
+	12, // 19: mydatabase.DatabaseService.WatchChanges:input_type -> mydatabase.WatchChangesRequest
+	17, // 20: mydatabase.DatabaseService.ScrubRecords:input_type -> mydatabase.ScrubRecordsRequest
+	15, // 21: mydatabase.DatabaseService.WriteBatch:input_type -> mydatabase.WriteBatchRequest
+	3,  // 22: mydatabase.DatabaseService.SetRecord:output_type -> mydatabase.SetRecordResponse
+	5,  // 23: mydatabase.DatabaseService.GetRecord:output_type -> mydatabase.GetRecordResponse
+	7,  // 24: mydatabase.DatabaseService.DeleteRecord:output_type -> mydatabase.DeleteRecordResponse
+	9,  // 25: mydatabase.DatabaseService.GetRecordHistory:output_type -> mydatabase.GetRecordHistoryResponse
+	11, // 26: mydatabase.DatabaseService.ScanRecords:output_type -> mydatabase.ScanRecordsResponse
+	13, // 27: mydatabase.DatabaseService.WatchChanges:output_type -> mydatabase.ChangeEvent
+	18, // 28: mydatabase.DatabaseService.ScrubRecords:output_type -> mydatabase.ScrubRecordsResponse
+	16, // 29: mydatabase.DatabaseService.WriteBatch:output_type -> mydatabase.WriteBatchResponse
+	22, // [22:30] is the sub-list for method output_type
+	14, // [14:22] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_proto_mydatabase_mydatabase_proto_init() }
+func file_proto_mydatabase_mydatabase_proto_init() {
+	if File_proto_mydatabase_mydatabase_proto != nil {
+		return
+	}
+	file_proto_mydatabase_mydatabase_proto_msgTypes[1].OneofWrappers = []any{}
+	file_proto_mydatabase_mydatabase_proto_msgTypes[5].OneofWrappers = []any{}
+	file_proto_mydatabase_mydatabase_proto_msgTypes[13].OneofWrappers = []any{}
+	file_proto_mydatabase_mydatabase_proto_msgTypes[18].OneofWrappers = []any{
+		(*DatabaseCommand_Set)(nil),
+		(*DatabaseCommand_Delete)(nil),
+		(*DatabaseCommand_CollectGarbageBefore)(nil),
+		(*DatabaseCommand_Batch)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_mydatabase_mydatabase_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      1,
+			NumMessages:   21,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_mydatabase_mydatabase_proto_goTypes,
 		DependencyIndexes: file_proto_mydatabase_mydatabase_proto_depIdxs,
+		EnumInfos:         file_proto_mydatabase_mydatabase_proto_enumTypes,
 		MessageInfos:      file_proto_mydatabase_mydatabase_proto_msgTypes,
 	}.Build()
 	File_proto_mydatabase_mydatabase_proto = out.File
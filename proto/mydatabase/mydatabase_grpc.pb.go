@@ -19,9 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	DatabaseService_SetRecord_FullMethodName    = "/mydatabase.DatabaseService/SetRecord"
-	DatabaseService_GetRecord_FullMethodName    = "/mydatabase.DatabaseService/GetRecord"
-	DatabaseService_DeleteRecord_FullMethodName = "/mydatabase.DatabaseService/DeleteRecord"
+	DatabaseService_SetRecord_FullMethodName        = "/mydatabase.DatabaseService/SetRecord"
+	DatabaseService_GetRecord_FullMethodName        = "/mydatabase.DatabaseService/GetRecord"
+	DatabaseService_DeleteRecord_FullMethodName     = "/mydatabase.DatabaseService/DeleteRecord"
+	DatabaseService_GetRecordHistory_FullMethodName = "/mydatabase.DatabaseService/GetRecordHistory"
+	DatabaseService_ScanRecords_FullMethodName      = "/mydatabase.DatabaseService/ScanRecords"
+	DatabaseService_WatchChanges_FullMethodName     = "/mydatabase.DatabaseService/WatchChanges"
+	DatabaseService_ScrubRecords_FullMethodName     = "/mydatabase.DatabaseService/ScrubRecords"
+	DatabaseService_WriteBatch_FullMethodName       = "/mydatabase.DatabaseService/WriteBatch"
 )
 
 // DatabaseServiceClient is the client API for DatabaseService service.
@@ -34,6 +39,16 @@ type DatabaseServiceClient interface {
 	GetRecord(ctx context.Context, in *GetRecordRequest, opts ...grpc.CallOption) (*GetRecordResponse, error)
 	// Delete a record from the database
 	DeleteRecord(ctx context.Context, in *DeleteRecordRequest, opts ...grpc.CallOption) (*DeleteRecordResponse, error)
+	// Get the retained versions of a record, newest first
+	GetRecordHistory(ctx context.Context, in *GetRecordHistoryRequest, opts ...grpc.CallOption) (*GetRecordHistoryResponse, error)
+	// Scan records in key order, one page at a time
+	ScanRecords(ctx context.Context, in *ScanRecordsRequest, opts ...grpc.CallOption) (*ScanRecordsResponse, error)
+	// Stream every put and delete in commit order
+	WatchChanges(ctx context.Context, in *WatchChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error)
+	// Verify every retained version on this replica and quarantine corrupt ones
+	ScrubRecords(ctx context.Context, in *ScrubRecordsRequest, opts ...grpc.CallOption) (*ScrubRecordsResponse, error)
+	// Apply several writes atomically: all of them or none
+	WriteBatch(ctx context.Context, in *WriteBatchRequest, opts ...grpc.CallOption) (*WriteBatchResponse, error)
 }
 
 type databaseServiceClient struct {
@@ -74,6 +89,65 @@ func (c *databaseServiceClient) DeleteRecord(ctx context.Context, in *DeleteReco
 	return out, nil
 }
 
+func (c *databaseServiceClient) GetRecordHistory(ctx context.Context, in *GetRecordHistoryRequest, opts ...grpc.CallOption) (*GetRecordHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRecordHistoryResponse)
+	err := c.cc.Invoke(ctx, DatabaseService_GetRecordHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *databaseServiceClient) ScanRecords(ctx context.Context, in *ScanRecordsRequest, opts ...grpc.CallOption) (*ScanRecordsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScanRecordsResponse)
+	err := c.cc.Invoke(ctx, DatabaseService_ScanRecords_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *databaseServiceClient) WatchChanges(ctx context.Context, in *WatchChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DatabaseService_ServiceDesc.Streams[0], DatabaseService_WatchChanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchChangesRequest, ChangeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DatabaseService_WatchChangesClient = grpc.ServerStreamingClient[ChangeEvent]
+
+func (c *databaseServiceClient) ScrubRecords(ctx context.Context, in *ScrubRecordsRequest, opts ...grpc.CallOption) (*ScrubRecordsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScrubRecordsResponse)
+	err := c.cc.Invoke(ctx, DatabaseService_ScrubRecords_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *databaseServiceClient) WriteBatch(ctx context.Context, in *WriteBatchRequest, opts ...grpc.CallOption) (*WriteBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteBatchResponse)
+	err := c.cc.Invoke(ctx, DatabaseService_WriteBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DatabaseServiceServer is the server API for DatabaseService service.
 // All implementations must embed UnimplementedDatabaseServiceServer
 // for forward compatibility.
@@ -84,6 +158,16 @@ type DatabaseServiceServer interface {
 	GetRecord(context.Context, *GetRecordRequest) (*GetRecordResponse, error)
 	// Delete a record from the database
 	DeleteRecord(context.Context, *DeleteRecordRequest) (*DeleteRecordResponse, error)
+	// Get the retained versions of a record, newest first
+	GetRecordHistory(context.Context, *GetRecordHistoryRequest) (*GetRecordHistoryResponse, error)
+	// Scan records in key order, one page at a time
+	ScanRecords(context.Context, *ScanRecordsRequest) (*ScanRecordsResponse, error)
+	// Stream every put and delete in commit order
+	WatchChanges(*WatchChangesRequest, grpc.ServerStreamingServer[ChangeEvent]) error
+	// Verify every retained version on this replica and quarantine corrupt ones
+	ScrubRecords(context.Context, *ScrubRecordsRequest) (*ScrubRecordsResponse, error)
+	// Apply several writes atomically: all of them or none
+	WriteBatch(context.Context, *WriteBatchRequest) (*WriteBatchResponse, error)
 	mustEmbedUnimplementedDatabaseServiceServer()
 }
 
@@ -103,6 +187,21 @@ func (UnimplementedDatabaseServiceServer) GetRecord(context.Context, *GetRecordR
 func (UnimplementedDatabaseServiceServer) DeleteRecord(context.Context, *DeleteRecordRequest) (*DeleteRecordResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteRecord not implemented")
 }
+func (UnimplementedDatabaseServiceServer) GetRecordHistory(context.Context, *GetRecordHistoryRequest) (*GetRecordHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecordHistory not implemented")
+}
+func (UnimplementedDatabaseServiceServer) ScanRecords(context.Context, *ScanRecordsRequest) (*ScanRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScanRecords not implemented")
+}
+func (UnimplementedDatabaseServiceServer) WatchChanges(*WatchChangesRequest, grpc.ServerStreamingServer[ChangeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchChanges not implemented")
+}
+func (UnimplementedDatabaseServiceServer) ScrubRecords(context.Context, *ScrubRecordsRequest) (*ScrubRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScrubRecords not implemented")
+}
+func (UnimplementedDatabaseServiceServer) WriteBatch(context.Context, *WriteBatchRequest) (*WriteBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WriteBatch not implemented")
+}
 func (UnimplementedDatabaseServiceServer) mustEmbedUnimplementedDatabaseServiceServer() {}
 func (UnimplementedDatabaseServiceServer) testEmbeddedByValue()                         {}
 
@@ -178,6 +277,89 @@ func _DatabaseService_DeleteRecord_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DatabaseService_GetRecordHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecordHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).GetRecordHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DatabaseService_GetRecordHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).GetRecordHistory(ctx, req.(*GetRecordHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DatabaseService_ScanRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).ScanRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DatabaseService_ScanRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).ScanRecords(ctx, req.(*ScanRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DatabaseService_WatchChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DatabaseServiceServer).WatchChanges(m, &grpc.GenericServerStream[WatchChangesRequest, ChangeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DatabaseService_WatchChangesServer = grpc.ServerStreamingServer[ChangeEvent]
+
+func _DatabaseService_ScrubRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScrubRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).ScrubRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DatabaseService_ScrubRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).ScrubRecords(ctx, req.(*ScrubRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DatabaseService_WriteBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DatabaseServiceServer).WriteBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DatabaseService_WriteBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DatabaseServiceServer).WriteBatch(ctx, req.(*WriteBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DatabaseService_ServiceDesc is the grpc.ServiceDesc for DatabaseService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -197,7 +379,29 @@ var DatabaseService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteRecord",
 			Handler:    _DatabaseService_DeleteRecord_Handler,
 		},
+		{
+			MethodName: "GetRecordHistory",
+			Handler:    _DatabaseService_GetRecordHistory_Handler,
+		},
+		{
+			MethodName: "ScanRecords",
+			Handler:    _DatabaseService_ScanRecords_Handler,
+		},
+		{
+			MethodName: "ScrubRecords",
+			Handler:    _DatabaseService_ScrubRecords_Handler,
+		},
+		{
+			MethodName: "WriteBatch",
+			Handler:    _DatabaseService_WriteBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchChanges",
+			Handler:       _DatabaseService_WatchChanges_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/mydatabase/mydatabase.proto",
 }
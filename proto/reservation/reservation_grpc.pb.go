@@ -23,9 +23,16 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ReservationService_MakeReservation_FullMethodName = "/reservation.ReservationService/MakeReservation"
-	ReservationService_GetReservation_FullMethodName  = "/reservation.ReservationService/GetReservation"
-	ReservationService_MostPopular_FullMethodName     = "/reservation.ReservationService/MostPopular"
+	ReservationService_MakeReservation_FullMethodName              = "/reservation.ReservationService/MakeReservation"
+	ReservationService_GetReservation_FullMethodName               = "/reservation.ReservationService/GetReservation"
+	ReservationService_CancelReservation_FullMethodName            = "/reservation.ReservationService/CancelReservation"
+	ReservationService_ModifyReservation_FullMethodName            = "/reservation.ReservationService/ModifyReservation"
+	ReservationService_DeleteRestaurantReservations_FullMethodName = "/reservation.ReservationService/DeleteRestaurantReservations"
+	ReservationService_GetAvailability_FullMethodName              = "/reservation.ReservationService/GetAvailability"
+	ReservationService_ListReservations_FullMethodName             = "/reservation.ReservationService/ListReservations"
+	ReservationService_MostPopular_FullMethodName                  = "/reservation.ReservationService/MostPopular"
+	ReservationService_Trending_FullMethodName                     = "/reservation.ReservationService/Trending"
+	ReservationService_RebuildPopularityTable_FullMethodName       = "/reservation.ReservationService/RebuildPopularityTable"
 )
 
 // ReservationServiceClient is the client API for ReservationService service.
@@ -38,8 +45,22 @@ type ReservationServiceClient interface {
 	MakeReservation(ctx context.Context, in *MakeReservationRequest, opts ...grpc.CallOption) (*MakeReservationResponse, error)
 	// GetReservation is an RPC method for retrieving restaurant reservations.
 	GetReservation(ctx context.Context, in *GetReservationRequest, opts ...grpc.CallOption) (*GetReservationResponse, error)
+	// CancelReservation is an RPC method for cancelling a reservation, promoting waitlisted parties into the seats it frees.
+	CancelReservation(ctx context.Context, in *CancelReservationRequest, opts ...grpc.CallOption) (*CancelReservationResponse, error)
+	// ModifyReservation is an RPC method for changing the date, time, duration or party size of a reservation.
+	ModifyReservation(ctx context.Context, in *ModifyReservationRequest, opts ...grpc.CallOption) (*ModifyReservationResponse, error)
+	// DeleteRestaurantReservations is an RPC method for deleting every reservation at a restaurant.
+	DeleteRestaurantReservations(ctx context.Context, in *DeleteRestaurantReservationsRequest, opts ...grpc.CallOption) (*DeleteRestaurantReservationsResponse, error)
+	// GetAvailability is an RPC method for finding a restaurant's open slots over a range of dates.
+	GetAvailability(ctx context.Context, in *GetAvailabilityRequest, opts ...grpc.CallOption) (*GetAvailabilityResponse, error)
+	// ListReservations is an RPC method for listing a user's or a restaurant's reservations.
+	ListReservations(ctx context.Context, in *ListReservationsRequest, opts ...grpc.CallOption) (*ListReservationsResponse, error)
 	// MostPopular is an RPC method for retrieving most popular restaurants.
 	MostPopular(ctx context.Context, in *MostPopularRequest, opts ...grpc.CallOption) (*MostPopularResponse, error)
+	// Trending is an RPC method for retrieving the restaurants whose reservations grew the most.
+	Trending(ctx context.Context, in *TrendingRequest, opts ...grpc.CallOption) (*TrendingResponse, error)
+	// Recount restaurant popularity from the database, e.g. after an import
+	RebuildPopularityTable(ctx context.Context, in *RebuildPopularityTableRequest, opts ...grpc.CallOption) (*RebuildPopularityTableResponse, error)
 }
 
 type reservationServiceClient struct {
@@ -70,6 +91,56 @@ func (c *reservationServiceClient) GetReservation(ctx context.Context, in *GetRe
 	return out, nil
 }
 
+func (c *reservationServiceClient) CancelReservation(ctx context.Context, in *CancelReservationRequest, opts ...grpc.CallOption) (*CancelReservationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelReservationResponse)
+	err := c.cc.Invoke(ctx, ReservationService_CancelReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reservationServiceClient) ModifyReservation(ctx context.Context, in *ModifyReservationRequest, opts ...grpc.CallOption) (*ModifyReservationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ModifyReservationResponse)
+	err := c.cc.Invoke(ctx, ReservationService_ModifyReservation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reservationServiceClient) DeleteRestaurantReservations(ctx context.Context, in *DeleteRestaurantReservationsRequest, opts ...grpc.CallOption) (*DeleteRestaurantReservationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteRestaurantReservationsResponse)
+	err := c.cc.Invoke(ctx, ReservationService_DeleteRestaurantReservations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reservationServiceClient) GetAvailability(ctx context.Context, in *GetAvailabilityRequest, opts ...grpc.CallOption) (*GetAvailabilityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAvailabilityResponse)
+	err := c.cc.Invoke(ctx, ReservationService_GetAvailability_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reservationServiceClient) ListReservations(ctx context.Context, in *ListReservationsRequest, opts ...grpc.CallOption) (*ListReservationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListReservationsResponse)
+	err := c.cc.Invoke(ctx, ReservationService_ListReservations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *reservationServiceClient) MostPopular(ctx context.Context, in *MostPopularRequest, opts ...grpc.CallOption) (*MostPopularResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(MostPopularResponse)
@@ -80,6 +151,26 @@ func (c *reservationServiceClient) MostPopular(ctx context.Context, in *MostPopu
 	return out, nil
 }
 
+func (c *reservationServiceClient) Trending(ctx context.Context, in *TrendingRequest, opts ...grpc.CallOption) (*TrendingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TrendingResponse)
+	err := c.cc.Invoke(ctx, ReservationService_Trending_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reservationServiceClient) RebuildPopularityTable(ctx context.Context, in *RebuildPopularityTableRequest, opts ...grpc.CallOption) (*RebuildPopularityTableResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RebuildPopularityTableResponse)
+	err := c.cc.Invoke(ctx, ReservationService_RebuildPopularityTable_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ReservationServiceServer is the server API for ReservationService service.
 // All implementations must embed UnimplementedReservationServiceServer
 // for forward compatibility.
@@ -90,8 +181,22 @@ type ReservationServiceServer interface {
 	MakeReservation(context.Context, *MakeReservationRequest) (*MakeReservationResponse, error)
 	// GetReservation is an RPC method for retrieving restaurant reservations.
 	GetReservation(context.Context, *GetReservationRequest) (*GetReservationResponse, error)
+	// CancelReservation is an RPC method for cancelling a reservation, promoting waitlisted parties into the seats it frees.
+	CancelReservation(context.Context, *CancelReservationRequest) (*CancelReservationResponse, error)
+	// ModifyReservation is an RPC method for changing the date, time, duration or party size of a reservation.
+	ModifyReservation(context.Context, *ModifyReservationRequest) (*ModifyReservationResponse, error)
+	// DeleteRestaurantReservations is an RPC method for deleting every reservation at a restaurant.
+	DeleteRestaurantReservations(context.Context, *DeleteRestaurantReservationsRequest) (*DeleteRestaurantReservationsResponse, error)
+	// GetAvailability is an RPC method for finding a restaurant's open slots over a range of dates.
+	GetAvailability(context.Context, *GetAvailabilityRequest) (*GetAvailabilityResponse, error)
+	// ListReservations is an RPC method for listing a user's or a restaurant's reservations.
+	ListReservations(context.Context, *ListReservationsRequest) (*ListReservationsResponse, error)
 	// MostPopular is an RPC method for retrieving most popular restaurants.
 	MostPopular(context.Context, *MostPopularRequest) (*MostPopularResponse, error)
+	// Trending is an RPC method for retrieving the restaurants whose reservations grew the most.
+	Trending(context.Context, *TrendingRequest) (*TrendingResponse, error)
+	// Recount restaurant popularity from the database, e.g. after an import
+	RebuildPopularityTable(context.Context, *RebuildPopularityTableRequest) (*RebuildPopularityTableResponse, error)
 	mustEmbedUnimplementedReservationServiceServer()
 }
 
@@ -108,9 +213,30 @@ func (UnimplementedReservationServiceServer) MakeReservation(context.Context, *M
 func (UnimplementedReservationServiceServer) GetReservation(context.Context, *GetReservationRequest) (*GetReservationResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetReservation not implemented")
 }
+func (UnimplementedReservationServiceServer) CancelReservation(context.Context, *CancelReservationRequest) (*CancelReservationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelReservation not implemented")
+}
+func (UnimplementedReservationServiceServer) ModifyReservation(context.Context, *ModifyReservationRequest) (*ModifyReservationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ModifyReservation not implemented")
+}
+func (UnimplementedReservationServiceServer) DeleteRestaurantReservations(context.Context, *DeleteRestaurantReservationsRequest) (*DeleteRestaurantReservationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRestaurantReservations not implemented")
+}
+func (UnimplementedReservationServiceServer) GetAvailability(context.Context, *GetAvailabilityRequest) (*GetAvailabilityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAvailability not implemented")
+}
+func (UnimplementedReservationServiceServer) ListReservations(context.Context, *ListReservationsRequest) (*ListReservationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReservations not implemented")
+}
 func (UnimplementedReservationServiceServer) MostPopular(context.Context, *MostPopularRequest) (*MostPopularResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MostPopular not implemented")
 }
+func (UnimplementedReservationServiceServer) Trending(context.Context, *TrendingRequest) (*TrendingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Trending not implemented")
+}
+func (UnimplementedReservationServiceServer) RebuildPopularityTable(context.Context, *RebuildPopularityTableRequest) (*RebuildPopularityTableResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RebuildPopularityTable not implemented")
+}
 func (UnimplementedReservationServiceServer) mustEmbedUnimplementedReservationServiceServer() {}
 func (UnimplementedReservationServiceServer) testEmbeddedByValue()                            {}
 
@@ -168,6 +294,96 @@ func _ReservationService_GetReservation_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ReservationService_CancelReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReservationServiceServer).CancelReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReservationService_CancelReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReservationServiceServer).CancelReservation(ctx, req.(*CancelReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReservationService_ModifyReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModifyReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReservationServiceServer).ModifyReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReservationService_ModifyReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReservationServiceServer).ModifyReservation(ctx, req.(*ModifyReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReservationService_DeleteRestaurantReservations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRestaurantReservationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReservationServiceServer).DeleteRestaurantReservations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReservationService_DeleteRestaurantReservations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReservationServiceServer).DeleteRestaurantReservations(ctx, req.(*DeleteRestaurantReservationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReservationService_GetAvailability_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAvailabilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReservationServiceServer).GetAvailability(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReservationService_GetAvailability_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReservationServiceServer).GetAvailability(ctx, req.(*GetAvailabilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReservationService_ListReservations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReservationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReservationServiceServer).ListReservations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReservationService_ListReservations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReservationServiceServer).ListReservations(ctx, req.(*ListReservationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ReservationService_MostPopular_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(MostPopularRequest)
 	if err := dec(in); err != nil {
@@ -186,6 +402,42 @@ func _ReservationService_MostPopular_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ReservationService_Trending_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrendingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReservationServiceServer).Trending(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReservationService_Trending_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReservationServiceServer).Trending(ctx, req.(*TrendingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReservationService_RebuildPopularityTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RebuildPopularityTableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReservationServiceServer).RebuildPopularityTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReservationService_RebuildPopularityTable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReservationServiceServer).RebuildPopularityTable(ctx, req.(*RebuildPopularityTableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ReservationService_ServiceDesc is the grpc.ServiceDesc for ReservationService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -201,10 +453,38 @@ var ReservationService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetReservation",
 			Handler:    _ReservationService_GetReservation_Handler,
 		},
+		{
+			MethodName: "CancelReservation",
+			Handler:    _ReservationService_CancelReservation_Handler,
+		},
+		{
+			MethodName: "ModifyReservation",
+			Handler:    _ReservationService_ModifyReservation_Handler,
+		},
+		{
+			MethodName: "DeleteRestaurantReservations",
+			Handler:    _ReservationService_DeleteRestaurantReservations_Handler,
+		},
+		{
+			MethodName: "GetAvailability",
+			Handler:    _ReservationService_GetAvailability_Handler,
+		},
+		{
+			MethodName: "ListReservations",
+			Handler:    _ReservationService_ListReservations_Handler,
+		},
 		{
 			MethodName: "MostPopular",
 			Handler:    _ReservationService_MostPopular_Handler,
 		},
+		{
+			MethodName: "Trending",
+			Handler:    _ReservationService_Trending_Handler,
+		},
+		{
+			MethodName: "RebuildPopularityTable",
+			Handler:    _ReservationService_RebuildPopularityTable_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/reservation/reservation.proto",
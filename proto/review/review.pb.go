@@ -24,6 +24,60 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ReviewSortOrder is the order SearchReviews returns reviews in. Ties go to
+// the newest review.
+type ReviewSortOrder int32
+
+const (
+	ReviewSortOrder_NEWEST        ReviewSortOrder = 0
+	ReviewSortOrder_HIGHEST_RATED ReviewSortOrder = 1
+	ReviewSortOrder_LOWEST_RATED  ReviewSortOrder = 2
+	ReviewSortOrder_MOST_HELPFUL  ReviewSortOrder = 3
+)
+
+// Enum value maps for ReviewSortOrder.
+var (
+	ReviewSortOrder_name = map[int32]string{
+		0: "NEWEST",
+		1: "HIGHEST_RATED",
+		2: "LOWEST_RATED",
+		3: "MOST_HELPFUL",
+	}
+	ReviewSortOrder_value = map[string]int32{
+		"NEWEST":        0,
+		"HIGHEST_RATED": 1,
+		"LOWEST_RATED":  2,
+		"MOST_HELPFUL":  3,
+	}
+)
+
+func (x ReviewSortOrder) Enum() *ReviewSortOrder {
+	p := new(ReviewSortOrder)
+	*p = x
+	return p
+}
+
+func (x ReviewSortOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ReviewSortOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_review_review_proto_enumTypes[0].Descriptor()
+}
+
+func (ReviewSortOrder) Type() protoreflect.EnumType {
+	return &file_proto_review_review_proto_enumTypes[0]
+}
+
+func (x ReviewSortOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ReviewSortOrder.Descriptor instead.
+func (ReviewSortOrder) EnumDescriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{0}
+}
+
 // PostReviewRequest is the request message to post a review.
 type PostReviewRequest struct {
 	state         protoimpl.MessageState
@@ -100,7 +154,9 @@ type PostReviewResponse struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Status bool `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Status          bool     `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Pending         bool     `protobuf:"varint,2,opt,name=pending,proto3" json:"pending,omitempty"`                                       // Held for moderation rather than published
+	ModerationFlags []string `protobuf:"bytes,3,rep,name=moderation_flags,json=moderationFlags,proto3" json:"moderation_flags,omitempty"` // Why the review was held
 }
 
 func (x *PostReviewResponse) Reset() {
@@ -140,6 +196,20 @@ func (x *PostReviewResponse) GetStatus() bool {
 	return false
 }
 
+func (x *PostReviewResponse) GetPending() bool {
+	if x != nil {
+		return x.Pending
+	}
+	return false
+}
+
+func (x *PostReviewResponse) GetModerationFlags() []string {
+	if x != nil {
+		return x.ModerationFlags
+	}
+	return nil
+}
+
 // GetReviewRequest is the request message for getting a review by a user.
 type GetReviewRequest struct {
 	state         protoimpl.MessageState
@@ -200,10 +270,17 @@ type GetReviewResponse struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	UserName       string `protobuf:"bytes,1,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
-	RestaurantName string `protobuf:"bytes,2,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
-	Review         string `protobuf:"bytes,3,opt,name=review,proto3" json:"review,omitempty"`
-	Rating         int32  `protobuf:"varint,4,opt,name=rating,proto3" json:"rating,omitempty"`
+	UserName        string   `protobuf:"bytes,1,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	RestaurantName  string   `protobuf:"bytes,2,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	Review          string   `protobuf:"bytes,3,opt,name=review,proto3" json:"review,omitempty"`
+	Rating          int32    `protobuf:"varint,4,opt,name=rating,proto3" json:"rating,omitempty"`
+	PostedAt        int64    `protobuf:"varint,5,opt,name=posted_at,json=postedAt,proto3" json:"posted_at,omitempty"`                      // Unix time in nanoseconds the review was last posted
+	HelpfulCount    int64    `protobuf:"varint,6,opt,name=helpful_count,json=helpfulCount,proto3" json:"helpful_count,omitempty"`          // Number of users who found the review helpful
+	EditedAt        int64    `protobuf:"varint,7,opt,name=edited_at,json=editedAt,proto3" json:"edited_at,omitempty"`                      // Unix time in nanoseconds the review was last edited, if it was
+	Deleted         bool     `protobuf:"varint,8,opt,name=deleted,proto3" json:"deleted,omitempty"`                                        // Set on a deleted review, which only its history shows
+	DeletedAt       int64    `protobuf:"varint,9,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`                   // Unix time in nanoseconds the review was deleted
+	Revision        int32    `protobuf:"varint,10,opt,name=revision,proto3" json:"revision,omitempty"`                                     // 1 when first posted, counting every change since
+	ModerationFlags []string `protobuf:"bytes,11,rep,name=moderation_flags,json=moderationFlags,proto3" json:"moderation_flags,omitempty"` // Why a review awaiting moderation was held
 }
 
 func (x *GetReviewResponse) Reset() {
@@ -264,29 +341,81 @@ func (x *GetReviewResponse) GetRating() int32 {
 	return 0
 }
 
-// SearchReviewsRequest is the request message to search for all reviews of a restaurant.
-type SearchReviewsRequest struct {
+func (x *GetReviewResponse) GetPostedAt() int64 {
+	if x != nil {
+		return x.PostedAt
+	}
+	return 0
+}
+
+func (x *GetReviewResponse) GetHelpfulCount() int64 {
+	if x != nil {
+		return x.HelpfulCount
+	}
+	return 0
+}
+
+func (x *GetReviewResponse) GetEditedAt() int64 {
+	if x != nil {
+		return x.EditedAt
+	}
+	return 0
+}
+
+func (x *GetReviewResponse) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *GetReviewResponse) GetDeletedAt() int64 {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return 0
+}
+
+func (x *GetReviewResponse) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *GetReviewResponse) GetModerationFlags() []string {
+	if x != nil {
+		return x.ModerationFlags
+	}
+	return nil
+}
+
+// EditReviewRequest is the request message to edit a review.
+type EditReviewRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	UserName       string `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+	Review         string `protobuf:"bytes,3,opt,name=review,proto3" json:"review,omitempty"`  // The new text, if set
+	Rating         int32  `protobuf:"varint,4,opt,name=rating,proto3" json:"rating,omitempty"` // The new rating, if set
 }
 
-func (x *SearchReviewsRequest) Reset() {
-	*x = SearchReviewsRequest{}
+func (x *EditReviewRequest) Reset() {
+	*x = EditReviewRequest{}
 	mi := &file_proto_review_review_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SearchReviewsRequest) String() string {
+func (x *EditReviewRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SearchReviewsRequest) ProtoMessage() {}
+func (*EditReviewRequest) ProtoMessage() {}
 
-func (x *SearchReviewsRequest) ProtoReflect() protoreflect.Message {
+func (x *EditReviewRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_review_review_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -298,42 +427,62 @@ func (x *SearchReviewsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SearchReviewsRequest.ProtoReflect.Descriptor instead.
-func (*SearchReviewsRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use EditReviewRequest.ProtoReflect.Descriptor instead.
+func (*EditReviewRequest) Descriptor() ([]byte, []int) {
 	return file_proto_review_review_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *SearchReviewsRequest) GetRestaurantName() string {
+func (x *EditReviewRequest) GetRestaurantName() string {
 	if x != nil {
 		return x.RestaurantName
 	}
 	return ""
 }
 
-// SearchReviewsResponse is the response message for the SearchReviews RPC method.
-type SearchReviewsResponse struct {
+func (x *EditReviewRequest) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *EditReviewRequest) GetReview() string {
+	if x != nil {
+		return x.Review
+	}
+	return ""
+}
+
+func (x *EditReviewRequest) GetRating() int32 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+// EditReviewResponse is the response message for the EditReview RPC method.
+type EditReviewResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// A map from user names to their respective reviews for a given restaurant
-	ReviewsMap map[string]*GetReviewResponse `protobuf:"bytes,1,rep,name=reviews_map,json=reviewsMap,proto3" json:"reviews_map,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Review *GetReviewResponse `protobuf:"bytes,1,opt,name=review,proto3" json:"review,omitempty"` // The review as edited
 }
 
-func (x *SearchReviewsResponse) Reset() {
-	*x = SearchReviewsResponse{}
+func (x *EditReviewResponse) Reset() {
+	*x = EditReviewResponse{}
 	mi := &file_proto_review_review_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SearchReviewsResponse) String() string {
+func (x *EditReviewResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SearchReviewsResponse) ProtoMessage() {}
+func (*EditReviewResponse) ProtoMessage() {}
 
-func (x *SearchReviewsResponse) ProtoReflect() protoreflect.Message {
+func (x *EditReviewResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_review_review_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -345,66 +494,1742 @@ func (x *SearchReviewsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SearchReviewsResponse.ProtoReflect.Descriptor instead.
-func (*SearchReviewsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use EditReviewResponse.ProtoReflect.Descriptor instead.
+func (*EditReviewResponse) Descriptor() ([]byte, []int) {
 	return file_proto_review_review_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *SearchReviewsResponse) GetReviewsMap() map[string]*GetReviewResponse {
+func (x *EditReviewResponse) GetReview() *GetReviewResponse {
 	if x != nil {
-		return x.ReviewsMap
+		return x.Review
 	}
 	return nil
 }
 
-var File_proto_review_review_proto protoreflect.FileDescriptor
+// DeleteReviewRequest is the request message to delete a review.
+type DeleteReviewRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_proto_review_review_proto_rawDesc = []byte{
-	0x0a, 0x19, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2f, 0x72,
-	0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x72, 0x65, 0x76,
-	0x69, 0x65, 0x77, 0x22, 0x89, 0x01, 0x0a, 0x11, 0x50, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x76, 0x69,
-	0x65, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73, 0x65,
-	0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73,
-	0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75,
-	0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12,
-	0x16, 0x0a, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e,
-	0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x22,
-	0x2c, 0x0a, 0x12, 0x50, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x58, 0x0a,
-	0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74,
-	0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73,
-	0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75,
-	0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x89, 0x01, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x52,
-	0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1b, 0x0a,
-	0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65,
-	0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e,
-	0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x16, 0x0a, 0x06, 0x72,
-	0x61, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72, 0x61, 0x74,
-	0x69, 0x6e, 0x67, 0x22, 0x3f, 0x0a, 0x14, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x76,
-	0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72,
-	0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74,
-	0x4e, 0x61, 0x6d, 0x65, 0x22, 0xc1, 0x01, 0x0a, 0x15, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52,
-	0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e,
-	0x0a, 0x0b, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x5f, 0x6d, 0x61, 0x70, 0x18, 0x01, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x2d, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x53, 0x65, 0x61,
-	0x72, 0x63, 0x68, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x2e, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74,
-	0x72, 0x79, 0x52, 0x0a, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x4d, 0x61, 0x70, 0x1a, 0x58,
-	0x0a, 0x0f, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x6b, 0x65, 0x79, 0x12, 0x2f, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x47, 0x65, 0x74, 0x52,
-	0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x05, 0x76,
-	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x32, 0xe4, 0x01, 0x0a, 0x0d, 0x52, 0x65, 0x76,
+	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	UserName       string `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+}
+
+func (x *DeleteReviewRequest) Reset() {
+	*x = DeleteReviewRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteReviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteReviewRequest) ProtoMessage() {}
+
+func (x *DeleteReviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteReviewRequest.ProtoReflect.Descriptor instead.
+func (*DeleteReviewRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteReviewRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+func (x *DeleteReviewRequest) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+// DeleteReviewResponse is the response message for the DeleteReview RPC method.
+type DeleteReviewResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeleteReviewResponse) Reset() {
+	*x = DeleteReviewResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteReviewResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteReviewResponse) ProtoMessage() {}
+
+func (x *DeleteReviewResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteReviewResponse.ProtoReflect.Descriptor instead.
+func (*DeleteReviewResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{7}
+}
+
+// GetReviewHistoryRequest is the request message to get the history of a review.
+type GetReviewHistoryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	UserName       string `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+}
+
+func (x *GetReviewHistoryRequest) Reset() {
+	*x = GetReviewHistoryRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReviewHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReviewHistoryRequest) ProtoMessage() {}
+
+func (x *GetReviewHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReviewHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetReviewHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetReviewHistoryRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+func (x *GetReviewHistoryRequest) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+// GetReviewHistoryResponse is the response message for the GetReviewHistory RPC method.
+type GetReviewHistoryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Revisions []*GetReviewResponse `protobuf:"bytes,1,rep,name=revisions,proto3" json:"revisions,omitempty"` // Oldest first, ending with the review as it is
+}
+
+func (x *GetReviewHistoryResponse) Reset() {
+	*x = GetReviewHistoryResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReviewHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReviewHistoryResponse) ProtoMessage() {}
+
+func (x *GetReviewHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReviewHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetReviewHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetReviewHistoryResponse) GetRevisions() []*GetReviewResponse {
+	if x != nil {
+		return x.Revisions
+	}
+	return nil
+}
+
+// VoteReviewRequest is the request message to vote for a review as helpful.
+type VoteReviewRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	UserName       string `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`    // The review's author
+	VoterName      string `protobuf:"bytes,3,opt,name=voter_name,json=voterName,proto3" json:"voter_name,omitempty"` // The user who found the review helpful
+	Retract        bool   `protobuf:"varint,4,opt,name=retract,proto3" json:"retract,omitempty"`                     // Take back the voter's earlier vote instead
+}
+
+func (x *VoteReviewRequest) Reset() {
+	*x = VoteReviewRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoteReviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteReviewRequest) ProtoMessage() {}
+
+func (x *VoteReviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteReviewRequest.ProtoReflect.Descriptor instead.
+func (*VoteReviewRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *VoteReviewRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+func (x *VoteReviewRequest) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *VoteReviewRequest) GetVoterName() string {
+	if x != nil {
+		return x.VoterName
+	}
+	return ""
+}
+
+func (x *VoteReviewRequest) GetRetract() bool {
+	if x != nil {
+		return x.Retract
+	}
+	return false
+}
+
+// VoteReviewResponse is the response message for the VoteReview RPC method.
+type VoteReviewResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	HelpfulCount int64 `protobuf:"varint,1,opt,name=helpful_count,json=helpfulCount,proto3" json:"helpful_count,omitempty"` // The review's votes, counting this one
+}
+
+func (x *VoteReviewResponse) Reset() {
+	*x = VoteReviewResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoteReviewResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteReviewResponse) ProtoMessage() {}
+
+func (x *VoteReviewResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteReviewResponse.ProtoReflect.Descriptor instead.
+func (*VoteReviewResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *VoteReviewResponse) GetHelpfulCount() int64 {
+	if x != nil {
+		return x.HelpfulCount
+	}
+	return 0
+}
+
+// ListPendingReviewsRequest is the request message to list the reviews held for moderation.
+type ListPendingReviewsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"` // Only list this restaurant's reviews, if set
+}
+
+func (x *ListPendingReviewsRequest) Reset() {
+	*x = ListPendingReviewsRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPendingReviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPendingReviewsRequest) ProtoMessage() {}
+
+func (x *ListPendingReviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPendingReviewsRequest.ProtoReflect.Descriptor instead.
+func (*ListPendingReviewsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListPendingReviewsRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+// ListPendingReviewsResponse is the response message for the ListPendingReviews RPC method.
+type ListPendingReviewsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reviews []*GetReviewResponse `protobuf:"bytes,1,rep,name=reviews,proto3" json:"reviews,omitempty"` // Oldest first
+}
+
+func (x *ListPendingReviewsResponse) Reset() {
+	*x = ListPendingReviewsResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPendingReviewsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPendingReviewsResponse) ProtoMessage() {}
+
+func (x *ListPendingReviewsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPendingReviewsResponse.ProtoReflect.Descriptor instead.
+func (*ListPendingReviewsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListPendingReviewsResponse) GetReviews() []*GetReviewResponse {
+	if x != nil {
+		return x.Reviews
+	}
+	return nil
+}
+
+// ApproveReviewRequest is the request message to publish a review held for moderation.
+type ApproveReviewRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	UserName       string `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+}
+
+func (x *ApproveReviewRequest) Reset() {
+	*x = ApproveReviewRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApproveReviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApproveReviewRequest) ProtoMessage() {}
+
+func (x *ApproveReviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApproveReviewRequest.ProtoReflect.Descriptor instead.
+func (*ApproveReviewRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ApproveReviewRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+func (x *ApproveReviewRequest) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+// ApproveReviewResponse is the response message for the ApproveReview RPC method.
+type ApproveReviewResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Review *GetReviewResponse `protobuf:"bytes,1,opt,name=review,proto3" json:"review,omitempty"` // The review as published
+}
+
+func (x *ApproveReviewResponse) Reset() {
+	*x = ApproveReviewResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApproveReviewResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApproveReviewResponse) ProtoMessage() {}
+
+func (x *ApproveReviewResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApproveReviewResponse.ProtoReflect.Descriptor instead.
+func (*ApproveReviewResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ApproveReviewResponse) GetReview() *GetReviewResponse {
+	if x != nil {
+		return x.Review
+	}
+	return nil
+}
+
+// RejectReviewRequest is the request message to discard a review held for moderation.
+type RejectReviewRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	UserName       string `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+}
+
+func (x *RejectReviewRequest) Reset() {
+	*x = RejectReviewRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RejectReviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RejectReviewRequest) ProtoMessage() {}
+
+func (x *RejectReviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RejectReviewRequest.ProtoReflect.Descriptor instead.
+func (*RejectReviewRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RejectReviewRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+func (x *RejectReviewRequest) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+// RejectReviewResponse is the response message for the RejectReview RPC method.
+type RejectReviewResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RejectReviewResponse) Reset() {
+	*x = RejectReviewResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RejectReviewResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RejectReviewResponse) ProtoMessage() {}
+
+func (x *RejectReviewResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RejectReviewResponse.ProtoReflect.Descriptor instead.
+func (*RejectReviewResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{17}
+}
+
+// PostingTimes is the stored list of when a user recently submitted reviews.
+type PostingTimes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PostedAt []int64 `protobuf:"varint,1,rep,packed,name=posted_at,json=postedAt,proto3" json:"posted_at,omitempty"` // Unix time in nanoseconds, oldest first
+}
+
+func (x *PostingTimes) Reset() {
+	*x = PostingTimes{}
+	mi := &file_proto_review_review_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostingTimes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostingTimes) ProtoMessage() {}
+
+func (x *PostingTimes) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostingTimes.ProtoReflect.Descriptor instead.
+func (*PostingTimes) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PostingTimes) GetPostedAt() []int64 {
+	if x != nil {
+		return x.PostedAt
+	}
+	return nil
+}
+
+// SearchReviewsRequest is the request message to search for the reviews of a restaurant.
+type SearchReviewsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestaurantName string          `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	PageSize       int32           `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // At most 100; 0 returns every review in reviews_map only
+	PageToken      string          `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // From the previous page, to get the next one
+	SortOrder      ReviewSortOrder `protobuf:"varint,4,opt,name=sort_order,json=sortOrder,proto3,enum=review.ReviewSortOrder" json:"sort_order,omitempty"`
+}
+
+func (x *SearchReviewsRequest) Reset() {
+	*x = SearchReviewsRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchReviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchReviewsRequest) ProtoMessage() {}
+
+func (x *SearchReviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchReviewsRequest.ProtoReflect.Descriptor instead.
+func (*SearchReviewsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SearchReviewsRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+func (x *SearchReviewsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *SearchReviewsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *SearchReviewsRequest) GetSortOrder() ReviewSortOrder {
+	if x != nil {
+		return x.SortOrder
+	}
+	return ReviewSortOrder_NEWEST
+}
+
+// SearchReviewsResponse is the response message for the SearchReviews RPC method.
+type SearchReviewsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// A map from user names to their respective reviews for a given restaurant,
+	// when every review is requested
+	ReviewsMap    map[string]*GetReviewResponse `protobuf:"bytes,1,rep,name=reviews_map,json=reviewsMap,proto3" json:"reviews_map,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Reviews       []*GetReviewResponse          `protobuf:"bytes,2,rep,name=reviews,proto3" json:"reviews,omitempty"`                                    // A page of reviews in order
+	NextPageToken string                        `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // Empty on the last page
+}
+
+func (x *SearchReviewsResponse) Reset() {
+	*x = SearchReviewsResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchReviewsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchReviewsResponse) ProtoMessage() {}
+
+func (x *SearchReviewsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchReviewsResponse.ProtoReflect.Descriptor instead.
+func (*SearchReviewsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SearchReviewsResponse) GetReviewsMap() map[string]*GetReviewResponse {
+	if x != nil {
+		return x.ReviewsMap
+	}
+	return nil
+}
+
+func (x *SearchReviewsResponse) GetReviews() []*GetReviewResponse {
+	if x != nil {
+		return x.Reviews
+	}
+	return nil
+}
+
+func (x *SearchReviewsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// ReviewIndex is the stored list of a restaurant's reviews.
+type ReviewIndex struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReviewIds []string `protobuf:"bytes,1,rep,name=review_ids,json=reviewIds,proto3" json:"review_ids,omitempty"` // Sorted review IDs
+}
+
+func (x *ReviewIndex) Reset() {
+	*x = ReviewIndex{}
+	mi := &file_proto_review_review_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReviewIndex) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewIndex) ProtoMessage() {}
+
+func (x *ReviewIndex) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewIndex.ProtoReflect.Descriptor instead.
+func (*ReviewIndex) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ReviewIndex) GetReviewIds() []string {
+	if x != nil {
+		return x.ReviewIds
+	}
+	return nil
+}
+
+// DeleteRestaurantReviewsRequest is the request message to delete a restaurant's reviews.
+type DeleteRestaurantReviewsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+}
+
+func (x *DeleteRestaurantReviewsRequest) Reset() {
+	*x = DeleteRestaurantReviewsRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRestaurantReviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRestaurantReviewsRequest) ProtoMessage() {}
+
+func (x *DeleteRestaurantReviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRestaurantReviewsRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRestaurantReviewsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeleteRestaurantReviewsRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+// DeleteRestaurantReviewsResponse is the response message for the DeleteRestaurantReviews RPC method.
+type DeleteRestaurantReviewsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reviews int32 `protobuf:"varint,1,opt,name=reviews,proto3" json:"reviews,omitempty"` // Number of reviews deleted
+}
+
+func (x *DeleteRestaurantReviewsResponse) Reset() {
+	*x = DeleteRestaurantReviewsResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRestaurantReviewsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRestaurantReviewsResponse) ProtoMessage() {}
+
+func (x *DeleteRestaurantReviewsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRestaurantReviewsResponse.ProtoReflect.Descriptor instead.
+func (*DeleteRestaurantReviewsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DeleteRestaurantReviewsResponse) GetReviews() int32 {
+	if x != nil {
+		return x.Reviews
+	}
+	return 0
+}
+
+// GetRatingSummaryRequest is the request message for getting a restaurant's ratings.
+type GetRatingSummaryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestaurantName string `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+}
+
+func (x *GetRatingSummaryRequest) Reset() {
+	*x = GetRatingSummaryRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRatingSummaryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRatingSummaryRequest) ProtoMessage() {}
+
+func (x *GetRatingSummaryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRatingSummaryRequest.ProtoReflect.Descriptor instead.
+func (*GetRatingSummaryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetRatingSummaryRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+// GetRatingSummaryResponse is the response message for the GetRatingSummary RPC method.
+type GetRatingSummaryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestaurantName string  `protobuf:"bytes,1,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	Count          int64   `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`                // Number of reviews
+	Mean           float64 `protobuf:"fixed64,3,opt,name=mean,proto3" json:"mean,omitempty"`                 // Mean rating; 0 without reviews
+	Histogram      []int64 `protobuf:"varint,4,rep,packed,name=histogram,proto3" json:"histogram,omitempty"` // Number of reviews rated 1 to 5
+	// The mean with the mean rating of every restaurant's reviews weighted in as
+	// if it were a few more reviews, so restaurants with few reviews rank less
+	// far from the middle; 0 without reviews
+	BayesianScore float64 `protobuf:"fixed64,5,opt,name=bayesian_score,json=bayesianScore,proto3" json:"bayesian_score,omitempty"`
+}
+
+func (x *GetRatingSummaryResponse) Reset() {
+	*x = GetRatingSummaryResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRatingSummaryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRatingSummaryResponse) ProtoMessage() {}
+
+func (x *GetRatingSummaryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRatingSummaryResponse.ProtoReflect.Descriptor instead.
+func (*GetRatingSummaryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetRatingSummaryResponse) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+func (x *GetRatingSummaryResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GetRatingSummaryResponse) GetMean() float64 {
+	if x != nil {
+		return x.Mean
+	}
+	return 0
+}
+
+func (x *GetRatingSummaryResponse) GetHistogram() []int64 {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+func (x *GetRatingSummaryResponse) GetBayesianScore() float64 {
+	if x != nil {
+		return x.BayesianScore
+	}
+	return 0
+}
+
+// RatingAggregate is the stored sum of the ratings of a restaurant's reviews,
+// or of every review.
+type RatingAggregate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count     int64   `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Total     int64   `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`                // Sum of the ratings
+	Histogram []int64 `protobuf:"varint,3,rep,packed,name=histogram,proto3" json:"histogram,omitempty"` // Number of reviews rated 1 to 5
+}
+
+func (x *RatingAggregate) Reset() {
+	*x = RatingAggregate{}
+	mi := &file_proto_review_review_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RatingAggregate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RatingAggregate) ProtoMessage() {}
+
+func (x *RatingAggregate) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RatingAggregate.ProtoReflect.Descriptor instead.
+func (*RatingAggregate) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *RatingAggregate) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *RatingAggregate) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *RatingAggregate) GetHistogram() []int64 {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+// SearchReviewTextRequest is the request message to search the text of reviews.
+type SearchReviewTextRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Query          string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	RestaurantName string `protobuf:"bytes,2,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"` // Only search this restaurant's reviews, if set
+	MinRating      int32  `protobuf:"varint,3,opt,name=min_rating,json=minRating,proto3" json:"min_rating,omitempty"`               // Only search reviews rated at least this, if set
+	MaxRating      int32  `protobuf:"varint,4,opt,name=max_rating,json=maxRating,proto3" json:"max_rating,omitempty"`               // Only search reviews rated at most this, if set
+	Limit          int32  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`                                        // At most 100; 0 returns 10
+}
+
+func (x *SearchReviewTextRequest) Reset() {
+	*x = SearchReviewTextRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchReviewTextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchReviewTextRequest) ProtoMessage() {}
+
+func (x *SearchReviewTextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchReviewTextRequest.ProtoReflect.Descriptor instead.
+func (*SearchReviewTextRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *SearchReviewTextRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchReviewTextRequest) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+func (x *SearchReviewTextRequest) GetMinRating() int32 {
+	if x != nil {
+		return x.MinRating
+	}
+	return 0
+}
+
+func (x *SearchReviewTextRequest) GetMaxRating() int32 {
+	if x != nil {
+		return x.MaxRating
+	}
+	return 0
+}
+
+func (x *SearchReviewTextRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// ReviewTextMatch is a review matching a text search.
+type ReviewTextMatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Review  *GetReviewResponse `protobuf:"bytes,1,opt,name=review,proto3" json:"review,omitempty"`
+	Score   float64            `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`   // BM25 relevance to the query; higher is better
+	Snippet string             `protobuf:"bytes,3,opt,name=snippet,proto3" json:"snippet,omitempty"` // Text around the first match, with matching words in <em> tags
+}
+
+func (x *ReviewTextMatch) Reset() {
+	*x = ReviewTextMatch{}
+	mi := &file_proto_review_review_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReviewTextMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewTextMatch) ProtoMessage() {}
+
+func (x *ReviewTextMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewTextMatch.ProtoReflect.Descriptor instead.
+func (*ReviewTextMatch) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ReviewTextMatch) GetReview() *GetReviewResponse {
+	if x != nil {
+		return x.Review
+	}
+	return nil
+}
+
+func (x *ReviewTextMatch) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *ReviewTextMatch) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+// SearchReviewTextResponse is the response message for the SearchReviewText RPC method.
+type SearchReviewTextResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Matches []*ReviewTextMatch `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"` // Most relevant first
+}
+
+func (x *SearchReviewTextResponse) Reset() {
+	*x = SearchReviewTextResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchReviewTextResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchReviewTextResponse) ProtoMessage() {}
+
+func (x *SearchReviewTextResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchReviewTextResponse.ProtoReflect.Descriptor instead.
+func (*SearchReviewTextResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *SearchReviewTextResponse) GetMatches() []*ReviewTextMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+// TextPosting is the stored entry of a review in the list of reviews using a term.
+type TextPosting struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TermFrequency  int32  `protobuf:"varint,1,opt,name=term_frequency,json=termFrequency,proto3" json:"term_frequency,omitempty"` // Times the review uses the term
+	ReviewLength   int32  `protobuf:"varint,2,opt,name=review_length,json=reviewLength,proto3" json:"review_length,omitempty"`    // Terms in the review
+	RestaurantName string `protobuf:"bytes,3,opt,name=restaurant_name,json=restaurantName,proto3" json:"restaurant_name,omitempty"`
+	Rating         int32  `protobuf:"varint,4,opt,name=rating,proto3" json:"rating,omitempty"`
+}
+
+func (x *TextPosting) Reset() {
+	*x = TextPosting{}
+	mi := &file_proto_review_review_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextPosting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextPosting) ProtoMessage() {}
+
+func (x *TextPosting) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextPosting.ProtoReflect.Descriptor instead.
+func (*TextPosting) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *TextPosting) GetTermFrequency() int32 {
+	if x != nil {
+		return x.TermFrequency
+	}
+	return 0
+}
+
+func (x *TextPosting) GetReviewLength() int32 {
+	if x != nil {
+		return x.ReviewLength
+	}
+	return 0
+}
+
+func (x *TextPosting) GetRestaurantName() string {
+	if x != nil {
+		return x.RestaurantName
+	}
+	return ""
+}
+
+func (x *TextPosting) GetRating() int32 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+// TextStats is the stored count of the reviews in the text index and their terms.
+type TextStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reviews int64 `protobuf:"varint,1,opt,name=reviews,proto3" json:"reviews,omitempty"`
+	Terms   int64 `protobuf:"varint,2,opt,name=terms,proto3" json:"terms,omitempty"`
+}
+
+func (x *TextStats) Reset() {
+	*x = TextStats{}
+	mi := &file_proto_review_review_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextStats) ProtoMessage() {}
+
+func (x *TextStats) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextStats.ProtoReflect.Descriptor instead.
+func (*TextStats) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *TextStats) GetReviews() int64 {
+	if x != nil {
+		return x.Reviews
+	}
+	return 0
+}
+
+func (x *TextStats) GetTerms() int64 {
+	if x != nil {
+		return x.Terms
+	}
+	return 0
+}
+
+type RebuildLookupTableRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RebuildLookupTableRequest) Reset() {
+	*x = RebuildLookupTableRequest{}
+	mi := &file_proto_review_review_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RebuildLookupTableRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RebuildLookupTableRequest) ProtoMessage() {}
+
+func (x *RebuildLookupTableRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RebuildLookupTableRequest.ProtoReflect.Descriptor instead.
+func (*RebuildLookupTableRequest) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{32}
+}
+
+type RebuildLookupTableResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reviews int32 `protobuf:"varint,1,opt,name=reviews,proto3" json:"reviews,omitempty"` // Number of reviews indexed
+}
+
+func (x *RebuildLookupTableResponse) Reset() {
+	*x = RebuildLookupTableResponse{}
+	mi := &file_proto_review_review_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RebuildLookupTableResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RebuildLookupTableResponse) ProtoMessage() {}
+
+func (x *RebuildLookupTableResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_review_review_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RebuildLookupTableResponse.ProtoReflect.Descriptor instead.
+func (*RebuildLookupTableResponse) Descriptor() ([]byte, []int) {
+	return file_proto_review_review_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *RebuildLookupTableResponse) GetReviews() int32 {
+	if x != nil {
+		return x.Reviews
+	}
+	return 0
+}
+
+var File_proto_review_review_proto protoreflect.FileDescriptor
+
+var file_proto_review_review_proto_rawDesc = []byte{
+	0x0a, 0x19, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2f, 0x72,
+	0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x72, 0x65, 0x76,
+	0x69, 0x65, 0x77, 0x22, 0x89, 0x01, 0x0a, 0x11, 0x50, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73, 0x65,
+	0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73,
+	0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75,
+	0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x16, 0x0a, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e,
+	0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x22,
+	0x71, 0x0a, 0x12, 0x50, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a,
+	0x07, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07,
+	0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x29, 0x0a, 0x10, 0x6d, 0x6f, 0x64, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0f, 0x6d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x6c, 0x61,
+	0x67, 0x73, 0x22, 0x58, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75,
+	0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x1b, 0x0a, 0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xe8, 0x02, 0x0a,
+	0x11, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75,
+	0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77,
+	0x12, 0x16, 0x0a, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6f, 0x73, 0x74,
+	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x70, 0x6f, 0x73,
+	0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x68, 0x65, 0x6c, 0x70, 0x66, 0x75, 0x6c,
+	0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x68, 0x65,
+	0x6c, 0x70, 0x66, 0x75, 0x6c, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x65, 0x64,
+	0x69, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x65,
+	0x64, 0x69, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18,
+	0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x41, 0x74,
+	0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x0a, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x29, 0x0a, 0x10,
+	0x6d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x66, 0x6c, 0x61, 0x67, 0x73,
+	0x18, 0x0b, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x6d, 0x6f, 0x64, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x46, 0x6c, 0x61, 0x67, 0x73, 0x22, 0x89, 0x01, 0x0a, 0x11, 0x45, 0x64, 0x69, 0x74,
+	0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a,
+	0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61,
+	0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x16, 0x0a, 0x06, 0x72,
+	0x61, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72, 0x61, 0x74,
+	0x69, 0x6e, 0x67, 0x22, 0x47, 0x0a, 0x12, 0x45, 0x64, 0x69, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x72, 0x65, 0x76,
+	0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x72, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x52, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x22, 0x5b, 0x0a, 0x13,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e,
+	0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65,
+	0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09,
+	0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x16, 0x0a, 0x14, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x22, 0x5f, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x48, 0x69,
+	0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f,
+	0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e,
+	0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61,
+	0x6d, 0x65, 0x22, 0x53, 0x0a, 0x18, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x48,
+	0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x37,
+	0x0a, 0x09, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65,
+	0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x09, 0x72, 0x65,
+	0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x92, 0x01, 0x0a, 0x11, 0x56, 0x6f, 0x74, 0x65,
+	0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a,
+	0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61,
+	0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x76, 0x6f, 0x74, 0x65, 0x72, 0x4e, 0x61,
+	0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x74, 0x72, 0x61, 0x63, 0x74, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x65, 0x74, 0x72, 0x61, 0x63, 0x74, 0x22, 0x39, 0x0a, 0x12,
+	0x56, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x68, 0x65, 0x6c, 0x70, 0x66, 0x75, 0x6c, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x68, 0x65, 0x6c, 0x70, 0x66,
+	0x75, 0x6c, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x44, 0x0a, 0x19, 0x4c, 0x69, 0x73, 0x74, 0x50,
+	0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61,
+	0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72,
+	0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x51, 0x0a,
+	0x1a, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x33, 0x0a, 0x07, 0x72,
+	0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x72,
+	0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x07, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73,
+	0x22, 0x5c, 0x0a, 0x14, 0x41, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74,
+	0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d,
+	0x65, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x4a,
+	0x0a, 0x15, 0x41, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77,
+	0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x52, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x22, 0x5b, 0x0a, 0x13, 0x52, 0x65,
+	0x6a, 0x65, 0x63, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74,
+	0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73,
+	0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75,
+	0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x16, 0x0a, 0x14, 0x52, 0x65, 0x6a, 0x65, 0x63,
+	0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x2b, 0x0a, 0x0c, 0x50, 0x6f, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x12,
+	0x1b, 0x0a, 0x09, 0x70, 0x6f, 0x73, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x03, 0x52, 0x08, 0x70, 0x6f, 0x73, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0xb3, 0x01, 0x0a,
+	0x14, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72,
+	0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e,
+	0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1b,
+	0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70,
+	0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x36, 0x0a, 0x0a, 0x73, 0x6f,
+	0x72, 0x74, 0x5f, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17,
+	0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x53, 0x6f,
+	0x72, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x09, 0x73, 0x6f, 0x72, 0x74, 0x4f, 0x72, 0x64,
+	0x65, 0x72, 0x22, 0x9e, 0x02, 0x0a, 0x15, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x76,
+	0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0b,
+	0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x5f, 0x6d, 0x61, 0x70, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x2d, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63,
+	0x68, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x2e, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x0a, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x4d, 0x61, 0x70, 0x12, 0x33, 0x0a, 0x07,
+	0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x07, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77,
+	0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74,
+	0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x1a, 0x58, 0x0a, 0x0f, 0x52, 0x65, 0x76,
+	0x69, 0x65, 0x77, 0x73, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2f,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x2c, 0x0a, 0x0b, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x49, 0x6e, 0x64,
+	0x65, 0x78, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x5f, 0x69, 0x64, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x49, 0x64,
+	0x73, 0x22, 0x49, 0x0a, 0x1e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x74, 0x61,
+	0x75, 0x72, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e,
+	0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65,
+	0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x3b, 0x0a, 0x1f,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74,
+	0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x07, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x22, 0x42, 0x0a, 0x17, 0x47, 0x65, 0x74,
+	0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61,
+	0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72,
+	0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xb2, 0x01,
+	0x0a, 0x18, 0x47, 0x65, 0x74, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x53, 0x75, 0x6d, 0x6d, 0x61,
+	0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65,
+	0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x65, 0x61,
+	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x6d, 0x65, 0x61, 0x6e, 0x12, 0x1c, 0x0a,
+	0x09, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x18, 0x04, 0x20, 0x03, 0x28, 0x03,
+	0x52, 0x09, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x12, 0x25, 0x0a, 0x0e, 0x62,
+	0x61, 0x79, 0x65, 0x73, 0x69, 0x61, 0x6e, 0x5f, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x0d, 0x62, 0x61, 0x79, 0x65, 0x73, 0x69, 0x61, 0x6e, 0x53, 0x63, 0x6f,
+	0x72, 0x65, 0x22, 0x5b, 0x0a, 0x0f, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x41, 0x67, 0x67, 0x72,
+	0x65, 0x67, 0x61, 0x74, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x12, 0x1c, 0x0a, 0x09, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x18, 0x03,
+	0x20, 0x03, 0x28, 0x03, 0x52, 0x09, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x22,
+	0xac, 0x01, 0x0a, 0x17, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77,
+	0x54, 0x65, 0x78, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x71,
+	0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71, 0x75, 0x65, 0x72,
+	0x79, 0x12, 0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74,
+	0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x69,
+	0x6e, 0x5f, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09,
+	0x6d, 0x69, 0x6e, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x61, 0x78,
+	0x5f, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x6d,
+	0x61, 0x78, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69,
+	0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x74,
+	0x0a, 0x0f, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x54, 0x65, 0x78, 0x74, 0x4d, 0x61, 0x74, 0x63,
+	0x68, 0x12, 0x31, 0x0a, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65,
+	0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x06, 0x72, 0x65,
+	0x76, 0x69, 0x65, 0x77, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x6e,
+	0x69, 0x70, 0x70, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x6e, 0x69,
+	0x70, 0x70, 0x65, 0x74, 0x22, 0x4d, 0x0a, 0x18, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65,
+	0x76, 0x69, 0x65, 0x77, 0x54, 0x65, 0x78, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x31, 0x0a, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x52, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x54, 0x65, 0x78, 0x74, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x07, 0x6d, 0x61, 0x74, 0x63,
+	0x68, 0x65, 0x73, 0x22, 0x9a, 0x01, 0x0a, 0x0b, 0x54, 0x65, 0x78, 0x74, 0x50, 0x6f, 0x73, 0x74,
+	0x69, 0x6e, 0x67, 0x12, 0x25, 0x0a, 0x0e, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x66, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x74, 0x65, 0x72,
+	0x6d, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65,
+	0x76, 0x69, 0x65, 0x77, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0c, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12,
+	0x27, 0x0a, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x61, 0x75,
+	0x72, 0x61, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x61, 0x74, 0x69,
+	0x6e, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67,
+	0x22, 0x3b, 0x0a, 0x09, 0x54, 0x65, 0x78, 0x74, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x18, 0x0a,
+	0x07, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07,
+	0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x65, 0x72, 0x6d, 0x73,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x65, 0x72, 0x6d, 0x73, 0x22, 0x1b, 0x0a,
+	0x19, 0x52, 0x65, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54, 0x61,
+	0x62, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x36, 0x0a, 0x1a, 0x52, 0x65,
+	0x62, 0x75, 0x69, 0x6c, 0x64, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54, 0x61, 0x62, 0x6c, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x72, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x73, 0x2a, 0x54, 0x0a, 0x0f, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x53, 0x6f, 0x72, 0x74,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x0a, 0x0a, 0x06, 0x4e, 0x45, 0x57, 0x45, 0x53, 0x54, 0x10,
+	0x00, 0x12, 0x11, 0x0a, 0x0d, 0x48, 0x49, 0x47, 0x48, 0x45, 0x53, 0x54, 0x5f, 0x52, 0x41, 0x54,
+	0x45, 0x44, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x4c, 0x4f, 0x57, 0x45, 0x53, 0x54, 0x5f, 0x52,
+	0x41, 0x54, 0x45, 0x44, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x4d, 0x4f, 0x53, 0x54, 0x5f, 0x48,
+	0x45, 0x4c, 0x50, 0x46, 0x55, 0x4c, 0x10, 0x03, 0x32, 0xfd, 0x08, 0x0a, 0x0d, 0x52, 0x65, 0x76,
 	0x69, 0x65, 0x77, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x43, 0x0a, 0x0a, 0x50, 0x6f,
 	0x73, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x19, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65,
 	0x77, 0x2e, 0x50, 0x6f, 0x73, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x71, 0x75,
@@ -414,13 +2239,71 @@ var file_proto_review_review_proto_rawDesc = []byte{
 	0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52,
 	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e,
 	0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x4c, 0x0a, 0x0d, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x76, 0x69, 0x65,
-	0x77, 0x73, 0x12, 0x1c, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x53, 0x65, 0x61, 0x72,
-	0x63, 0x68, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x1d, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68,
-	0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42,
-	0x10, 0x5a, 0x0e, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x72, 0x65, 0x76, 0x69, 0x65,
-	0x77, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x12, 0x43, 0x0a, 0x0a, 0x45, 0x64, 0x69, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12,
+	0x19, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x45, 0x64, 0x69, 0x74, 0x52, 0x65, 0x76,
+	0x69, 0x65, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x72, 0x65, 0x76,
+	0x69, 0x65, 0x77, 0x2e, 0x45, 0x64, 0x69, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x49, 0x0a, 0x0c, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x1b, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x55, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x48, 0x69,
+	0x73, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x1f, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x47,
+	0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e,
+	0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x0a, 0x56, 0x6f, 0x74, 0x65,
+	0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x19, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e,
+	0x56, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1a, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x56, 0x6f, 0x74, 0x65, 0x52,
+	0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5b, 0x0a,
+	0x12, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x73, 0x12, 0x21, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x0d, 0x41, 0x70,
+	0x70, 0x72, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x1c, 0x2e, 0x72, 0x65,
+	0x76, 0x69, 0x65, 0x77, 0x2e, 0x41, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x72, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x2e, 0x41, 0x70, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x49, 0x0a, 0x0c, 0x52, 0x65, 0x6a, 0x65,
+	0x63, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x1b, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x2e, 0x52, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x52,
+	0x65, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x0d, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x76,
+	0x69, 0x65, 0x77, 0x73, 0x12, 0x1c, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x53, 0x65,
+	0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x53, 0x65, 0x61, 0x72,
+	0x63, 0x68, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x55, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x53, 0x75,
+	0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x1f, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x47,
+	0x65, 0x74, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e,
+	0x47, 0x65, 0x74, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x55, 0x0a, 0x10, 0x53, 0x65, 0x61, 0x72,
+	0x63, 0x68, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x54, 0x65, 0x78, 0x74, 0x12, 0x1f, 0x2e, 0x72,
+	0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x54, 0x65, 0x78, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e,
+	0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x76,
+	0x69, 0x65, 0x77, 0x54, 0x65, 0x78, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x6a, 0x0a, 0x17, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72,
+	0x61, 0x6e, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x12, 0x26, 0x2e, 0x72, 0x65, 0x76,
+	0x69, 0x65, 0x77, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x74, 0x61, 0x75,
+	0x72, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x76, 0x69, 0x65, 0x77, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x27, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x52, 0x65, 0x73, 0x74, 0x61, 0x75, 0x72, 0x61, 0x6e, 0x74, 0x52, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5b, 0x0a, 0x12, 0x52,
+	0x65, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54, 0x61, 0x62, 0x6c,
+	0x65, 0x12, 0x21, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x52, 0x65, 0x62, 0x75, 0x69,
+	0x6c, 0x64, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x52, 0x65,
+	0x62, 0x75, 0x69, 0x6c, 0x64, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x54, 0x61, 0x62, 0x6c, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x10, 0x5a, 0x0e, 0x2e, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2f, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -435,30 +2318,90 @@ func file_proto_review_review_proto_rawDescGZIP() []byte {
 	return file_proto_review_review_proto_rawDescData
 }
 
-var file_proto_review_review_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_review_review_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_review_review_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
 var file_proto_review_review_proto_goTypes = []any{
-	(*PostReviewRequest)(nil),     // 0: review.PostReviewRequest
-	(*PostReviewResponse)(nil),    // 1: review.PostReviewResponse
-	(*GetReviewRequest)(nil),      // 2: review.GetReviewRequest
-	(*GetReviewResponse)(nil),     // 3: review.GetReviewResponse
-	(*SearchReviewsRequest)(nil),  // 4: review.SearchReviewsRequest
-	(*SearchReviewsResponse)(nil), // 5: review.SearchReviewsResponse
-	nil,                           // 6: review.SearchReviewsResponse.ReviewsMapEntry
+	(ReviewSortOrder)(0),                    // 0: review.ReviewSortOrder
+	(*PostReviewRequest)(nil),               // 1: review.PostReviewRequest
+	(*PostReviewResponse)(nil),              // 2: review.PostReviewResponse
+	(*GetReviewRequest)(nil),                // 3: review.GetReviewRequest
+	(*GetReviewResponse)(nil),               // 4: review.GetReviewResponse
+	(*EditReviewRequest)(nil),               // 5: review.EditReviewRequest
+	(*EditReviewResponse)(nil),              // 6: review.EditReviewResponse
+	(*DeleteReviewRequest)(nil),             // 7: review.DeleteReviewRequest
+	(*DeleteReviewResponse)(nil),            // 8: review.DeleteReviewResponse
+	(*GetReviewHistoryRequest)(nil),         // 9: review.GetReviewHistoryRequest
+	(*GetReviewHistoryResponse)(nil),        // 10: review.GetReviewHistoryResponse
+	(*VoteReviewRequest)(nil),               // 11: review.VoteReviewRequest
+	(*VoteReviewResponse)(nil),              // 12: review.VoteReviewResponse
+	(*ListPendingReviewsRequest)(nil),       // 13: review.ListPendingReviewsRequest
+	(*ListPendingReviewsResponse)(nil),      // 14: review.ListPendingReviewsResponse
+	(*ApproveReviewRequest)(nil),            // 15: review.ApproveReviewRequest
+	(*ApproveReviewResponse)(nil),           // 16: review.ApproveReviewResponse
+	(*RejectReviewRequest)(nil),             // 17: review.RejectReviewRequest
+	(*RejectReviewResponse)(nil),            // 18: review.RejectReviewResponse
+	(*PostingTimes)(nil),                    // 19: review.PostingTimes
+	(*SearchReviewsRequest)(nil),            // 20: review.SearchReviewsRequest
+	(*SearchReviewsResponse)(nil),           // 21: review.SearchReviewsResponse
+	(*ReviewIndex)(nil),                     // 22: review.ReviewIndex
+	(*DeleteRestaurantReviewsRequest)(nil),  // 23: review.DeleteRestaurantReviewsRequest
+	(*DeleteRestaurantReviewsResponse)(nil), // 24: review.DeleteRestaurantReviewsResponse
+	(*GetRatingSummaryRequest)(nil),         // 25: review.GetRatingSummaryRequest
+	(*GetRatingSummaryResponse)(nil),        // 26: review.GetRatingSummaryResponse
+	(*RatingAggregate)(nil),                 // 27: review.RatingAggregate
+	(*SearchReviewTextRequest)(nil),         // 28: review.SearchReviewTextRequest
+	(*ReviewTextMatch)(nil),                 // 29: review.ReviewTextMatch
+	(*SearchReviewTextResponse)(nil),        // 30: review.SearchReviewTextResponse
+	(*TextPosting)(nil),                     // 31: review.TextPosting
+	(*TextStats)(nil),                       // 32: review.TextStats
+	(*RebuildLookupTableRequest)(nil),       // 33: review.RebuildLookupTableRequest
+	(*RebuildLookupTableResponse)(nil),      // 34: review.RebuildLookupTableResponse
+	nil,                                     // 35: review.SearchReviewsResponse.ReviewsMapEntry
 }
 var file_proto_review_review_proto_depIdxs = []int32{
-	6, // 0: review.SearchReviewsResponse.reviews_map:type_name -> review.SearchReviewsResponse.ReviewsMapEntry
-	3, // 1: review.SearchReviewsResponse.ReviewsMapEntry.value:type_name -> review.GetReviewResponse
-	0, // 2: review.ReviewService.PostReview:input_type -> review.PostReviewRequest
-	2, // 3: review.ReviewService.GetReview:input_type -> review.GetReviewRequest
-	4, // 4: review.ReviewService.SearchReviews:input_type -> review.SearchReviewsRequest
-	1, // 5: review.ReviewService.PostReview:output_type -> review.PostReviewResponse
-	3, // 6: review.ReviewService.GetReview:output_type -> review.GetReviewResponse
-	5, // 7: review.ReviewService.SearchReviews:output_type -> review.SearchReviewsResponse
-	5, // [5:8] is the sub-list for method output_type
-	2, // [2:5] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	4,  // 0: review.EditReviewResponse.review:type_name -> review.GetReviewResponse
+	4,  // 1: review.GetReviewHistoryResponse.revisions:type_name -> review.GetReviewResponse
+	4,  // 2: review.ListPendingReviewsResponse.reviews:type_name -> review.GetReviewResponse
+	4,  // 3: review.ApproveReviewResponse.review:type_name -> review.GetReviewResponse
+	0,  // 4: review.SearchReviewsRequest.sort_order:type_name -> review.ReviewSortOrder
+	35, // 5: review.SearchReviewsResponse.reviews_map:type_name -> review.SearchReviewsResponse.ReviewsMapEntry
+	4,  // 6: review.SearchReviewsResponse.reviews:type_name -> review.GetReviewResponse
+	4,  // 7: review.ReviewTextMatch.review:type_name -> review.GetReviewResponse
+	29, // 8: review.SearchReviewTextResponse.matches:type_name -> review.ReviewTextMatch
+	4,  // 9: review.SearchReviewsResponse.ReviewsMapEntry.value:type_name -> review.GetReviewResponse
+	1,  // 10: review.ReviewService.PostReview:input_type -> review.PostReviewRequest
+	3,  // 11: review.ReviewService.GetReview:input_type -> review.GetReviewRequest
+	5,  // 12: review.ReviewService.EditReview:input_type -> review.EditReviewRequest
+	7,  // 13: review.ReviewService.DeleteReview:input_type -> review.DeleteReviewRequest
+	9,  // 14: review.ReviewService.GetReviewHistory:input_type -> review.GetReviewHistoryRequest
+	11, // 15: review.ReviewService.VoteReview:input_type -> review.VoteReviewRequest
+	13, // 16: review.ReviewService.ListPendingReviews:input_type -> review.ListPendingReviewsRequest
+	15, // 17: review.ReviewService.ApproveReview:input_type -> review.ApproveReviewRequest
+	17, // 18: review.ReviewService.RejectReview:input_type -> review.RejectReviewRequest
+	20, // 19: review.ReviewService.SearchReviews:input_type -> review.SearchReviewsRequest
+	25, // 20: review.ReviewService.GetRatingSummary:input_type -> review.GetRatingSummaryRequest
+	28, // 21: review.ReviewService.SearchReviewText:input_type -> review.SearchReviewTextRequest
+	23, // 22: review.ReviewService.DeleteRestaurantReviews:input_type -> review.DeleteRestaurantReviewsRequest
+	33, // 23: review.ReviewService.RebuildLookupTable:input_type -> review.RebuildLookupTableRequest
+	2,  // 24: review.ReviewService.PostReview:output_type -> review.PostReviewResponse
+	4,  // 25: review.ReviewService.GetReview:output_type -> review.GetReviewResponse
+	6,  // 26: review.ReviewService.EditReview:output_type -> review.EditReviewResponse
+	8,  // 27: review.ReviewService.DeleteReview:output_type -> review.DeleteReviewResponse
+	10, // 28: review.ReviewService.GetReviewHistory:output_type -> review.GetReviewHistoryResponse
+	12, // 29: review.ReviewService.VoteReview:output_type -> review.VoteReviewResponse
+	14, // 30: review.ReviewService.ListPendingReviews:output_type -> review.ListPendingReviewsResponse
+	16, // 31: review.ReviewService.ApproveReview:output_type -> review.ApproveReviewResponse
+	18, // 32: review.ReviewService.RejectReview:output_type -> review.RejectReviewResponse
+	21, // 33: review.ReviewService.SearchReviews:output_type -> review.SearchReviewsResponse
+	26, // 34: review.ReviewService.GetRatingSummary:output_type -> review.GetRatingSummaryResponse
+	30, // 35: review.ReviewService.SearchReviewText:output_type -> review.SearchReviewTextResponse
+	24, // 36: review.ReviewService.DeleteRestaurantReviews:output_type -> review.DeleteRestaurantReviewsResponse
+	34, // 37: review.ReviewService.RebuildLookupTable:output_type -> review.RebuildLookupTableResponse
+	24, // [24:38] is the sub-list for method output_type
+	10, // [10:24] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_proto_review_review_proto_init() }
@@ -471,13 +2414,14 @@ func file_proto_review_review_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_review_review_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      1,
+			NumMessages:   35,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_review_review_proto_goTypes,
 		DependencyIndexes: file_proto_review_review_proto_depIdxs,
+		EnumInfos:         file_proto_review_review_proto_enumTypes,
 		MessageInfos:      file_proto_review_review_proto_msgTypes,
 	}.Build()
 	File_proto_review_review_proto = out.File
@@ -23,9 +23,20 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ReviewService_PostReview_FullMethodName    = "/review.ReviewService/PostReview"
-	ReviewService_GetReview_FullMethodName     = "/review.ReviewService/GetReview"
-	ReviewService_SearchReviews_FullMethodName = "/review.ReviewService/SearchReviews"
+	ReviewService_PostReview_FullMethodName              = "/review.ReviewService/PostReview"
+	ReviewService_GetReview_FullMethodName               = "/review.ReviewService/GetReview"
+	ReviewService_EditReview_FullMethodName              = "/review.ReviewService/EditReview"
+	ReviewService_DeleteReview_FullMethodName            = "/review.ReviewService/DeleteReview"
+	ReviewService_GetReviewHistory_FullMethodName        = "/review.ReviewService/GetReviewHistory"
+	ReviewService_VoteReview_FullMethodName              = "/review.ReviewService/VoteReview"
+	ReviewService_ListPendingReviews_FullMethodName      = "/review.ReviewService/ListPendingReviews"
+	ReviewService_ApproveReview_FullMethodName           = "/review.ReviewService/ApproveReview"
+	ReviewService_RejectReview_FullMethodName            = "/review.ReviewService/RejectReview"
+	ReviewService_SearchReviews_FullMethodName           = "/review.ReviewService/SearchReviews"
+	ReviewService_GetRatingSummary_FullMethodName        = "/review.ReviewService/GetRatingSummary"
+	ReviewService_SearchReviewText_FullMethodName        = "/review.ReviewService/SearchReviewText"
+	ReviewService_DeleteRestaurantReviews_FullMethodName = "/review.ReviewService/DeleteRestaurantReviews"
+	ReviewService_RebuildLookupTable_FullMethodName      = "/review.ReviewService/RebuildLookupTable"
 )
 
 // ReviewServiceClient is the client API for ReviewService service.
@@ -38,8 +49,30 @@ type ReviewServiceClient interface {
 	PostReview(ctx context.Context, in *PostReviewRequest, opts ...grpc.CallOption) (*PostReviewResponse, error)
 	// GetReview is an RPC method for getting a restaurant review of a user.
 	GetReview(ctx context.Context, in *GetReviewRequest, opts ...grpc.CallOption) (*GetReviewResponse, error)
-	// SearchReviews is an RPC method for searching for all reviews of a restaurant.
+	// EditReview is an RPC method for changing the text or rating of a review.
+	EditReview(ctx context.Context, in *EditReviewRequest, opts ...grpc.CallOption) (*EditReviewResponse, error)
+	// DeleteReview is an RPC method for deleting a review, keeping its history.
+	DeleteReview(ctx context.Context, in *DeleteReviewRequest, opts ...grpc.CallOption) (*DeleteReviewResponse, error)
+	// GetReviewHistory is an RPC method for getting every revision of a review.
+	GetReviewHistory(ctx context.Context, in *GetReviewHistoryRequest, opts ...grpc.CallOption) (*GetReviewHistoryResponse, error)
+	// VoteReview is an RPC method for marking a review as helpful, once per user.
+	VoteReview(ctx context.Context, in *VoteReviewRequest, opts ...grpc.CallOption) (*VoteReviewResponse, error)
+	// ListPendingReviews is an admin RPC method for listing the reviews held for moderation.
+	ListPendingReviews(ctx context.Context, in *ListPendingReviewsRequest, opts ...grpc.CallOption) (*ListPendingReviewsResponse, error)
+	// ApproveReview is an admin RPC method for publishing a review held for moderation.
+	ApproveReview(ctx context.Context, in *ApproveReviewRequest, opts ...grpc.CallOption) (*ApproveReviewResponse, error)
+	// RejectReview is an admin RPC method for discarding a review held for moderation.
+	RejectReview(ctx context.Context, in *RejectReviewRequest, opts ...grpc.CallOption) (*RejectReviewResponse, error)
+	// SearchReviews is an RPC method for listing the reviews of a restaurant, a page at a time.
 	SearchReviews(ctx context.Context, in *SearchReviewsRequest, opts ...grpc.CallOption) (*SearchReviewsResponse, error)
+	// GetRatingSummary is an RPC method for getting the ratings of a restaurant's reviews in aggregate.
+	GetRatingSummary(ctx context.Context, in *GetRatingSummaryRequest, opts ...grpc.CallOption) (*GetRatingSummaryResponse, error)
+	// SearchReviewText is an RPC method for finding the reviews whose text best matches a query.
+	SearchReviewText(ctx context.Context, in *SearchReviewTextRequest, opts ...grpc.CallOption) (*SearchReviewTextResponse, error)
+	// DeleteRestaurantReviews is an RPC method for deleting every review of a restaurant.
+	DeleteRestaurantReviews(ctx context.Context, in *DeleteRestaurantReviewsRequest, opts ...grpc.CallOption) (*DeleteRestaurantReviewsResponse, error)
+	// Rebuild the restaurant to review index from the database, e.g. after an import
+	RebuildLookupTable(ctx context.Context, in *RebuildLookupTableRequest, opts ...grpc.CallOption) (*RebuildLookupTableResponse, error)
 }
 
 type reviewServiceClient struct {
@@ -70,6 +103,76 @@ func (c *reviewServiceClient) GetReview(ctx context.Context, in *GetReviewReques
 	return out, nil
 }
 
+func (c *reviewServiceClient) EditReview(ctx context.Context, in *EditReviewRequest, opts ...grpc.CallOption) (*EditReviewResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EditReviewResponse)
+	err := c.cc.Invoke(ctx, ReviewService_EditReview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewServiceClient) DeleteReview(ctx context.Context, in *DeleteReviewRequest, opts ...grpc.CallOption) (*DeleteReviewResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteReviewResponse)
+	err := c.cc.Invoke(ctx, ReviewService_DeleteReview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewServiceClient) GetReviewHistory(ctx context.Context, in *GetReviewHistoryRequest, opts ...grpc.CallOption) (*GetReviewHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetReviewHistoryResponse)
+	err := c.cc.Invoke(ctx, ReviewService_GetReviewHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewServiceClient) VoteReview(ctx context.Context, in *VoteReviewRequest, opts ...grpc.CallOption) (*VoteReviewResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VoteReviewResponse)
+	err := c.cc.Invoke(ctx, ReviewService_VoteReview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewServiceClient) ListPendingReviews(ctx context.Context, in *ListPendingReviewsRequest, opts ...grpc.CallOption) (*ListPendingReviewsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPendingReviewsResponse)
+	err := c.cc.Invoke(ctx, ReviewService_ListPendingReviews_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewServiceClient) ApproveReview(ctx context.Context, in *ApproveReviewRequest, opts ...grpc.CallOption) (*ApproveReviewResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApproveReviewResponse)
+	err := c.cc.Invoke(ctx, ReviewService_ApproveReview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewServiceClient) RejectReview(ctx context.Context, in *RejectReviewRequest, opts ...grpc.CallOption) (*RejectReviewResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RejectReviewResponse)
+	err := c.cc.Invoke(ctx, ReviewService_RejectReview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *reviewServiceClient) SearchReviews(ctx context.Context, in *SearchReviewsRequest, opts ...grpc.CallOption) (*SearchReviewsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SearchReviewsResponse)
@@ -80,6 +183,46 @@ func (c *reviewServiceClient) SearchReviews(ctx context.Context, in *SearchRevie
 	return out, nil
 }
 
+func (c *reviewServiceClient) GetRatingSummary(ctx context.Context, in *GetRatingSummaryRequest, opts ...grpc.CallOption) (*GetRatingSummaryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRatingSummaryResponse)
+	err := c.cc.Invoke(ctx, ReviewService_GetRatingSummary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewServiceClient) SearchReviewText(ctx context.Context, in *SearchReviewTextRequest, opts ...grpc.CallOption) (*SearchReviewTextResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchReviewTextResponse)
+	err := c.cc.Invoke(ctx, ReviewService_SearchReviewText_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewServiceClient) DeleteRestaurantReviews(ctx context.Context, in *DeleteRestaurantReviewsRequest, opts ...grpc.CallOption) (*DeleteRestaurantReviewsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteRestaurantReviewsResponse)
+	err := c.cc.Invoke(ctx, ReviewService_DeleteRestaurantReviews_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewServiceClient) RebuildLookupTable(ctx context.Context, in *RebuildLookupTableRequest, opts ...grpc.CallOption) (*RebuildLookupTableResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RebuildLookupTableResponse)
+	err := c.cc.Invoke(ctx, ReviewService_RebuildLookupTable_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ReviewServiceServer is the server API for ReviewService service.
 // All implementations must embed UnimplementedReviewServiceServer
 // for forward compatibility.
@@ -90,8 +233,30 @@ type ReviewServiceServer interface {
 	PostReview(context.Context, *PostReviewRequest) (*PostReviewResponse, error)
 	// GetReview is an RPC method for getting a restaurant review of a user.
 	GetReview(context.Context, *GetReviewRequest) (*GetReviewResponse, error)
-	// SearchReviews is an RPC method for searching for all reviews of a restaurant.
+	// EditReview is an RPC method for changing the text or rating of a review.
+	EditReview(context.Context, *EditReviewRequest) (*EditReviewResponse, error)
+	// DeleteReview is an RPC method for deleting a review, keeping its history.
+	DeleteReview(context.Context, *DeleteReviewRequest) (*DeleteReviewResponse, error)
+	// GetReviewHistory is an RPC method for getting every revision of a review.
+	GetReviewHistory(context.Context, *GetReviewHistoryRequest) (*GetReviewHistoryResponse, error)
+	// VoteReview is an RPC method for marking a review as helpful, once per user.
+	VoteReview(context.Context, *VoteReviewRequest) (*VoteReviewResponse, error)
+	// ListPendingReviews is an admin RPC method for listing the reviews held for moderation.
+	ListPendingReviews(context.Context, *ListPendingReviewsRequest) (*ListPendingReviewsResponse, error)
+	// ApproveReview is an admin RPC method for publishing a review held for moderation.
+	ApproveReview(context.Context, *ApproveReviewRequest) (*ApproveReviewResponse, error)
+	// RejectReview is an admin RPC method for discarding a review held for moderation.
+	RejectReview(context.Context, *RejectReviewRequest) (*RejectReviewResponse, error)
+	// SearchReviews is an RPC method for listing the reviews of a restaurant, a page at a time.
 	SearchReviews(context.Context, *SearchReviewsRequest) (*SearchReviewsResponse, error)
+	// GetRatingSummary is an RPC method for getting the ratings of a restaurant's reviews in aggregate.
+	GetRatingSummary(context.Context, *GetRatingSummaryRequest) (*GetRatingSummaryResponse, error)
+	// SearchReviewText is an RPC method for finding the reviews whose text best matches a query.
+	SearchReviewText(context.Context, *SearchReviewTextRequest) (*SearchReviewTextResponse, error)
+	// DeleteRestaurantReviews is an RPC method for deleting every review of a restaurant.
+	DeleteRestaurantReviews(context.Context, *DeleteRestaurantReviewsRequest) (*DeleteRestaurantReviewsResponse, error)
+	// Rebuild the restaurant to review index from the database, e.g. after an import
+	RebuildLookupTable(context.Context, *RebuildLookupTableRequest) (*RebuildLookupTableResponse, error)
 	mustEmbedUnimplementedReviewServiceServer()
 }
 
@@ -108,9 +273,42 @@ func (UnimplementedReviewServiceServer) PostReview(context.Context, *PostReviewR
 func (UnimplementedReviewServiceServer) GetReview(context.Context, *GetReviewRequest) (*GetReviewResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetReview not implemented")
 }
+func (UnimplementedReviewServiceServer) EditReview(context.Context, *EditReviewRequest) (*EditReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EditReview not implemented")
+}
+func (UnimplementedReviewServiceServer) DeleteReview(context.Context, *DeleteReviewRequest) (*DeleteReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteReview not implemented")
+}
+func (UnimplementedReviewServiceServer) GetReviewHistory(context.Context, *GetReviewHistoryRequest) (*GetReviewHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReviewHistory not implemented")
+}
+func (UnimplementedReviewServiceServer) VoteReview(context.Context, *VoteReviewRequest) (*VoteReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VoteReview not implemented")
+}
+func (UnimplementedReviewServiceServer) ListPendingReviews(context.Context, *ListPendingReviewsRequest) (*ListPendingReviewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPendingReviews not implemented")
+}
+func (UnimplementedReviewServiceServer) ApproveReview(context.Context, *ApproveReviewRequest) (*ApproveReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveReview not implemented")
+}
+func (UnimplementedReviewServiceServer) RejectReview(context.Context, *RejectReviewRequest) (*RejectReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectReview not implemented")
+}
 func (UnimplementedReviewServiceServer) SearchReviews(context.Context, *SearchReviewsRequest) (*SearchReviewsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SearchReviews not implemented")
 }
+func (UnimplementedReviewServiceServer) GetRatingSummary(context.Context, *GetRatingSummaryRequest) (*GetRatingSummaryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRatingSummary not implemented")
+}
+func (UnimplementedReviewServiceServer) SearchReviewText(context.Context, *SearchReviewTextRequest) (*SearchReviewTextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchReviewText not implemented")
+}
+func (UnimplementedReviewServiceServer) DeleteRestaurantReviews(context.Context, *DeleteRestaurantReviewsRequest) (*DeleteRestaurantReviewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRestaurantReviews not implemented")
+}
+func (UnimplementedReviewServiceServer) RebuildLookupTable(context.Context, *RebuildLookupTableRequest) (*RebuildLookupTableResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RebuildLookupTable not implemented")
+}
 func (UnimplementedReviewServiceServer) mustEmbedUnimplementedReviewServiceServer() {}
 func (UnimplementedReviewServiceServer) testEmbeddedByValue()                       {}
 
@@ -168,6 +366,132 @@ func _ReviewService_GetReview_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ReviewService_EditReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EditReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).EditReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_EditReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).EditReview(ctx, req.(*EditReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewService_DeleteReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).DeleteReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_DeleteReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).DeleteReview(ctx, req.(*DeleteReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewService_GetReviewHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReviewHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).GetReviewHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_GetReviewHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).GetReviewHistory(ctx, req.(*GetReviewHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewService_VoteReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoteReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).VoteReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_VoteReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).VoteReview(ctx, req.(*VoteReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewService_ListPendingReviews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPendingReviewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).ListPendingReviews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_ListPendingReviews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).ListPendingReviews(ctx, req.(*ListPendingReviewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewService_ApproveReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApproveReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).ApproveReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_ApproveReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).ApproveReview(ctx, req.(*ApproveReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewService_RejectReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RejectReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).RejectReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_RejectReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).RejectReview(ctx, req.(*RejectReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ReviewService_SearchReviews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SearchReviewsRequest)
 	if err := dec(in); err != nil {
@@ -186,6 +510,78 @@ func _ReviewService_SearchReviews_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ReviewService_GetRatingSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRatingSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).GetRatingSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_GetRatingSummary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).GetRatingSummary(ctx, req.(*GetRatingSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewService_SearchReviewText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchReviewTextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).SearchReviewText(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_SearchReviewText_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).SearchReviewText(ctx, req.(*SearchReviewTextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewService_DeleteRestaurantReviews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRestaurantReviewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).DeleteRestaurantReviews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_DeleteRestaurantReviews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).DeleteRestaurantReviews(ctx, req.(*DeleteRestaurantReviewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReviewService_RebuildLookupTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RebuildLookupTableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewServiceServer).RebuildLookupTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReviewService_RebuildLookupTable_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewServiceServer).RebuildLookupTable(ctx, req.(*RebuildLookupTableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ReviewService_ServiceDesc is the grpc.ServiceDesc for ReviewService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -201,10 +597,54 @@ var ReviewService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetReview",
 			Handler:    _ReviewService_GetReview_Handler,
 		},
+		{
+			MethodName: "EditReview",
+			Handler:    _ReviewService_EditReview_Handler,
+		},
+		{
+			MethodName: "DeleteReview",
+			Handler:    _ReviewService_DeleteReview_Handler,
+		},
+		{
+			MethodName: "GetReviewHistory",
+			Handler:    _ReviewService_GetReviewHistory_Handler,
+		},
+		{
+			MethodName: "VoteReview",
+			Handler:    _ReviewService_VoteReview_Handler,
+		},
+		{
+			MethodName: "ListPendingReviews",
+			Handler:    _ReviewService_ListPendingReviews_Handler,
+		},
+		{
+			MethodName: "ApproveReview",
+			Handler:    _ReviewService_ApproveReview_Handler,
+		},
+		{
+			MethodName: "RejectReview",
+			Handler:    _ReviewService_RejectReview_Handler,
+		},
 		{
 			MethodName: "SearchReviews",
 			Handler:    _ReviewService_SearchReviews_Handler,
 		},
+		{
+			MethodName: "GetRatingSummary",
+			Handler:    _ReviewService_GetRatingSummary_Handler,
+		},
+		{
+			MethodName: "SearchReviewText",
+			Handler:    _ReviewService_SearchReviewText_Handler,
+		},
+		{
+			MethodName: "DeleteRestaurantReviews",
+			Handler:    _ReviewService_DeleteRestaurantReviews_Handler,
+		},
+		{
+			MethodName: "RebuildLookupTable",
+			Handler:    _ReviewService_RebuildLookupTable_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/review/review.proto",
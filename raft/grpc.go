@@ -0,0 +1,106 @@
+package raft
+
+import (
+	"context"
+	"sync"
+
+	raftpb "cse190-welp/proto/raft"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCTransport sends Raft RPCs over gRPC. Node IDs are the peers' addresses.
+type GRPCTransport struct {
+	mu      sync.Mutex
+	clients map[string]raftpb.RaftServiceClient
+	dial    func(addr string) *grpc.ClientConn
+}
+
+// NewGRPCTransport returns a transport that opens connections with dial.
+func NewGRPCTransport(dial func(addr string) *grpc.ClientConn) *GRPCTransport {
+	return &GRPCTransport{
+		clients: make(map[string]raftpb.RaftServiceClient),
+		dial:    dial,
+	}
+}
+
+func (t *GRPCTransport) client(target string) raftpb.RaftServiceClient {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	client, ok := t.clients[target]
+	if !ok {
+		client = raftpb.NewRaftServiceClient(t.dial(target))
+		t.clients[target] = client
+	}
+	return client
+}
+
+func (t *GRPCTransport) RequestVote(ctx context.Context, target string, req *raftpb.RequestVoteRequest) (*raftpb.RequestVoteResponse, error) {
+	return t.client(target).RequestVote(ctx, req)
+}
+
+func (t *GRPCTransport) AppendEntries(ctx context.Context, target string, req *raftpb.AppendEntriesRequest) (*raftpb.AppendEntriesResponse, error) {
+	return t.client(target).AppendEntries(ctx, req)
+}
+
+func (t *GRPCTransport) InstallSnapshot(ctx context.Context, target string, req *raftpb.InstallSnapshotRequest) (*raftpb.InstallSnapshotResponse, error) {
+	return t.client(target).InstallSnapshot(ctx, req)
+}
+
+// Server exposes a node over gRPC.
+type Server struct {
+	raftpb.UnimplementedRaftServiceServer
+	node *Node
+}
+
+// NewServer returns a RaftService implementation backed by node.
+func NewServer(node *Node) *Server {
+	return &Server{node: node}
+}
+
+func (s *Server) RequestVote(ctx context.Context, req *raftpb.RequestVoteRequest) (*raftpb.RequestVoteResponse, error) {
+	resp, err := s.node.HandleRequestVote(req)
+	return resp, unavailable(err)
+}
+
+func (s *Server) AppendEntries(ctx context.Context, req *raftpb.AppendEntriesRequest) (*raftpb.AppendEntriesResponse, error) {
+	resp, err := s.node.HandleAppendEntries(req)
+	return resp, unavailable(err)
+}
+
+func (s *Server) InstallSnapshot(ctx context.Context, req *raftpb.InstallSnapshotRequest) (*raftpb.InstallSnapshotResponse, error) {
+	resp, err := s.node.HandleInstallSnapshot(req)
+	return resp, unavailable(err)
+}
+
+// AddServer adds a replica to the cluster.
+func (s *Server) AddServer(ctx context.Context, req *raftpb.ChangeMembershipRequest) (*raftpb.ChangeMembershipResponse, error) {
+	return s.membershipReply(s.node.AddServer(ctx, req.GetServerId()))
+}
+
+// RemoveServer removes a replica from the cluster.
+func (s *Server) RemoveServer(ctx context.Context, req *raftpb.ChangeMembershipRequest) (*raftpb.ChangeMembershipResponse, error) {
+	return s.membershipReply(s.node.RemoveServer(ctx, req.GetServerId()))
+}
+
+// unavailable reports a stopped node as unavailable.
+func unavailable(err error) error {
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return nil
+}
+
+func (s *Server) membershipReply(err error) (*raftpb.ChangeMembershipResponse, error) {
+	switch err {
+	case nil:
+		return &raftpb.ChangeMembershipResponse{Status: true}, nil
+	case ErrNotLeader:
+		return &raftpb.ChangeMembershipResponse{LeaderId: s.node.Leader()}, status.Error(codes.FailedPrecondition, err.Error())
+	case ErrConfigChangeInProgress:
+		return &raftpb.ChangeMembershipResponse{}, status.Error(codes.Aborted, err.Error())
+	default:
+		return &raftpb.ChangeMembershipResponse{}, status.Error(codes.Unavailable, err.Error())
+	}
+}
@@ -0,0 +1,1096 @@
+package raft
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	raftpb "cse190-welp/proto/raft"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrNotLeader              = errors.New("raft: not the leader")
+	ErrLeadershipLost         = errors.New("raft: leadership lost before the entry was committed")
+	ErrStopped                = errors.New("raft: node stopped")
+	ErrConfigChangeInProgress = errors.New("raft: a membership change is already in progress")
+)
+
+// StateMachine is the replicated application. Apply, Snapshot and Restore are
+// only ever called from a single goroutine.
+type StateMachine interface {
+	// Apply applies a committed command and returns its result to the proposer.
+	Apply(command []byte) interface{}
+
+	// Snapshot serializes the state machine as of the last applied command.
+	Snapshot() ([]byte, error)
+
+	// Restore replaces the state machine with a snapshot.
+	Restore(snapshot []byte) error
+}
+
+// Transport sends Raft RPCs to other nodes.
+type Transport interface {
+	RequestVote(ctx context.Context, target string, req *raftpb.RequestVoteRequest) (*raftpb.RequestVoteResponse, error)
+	AppendEntries(ctx context.Context, target string, req *raftpb.AppendEntriesRequest) (*raftpb.AppendEntriesResponse, error)
+	InstallSnapshot(ctx context.Context, target string, req *raftpb.InstallSnapshotRequest) (*raftpb.InstallSnapshotResponse, error)
+}
+
+// Config holds the timing and compaction settings of a node.
+type Config struct {
+	// ElectionTimeout is the minimum time without hearing from a leader before
+	// starting an election. The actual timeout is randomized up to twice this.
+	ElectionTimeout time.Duration
+
+	// HeartbeatInterval is how often the leader contacts idle followers.
+	HeartbeatInterval time.Duration
+
+	// SnapshotThreshold is the number of applied entries after which the log is
+	// compacted into a snapshot. Zero disables snapshots.
+	SnapshotThreshold int
+
+	// MaxEntriesPerAppend caps the number of entries in one AppendEntries call.
+	MaxEntriesPerAppend int
+}
+
+// DefaultConfig returns settings suitable for a cluster on a local network.
+func DefaultConfig() Config {
+	return Config{
+		ElectionTimeout:     300 * time.Millisecond,
+		HeartbeatInterval:   50 * time.Millisecond,
+		SnapshotThreshold:   10000,
+		MaxEntriesPerAppend: 256,
+	}
+}
+
+// Role is the role a node currently plays in the cluster.
+type Role int
+
+const (
+	Follower Role = iota
+	Candidate
+	Leader
+)
+
+func (r Role) String() string {
+	switch r {
+	case Follower:
+		return "follower"
+	case Candidate:
+		return "candidate"
+	default:
+		return "leader"
+	}
+}
+
+// result is delivered to a proposer once its entry is applied.
+type result struct {
+	value interface{}
+	err   error
+}
+
+// future tracks a proposed entry until it is applied or overwritten.
+type future struct {
+	term uint64
+	done chan result
+}
+
+// Node is a single member of a Raft cluster.
+type Node struct {
+	id        string
+	config    Config
+	sm        StateMachine
+	transport Transport
+	storage   *Storage
+
+	mu               sync.Mutex
+	role             Role
+	leader           string
+	members          []string // latest configuration, committed or not
+	configIndex      uint64   // index of the entry that set members
+	commitIndex      uint64
+	lastApplied      uint64
+	electionDeadline time.Time
+	lastLeaderHeard  time.Time
+	pendingRestore   *raftpb.Snapshot
+	futures          map[uint64]*future
+
+	// leader state, reset on every election won
+	nextIndex    map[string]uint64
+	matchIndex   map[string]uint64
+	ackRound     map[string]uint64 // latest heartbeat round acknowledged by each peer
+	round        uint64
+	replicators  map[string]chan struct{}
+	leaderCancel context.CancelFunc
+
+	changed chan struct{} // closed and replaced whenever waiters should re-check
+	applyCh chan struct{}
+	stopCh  chan struct{}
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewNode creates a node. peers is the initial membership including id; it is
+// only used when storage is empty, and should be left empty for a node that
+// will be added to an existing cluster with AddServer.
+func NewNode(id string, peers []string, sm StateMachine, transport Transport, storage *Storage, config Config) *Node {
+	if storage.entries == nil {
+		storage.snapshot = &raftpb.Snapshot{Configuration: &raftpb.Configuration{Members: peers}}
+		storage.entries = []*raftpb.LogEntry{{}}
+		if err := storage.saveAll(); err != nil {
+			log.Fatalf("raft <%s>: failed to persist state: %v", id, err)
+		}
+	}
+	n := &Node{
+		id:        id,
+		config:    config,
+		sm:        sm,
+		transport: transport,
+		storage:   storage,
+		futures:   make(map[uint64]*future),
+		changed:   make(chan struct{}),
+		applyCh:   make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+	}
+
+	// Recover the state machine from the latest snapshot.
+	snapshot := storage.snapshot
+	if len(snapshot.Data) > 0 {
+		if err := sm.Restore(snapshot.Data); err != nil {
+			log.Fatalf("raft <%s>: failed to restore snapshot: %v", id, err)
+		}
+	}
+	n.commitIndex = snapshot.LastIncludedIndex
+	n.lastApplied = snapshot.LastIncludedIndex
+	n.refreshMembers()
+	n.resetElectionTimer()
+	return n
+}
+
+// Start launches the node's background goroutines.
+func (n *Node) Start() {
+	n.wg.Add(2)
+	go n.run()
+	go n.applyLoop()
+}
+
+// Stop halts the node. Pending proposals fail with ErrStopped.
+func (n *Node) Stop() {
+	n.mu.Lock()
+	if n.stopped {
+		n.mu.Unlock()
+		return
+	}
+	n.stopped = true
+	n.stopLeading()
+	for index, f := range n.futures {
+		f.done <- result{err: ErrStopped}
+		delete(n.futures, index)
+	}
+	close(n.stopCh)
+	n.mu.Unlock()
+	n.wg.Wait()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.storage.Close(); err != nil {
+		log.Printf("raft <%s>: failed to close storage: %v", n.id, err)
+	}
+}
+
+// ID returns the node's identifier.
+func (n *Node) ID() string {
+	return n.id
+}
+
+// State returns the node's current term and role.
+func (n *Node) State() (uint64, Role) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.storage.term, n.role
+}
+
+// Leader returns the last known leader, or "" if none is known.
+func (n *Node) Leader() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.leader
+}
+
+// Members returns the latest cluster configuration.
+func (n *Node) Members() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.members...)
+}
+
+// Propose replicates a command and waits until it is applied, returning the
+// state machine's result. Only the leader accepts proposals.
+func (n *Node) Propose(ctx context.Context, command []byte) (interface{}, error) {
+	return n.propose(ctx, raftpb.EntryType_COMMAND, command)
+}
+
+// AddServer adds a member to the cluster and waits for the change to commit.
+func (n *Node) AddServer(ctx context.Context, id string) error {
+	return n.changeMembership(ctx, id, true)
+}
+
+// RemoveServer removes a member from the cluster and waits for the change to commit.
+func (n *Node) RemoveServer(ctx context.Context, id string) error {
+	return n.changeMembership(ctx, id, false)
+}
+
+func (n *Node) changeMembership(ctx context.Context, id string, add bool) error {
+	n.mu.Lock()
+	if n.role != Leader {
+		n.mu.Unlock()
+		return ErrNotLeader
+	}
+	if n.configIndex > n.commitIndex {
+		n.mu.Unlock()
+		return ErrConfigChangeInProgress
+	}
+	members := make([]string, 0, len(n.members)+1)
+	for _, member := range n.members {
+		if member != id {
+			members = append(members, member)
+		}
+	}
+	if add {
+		members = append(members, id)
+	}
+	n.mu.Unlock()
+
+	data, err := proto.Marshal(&raftpb.Configuration{Members: members})
+	if err != nil {
+		return err
+	}
+	_, err = n.propose(ctx, raftpb.EntryType_CONFIGURATION, data)
+	return err
+}
+
+func (n *Node) propose(ctx context.Context, entryType raftpb.EntryType, data []byte) (interface{}, error) {
+	n.mu.Lock()
+	if n.stopped {
+		n.mu.Unlock()
+		return nil, ErrStopped
+	}
+	if n.role != Leader {
+		n.mu.Unlock()
+		return nil, ErrNotLeader
+	}
+	entry := n.appendLocal(entryType, data)
+	f := &future{term: entry.Term, done: make(chan result, 1)}
+	n.futures[entry.Index] = f
+	n.mu.Unlock()
+
+	select {
+	case r := <-f.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ReadIndex blocks until it is safe to serve a linearizable read from the local
+// state machine: the node has confirmed it is still the leader with a majority
+// and has applied every entry committed before the read began.
+func (n *Node) ReadIndex(ctx context.Context) error {
+	n.mu.Lock()
+	if n.role != Leader {
+		n.mu.Unlock()
+		return ErrNotLeader
+	}
+	term := n.storage.term
+	// A new leader does not know the commit index until an entry of its own term commits.
+	for n.termAt(n.commitIndex) != term {
+		if err := n.wait(ctx); err != nil {
+			return err
+		}
+		if n.role != Leader || n.storage.term != term {
+			n.mu.Unlock()
+			return ErrNotLeader
+		}
+	}
+	readIndex := n.commitIndex
+
+	// Confirm leadership with a round of heartbeats.
+	n.round++
+	round := n.round
+	n.triggerReplication()
+	for !n.quorumAcked(round) {
+		if err := n.wait(ctx); err != nil {
+			return err
+		}
+		if n.role != Leader || n.storage.term != term {
+			n.mu.Unlock()
+			return ErrNotLeader
+		}
+	}
+
+	for n.lastApplied < readIndex {
+		if err := n.wait(ctx); err != nil {
+			return err
+		}
+	}
+	n.mu.Unlock()
+	return nil
+}
+
+// wait releases n.mu until the node's state changes, the context is done or
+// the node stops. It is called with n.mu held and, on success, returns with
+// n.mu held; on error n.mu has been released.
+func (n *Node) wait(ctx context.Context) error {
+	changed := n.changed
+	n.mu.Unlock()
+	select {
+	case <-changed:
+		n.mu.Lock()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-n.stopCh:
+		return ErrStopped
+	}
+}
+
+// notify wakes every waiter. Caller must hold n.mu.
+func (n *Node) notify() {
+	close(n.changed)
+	n.changed = make(chan struct{})
+}
+
+// quorumAcked reports whether a majority acknowledged the given heartbeat round.
+func (n *Node) quorumAcked(round uint64) bool {
+	acks := 0
+	for _, member := range n.members {
+		if member == n.id || n.ackRound[member] >= round {
+			acks++
+		}
+	}
+	return acks > len(n.members)/2
+}
+
+//
+// log helpers; callers must hold n.mu
+//
+
+func (n *Node) snapshotIndex() uint64 {
+	return n.storage.entries[0].Index
+}
+
+func (n *Node) lastIndex() uint64 {
+	return n.storage.entries[len(n.storage.entries)-1].Index
+}
+
+func (n *Node) lastTerm() uint64 {
+	return n.storage.entries[len(n.storage.entries)-1].Term
+}
+
+// entry returns the entry at index, which must be in (snapshotIndex, lastIndex].
+func (n *Node) entry(index uint64) *raftpb.LogEntry {
+	return n.storage.entries[index-n.snapshotIndex()]
+}
+
+// termAt returns the term of the entry at index, or 0 if it is not in the log.
+func (n *Node) termAt(index uint64) uint64 {
+	if index < n.snapshotIndex() || index > n.lastIndex() {
+		return 0
+	}
+	return n.storage.entries[index-n.snapshotIndex()].Term
+}
+
+func (n *Node) appendLocal(entryType raftpb.EntryType, data []byte) *raftpb.LogEntry {
+	entry := &raftpb.LogEntry{
+		Index: n.lastIndex() + 1,
+		Term:  n.storage.term,
+		Type:  entryType,
+		Data:  data,
+	}
+	n.storage.entries = append(n.storage.entries, entry)
+	n.mustSave(n.storage.saveEntries(entry.Index))
+	if entryType == raftpb.EntryType_CONFIGURATION {
+		n.refreshMembers()
+	}
+	n.advanceCommit()
+	n.triggerReplication()
+	return entry
+}
+
+// mustSave stops the process if a change to the storage could not be
+// persisted, since the node must not act on state it could lose.
+func (n *Node) mustSave(err error) {
+	if err != nil {
+		log.Fatalf("raft <%s>: failed to persist state: %v", n.id, err)
+	}
+}
+
+// truncateFrom drops every entry at or after index and fails their proposals.
+func (n *Node) truncateFrom(index uint64) {
+	n.storage.entries = n.storage.entries[:index-n.snapshotIndex()]
+	for i, f := range n.futures {
+		if i >= index {
+			f.done <- result{err: ErrLeadershipLost}
+			delete(n.futures, i)
+		}
+	}
+	n.refreshMembers()
+}
+
+// refreshMembers sets the membership from the latest configuration entry, or
+// from the snapshot if the log holds none. Membership changes take effect as
+// soon as they are appended.
+func (n *Node) refreshMembers() {
+	previous := n.members
+	n.members = n.storage.snapshot.GetConfiguration().GetMembers()
+	n.configIndex = n.snapshotIndex()
+	for i := len(n.storage.entries) - 1; i > 0; i-- {
+		entry := n.storage.entries[i]
+		if entry.Type == raftpb.EntryType_CONFIGURATION {
+			configuration := &raftpb.Configuration{}
+			if err := proto.Unmarshal(entry.Data, configuration); err != nil {
+				log.Fatalf("raft <%s>: corrupt configuration entry %d: %v", n.id, entry.Index, err)
+			}
+			n.members = configuration.Members
+			n.configIndex = entry.Index
+			break
+		}
+	}
+	if n.role == Leader && !sameMembers(previous, n.members) {
+		n.startReplicators()
+	}
+}
+
+func (n *Node) isMember(id string) bool {
+	for _, member := range n.members {
+		if member == id {
+			return true
+		}
+	}
+	return false
+}
+
+func sameMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//
+// elections
+//
+
+func (n *Node) resetElectionTimer() {
+	timeout := n.config.ElectionTimeout + time.Duration(rand.Int63n(int64(n.config.ElectionTimeout)))
+	n.electionDeadline = time.Now().Add(timeout)
+}
+
+// becomeFollower steps down into term. Caller must hold n.mu.
+func (n *Node) becomeFollower(term uint64) {
+	if term > n.storage.term {
+		n.storage.term = term
+		n.storage.votedFor = ""
+		n.mustSave(n.storage.saveHardState())
+	}
+	if n.role == Leader {
+		n.stopLeading()
+	}
+	n.role = Follower
+	n.notify()
+}
+
+// run drives elections and heartbeats until the node stops.
+func (n *Node) run() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.config.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		n.mu.Lock()
+		switch {
+		case n.role == Leader:
+			n.triggerReplication()
+		case time.Now().After(n.electionDeadline) && n.isMember(n.id):
+			n.campaign()
+		}
+		n.mu.Unlock()
+	}
+}
+
+// campaign starts an election. Caller must hold n.mu.
+func (n *Node) campaign() {
+	n.role = Candidate
+	n.leader = ""
+	n.storage.term++
+	n.storage.votedFor = n.id
+	n.mustSave(n.storage.saveHardState())
+	n.resetElectionTimer()
+	term := n.storage.term
+	log.Printf("raft <%s>: starting election for term %d", n.id, term)
+
+	req := &raftpb.RequestVoteRequest{
+		Term:         term,
+		CandidateId:  n.id,
+		LastLogIndex: n.lastIndex(),
+		LastLogTerm:  n.lastTerm(),
+	}
+	votes := 1
+	if votes > len(n.members)/2 {
+		n.becomeLeader()
+		return
+	}
+	for _, member := range n.members {
+		if member == n.id {
+			continue
+		}
+		go func(peer string) {
+			ctx, cancel := context.WithTimeout(context.Background(), n.config.ElectionTimeout)
+			defer cancel()
+			resp, err := n.transport.RequestVote(ctx, peer, req)
+			if err != nil {
+				return
+			}
+
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			if resp.Term > n.storage.term {
+				n.becomeFollower(resp.Term)
+				return
+			}
+			if n.role != Candidate || n.storage.term != term || !resp.VoteGranted {
+				return
+			}
+			votes++
+			if votes > len(n.members)/2 {
+				n.becomeLeader()
+			}
+		}(member)
+	}
+}
+
+// becomeLeader takes over as leader of the current term. Caller must hold n.mu.
+func (n *Node) becomeLeader() {
+	log.Printf("raft <%s>: elected leader for term %d", n.id, n.storage.term)
+	n.role = Leader
+	n.leader = n.id
+	n.nextIndex = make(map[string]uint64)
+	n.matchIndex = make(map[string]uint64)
+	n.ackRound = make(map[string]uint64)
+	n.startReplicators()
+	// Committing an entry of the new term also commits everything before it.
+	n.appendLocal(raftpb.EntryType_NOOP, nil)
+	n.notify()
+}
+
+// HandleRequestVote processes a RequestVote RPC from a candidate. It fails
+// with ErrStopped once the node is stopped, as a node's storage is closed then.
+func (n *Node) HandleRequestVote(req *raftpb.RequestVoteRequest) (*raftpb.RequestVoteResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped {
+		return nil, ErrStopped
+	}
+	return n.handleRequestVote(req), nil
+}
+
+// handleRequestVote processes a RequestVote RPC. Caller must hold n.mu.
+func (n *Node) handleRequestVote(req *raftpb.RequestVoteRequest) *raftpb.RequestVoteResponse {
+	// Ignore candidates while a leader is known to be alive, so that removed
+	// or partitioned servers can't disrupt the cluster by bumping the term.
+	if n.leader != "" && n.leader != req.CandidateId && time.Since(n.lastLeaderHeard) < n.config.ElectionTimeout {
+		return &raftpb.RequestVoteResponse{Term: n.storage.term}
+	}
+	if req.Term > n.storage.term {
+		n.becomeFollower(req.Term)
+		n.leader = ""
+	}
+
+	resp := &raftpb.RequestVoteResponse{Term: n.storage.term}
+	if req.Term < n.storage.term {
+		return resp
+	}
+	upToDate := req.LastLogTerm > n.lastTerm() ||
+		(req.LastLogTerm == n.lastTerm() && req.LastLogIndex >= n.lastIndex())
+	if (n.storage.votedFor == "" || n.storage.votedFor == req.CandidateId) && upToDate {
+		n.storage.votedFor = req.CandidateId
+		n.mustSave(n.storage.saveHardState())
+		n.resetElectionTimer()
+		resp.VoteGranted = true
+	}
+	return resp
+}
+
+//
+// replication
+//
+
+// startReplicators (re)starts one replication goroutine per peer. Caller must hold n.mu.
+func (n *Node) startReplicators() {
+	n.stopLeading()
+	ctx, cancel := context.WithCancel(context.Background())
+	n.leaderCancel = cancel
+	n.replicators = make(map[string]chan struct{})
+	for _, member := range n.members {
+		if member == n.id {
+			continue
+		}
+		if _, ok := n.nextIndex[member]; !ok {
+			n.nextIndex[member] = n.lastIndex() + 1
+			n.matchIndex[member] = 0
+		}
+		trigger := make(chan struct{}, 1)
+		trigger <- struct{}{}
+		n.replicators[member] = trigger
+		n.wg.Add(1)
+		go n.replicate(ctx, member, n.storage.term, trigger)
+	}
+}
+
+// stopLeading stops the replication goroutines. Caller must hold n.mu.
+func (n *Node) stopLeading() {
+	if n.leaderCancel != nil {
+		n.leaderCancel()
+		n.leaderCancel = nil
+	}
+	n.replicators = nil
+}
+
+// triggerReplication wakes every replicator. Caller must hold n.mu.
+func (n *Node) triggerReplication() {
+	for _, trigger := range n.replicators {
+		select {
+		case trigger <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// replicate sends entries, snapshots and heartbeats to one peer for as long as
+// the node leads term.
+func (n *Node) replicate(ctx context.Context, peer string, term uint64, trigger chan struct{}) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-trigger:
+		}
+		for n.sendTo(ctx, peer, term) {
+			// keep going while the peer is behind
+		}
+	}
+}
+
+// sendTo sends one AppendEntries or InstallSnapshot to peer. It returns true
+// if the peer is still behind and should be sent more immediately.
+func (n *Node) sendTo(ctx context.Context, peer string, term uint64) bool {
+	n.mu.Lock()
+	if n.role != Leader || n.storage.term != term || ctx.Err() != nil {
+		n.mu.Unlock()
+		return false
+	}
+	round := n.round
+	next := n.nextIndex[peer]
+	if next <= n.snapshotIndex() {
+		req := &raftpb.InstallSnapshotRequest{Term: term, LeaderId: n.id, Snapshot: n.storage.snapshot}
+		n.mu.Unlock()
+		return n.sendSnapshot(ctx, peer, term, round, req)
+	}
+
+	prev := next - 1
+	last := n.lastIndex()
+	if max := uint64(n.config.MaxEntriesPerAppend); max > 0 && last-prev > max {
+		last = prev + max
+	}
+	entries := make([]*raftpb.LogEntry, 0, last-prev)
+	for i := next; i <= last; i++ {
+		entries = append(entries, n.entry(i))
+	}
+	req := &raftpb.AppendEntriesRequest{
+		Term:         term,
+		LeaderId:     n.id,
+		PrevLogIndex: prev,
+		PrevLogTerm:  n.termAt(prev),
+		Entries:      entries,
+		LeaderCommit: n.commitIndex,
+	}
+	n.mu.Unlock()
+
+	callCtx, cancel := context.WithTimeout(ctx, n.config.ElectionTimeout)
+	resp, err := n.transport.AppendEntries(callCtx, peer, req)
+	cancel()
+	if err != nil {
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if resp.Term > n.storage.term {
+		n.becomeFollower(resp.Term)
+		return false
+	}
+	if n.role != Leader || n.storage.term != term {
+		return false
+	}
+	n.acknowledge(peer, round)
+	if resp.Success {
+		if match := prev + uint64(len(entries)); match > n.matchIndex[peer] {
+			n.matchIndex[peer] = match
+			n.nextIndex[peer] = match + 1
+			n.advanceCommit()
+		}
+		return n.nextIndex[peer] <= n.lastIndex()
+	}
+
+	// Back up past the conflicting term in one step.
+	next = resp.ConflictIndex
+	if resp.ConflictTerm != 0 {
+		for i := n.lastIndex(); i > n.snapshotIndex(); i-- {
+			if n.termAt(i) == resp.ConflictTerm {
+				next = i + 1
+				break
+			}
+		}
+	}
+	if next < 1 {
+		next = 1
+	}
+	n.nextIndex[peer] = next
+	return true
+}
+
+func (n *Node) sendSnapshot(ctx context.Context, peer string, term, round uint64, req *raftpb.InstallSnapshotRequest) bool {
+	callCtx, cancel := context.WithTimeout(ctx, n.config.ElectionTimeout)
+	resp, err := n.transport.InstallSnapshot(callCtx, peer, req)
+	cancel()
+	if err != nil {
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if resp.Term > n.storage.term {
+		n.becomeFollower(resp.Term)
+		return false
+	}
+	if n.role != Leader || n.storage.term != term {
+		return false
+	}
+	n.acknowledge(peer, round)
+	if index := req.Snapshot.LastIncludedIndex; index > n.matchIndex[peer] {
+		n.matchIndex[peer] = index
+		n.nextIndex[peer] = index + 1
+		n.advanceCommit()
+	}
+	return n.nextIndex[peer] <= n.lastIndex()
+}
+
+// acknowledge records that peer accepted the leader's term as of round. Caller must hold n.mu.
+func (n *Node) acknowledge(peer string, round uint64) {
+	if round > n.ackRound[peer] {
+		n.ackRound[peer] = round
+		n.notify()
+	}
+}
+
+// advanceCommit commits the newest entry of the current term that is stored
+// on a majority. Caller must hold n.mu.
+func (n *Node) advanceCommit() {
+	if n.role != Leader {
+		return
+	}
+	for index := n.lastIndex(); index > n.commitIndex; index-- {
+		if n.termAt(index) != n.storage.term {
+			break
+		}
+		replicas := 0
+		for _, member := range n.members {
+			if member == n.id || n.matchIndex[member] >= index {
+				replicas++
+			}
+		}
+		if replicas > len(n.members)/2 {
+			n.setCommitIndex(index)
+			break
+		}
+	}
+}
+
+func (n *Node) setCommitIndex(index uint64) {
+	n.commitIndex = index
+	select {
+	case n.applyCh <- struct{}{}:
+	default:
+	}
+	// A leader that is no longer a member steps down once its removal commits.
+	if n.role == Leader && n.configIndex <= index && !n.isMember(n.id) {
+		log.Printf("raft <%s>: removed from the cluster, stepping down", n.id)
+		n.becomeFollower(n.storage.term)
+	}
+	n.notify()
+}
+
+// HandleAppendEntries processes an AppendEntries RPC from the leader. It
+// fails with ErrStopped once the node is stopped.
+func (n *Node) HandleAppendEntries(req *raftpb.AppendEntriesRequest) (*raftpb.AppendEntriesResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped {
+		return nil, ErrStopped
+	}
+	return n.handleAppendEntries(req), nil
+}
+
+// handleAppendEntries processes a AppendEntries RPC. Caller must hold n.mu.
+func (n *Node) handleAppendEntries(req *raftpb.AppendEntriesRequest) *raftpb.AppendEntriesResponse {
+	resp := &raftpb.AppendEntriesResponse{Term: n.storage.term}
+	if req.Term < n.storage.term {
+		return resp
+	}
+	if req.Term > n.storage.term || n.role != Follower {
+		n.becomeFollower(req.Term)
+	}
+	resp.Term = n.storage.term
+	n.leader = req.LeaderId
+	n.lastLeaderHeard = time.Now()
+	n.resetElectionTimer()
+
+	// Entries already covered by our snapshot are committed and therefore match.
+	prev, entries := req.PrevLogIndex, req.Entries
+	if prev < n.snapshotIndex() {
+		skip := n.snapshotIndex() - prev
+		if skip > uint64(len(entries)) {
+			skip = uint64(len(entries))
+		}
+		entries = entries[skip:]
+		prev = n.snapshotIndex()
+	} else {
+		if prev > n.lastIndex() {
+			resp.ConflictIndex = n.lastIndex() + 1
+			return resp
+		}
+		if term := n.termAt(prev); term != req.PrevLogTerm {
+			resp.ConflictTerm = term
+			resp.ConflictIndex = prev
+			for resp.ConflictIndex-1 > n.snapshotIndex() && n.termAt(resp.ConflictIndex-1) == term {
+				resp.ConflictIndex--
+			}
+			return resp
+		}
+	}
+
+	appended := false
+	for i, entry := range entries {
+		index := prev + 1 + uint64(i)
+		if index <= n.lastIndex() {
+			if n.termAt(index) == entry.Term {
+				continue
+			}
+			n.truncateFrom(index)
+		}
+		n.storage.entries = append(n.storage.entries, entries[i:]...)
+		n.mustSave(n.storage.saveEntries(index))
+		appended = true
+		break
+	}
+	if appended {
+		n.refreshMembers()
+	}
+
+	if lastNew := prev + uint64(len(entries)); req.LeaderCommit > n.commitIndex {
+		commit := req.LeaderCommit
+		if lastNew < commit {
+			commit = lastNew
+		}
+		if commit > n.commitIndex {
+			n.setCommitIndex(commit)
+		}
+	}
+	resp.Success = true
+	return resp
+}
+
+// HandleInstallSnapshot processes an InstallSnapshot RPC from the leader. It
+// fails with ErrStopped once the node is stopped.
+func (n *Node) HandleInstallSnapshot(req *raftpb.InstallSnapshotRequest) (*raftpb.InstallSnapshotResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped {
+		return nil, ErrStopped
+	}
+	return n.handleInstallSnapshot(req), nil
+}
+
+// handleInstallSnapshot processes a InstallSnapshot RPC. Caller must hold n.mu.
+func (n *Node) handleInstallSnapshot(req *raftpb.InstallSnapshotRequest) *raftpb.InstallSnapshotResponse {
+	resp := &raftpb.InstallSnapshotResponse{Term: n.storage.term}
+	if req.Term < n.storage.term {
+		return resp
+	}
+	if req.Term > n.storage.term || n.role != Follower {
+		n.becomeFollower(req.Term)
+	}
+	resp.Term = n.storage.term
+	n.leader = req.LeaderId
+	n.lastLeaderHeard = time.Now()
+	n.resetElectionTimer()
+
+	snapshot := req.Snapshot
+	if snapshot.LastIncludedIndex <= n.snapshotIndex() || snapshot.LastIncludedIndex <= n.lastApplied {
+		return resp
+	}
+
+	// Keep any entries that follow the snapshot if our log agrees with it.
+	sentinel := &raftpb.LogEntry{Index: snapshot.LastIncludedIndex, Term: snapshot.LastIncludedTerm}
+	if n.termAt(snapshot.LastIncludedIndex) == snapshot.LastIncludedTerm {
+		rest := n.storage.entries[snapshot.LastIncludedIndex-n.snapshotIndex()+1:]
+		n.storage.entries = append([]*raftpb.LogEntry{sentinel}, rest...)
+	} else {
+		n.storage.entries = []*raftpb.LogEntry{sentinel}
+	}
+	n.storage.snapshot = snapshot
+	n.mustSave(n.storage.saveAll())
+	n.pendingRestore = snapshot
+	n.refreshMembers()
+	if snapshot.LastIncludedIndex > n.commitIndex {
+		n.setCommitIndex(snapshot.LastIncludedIndex)
+	} else {
+		select {
+		case n.applyCh <- struct{}{}:
+		default:
+		}
+	}
+	return resp
+}
+
+//
+// applying and compaction
+//
+
+// applyLoop applies committed entries to the state machine in order.
+func (n *Node) applyLoop() {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.stopCh:
+			return
+		case <-n.applyCh:
+		}
+
+		n.mu.Lock()
+		if restore := n.pendingRestore; restore != nil {
+			n.pendingRestore = nil
+			n.mu.Unlock()
+			if err := n.sm.Restore(restore.Data); err != nil {
+				log.Fatalf("raft <%s>: failed to restore snapshot: %v", n.id, err)
+			}
+			n.mu.Lock()
+			n.lastApplied = restore.LastIncludedIndex
+			for index, f := range n.futures {
+				if index <= n.lastApplied {
+					f.done <- result{err: ErrLeadershipLost}
+					delete(n.futures, index)
+				}
+			}
+			n.notify()
+		}
+		if n.lastApplied < n.snapshotIndex() {
+			// a newer snapshot is queued for restore
+			n.mu.Unlock()
+			continue
+		}
+		var entries []*raftpb.LogEntry
+		for index := n.lastApplied + 1; index <= n.commitIndex; index++ {
+			entries = append(entries, n.entry(index))
+		}
+		n.mu.Unlock()
+
+		for _, entry := range entries {
+			var value interface{}
+			if entry.Type == raftpb.EntryType_COMMAND {
+				value = n.sm.Apply(entry.Data)
+			}
+
+			n.mu.Lock()
+			if n.lastApplied+1 != entry.Index {
+				// a snapshot was installed underneath us
+				n.mu.Unlock()
+				break
+			}
+			n.lastApplied = entry.Index
+			if f, ok := n.futures[entry.Index]; ok {
+				if f.term == entry.Term {
+					f.done <- result{value: value}
+				} else {
+					f.done <- result{err: ErrLeadershipLost}
+				}
+				delete(n.futures, entry.Index)
+			}
+			n.notify()
+			n.mu.Unlock()
+		}
+
+		n.maybeSnapshot()
+	}
+}
+
+// maybeSnapshot compacts the log once enough entries have been applied. It
+// runs on the apply goroutine so the state machine matches lastApplied.
+func (n *Node) maybeSnapshot() {
+	n.mu.Lock()
+	threshold := uint64(n.config.SnapshotThreshold)
+	if threshold == 0 || n.pendingRestore != nil || n.lastApplied < n.snapshotIndex()+threshold {
+		n.mu.Unlock()
+		return
+	}
+	index := n.lastApplied
+	n.mu.Unlock()
+
+	data, err := n.sm.Snapshot()
+	if err != nil {
+		log.Printf("raft <%s>: snapshot failed: %v", n.id, err)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if index <= n.snapshotIndex() {
+		return
+	}
+	// Membership as of the snapshot is the latest configuration at or before index.
+	configuration := n.storage.snapshot.Configuration
+	for i := index; i > n.snapshotIndex(); i-- {
+		if entry := n.entry(i); entry.Type == raftpb.EntryType_CONFIGURATION {
+			configuration = &raftpb.Configuration{}
+			if err := proto.Unmarshal(entry.Data, configuration); err != nil {
+				log.Fatalf("raft <%s>: corrupt configuration entry %d: %v", n.id, entry.Index, err)
+			}
+			break
+		}
+	}
+	term := n.termAt(index)
+	rest := n.storage.entries[index-n.snapshotIndex()+1:]
+	n.storage.entries = append([]*raftpb.LogEntry{{Index: index, Term: term}}, rest...)
+	n.storage.snapshot = &raftpb.Snapshot{
+		LastIncludedIndex: index,
+		LastIncludedTerm:  term,
+		Configuration:     configuration,
+		Data:              data,
+	}
+	n.mustSave(n.storage.saveAll())
+	log.Printf("raft <%s>: compacted log through index %d", n.id, index)
+}
@@ -0,0 +1,157 @@
+package raft
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	raftpb "cse190-welp/proto/raft"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrUnreachable = errors.New("raft: node unreachable")
+
+// SimNetwork is an in-process network between nodes for tests. It can
+// disconnect nodes, split them into partitions and delay their traffic.
+type SimNetwork struct {
+	mu         sync.Mutex
+	nodes      map[string]*Node
+	down       map[string]bool
+	partitions map[string]int // partition of each node; nodes in different partitions can't talk
+	delays     map[string]time.Duration
+}
+
+// NewSimNetwork returns a fully connected network with no delays.
+func NewSimNetwork() *SimNetwork {
+	return &SimNetwork{
+		nodes:      make(map[string]*Node),
+		down:       make(map[string]bool),
+		partitions: make(map[string]int),
+		delays:     make(map[string]time.Duration),
+	}
+}
+
+// Register attaches a node to the network, replacing any earlier node with the same ID.
+func (s *SimNetwork) Register(n *Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[n.ID()] = n
+}
+
+// Transport returns the transport a node uses to send messages.
+func (s *SimNetwork) Transport(from string) Transport {
+	return &simTransport{network: s, from: from}
+}
+
+// Disconnect drops all traffic to and from a node.
+func (s *SimNetwork) Disconnect(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down[id] = true
+}
+
+// Reconnect restores traffic to and from a node.
+func (s *SimNetwork) Reconnect(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.down, id)
+}
+
+// Partition splits the nodes into groups that can only talk among themselves.
+// Nodes not named in any group form a group of their own.
+func (s *SimNetwork) Partition(groups ...[]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.partitions = make(map[string]int)
+	for i, group := range groups {
+		for _, id := range group {
+			s.partitions[id] = i + 1
+		}
+	}
+}
+
+// Heal removes every partition.
+func (s *SimNetwork) Heal() {
+	s.Partition()
+}
+
+// SetDelay delays every message sent to or from a node by d.
+func (s *SimNetwork) SetDelay(id string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delays[id] = d
+}
+
+// route returns the target node if from can currently reach it.
+func (s *SimNetwork) route(from, to string) (*Node, time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	target, ok := s.nodes[to]
+	if !ok || s.down[from] || s.down[to] || s.partitions[from] != s.partitions[to] {
+		return nil, 0, false
+	}
+	return target, s.delays[from] + s.delays[to], true
+}
+
+type simTransport struct {
+	network *SimNetwork
+	from    string
+}
+
+// deliver carries a request to the target and the response back, honouring
+// delays and dropping the exchange if the nodes are cut off in the meantime.
+func (t *simTransport) deliver(ctx context.Context, to string, handle func(*Node) (proto.Message, error)) (proto.Message, error) {
+	target, delay, ok := t.network.route(t.from, to)
+	if !ok {
+		return nil, ErrUnreachable
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if _, _, ok = t.network.route(t.from, to); !ok {
+		return nil, ErrUnreachable
+	}
+	resp, err := handle(target)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, ok = t.network.route(t.from, to); !ok {
+		return nil, ErrUnreachable
+	}
+	return resp, ctx.Err()
+}
+
+func (t *simTransport) RequestVote(ctx context.Context, target string, req *raftpb.RequestVoteRequest) (*raftpb.RequestVoteResponse, error) {
+	resp, err := t.deliver(ctx, target, func(n *Node) (proto.Message, error) {
+		return n.HandleRequestVote(proto.Clone(req).(*raftpb.RequestVoteRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*raftpb.RequestVoteResponse), nil
+}
+
+func (t *simTransport) AppendEntries(ctx context.Context, target string, req *raftpb.AppendEntriesRequest) (*raftpb.AppendEntriesResponse, error) {
+	resp, err := t.deliver(ctx, target, func(n *Node) (proto.Message, error) {
+		return n.HandleAppendEntries(proto.Clone(req).(*raftpb.AppendEntriesRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*raftpb.AppendEntriesResponse), nil
+}
+
+func (t *simTransport) InstallSnapshot(ctx context.Context, target string, req *raftpb.InstallSnapshotRequest) (*raftpb.InstallSnapshotResponse, error) {
+	resp, err := t.deliver(ctx, target, func(n *Node) (proto.Message, error) {
+		return n.HandleInstallSnapshot(proto.Clone(req).(*raftpb.InstallSnapshotRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*raftpb.InstallSnapshotResponse), nil
+}
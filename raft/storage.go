@@ -0,0 +1,202 @@
+package raft
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	raftpb "cse190-welp/proto/raft"
+	"google.golang.org/protobuf/proto"
+)
+
+// storageFile is the file, in the directory given to OpenStorage, that a
+// node's storage is kept in. It is a sequence of StorageRecords, each framed
+// by its length and CRC-32.
+const storageFile = "raft.log"
+
+// recordHeaderSize is the size of the length and checksum framing a record.
+const recordHeaderSize = 8
+
+// Storage holds the state a node must keep across restarts: the current term,
+// its vote, the log and the latest snapshot. Storage from OpenStorage writes
+// every change to disk and syncs it before the node acts on it. Storage from
+// NewStorage is kept in memory only; handing the same Storage to a new node
+// emulates a crash and restart.
+type Storage struct {
+	term     uint64
+	votedFor string
+	// entries[0] is a sentinel holding the index and term of the snapshot
+	entries  []*raftpb.LogEntry
+	snapshot *raftpb.Snapshot
+
+	dir  string
+	file *os.File // nil if the storage is kept in memory only
+}
+
+// NewStorage returns empty storage for a node that has never run.
+func NewStorage() *Storage {
+	return &Storage{}
+}
+
+// OpenStorage returns the storage kept in dir, which is created if it does
+// not exist. A record torn by a crash while it was written is dropped, along
+// with anything after it.
+func OpenStorage(dir string) (*Storage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, storageFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s := &Storage{dir: dir}
+	end, err := s.replay(file)
+	if err == nil {
+		err = file.Truncate(end)
+	}
+	if err == nil {
+		_, err = file.Seek(end, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.file = file
+	return s, nil
+}
+
+// Close closes the storage file. Later changes are kept in memory only.
+func (s *Storage) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// replay applies every intact record of file in order, and returns the
+// offset just past the last one.
+func (s *Storage) replay(file *os.File) (int64, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return 0, err
+	}
+	offset := 0
+	for len(data)-offset >= recordHeaderSize {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		checksum := binary.BigEndian.Uint32(data[offset+4:])
+		end := offset + recordHeaderSize + length
+		if end > len(data) || crc32.ChecksumIEEE(data[offset+recordHeaderSize:end]) != checksum {
+			break
+		}
+		record := &raftpb.StorageRecord{}
+		if err := proto.Unmarshal(data[offset+recordHeaderSize:end], record); err != nil {
+			return 0, fmt.Errorf("raft: corrupt storage record at offset %d: %v", offset, err)
+		}
+		if err := s.apply(record); err != nil {
+			return 0, fmt.Errorf("raft: storage record at offset %d: %v", offset, err)
+		}
+		offset = end
+	}
+	return int64(offset), nil
+}
+
+// apply applies a record to the state in memory.
+func (s *Storage) apply(record *raftpb.StorageRecord) error {
+	if hardState := record.GetHardState(); hardState != nil {
+		s.term = hardState.GetTerm()
+		s.votedFor = hardState.GetVotedFor()
+	}
+	if snapshot := record.GetSnapshot(); snapshot != nil {
+		s.snapshot = snapshot
+		s.entries = []*raftpb.LogEntry{{Index: snapshot.GetLastIncludedIndex(), Term: snapshot.GetLastIncludedTerm()}}
+	}
+	if suffix := record.GetLogSuffix(); suffix != nil {
+		if s.entries == nil || suffix.GetFrom() <= s.entries[0].Index || suffix.GetFrom() > s.entries[0].Index+uint64(len(s.entries)) {
+			return fmt.Errorf("log from index %d does not follow the stored log", suffix.GetFrom())
+		}
+		s.entries = append(s.entries[:suffix.GetFrom()-s.entries[0].Index], suffix.GetEntries()...)
+	}
+	return nil
+}
+
+// saveHardState persists the term and vote.
+func (s *Storage) saveHardState() error {
+	return s.write(&raftpb.StorageRecord{HardState: &raftpb.HardState{Term: s.term, VotedFor: s.votedFor}})
+}
+
+// saveEntries persists the log from index from on, replacing whatever was
+// persisted from there.
+func (s *Storage) saveEntries(from uint64) error {
+	return s.write(&raftpb.StorageRecord{LogSuffix: &raftpb.LogSuffix{From: from, Entries: s.entries[from-s.entries[0].Index:]}})
+}
+
+// saveAll replaces the file with one holding the whole state, so that the
+// entries compacted into the snapshot stop taking space.
+func (s *Storage) saveAll() error {
+	if s.file == nil {
+		return nil
+	}
+	path := filepath.Join(s.dir, storageFile)
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	record := &raftpb.StorageRecord{
+		HardState: &raftpb.HardState{Term: s.term, VotedFor: s.votedFor},
+		Snapshot:  s.snapshot,
+		LogSuffix: &raftpb.LogSuffix{From: s.entries[0].Index + 1, Entries: s.entries[1:]},
+	}
+	if err := writeRecord(file, record); err != nil {
+		file.Close()
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		file.Close()
+		return err
+	}
+	if err := syncDir(s.dir); err != nil {
+		file.Close()
+		return err
+	}
+	s.file.Close()
+	s.file = file
+	return nil
+}
+
+// write appends a record to the file and syncs it.
+func (s *Storage) write(record *raftpb.StorageRecord) error {
+	if s.file == nil {
+		return nil
+	}
+	return writeRecord(s.file, record)
+}
+
+// writeRecord frames a record, writes it to file and syncs it.
+func writeRecord(file *os.File, record *raftpb.StorageRecord) error {
+	body, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, recordHeaderSize, recordHeaderSize+len(body))
+	binary.BigEndian.PutUint32(frame, uint32(len(body)))
+	binary.BigEndian.PutUint32(frame[4:], crc32.ChecksumIEEE(body))
+	if _, err := file.Write(append(frame, body...)); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// syncDir syncs a directory, so that a file renamed into it survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -1,5 +1,6 @@
 import argparse
 import csv
+import datetime
 import subprocess
 import requests
 import sys
@@ -81,12 +82,18 @@ def make_reservations(filename, op='GET'):
         reader = csv.DictReader(file, fieldnames=["UserName", "RestaurantName", "Year", "Month", "Day"])
         # skip header column
         next(reader)
-        for row in reader:
+        rows = list(reader)
+        # Reservations can't be made in the past, so move the sample dates to
+        # start tomorrow, keeping the days between them
+        dates = [datetime.date(int(row["Year"]), int(row["Month"]), int(row["Day"])) for row in rows]
+        shift = datetime.date.today() + datetime.timedelta(days=1) - min(dates, default=datetime.date.today())
+        for row, date in zip(rows, dates):
             user_name = row["UserName"]
             restaurant_name = row["RestaurantName"]
-            year = row["Year"]
-            month = row["Month"]
-            day = row["Day"]
+            date += shift
+            year = str(date.year)
+            month = str(date.month)
+            day = str(date.day)
             # print(user_name, "|", restaurant_name, "|", year, "|", month,"|", day)
             if op == 'PUT':
                 execute_make_reservation(user_name, restaurant_name, year, month, day)
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	apps "cse190-welp/applications"
+	"cse190-welp/proto/detail"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"cse190-welp/proto/review"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// backupStores maps each service's store to the message its records hold.
+var backupStores = map[string]func() proto.Message{
+	"detail":      func() proto.Message { return &detail.GetDetailResponse{} },
+	"review":      func() proto.Message { return &review.GetReviewResponse{} },
+	"reservation": func() proto.Message { return &reservation.GetReservationResponse{} },
+}
+
+var (
+	backupMarshal   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	backupUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// backupLine is one record of a JSON Lines backup.
+type backupLine struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ExportRecords writes every record of a service's store to w, as JSON Lines
+// ("jsonl") or CSV ("csv") with the record values decoded. It returns the
+// number of records written.
+func ExportRecords(ctx context.Context, databaseAddr string, store string, format string, w io.Writer) (int, error) {
+	newMessage, ok := backupStores[store]
+	if !ok {
+		return 0, fmt.Errorf("unknown store %q", store)
+	}
+	encode, flush, err := newBackupEncoder(format, newMessage(), w)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = scanAll(ctx, newDatabaseClient(databaseAddr), "", func(record *mydatabase.DatabaseRecord) error {
+		if strings.HasPrefix(record.GetKey(), internalKeyPrefix) && !hasAnyPrefix(record.GetKey(), revisionsPrefix, pendingReviewsPrefix, votesPrefix) {
+			return nil // derived data, rebuilt after an import; revisions, reviews held for moderation and votes are kept
+		}
+		msg := newMessage()
+		if err := proto.Unmarshal(record.GetValue(), msg); err != nil {
+			return fmt.Errorf("decoding %s: %w", record.GetKey(), err)
+		}
+		count++
+		return encode(record.GetKey(), msg)
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, flush()
+}
+
+// ImportRecords reads records written by ExportRecords from r and writes them
+// to a service's store using the given number of parallel writers. It returns
+// the number of records written.
+func ImportRecords(ctx context.Context, databaseAddr string, store string, format string, r io.Reader, workers int) (int, error) {
+	newMessage, ok := backupStores[store]
+	if !ok {
+		return 0, fmt.Errorf("unknown store %q", store)
+	}
+	decode, err := newBackupDecoder(format, newMessage, r)
+	if err != nil {
+		return 0, err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	database := newDatabaseClient(databaseAddr)
+	records := make(chan *mydatabase.DatabaseRecord, workers)
+
+	var mu sync.Mutex
+	var firstErr error
+	count := 0
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for record := range records {
+				if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: record}); err != nil {
+					fail(fmt.Errorf("writing %s: %w", record.GetKey(), err))
+					continue
+				}
+				mu.Lock()
+				count++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for ctx.Err() == nil {
+		key, msg, err := decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fail(err)
+			break
+		}
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			fail(err)
+			break
+		}
+		record := &mydatabase.DatabaseRecord{Key: key, Value: data}
+		record.Checksum = apps.Checksum(record)
+		records <- record
+	}
+	close(records)
+	wg.Wait()
+	return count, firstErr
+}
+
+// newBackupEncoder returns a function that writes one record in the given
+// format, and one that flushes the output.
+func newBackupEncoder(format string, example proto.Message, w io.Writer) (func(string, proto.Message) error, func() error, error) {
+	switch format {
+	case "jsonl":
+		out := bufio.NewWriter(w)
+		encoder := json.NewEncoder(out)
+		encode := func(key string, msg proto.Message) error {
+			value, err := backupMarshal.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			return encoder.Encode(backupLine{Key: key, Value: value})
+		}
+		return encode, out.Flush, nil
+	case "csv":
+		out := csv.NewWriter(w)
+		fields := example.ProtoReflect().Descriptor().Fields()
+		header := []string{"key"}
+		for i := 0; i < fields.Len(); i++ {
+			header = append(header, string(fields.Get(i).Name()))
+		}
+		if err := out.Write(header); err != nil {
+			return nil, nil, err
+		}
+		encode := func(key string, msg proto.Message) error {
+			row, err := csvRow(key, msg)
+			if err != nil {
+				return err
+			}
+			return out.Write(row)
+		}
+		flush := func() error {
+			out.Flush()
+			return out.Error()
+		}
+		return encode, flush, nil
+	}
+	return nil, nil, fmt.Errorf("unknown format %q, expected jsonl or csv", format)
+}
+
+// newBackupDecoder returns a function that reads the next record in the given
+// format, returning io.EOF at the end of the input.
+func newBackupDecoder(format string, newMessage func() proto.Message, r io.Reader) (func() (string, proto.Message, error), error) {
+	switch format {
+	case "jsonl":
+		decoder := json.NewDecoder(bufio.NewReader(r))
+		return func() (string, proto.Message, error) {
+			var line backupLine
+			if err := decoder.Decode(&line); err != nil {
+				return "", nil, err
+			}
+			msg := newMessage()
+			if err := backupUnmarshal.Unmarshal(line.Value, msg); err != nil {
+				return "", nil, fmt.Errorf("decoding %s: %w", line.Key, err)
+			}
+			return line.Key, msg, nil
+		}, nil
+	case "csv":
+		in := csv.NewReader(r)
+		header, err := in.Read()
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV header: %w", err)
+		}
+		if len(header) == 0 || header[0] != "key" {
+			return nil, fmt.Errorf("CSV header must start with key, got %v", header)
+		}
+		return func() (string, proto.Message, error) {
+			row, err := in.Read()
+			if err != nil {
+				return "", nil, err
+			}
+			msg := newMessage()
+			if err := parseCSVRow(header, row, msg); err != nil {
+				return "", nil, fmt.Errorf("decoding %s: %w", row[0], err)
+			}
+			return row[0], msg, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, expected jsonl or csv", format)
+}
+
+// csvRow flattens a message into one CSV row with a column per top-level
+// field. Scalars are written as plain text and other fields as JSON.
+func csvRow(key string, msg proto.Message) ([]string, error) {
+	data, err := backupMarshal.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	row := []string{key}
+	fields := msg.ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		raw := values[string(fields.Get(i).Name())]
+		var text string
+		if json.Unmarshal(raw, &text) != nil {
+			text = string(raw) // not a JSON string
+		}
+		row = append(row, text)
+	}
+	return row, nil
+}
+
+// parseCSVRow is the inverse of csvRow.
+func parseCSVRow(header []string, row []string, msg proto.Message) error {
+	fields := msg.ProtoReflect().Descriptor().Fields()
+	values := make(map[string]json.RawMessage)
+	for i := 1; i < len(header) && i < len(row); i++ {
+		field := fields.ByName(protoreflect.Name(header[i]))
+		if field == nil || row[i] == "" {
+			continue
+		}
+		raw := json.RawMessage(row[i])
+		quote := !json.Valid(raw)
+		if !field.IsList() && !field.IsMap() {
+			switch field.Kind() {
+			case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.EnumKind:
+				quote = true
+			}
+		}
+		if quote {
+			raw, _ = json.Marshal(row[i])
+		}
+		values[header[i]] = raw
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return backupUnmarshal.Unmarshal(data, msg)
+}
+
+// RebuildDerivedData asks a service to rebuild the in-memory structures it
+// derives from its store, after the store was changed behind its back. The
+// detail service keeps none.
+func RebuildDerivedData(ctx context.Context, store string, serviceAddr string) (int, error) {
+	switch store {
+	case "review":
+		reply, err := review.NewReviewServiceClient(dial(serviceAddr)).RebuildLookupTable(ctx, &review.RebuildLookupTableRequest{})
+		return int(reply.GetReviews()), err
+	case "reservation":
+		reply, err := reservation.NewReservationServiceClient(dial(serviceAddr)).RebuildPopularityTable(ctx, &reservation.RebuildPopularityTableRequest{})
+		return int(reply.GetReservations()), err
+	}
+	return 0, nil
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cse190-welp/proto/detail"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// bookedPrefix prefixes the database key of the seats booked at a restaurant
+// in one slot, bookedPrefix + restaurant + ":" + slot, stored like a
+// popularity counter. Slots are slotMinutes long and formatted slotLayout.
+const bookedPrefix = internalKeyPrefix + "booked:"
+
+const (
+	slotMinutes            = 30
+	slotLayout             = "2006-01-02T15:04"
+	defaultDurationMinutes = 90
+	maxAvailabilityDays    = 31
+)
+
+// slotKey returns the key of the seats booked at a restaurant in the slot
+// starting at start.
+func slotKey(restaurantName string, start time.Time) string {
+	return bookedPrefix + restaurantName + ":" + start.Format(slotLayout)
+}
+
+// calendarDate returns the start of a date, or an error if it isn't a real
+// calendar date.
+func calendarDate(date *reservation.Date) (time.Time, error) {
+	if date == nil {
+		return time.Time{}, status.Error(codes.InvalidArgument, "A date is required")
+	}
+	t := time.Date(int(date.GetYear()), time.Month(date.GetMonth()), int(date.GetDay()), 0, 0, 0, 0, time.Local)
+	if t.Year() != int(date.GetYear()) || t.Month() != time.Month(date.GetMonth()) || t.Day() != int(date.GetDay()) {
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "%s is not a calendar date", dateBucket(date))
+	}
+	return t, nil
+}
+
+// reservationStart returns when a reservation starts, or false if it has no
+// start time, as when it was made before reservations had one.
+func reservationStart(r *reservation.GetReservationResponse) (time.Time, bool) {
+	day, err := calendarDate(r.GetTime())
+	if err != nil || r.GetStartTime() == nil {
+		return time.Time{}, false
+	}
+	return day.Add(time.Duration(r.GetStartTime().GetHour())*time.Hour + time.Duration(r.GetStartTime().GetMinute())*time.Minute), true
+}
+
+// startBucket orders reservations by date and start time.
+func startBucket(r *reservation.GetReservationResponse) string {
+	return fmt.Sprintf("%sT%02d:%02d", dateBucket(r.GetTime()), r.GetStartTime().GetHour(), r.GetStartTime().GetMinute())
+}
+
+// slotOf returns the start of the slot a time falls in.
+func slotOf(t time.Time) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return midnight.Add(t.Sub(midnight).Truncate(slotMinutes * time.Minute))
+}
+
+// slotsOf returns the starts of the slots a booking overlaps.
+func slotsOf(start time.Time, durationMinutes int32) []time.Time {
+	end := start.Add(time.Duration(durationMinutes) * time.Minute)
+	var slots []time.Time
+	for slot := slotOf(start); slot.Before(end); slot = slot.Add(slotMinutes * time.Minute) {
+		slots = append(slots, slot)
+	}
+	return slots
+}
+
+// bookedKeys returns the keys of the slots a reservation takes seats in.
+func bookedKeys(r *reservation.GetReservationResponse) []string {
+	start, ok := reservationStart(r)
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for _, slot := range slotsOf(start, r.GetDurationMinutes()) {
+		keys = append(keys, slotKey(r.GetRestaurantName(), slot))
+	}
+	return keys
+}
+
+// restaurantDetail returns a restaurant's details from the detail service. A
+// restaurant without details has unlimited capacity and is always open.
+func (s *Reservation) restaurantDetail(ctx context.Context, restaurantName string) (*detail.GetDetailResponse, error) {
+	reply, err := s.detailClient.GetDetail(ctx, &detail.GetDetailRequest{RestaurantName: restaurantName})
+	switch status.Code(err) {
+	case codes.OK:
+		return reply, nil
+	case codes.NotFound:
+		return &detail.GetDetailResponse{RestaurantName: restaurantName}, nil
+	default:
+		return nil, status.Errorf(status.Code(err), "Details of %s could not be read: %v", restaurantName, status.Convert(err).Message())
+	}
+}
+
+// openingHours returns the times, in minutes after midnight, a restaurant
+// opens and closes on a day.
+func openingHours(restaurant *detail.GetDetailResponse, day time.Time) [][2]int32 {
+	if len(restaurant.GetOpeningHours()) == 0 {
+		return [][2]int32{{0, 24 * 60}}
+	}
+	var hours [][2]int32
+	for _, h := range restaurant.GetOpeningHours() {
+		if time.Weekday(h.GetDayOfWeek()) == day.Weekday() {
+			hours = append(hours, [2]int32{h.GetOpensAt(), h.GetClosesAt()})
+		}
+	}
+	return hours
+}
+
+// isOpen reports whether a restaurant is open for the whole of a booking.
+func isOpen(restaurant *detail.GetDetailResponse, day time.Time, startMinute int32, durationMinutes int32) bool {
+	for _, hours := range openingHours(restaurant, day) {
+		if startMinute >= hours[0] && startMinute+durationMinutes <= hours[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// validateBooking checks a booking request and returns the reservation it
+// makes, with defaults filled in: a real calendar date and time of day, not
+// in the past, and within the restaurant's opening hours.
+func validateBooking(req *reservation.MakeReservationRequest, restaurant *detail.GetDetailResponse, now time.Time) (*reservation.GetReservationResponse, error) {
+	day, err := calendarDate(req.GetTime())
+	if err != nil {
+		return nil, err
+	}
+	start := req.GetStartTime()
+	if start == nil || start.GetHour() < 0 || start.GetHour() > 23 || start.GetMinute() < 0 || start.GetMinute() > 59 {
+		return nil, status.Error(codes.InvalidArgument, "A start_time between 00:00 and 23:59 is required")
+	}
+	r := &reservation.GetReservationResponse{
+		UserName:        req.GetUserName(),
+		RestaurantName:  req.GetRestaurantName(),
+		Time:            req.GetTime(),
+		StartTime:       start,
+		DurationMinutes: req.GetDurationMinutes(),
+		PartySize:       req.GetPartySize(),
+	}
+	if r.DurationMinutes == 0 {
+		r.DurationMinutes = defaultDurationMinutes
+	}
+	if r.PartySize == 0 {
+		r.PartySize = 1
+	}
+	switch {
+	case r.UserName == "" || r.RestaurantName == "":
+		return nil, status.Error(codes.InvalidArgument, "A user_name and restaurant_name are required")
+	case r.DurationMinutes < 0 || r.DurationMinutes > 24*60:
+		return nil, status.Error(codes.InvalidArgument, "duration_minutes must be between 1 and 1440")
+	case r.PartySize < 0:
+		return nil, status.Error(codes.InvalidArgument, "party_size must be positive")
+	}
+	if begins, _ := reservationStart(r); begins.Before(now) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is in the past", begins.Format(slotLayout))
+	}
+	if !isOpen(restaurant, day, start.GetHour()*60+start.GetMinute(), r.DurationMinutes) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not open for all of %d minutes from %s", r.RestaurantName, r.DurationMinutes, startBucket(r))
+	}
+	return r, nil
+}
+
+// claimSeats returns the update that books seats at a restaurant in one
+// slot, failing with ResourceExhausted if that would exceed capacity (0 is
+// unlimited). Because the update is conditional on the booked seats it read,
+// two bookings racing for the last seat can't both succeed. Undoing it gives
+// the seats back.
+func claimSeats(restaurantName string, slot time.Time, seats int64, capacity int64) recordUpdate {
+	key := slotKey(restaurantName, slot)
+	return recordUpdate{key: key, undo: countReservation(key, -seats).apply, apply: func(current []byte) ([]byte, error) {
+		booked, err := decodePopularity(&mydatabase.DatabaseRecord{Key: key, Value: current})
+		if err != nil {
+			return nil, err
+		}
+		if capacity > 0 && booked+seats > capacity {
+			return nil, status.Errorf(codes.ResourceExhausted, "%s is fully booked at %s: %d of %d seats taken", restaurantName, slot.Format(slotLayout), booked, capacity)
+		}
+		return encodePopularity(booked + seats), nil
+	}}
+}
+
+// availability returns the slots in which a party can book a restaurant
+// between two dates.
+func (s *Reservation) availability(ctx context.Context, req *reservation.GetAvailabilityRequest, restaurant *detail.GetDetailResponse, now time.Time) ([]*reservation.AvailableSlot, error) {
+	from, err := calendarDate(req.GetFromDate())
+	if err != nil {
+		return nil, err
+	}
+	to := from
+	if req.GetToDate() != nil {
+		if to, err = calendarDate(req.GetToDate()); err != nil {
+			return nil, err
+		}
+	}
+	party, duration := int64(max(req.GetPartySize(), 1)), req.GetDurationMinutes()
+	if duration == 0 {
+		duration = defaultDurationMinutes
+	}
+	switch {
+	case to.Before(from) || to.After(from.AddDate(0, 0, maxAvailabilityDays)):
+		return nil, status.Errorf(codes.InvalidArgument, "to_date must be within %d days after from_date", maxAvailabilityDays)
+	case duration < 0 || duration > 24*60:
+		return nil, status.Error(codes.InvalidArgument, "duration_minutes must be between 1 and 1440")
+	}
+
+	// Seats booked in each slot of the range
+	prefix := bookedPrefix + req.GetRestaurantName() + ":"
+	last := to.AddDate(0, 0, 1).Format(slotLayout)
+	booked := make(map[string]int64)
+	_, err = scanAt(ctx, s.reservationDatabaseClient, prefix, prefix+from.Format(slotLayout[:10]), 0, func(record *mydatabase.DatabaseRecord) error {
+		slot := strings.TrimPrefix(record.GetKey(), prefix)
+		if len(slot) != len(slotLayout) {
+			return nil // another restaurant whose name starts with this one's
+		}
+		if slot >= last {
+			return errStopScan
+		}
+		seats, err := decodePopularity(record)
+		booked[slot] = seats
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := int64(restaurant.GetCapacity())
+	var slots []*reservation.AvailableSlot
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, hours := range openingHours(restaurant, day) {
+			for minute := hours[0]; minute+duration <= hours[1]; minute += slotMinutes {
+				start := day.Add(time.Duration(minute) * time.Minute)
+				if start.Before(now) {
+					continue
+				}
+				var taken int64
+				for _, slot := range slotsOf(start, duration) {
+					taken = max(taken, booked[slot.Format(slotLayout)])
+				}
+				if capacity > 0 && capacity-taken < party {
+					continue
+				}
+				slot := &reservation.AvailableSlot{
+					Date:      &reservation.Date{Year: int32(day.Year()), Month: int32(day.Month()), Day: int32(day.Day())},
+					StartTime: &reservation.TimeOfDay{Hour: minute / 60, Minute: minute % 60},
+				}
+				if capacity > 0 {
+					slot.AvailableSeats = int32(capacity - taken)
+				}
+				slots = append(slots, slot)
+			}
+		}
+	}
+	return slots, nil
+}
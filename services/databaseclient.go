@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"cse190-welp/proto/mydatabase"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// internalKeyPrefix starts the database keys that services use for their own
+// bookkeeping, such as indexes, rather than for the records they serve.
+const internalKeyPrefix = "__"
+
+// redirectBackoff is how long to wait before retrying when no replica knows the leader.
+const redirectBackoff = 50 * time.Millisecond
+
+// newDatabaseClient returns a client for a database given as one address, as
+// a comma-separated list of replica addresses for a replicated database, or as
+// a semicolon-separated list of shards, each of which may be replicated.
+func newDatabaseClient(addrs string) mydatabase.DatabaseServiceClient {
+	if strings.Contains(addrs, ";") {
+		shards := make(map[string]mydatabase.DatabaseServiceClient)
+		for _, shard := range strings.Split(addrs, ";") {
+			shards[shard] = newDatabaseClient(shard)
+		}
+		return NewShardedDatabaseClient(shards, DefaultVirtualNodes)
+	}
+
+	replicas := strings.Split(addrs, ",")
+	if len(replicas) == 1 {
+		return mydatabase.NewDatabaseServiceClient(dial(replicas[0]))
+	}
+	return &leaderClient{
+		replicas: replicas,
+		clients:  make(map[string]mydatabase.DatabaseServiceClient),
+	}
+}
+
+// leaderClient sends every call to the leader of a replicated database and
+// follows the redirects returned by followers.
+type leaderClient struct {
+	mu       sync.Mutex
+	replicas []string
+	clients  map[string]mydatabase.DatabaseServiceClient
+	leader   string
+	next     int // replica to try when the leader is unknown
+}
+
+// target returns the replica to send the next call to.
+func (c *leaderClient) target() (string, mydatabase.DatabaseServiceClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addr := c.leader
+	if addr == "" {
+		addr = c.replicas[c.next%len(c.replicas)]
+	}
+	client, ok := c.clients[addr]
+	if !ok {
+		client = mydatabase.NewDatabaseServiceClient(dial(addr))
+		c.clients[addr] = client
+	}
+	return addr, client
+}
+
+// redirect records where the leader is after a call to addr was refused. It
+// reports whether a leader hint was received.
+func (c *leaderClient) redirect(addr string, trailer metadata.MD) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hints := trailer.Get(leaderMetadataKey); len(hints) > 0 && hints[0] != "" && hints[0] != addr {
+		c.leader = hints[0]
+		return true
+	}
+	if c.leader == addr {
+		c.leader = ""
+	}
+	c.next++
+	return false
+}
+
+// callLeader runs call against the leader, retrying on other replicas until
+// one accepts it or every replica has been tried a few times. Unless the call
+// is safe to repeat, it is only retried when a follower refused it, as
+// otherwise it may have been proposed and could be applied twice.
+func callLeader[T any](ctx context.Context, c *leaderClient, repeatable bool, call func(mydatabase.DatabaseServiceClient, ...grpc.CallOption) (T, error), opts ...grpc.CallOption) (T, error) {
+	var reply T
+	var err error
+	for attempt := 0; attempt < 3*len(c.replicas); attempt++ {
+		var trailer metadata.MD
+		addr, client := c.target()
+		reply, err = call(client, append(opts, grpc.Trailer(&trailer))...)
+		if status.Code(err) != codes.Unavailable {
+			return reply, err
+		}
+		_, refused := trailer[leaderMetadataKey]
+		redirected := c.redirect(addr, trailer)
+		if !repeatable && !refused {
+			return reply, err
+		}
+		if !redirected {
+			select {
+			case <-time.After(redirectBackoff):
+			case <-ctx.Done():
+				return reply, status.FromContextError(ctx.Err()).Err()
+			}
+		}
+	}
+	return reply, err
+}
+
+func (c *leaderClient) SetRecord(ctx context.Context, in *mydatabase.SetRecordRequest, opts ...grpc.CallOption) (*mydatabase.SetRecordResponse, error) {
+	return callLeader(ctx, c, in.ExpectedVersion != nil, func(client mydatabase.DatabaseServiceClient, opts ...grpc.CallOption) (*mydatabase.SetRecordResponse, error) {
+		return client.SetRecord(ctx, in, opts...)
+	}, opts...)
+}
+
+func (c *leaderClient) GetRecord(ctx context.Context, in *mydatabase.GetRecordRequest, opts ...grpc.CallOption) (*mydatabase.GetRecordResponse, error) {
+	return callLeader(ctx, c, true, func(client mydatabase.DatabaseServiceClient, opts ...grpc.CallOption) (*mydatabase.GetRecordResponse, error) {
+		return client.GetRecord(ctx, in, opts...)
+	}, opts...)
+}
+
+func (c *leaderClient) DeleteRecord(ctx context.Context, in *mydatabase.DeleteRecordRequest, opts ...grpc.CallOption) (*mydatabase.DeleteRecordResponse, error) {
+	return callLeader(ctx, c, in.ExpectedVersion != nil, func(client mydatabase.DatabaseServiceClient, opts ...grpc.CallOption) (*mydatabase.DeleteRecordResponse, error) {
+		return client.DeleteRecord(ctx, in, opts...)
+	}, opts...)
+}
+
+func (c *leaderClient) GetRecordHistory(ctx context.Context, in *mydatabase.GetRecordHistoryRequest, opts ...grpc.CallOption) (*mydatabase.GetRecordHistoryResponse, error) {
+	return callLeader(ctx, c, true, func(client mydatabase.DatabaseServiceClient, opts ...grpc.CallOption) (*mydatabase.GetRecordHistoryResponse, error) {
+		return client.GetRecordHistory(ctx, in, opts...)
+	}, opts...)
+}
+
+func (c *leaderClient) ScanRecords(ctx context.Context, in *mydatabase.ScanRecordsRequest, opts ...grpc.CallOption) (*mydatabase.ScanRecordsResponse, error) {
+	return callLeader(ctx, c, true, func(client mydatabase.DatabaseServiceClient, opts ...grpc.CallOption) (*mydatabase.ScanRecordsResponse, error) {
+		return client.ScanRecords(ctx, in, opts...)
+	}, opts...)
+}
+
+func (c *leaderClient) ScrubRecords(ctx context.Context, in *mydatabase.ScrubRecordsRequest, opts ...grpc.CallOption) (*mydatabase.ScrubRecordsResponse, error) {
+	return callLeader(ctx, c, true, func(client mydatabase.DatabaseServiceClient, opts ...grpc.CallOption) (*mydatabase.ScrubRecordsResponse, error) {
+		return client.ScrubRecords(ctx, in, opts...)
+	}, opts...)
+}
+
+func (c *leaderClient) WriteBatch(ctx context.Context, in *mydatabase.WriteBatchRequest, opts ...grpc.CallOption) (*mydatabase.WriteBatchResponse, error) {
+	return callLeader(ctx, c, conditionalBatch(in), func(client mydatabase.DatabaseServiceClient, opts ...grpc.CallOption) (*mydatabase.WriteBatchResponse, error) {
+		return client.WriteBatch(ctx, in, opts...)
+	}, opts...)
+}
+
+// conditionalBatch reports whether every write of a batch is conditional on
+// a version, so that applying the batch a second time fails.
+func conditionalBatch(in *mydatabase.WriteBatchRequest) bool {
+	for _, op := range in.GetOperations() {
+		if op.ExpectedVersion == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// WatchChanges streams changes from the leader or, when it is unknown, any
+// replica. Every replica applies the same log, so sequence numbers agree and a
+// watcher can resume on another replica after an error.
+func (c *leaderClient) WatchChanges(ctx context.Context, in *mydatabase.WatchChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[mydatabase.ChangeEvent], error) {
+	addr, client := c.target()
+	stream, err := client.WatchChanges(ctx, in, opts...)
+	if err != nil {
+		c.redirect(addr, nil)
+		return nil, err
+	}
+	return &watchStream{ServerStreamingClient: stream, client: c, addr: addr}, nil
+}
+
+// watchStream moves on to another replica once a change stream fails.
+type watchStream struct {
+	grpc.ServerStreamingClient[mydatabase.ChangeEvent]
+	client *leaderClient
+	addr   string
+}
+
+func (s *watchStream) Recv() (*mydatabase.ChangeEvent, error) {
+	event, err := s.ServerStreamingClient.Recv()
+	if status.Code(err) == codes.Unavailable {
+		s.client.redirect(s.addr, nil)
+	}
+	return event, err
+}
+
+// scanAll calls fn for every record whose key starts with prefix, in key
+// order, one page at a time. Every page is read at the snapshot of the first,
+// where the database supports it. It stops at the first error.
+func scanAll(ctx context.Context, client mydatabase.DatabaseServiceClient, prefix string, fn func(*mydatabase.DatabaseRecord) error) error {
+	_, err := scanAt(ctx, client, prefix, "", 0, fn)
+	return err
+}
+
+// scanAt is scanAll starting after the key startAfter and reading at the
+// given snapshot (zero reads the latest). fn may return errStopScan to end
+// the scan early. It returns the snapshot the scan was served at.
+func scanAt(ctx context.Context, client mydatabase.DatabaseServiceClient, prefix string, startAfter string, snapshot uint64, fn func(*mydatabase.DatabaseRecord) error) (uint64, error) {
+	for {
+		reply, err := client.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{Prefix: prefix, StartAfter: startAfter, Limit: maxScanLimit, SnapshotVersion: snapshot})
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot = reply.GetSnapshotVersion()
+		for _, record := range reply.GetRecords() {
+			if err := fn(record); err == errStopScan {
+				return snapshot, nil
+			} else if err != nil {
+				return snapshot, err
+			}
+		}
+		if startAfter = reply.GetNextStartAfter(); startAfter == "" {
+			return snapshot, nil
+		}
+	}
+}
+
+// recordUpdate derives the new value of a record from its current value,
+// which is nil if there is no record. A nil result deletes the record.
+type recordUpdate struct {
+	key   string
+	apply func(current []byte) ([]byte, error)
+	// undo, if set, makes the update a guard, such as claiming capacity, and
+	// reverses it. Where updates can't be applied atomically with the writes,
+	// guards are applied before the writes and undone if they fail; other
+	// updates are applied after them.
+	undo func(current []byte) ([]byte, error)
+}
+
+// writeWithUpdates applies writes together with updates to other records,
+// atomically, retrying when another writer changes an updated record first.
+// Where the database can't apply them atomically, as when they span shards,
+// each is applied on its own: guard updates, then the writes, then the other
+// updates, and guards already applied are undone if a guard or a write
+// fails. It returns the database version after the last write.
+func writeWithUpdates(ctx context.Context, client mydatabase.DatabaseServiceClient, writes []*mydatabase.WriteOperation, updates []recordUpdate) (uint64, error) {
+	for conflicts := 0; ; conflicts++ {
+		ops := writes[:len(writes):len(writes)]
+		for _, update := range updates {
+			op, err := updateOperation(ctx, client, update)
+			if err != nil {
+				return 0, err
+			}
+			if op != nil {
+				ops = append(ops, op)
+			}
+		}
+		if len(ops) == 0 {
+			return 0, nil
+		}
+		reply, err := client.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: ops})
+		switch status.Code(err) {
+		case codes.OK:
+			return reply.GetVersion(), nil
+		case codes.Aborted:
+			if conflicts == maxWriteConflicts {
+				return 0, err
+			}
+		case codes.Unimplemented:
+			return writeSeparately(ctx, client, writes, updates)
+		default:
+			return 0, err
+		}
+	}
+}
+
+// writeSeparately is writeWithUpdates for databases that can only write
+// records atomically one at a time.
+func writeSeparately(ctx context.Context, client mydatabase.DatabaseServiceClient, writes []*mydatabase.WriteOperation, updates []recordUpdate) (uint64, error) {
+	var version uint64
+	update := func(update recordUpdate) error {
+		for conflicts := 0; ; conflicts++ {
+			op, err := updateOperation(ctx, client, update)
+			if err != nil || op == nil {
+				return err
+			}
+			reply, err := client.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{op}})
+			if err == nil {
+				version = reply.GetVersion()
+				return nil
+			}
+			if status.Code(err) != codes.Aborted || conflicts == maxWriteConflicts {
+				return err
+			}
+		}
+	}
+
+	// release undoes the guards applied so far once a guard or write fails.
+	var applied []recordUpdate
+	release := func(err error) (uint64, error) {
+		for _, u := range applied {
+			if undoErr := update(recordUpdate{key: u.key, apply: u.undo}); undoErr != nil {
+				log.Printf("failed to undo the update of %s after a failed write: %v", u.key, undoErr)
+			}
+		}
+		return 0, err
+	}
+	for _, u := range updates {
+		if u.undo != nil {
+			if err := update(u); err != nil {
+				return release(err)
+			}
+			applied = append(applied, u)
+		}
+	}
+	for _, op := range writes {
+		reply, err := client.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{op}})
+		if err != nil {
+			return release(err)
+		}
+		version = reply.GetVersion()
+	}
+	for _, u := range updates {
+		if u.undo == nil {
+			if err := update(u); err != nil {
+				return version, err
+			}
+		}
+	}
+	return version, nil
+}
+
+// updateOperation reads the record an update applies to and returns the
+// write that updates it, provided it hasn't changed since. It returns nil if
+// there is nothing to write.
+func updateOperation(ctx context.Context, client mydatabase.DatabaseServiceClient, update recordUpdate) (*mydatabase.WriteOperation, error) {
+	reply, err := client.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: update.key})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return nil, err
+	}
+	current := reply.GetRecord()
+	value, err := update.apply(current.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	if value == nil && current == nil {
+		return nil, nil
+	}
+	record := &mydatabase.DatabaseRecord{Key: update.key, Value: value, Deleted: value == nil}
+	return &mydatabase.WriteOperation{Record: record, ExpectedVersion: proto.Uint64(current.GetVersion())}, nil
+}
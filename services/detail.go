@@ -10,10 +10,13 @@ import (
 	"cse190-welp/proto/detail"
 	"cse190-welp/proto/mycache"
 	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"cse190-welp/proto/review"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // Detail implements the detail service
@@ -24,23 +27,35 @@ type Detail struct {
 	lock                 sync.Mutex
 	detailCacheClient    mycache.CacheServiceClient
 	detailDatabaseClient mydatabase.DatabaseServiceClient
+	reviewClient         review.ReviewServiceClient           // nil if deletes can't cascade
+	reservationClient    reservation.ReservationServiceClient // nil if deletes can't cascade
+	indexChecked         bool                                 // the search indexes are known to exist
 }
 
-// NewDetail returns a new server for the detail service.
-func NewDetail(name string, detailPort int, detailCacheAddr string, detailDatabaseAddr string) *Detail {
-	return &Detail{
+// NewDetail returns a new server for the detail service. Deleting a
+// restaurant cascades to its reviews and reservations through the review and
+// reservation services; with either address empty, it can't.
+func NewDetail(name string, detailPort int, detailCacheAddr string, detailDatabaseAddr string, reviewAddr string, reservationAddr string) *Detail {
+	s := &Detail{
 		name:                 name,
 		port:                 detailPort,
 		detailCacheClient:    mycache.NewCacheServiceClient(dial(detailCacheAddr)),
-		detailDatabaseClient: mydatabase.NewDatabaseServiceClient(dial(detailDatabaseAddr)),
+		detailDatabaseClient: newDatabaseClient(detailDatabaseAddr),
 	}
+	if reviewAddr != "" && reservationAddr != "" {
+		s.reviewClient = review.NewReviewServiceClient(dial(reviewAddr))
+		s.reservationClient = reservation.NewReservationServiceClient(dial(reservationAddr))
+	}
+	return s
 }
 
 // Run starts the Detail gRPC server and listens for incoming requests.
 // It returns an error if the server fails to start or encounters an error.
 func (s *Detail) Run() error {
-	// Create a new gRPC server instance.
-	srv := grpc.NewServer()
+	// Create a new gRPC server instance, replaying the responses to retried writes.
+	keys := newIdempotency(s.name, s.detailDatabaseClient, detail.DetailService_PostDetail_FullMethodName)
+	go keys.expireForever()
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(validateRequest, keys.intercept))
 
 	// Register the Detail server implementation with the gRPC server.
 	detail.RegisterDetailServiceServer(srv, s)
@@ -131,11 +146,28 @@ func (s *Detail) PostDetail(ctx context.Context, req *detail.PostDetailRequest)
 	capacity := req.GetCapacity()
 	style := req.GetStyle()
 
+	if err := validateOpeningHours(req.GetOpeningHours()); err != nil {
+		return &detail.PostDetailResponse{Status: false}, err
+	}
+	if err := validateCoordinates(req.GetCoordinates()); err != nil {
+		return &detail.PostDetailResponse{Status: false}, err
+	}
+
 	msg := &detail.GetDetailResponse{
 		RestaurantName: restaurantName,
 		Location:       location,
 		Style:          style,
 		Capacity:       capacity,
+		OpeningHours:   req.GetOpeningHours(),
+		Coordinates:    req.GetCoordinates(),
+	}
+
+	// Store the details with their index entries, then cache them
+	_, _, err := s.writeDetail(ctx, restaurantName, func(*detail.GetDetailResponse) (*detail.GetDetailResponse, error) {
+		return msg, nil
+	})
+	if err != nil {
+		return &detail.PostDetailResponse{Status: false}, err
 	}
 
 	data, err := proto.Marshal(msg)
@@ -149,11 +181,6 @@ func (s *Detail) PostDetail(ctx context.Context, req *detail.PostDetailRequest)
 		Value: data,
 	}
 
-	record := &mydatabase.DatabaseRecord{
-		Key:   restaurantName,
-		Value: data,
-	}
-
 	// Create a protobuf response indicating whether the detail was successfully posted
 	detailResponse := &detail.PostDetailResponse{Status: true}
 
@@ -162,11 +189,166 @@ func (s *Detail) PostDetail(ctx context.Context, req *detail.PostDetailRequest)
 		detailResponse.Status = false
 	}
 
-	err = storageSetHelper(s.detailDatabaseClient, ctx, record, s.name)
+	// Return the response object.
+	return detailResponse, err
+}
+
+// UpdateDetail changes the details of a restaurant named in the request to
+// the values in it, for the fields in the update mask, or every field set in
+// the request if there is no mask. Fields in the mask but not set in the
+// request are cleared.
+func (s *Detail) UpdateDetail(ctx context.Context, req *detail.UpdateDetailRequest) (*detail.UpdateDetailResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	restaurantName := req.GetDetail().GetRestaurantName()
+	if restaurantName == "" {
+		return &detail.UpdateDetailResponse{}, status.Error(codes.InvalidArgument, "A restaurant_name is required")
+	}
+	fields, err := updatedFields(req)
+	if err != nil {
+		return &detail.UpdateDetailResponse{}, err
+	}
+	if err := validateOpeningHours(req.GetDetail().GetOpeningHours()); err != nil {
+		return &detail.UpdateDetailResponse{}, err
+	}
+	if err := validateCoordinates(req.GetDetail().GetCoordinates()); err != nil {
+		return &detail.UpdateDetailResponse{}, err
+	}
+
+	_, updated, err := s.writeDetail(ctx, restaurantName, func(before *detail.GetDetailResponse) (*detail.GetDetailResponse, error) {
+		if before == nil {
+			return nil, status.Errorf(codes.NotFound, "Restaurant %s does not exist", restaurantName)
+		}
+		source, target := req.GetDetail().ProtoReflect(), proto.Clone(before).ProtoReflect()
+		for _, field := range fields {
+			if source.Has(field) {
+				target.Set(field, source.Get(field))
+			} else {
+				target.Clear(field)
+			}
+		}
+		return target.Interface().(*detail.GetDetailResponse), nil
+	})
 	if err != nil {
-		detailResponse.Status = false
+		return &detail.UpdateDetailResponse{}, err
 	}
+	s.invalidate(ctx, restaurantName)
+	return &detail.UpdateDetailResponse{Detail: updated}, nil
+}
 
-	// Return the response object.
-	return detailResponse, err
+// DeleteDetail removes a restaurant and, if asked to cascade, its reviews
+// and reservations, with the popularity counted for them.
+func (s *Detail) DeleteDetail(ctx context.Context, req *detail.DeleteDetailRequest) (*detail.DeleteDetailResponse, error) {
+	restaurantName := req.GetRestaurantName()
+	if restaurantName == "" {
+		return &detail.DeleteDetailResponse{}, status.Error(codes.InvalidArgument, "A restaurant_name is required")
+	}
+	response := &detail.DeleteDetailResponse{}
+	if req.GetCascade() {
+		if s.reviewClient == nil || s.reservationClient == nil {
+			return response, status.Error(codes.FailedPrecondition, "Deletes can't cascade without the review and reservation services")
+		}
+		// The reviews and reservations go first, so a failure can be retried.
+		reviews, err := s.reviewClient.DeleteRestaurantReviews(ctx, &review.DeleteRestaurantReviewsRequest{RestaurantName: restaurantName})
+		if err != nil {
+			return response, err
+		}
+		response.ReviewsDeleted = reviews.GetReviews()
+		reservations, err := s.reservationClient.DeleteRestaurantReservations(ctx, &reservation.DeleteRestaurantReservationsRequest{RestaurantName: restaurantName})
+		if err != nil {
+			return response, err
+		}
+		response.ReservationsDeleted = reservations.GetReservations()
+	}
+
+	// The lock is only taken now: the reservation service looks up details
+	// while it deletes, so holding it across the cascade could deadlock.
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	before, _, err := s.writeDetail(ctx, restaurantName, func(*detail.GetDetailResponse) (*detail.GetDetailResponse, error) {
+		return nil, nil
+	})
+	if err != nil {
+		return response, err
+	}
+	if before == nil && !req.GetCascade() {
+		return response, status.Errorf(codes.NotFound, "Restaurant %s does not exist", restaurantName)
+	}
+	s.invalidate(ctx, restaurantName)
+	response.Status = true
+	return response, nil
+}
+
+// SearchDetails finds the restaurants in a location, of a style and seating
+// at least a number of people, one page at a time.
+func (s *Detail) SearchDetails(ctx context.Context, req *detail.SearchDetailsRequest) (*detail.SearchDetailsResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	details, next, err := s.searchDetails(ctx, req)
+	if err != nil {
+		return &detail.SearchDetailsResponse{}, err
+	}
+	return &detail.SearchDetailsResponse{Details: details, NextPageToken: next}, nil
+}
+
+// NearbyRestaurants finds the restaurants within a distance of a point,
+// nearest first, optionally only those of a style.
+func (s *Detail) NearbyRestaurants(ctx context.Context, req *detail.NearbyRestaurantsRequest) (*detail.NearbyRestaurantsResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	nearby, err := s.nearbyRestaurants(ctx, req)
+	if err != nil {
+		return &detail.NearbyRestaurantsResponse{}, err
+	}
+	return &detail.NearbyRestaurantsResponse{Restaurants: nearby}, nil
+}
+
+// invalidate removes a restaurant's cached details, if any.
+func (s *Detail) invalidate(ctx context.Context, restaurantName string) {
+	_, err := s.detailCacheClient.DeleteItem(ctx, &mycache.DeleteItemRequest{Key: restaurantName})
+	if err != nil && status.Code(err) != codes.NotFound {
+		log.Printf("detail server <%s> failed to invalidate cached detail %s: %v", s.name, restaurantName, err)
+	}
+}
+
+// updatedFields returns the fields an update changes: those in its mask, or
+// those set in it if it has none.
+func updatedFields(req *detail.UpdateDetailRequest) ([]protoreflect.FieldDescriptor, error) {
+	message := req.GetDetail().ProtoReflect()
+	fields := message.Descriptor().Fields()
+	var updated []protoreflect.FieldDescriptor
+	if req.GetUpdateMask() == nil {
+		message.Range(func(field protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+			if field.Name() != "restaurant_name" {
+				updated = append(updated, field)
+			}
+			return true
+		})
+		return updated, nil
+	}
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		field := fields.ByName(protoreflect.Name(path))
+		if field == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Unknown field in update_mask: %q", path)
+		}
+		if field.Name() == "restaurant_name" {
+			return nil, status.Error(codes.InvalidArgument, "The restaurant_name can't be updated")
+		}
+		updated = append(updated, field)
+	}
+	return updated, nil
+}
+
+// validateOpeningHours checks that each day's hours are a valid range within
+// the day.
+func validateOpeningHours(openingHours []*detail.OpeningHours) error {
+	for _, hours := range openingHours {
+		if hours.GetDayOfWeek() < 0 || hours.GetDayOfWeek() > 6 || hours.GetOpensAt() < 0 || hours.GetOpensAt() >= hours.GetClosesAt() || hours.GetClosesAt() > 24*60 {
+			return status.Errorf(codes.InvalidArgument, "Invalid opening hours: %v", hours)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"cse190-welp/proto/detail"
+	"cse190-welp/proto/mydatabase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Prefixes of the database keys of the restaurant indexes. Each restaurant has
+// one entry in each (in the geohash index only if it has coordinates), keyed by the indexed value and then the restaurant's
+// name, whose value is the name. Text is indexed in lower case.
+const (
+	detailIndexPrefix     = internalKeyPrefix + "details-by-"
+	locationDetailsPrefix = detailIndexPrefix + "location:"
+	styleDetailsPrefix    = detailIndexPrefix + "style:"
+	capacityDetailsPrefix = detailIndexPrefix + "capacity:"
+)
+
+// Bounds on the number of restaurants a search returns at a time.
+const (
+	defaultSearchPageSize = 10
+	maxSearchPageSize     = 100
+)
+
+// textIndexPrefix returns the prefix of the index entries for a text value.
+func textIndexPrefix(prefix string, value string) string {
+	return prefix + url.QueryEscape(strings.ToLower(value)) + ":"
+}
+
+// capacityIndexPrefix returns the prefix of the index entries for a
+// capacity, padded so that capacities sort in numeric order.
+func capacityIndexPrefix(capacity int32) string {
+	return capacityDetailsPrefix + fmt.Sprintf("%010d:", max(capacity, 0))
+}
+
+// detailIndexKeys returns the keys of a restaurant's index entries.
+func detailIndexKeys(d *detail.GetDetailResponse) []string {
+	if d == nil {
+		return nil
+	}
+	keys := []string{
+		textIndexPrefix(locationDetailsPrefix, d.GetLocation()) + d.GetRestaurantName(),
+		textIndexPrefix(styleDetailsPrefix, d.GetStyle()) + d.GetRestaurantName(),
+		capacityIndexPrefix(d.GetCapacity()) + d.GetRestaurantName(),
+	}
+	if d.GetCoordinates() != nil {
+		keys = append(keys, geohashDetailsPrefix+geohash(d.GetCoordinates(), geohashPrecision)+":"+d.GetRestaurantName())
+	}
+	return keys
+}
+
+// readDetail returns a restaurant's stored details and the version of their
+// record, or nil and 0 if there are none.
+func (s *Detail) readDetail(ctx context.Context, restaurantName string) (*detail.GetDetailResponse, uint64, error) {
+	reply, err := s.detailDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: restaurantName})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound:
+		return nil, 0, nil
+	default:
+		return nil, 0, err
+	}
+	d := &detail.GetDetailResponse{}
+	if err := proto.Unmarshal(reply.GetRecord().GetValue(), d); err != nil {
+		return nil, 0, status.Errorf(codes.DataLoss, "Details of %s could not be decoded: %v", restaurantName, err)
+	}
+	return d, reply.GetRecord().GetVersion(), nil
+}
+
+// writeDetail replaces a restaurant's details with what change returns for
+// the current ones, nil if there are none, and keeps the indexes in line. A
+// nil result deletes the details. If another writer changes them first, it
+// rereads them and tries again. It returns the details before and after.
+// Caller must hold s.lock.
+func (s *Detail) writeDetail(ctx context.Context, restaurantName string, change func(before *detail.GetDetailResponse) (*detail.GetDetailResponse, error)) (*detail.GetDetailResponse, *detail.GetDetailResponse, error) {
+	if err := s.ensureDetailIndex(ctx); err != nil {
+		return nil, nil, err
+	}
+	for conflicts := 0; ; conflicts++ {
+		before, version, err := s.readDetail(ctx, restaurantName)
+		if err != nil {
+			return nil, nil, err
+		}
+		after, err := change(before)
+		if err != nil || (before == nil && after == nil) {
+			return before, after, err
+		}
+
+		// Where the writes can't be applied atomically they are applied in
+		// order, so a restaurant is never missing from an index; searches
+		// skip entries that are out of date.
+		record := &mydatabase.DatabaseRecord{Key: restaurantName, Deleted: true}
+		if after != nil {
+			record = &mydatabase.DatabaseRecord{Key: restaurantName}
+			if record.Value, err = proto.Marshal(after); err != nil {
+				return nil, nil, err
+			}
+		}
+		stale := detailIndexKeys(before)
+		var writes []*mydatabase.WriteOperation
+		for _, key := range detailIndexKeys(after) {
+			if !containsString(stale, key) {
+				writes = append(writes, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Value: []byte(restaurantName)}})
+			}
+		}
+		writes = append(writes, &mydatabase.WriteOperation{Record: record, ExpectedVersion: proto.Uint64(version)})
+		for _, key := range stale {
+			if !containsString(detailIndexKeys(after), key) {
+				writes = append(writes, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Deleted: true}})
+			}
+		}
+
+		_, err = writeWithUpdates(ctx, s.detailDatabaseClient, writes, nil)
+		if status.Code(err) != codes.Aborted || conflicts == maxWriteConflicts {
+			return before, after, err
+		}
+	}
+}
+
+// ensureDetailIndex indexes every restaurant the first time the indexes are
+// needed if the database holds none, as when the details were written before
+// they were indexed. Caller must hold s.lock.
+func (s *Detail) ensureDetailIndex(ctx context.Context) error {
+	if s.indexChecked {
+		return nil
+	}
+	reply, err := s.detailDatabaseClient.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{Prefix: detailIndexPrefix, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(reply.GetRecords()) == 0 {
+		var writes []*mydatabase.WriteOperation
+		err := scanAll(ctx, s.detailDatabaseClient, "", func(record *mydatabase.DatabaseRecord) error {
+			if strings.HasPrefix(record.GetKey(), internalKeyPrefix) {
+				return nil
+			}
+			d := &detail.GetDetailResponse{}
+			if err := proto.Unmarshal(record.GetValue(), d); err != nil {
+				return status.Errorf(codes.DataLoss, "Details of %s could not be decoded: %v", record.GetKey(), err)
+			}
+			for _, key := range detailIndexKeys(d) {
+				writes = append(writes, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Value: []byte(d.GetRestaurantName())}})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := writeWithUpdates(ctx, s.detailDatabaseClient, writes, nil); err != nil {
+			return err
+		}
+	}
+	s.indexChecked = true
+	return nil
+}
+
+// scanRange is the index entries with a prefix whose keys sort after
+// startAfter.
+type scanRange struct {
+	prefix     string
+	startAfter string
+}
+
+// indexedNames returns the names of the restaurants in the index entries in
+// any of the ranges.
+func (s *Detail) indexedNames(ctx context.Context, ranges []scanRange) (map[string]bool, error) {
+	names := make(map[string]bool)
+	for _, r := range ranges {
+		_, err := scanAt(ctx, s.detailDatabaseClient, r.prefix, r.startAfter, 0, func(record *mydatabase.DatabaseRecord) error {
+			names[string(record.GetValue())] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// searchDetails returns a page of the restaurants matching a search, found
+// through the indexes, and the token of the next page. Caller must hold
+// s.lock.
+func (s *Detail) searchDetails(ctx context.Context, req *detail.SearchDetailsRequest) ([]*detail.GetDetailResponse, string, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, "", status.Errorf(codes.InvalidArgument, "Invalid page_size: %d", pageSize)
+	case pageSize == 0:
+		pageSize = defaultSearchPageSize
+	case pageSize > maxSearchPageSize:
+		pageSize = maxSearchPageSize
+	}
+	if err := s.ensureDetailIndex(ctx); err != nil {
+		return nil, "", err
+	}
+
+	// Intersect the restaurants matching each filter, found in its index
+	// entries. Every restaurant has a capacity entry, so with no filters
+	// those list them all.
+	var filters [][]scanRange
+	if req.GetLocation() != "" {
+		filters = append(filters, []scanRange{{prefix: textIndexPrefix(locationDetailsPrefix, req.GetLocation())}})
+	}
+	if req.GetStyle() != "" {
+		filters = append(filters, []scanRange{{prefix: textIndexPrefix(styleDetailsPrefix, req.GetStyle())}})
+	}
+	switch {
+	case req.GetMinCapacity() > 0:
+		// Entries sort after the prefix of their capacity, so start after
+		// every entry of the capacity below the minimum. Restaurants with
+		// unlimited capacity (0) always match.
+		below := capacityIndexPrefix(req.GetMinCapacity() - 1)
+		filters = append(filters, []scanRange{
+			{prefix: capacityDetailsPrefix, startAfter: strings.TrimSuffix(below, ":") + ";"},
+			{prefix: capacityIndexPrefix(0)},
+		})
+	case len(filters) == 0:
+		filters = append(filters, []scanRange{{prefix: capacityDetailsPrefix}})
+	}
+	var matches map[string]bool
+	for _, ranges := range filters {
+		names, err := s.indexedNames(ctx, ranges)
+		if err != nil {
+			return nil, "", err
+		}
+		if matches != nil {
+			for name := range matches {
+				if !names[name] {
+					delete(matches, name)
+				}
+			}
+		} else {
+			matches = names
+		}
+	}
+	var names []string
+	for name := range matches {
+		if name > req.GetPageToken() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var page []*detail.GetDetailResponse
+	for i, name := range names {
+		if len(page) == pageSize {
+			return page, names[i-1], nil
+		}
+		d, _, err := s.readDetail(ctx, name)
+		if err != nil {
+			return nil, "", err
+		}
+		if d != nil && detailMatches(d, req) {
+			page = append(page, d)
+		}
+	}
+	return page, "", nil
+}
+
+// detailMatches reports whether a restaurant matches a search, in case an
+// index entry is out of date.
+func detailMatches(d *detail.GetDetailResponse, req *detail.SearchDetailsRequest) bool {
+	return (req.GetLocation() == "" || strings.EqualFold(d.GetLocation(), req.GetLocation())) &&
+		(req.GetStyle() == "" || strings.EqualFold(d.GetStyle(), req.GetStyle())) &&
+		(d.GetCapacity() == 0 || d.GetCapacity() >= req.GetMinCapacity())
+}
@@ -17,11 +17,18 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"cse190-welp/proto/detail"
 	"cse190-welp/proto/reservation"
 	"cse190-welp/proto/review"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 // Frontend implements a service that acts as an interface to interact with different microservices.
@@ -76,27 +83,47 @@ func Schedule(t RequestType) {
 
 // Run starts the Frontend server and listens for incoming requests on the specified port.
 func (s *Frontend) Run() error {
-	http.Handle("/", http.FileServer(http.Dir("./static")))
-	http.HandleFunc("/get-detail", s.getDetailHandler)
-	http.HandleFunc("/post-detail", s.postDetailHandler)
-	http.HandleFunc("/get-review", s.getReviewHandler)
-	http.HandleFunc("/post-review", s.postReviewHandler)
-	http.HandleFunc("/search-reviews", s.searchReviewsHandler)
-	http.HandleFunc("/get-reservation", s.getReservationHandler)
-	http.HandleFunc("/make-reservation", s.makeReservationHandler)
-	http.HandleFunc("/most-popular", s.mostPopularHandler)
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir("./static")))
+	mux.HandleFunc("/get-detail", s.getDetailHandler)
+	mux.HandleFunc("/post-detail", s.postDetailHandler)
+	mux.HandleFunc("/update-detail", s.updateDetailHandler)
+	mux.HandleFunc("/delete-detail", s.deleteDetailHandler)
+	mux.HandleFunc("/search-details", s.searchDetailsHandler)
+	mux.HandleFunc("/nearby-restaurants", s.nearbyRestaurantsHandler)
+	mux.HandleFunc("/get-review", s.getReviewHandler)
+	mux.HandleFunc("/post-review", s.postReviewHandler)
+	mux.HandleFunc("/edit-review", s.editReviewHandler)
+	mux.HandleFunc("/delete-review", s.deleteReviewHandler)
+	mux.HandleFunc("/review-history", s.reviewHistoryHandler)
+	mux.HandleFunc("/vote-review", s.voteReviewHandler)
+	mux.HandleFunc("/pending-reviews", s.pendingReviewsHandler)
+	mux.HandleFunc("/approve-review", s.approveReviewHandler)
+	mux.HandleFunc("/reject-review", s.rejectReviewHandler)
+	mux.HandleFunc("/search-reviews", s.searchReviewsHandler)
+	mux.HandleFunc("/search-review-text", s.searchReviewTextHandler)
+	mux.HandleFunc("/get-reservation", s.getReservationHandler)
+	mux.HandleFunc("/make-reservation", s.makeReservationHandler)
+	mux.HandleFunc("/cancel-reservation", s.cancelReservationHandler)
+	mux.HandleFunc("/modify-reservation", s.modifyReservationHandler)
+	mux.HandleFunc("/get-availability", s.getAvailabilityHandler)
+	mux.HandleFunc("/list-reservations", s.listReservationsHandler)
+	mux.HandleFunc("/most-popular", s.mostPopularHandler)
+	mux.HandleFunc("/trending", s.trendingHandler)
 
 	log.Printf("frontend server running at port hello hello hello: %d", s.port)
-	return http.ListenAndServe(fmt.Sprintf(":%d", s.port), nil)
+	return http.ListenAndServe(fmt.Sprintf(":%d", s.port), mux)
 }
 
-// getDetailHandler handles requests for retrieving restaurant details.
+// getDetailHandler handles requests for retrieving restaurant details, with
+// `ratings` also a summary of the restaurant's review ratings.
 func (s *Frontend) getDetailHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	restaurant_name := r.URL.Query().Get("restaurant_name")
+	ratings, ratings_err := optionalBool(r.URL.Query().Get("ratings"))
 
-	if restaurant_name == "" {
+	if restaurant_name == "" || ratings_err != nil {
 		http.Error(w, "Malformed request to `/get-detail` endpoint!", http.StatusBadRequest)
 		return
 	}
@@ -106,22 +133,38 @@ func (s *Frontend) getDetailHandler(w http.ResponseWriter, r *http.Request) {
 	reply, err := s.detailClient.GetDetail(ctx, req)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+	if !ratings {
+		_ = json.NewEncoder(w).Encode(reply)
 		return
 	}
 
-	_ = json.NewEncoder(w).Encode(reply)
+	summary, err := s.reviewClient.GetRatingSummary(ctx, &review.GetRatingSummaryRequest{RestaurantName: restaurant_name})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(struct {
+		*detail.GetDetailResponse
+		RatingSummary *review.GetRatingSummaryResponse `json:"rating_summary"`
+	}{reply, summary})
 }
 
-// postDetailHandler handles requests for posting restaurant details.
+// postDetailHandler handles requests for posting restaurant details,
+// optionally with the restaurant's `latitude` and `longitude`. An
+// `Idempotency-Key` header makes retries of the request safe.
 func (s *Frontend) postDetailHandler(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := withIdempotencyKey(r)
 	restaurant_name := r.URL.Query().Get("restaurant_name")
 	location := r.URL.Query().Get("location")
 	style := r.URL.Query().Get("style")
 	capacity, err := strconv.Atoi(r.URL.Query().Get("capacity"))
+	coordinates, coordinates_err := optionalCoordinates(r.URL.Query())
 
-	if restaurant_name == "" || location == "" || style == "" || err != nil {
+	if restaurant_name == "" || location == "" || style == "" || err != nil || coordinates_err != nil {
 		http.Error(w, "Malformed request to `/post-detail` endpoint!", http.StatusBadRequest)
 		return
 	}
@@ -131,12 +174,156 @@ func (s *Frontend) postDetailHandler(w http.ResponseWriter, r *http.Request) {
 		Location:       location,
 		Style:          style,
 		Capacity:       int32(capacity),
+		Coordinates:    coordinates,
 	}
 	Schedule(DETAIL)
 	reply, err := s.detailClient.PostDetail(ctx, req)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// updateDetailHandler handles PATCH requests for changing some of a
+// restaurant's details: the `location`, `style`, `capacity` or `latitude`
+// and `longitude` given.
+func (s *Frontend) updateDetailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		w.Header().Set("Allow", http.MethodPatch)
+		http.Error(w, "Method not allowed at `/update-detail` endpoint!", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	query := r.URL.Query()
+	restaurant_name := query.Get("restaurant_name")
+	capacity, capacity_err := optionalInt(query.Get("capacity"))
+	coordinates, coordinates_err := optionalCoordinates(query)
+
+	mask := &fieldmaskpb.FieldMask{}
+	for _, field := range []string{"location", "style", "capacity"} {
+		if query.Has(field) {
+			mask.Paths = append(mask.Paths, field)
+		}
+	}
+	if coordinates != nil {
+		mask.Paths = append(mask.Paths, "coordinates")
+	}
+	if restaurant_name == "" || capacity_err != nil || coordinates_err != nil || len(mask.Paths) == 0 {
+		http.Error(w, "Malformed request to `/update-detail` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &detail.UpdateDetailRequest{
+		Detail: &detail.GetDetailResponse{
+			RestaurantName: restaurant_name,
+			Location:       query.Get("location"),
+			Style:          query.Get("style"),
+			Capacity:       int32(capacity),
+			Coordinates:    coordinates,
+		},
+		UpdateMask: mask,
+	}
+	Schedule(DETAIL)
+	reply, err := s.detailClient.UpdateDetail(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// deleteDetailHandler handles DELETE requests for removing a restaurant, and
+// with `cascade` its reviews and reservations too.
+func (s *Frontend) deleteDetailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		http.Error(w, "Method not allowed at `/delete-detail` endpoint!", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	restaurant_name := r.URL.Query().Get("restaurant_name")
+	cascade, cascade_err := optionalBool(r.URL.Query().Get("cascade"))
+
+	if restaurant_name == "" || cascade_err != nil {
+		http.Error(w, "Malformed request to `/delete-detail` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &detail.DeleteDetailRequest{RestaurantName: restaurant_name, Cascade: cascade}
+	Schedule(DETAIL)
+	reply, err := s.detailClient.DeleteDetail(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// searchDetailsHandler handles requests for finding restaurants by
+// `location`, `style` and `min_capacity`, a page of `page_size` at a time;
+// the `next_page_token` of one page is the `page_token` of the next.
+func (s *Frontend) searchDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	min_capacity, min_capacity_err := optionalInt(query.Get("min_capacity"))
+	page_size, page_size_err := optionalInt(query.Get("page_size"))
+
+	if min_capacity_err != nil || page_size_err != nil {
+		http.Error(w, "Malformed request to `/search-details` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &detail.SearchDetailsRequest{
+		Location:    query.Get("location"),
+		Style:       query.Get("style"),
+		MinCapacity: int32(min_capacity),
+		PageSize:    int32(page_size),
+		PageToken:   query.Get("page_token"),
+	}
+	Schedule(DETAIL)
+	reply, err := s.detailClient.SearchDetails(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// nearbyRestaurantsHandler handles requests for the restaurants within
+// `radius_km` of a `latitude` and `longitude`, nearest first, optionally of a
+// `style` and at most `limit` of them.
+func (s *Frontend) nearbyRestaurantsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	center, center_err := optionalCoordinates(query)
+	radius_km, radius_err := strconv.ParseFloat(query.Get("radius_km"), 64)
+	limit, limit_err := optionalInt(query.Get("limit"))
+
+	if center == nil || center_err != nil || radius_err != nil || limit_err != nil {
+		http.Error(w, "Malformed request to `/nearby-restaurants` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &detail.NearbyRestaurantsRequest{
+		Center:   center,
+		RadiusKm: radius_km,
+		Style:    query.Get("style"),
+		Limit:    int32(limit),
+	}
+	Schedule(DETAIL)
+	reply, err := s.detailClient.NearbyRestaurants(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -161,16 +348,17 @@ func (s *Frontend) getReviewHandler(w http.ResponseWriter, r *http.Request) {
 	reply, err := s.reviewClient.GetReview(ctx, req)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	_ = json.NewEncoder(w).Encode(reply)
 }
 
-// postReviewHandler handles requests for posting reviews.
+// postReviewHandler handles requests for posting reviews. An
+// `Idempotency-Key` header makes retries of the request safe.
 func (s *Frontend) postReviewHandler(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := withIdempotencyKey(r)
 	user_name := r.URL.Query().Get("user_name")
 	restaurant_name := r.URL.Query().Get("restaurant_name")
 	restaurant_review := r.URL.Query().Get("review")
@@ -190,29 +378,256 @@ func (s *Frontend) postReviewHandler(w http.ResponseWriter, r *http.Request) {
 	reply, err := s.reviewClient.PostReview(ctx, req)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	_ = json.NewEncoder(w).Encode(reply)
 }
 
-// searchReviewHandler handles requests for searching reviews.
-func (s *Frontend) searchReviewsHandler(w http.ResponseWriter, r *http.Request) {
+// editReviewHandler handles PATCH requests for changing the `review` or
+// `rating` of a review.
+func (s *Frontend) editReviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		w.Header().Set("Allow", http.MethodPatch)
+		http.Error(w, "Method not allowed at `/edit-review` endpoint!", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	user_name := r.URL.Query().Get("user_name")
+	restaurant_name := r.URL.Query().Get("restaurant_name")
+	restaurant_review := r.URL.Query().Get("review")
+	restaurant_rating, rating_err := optionalInt(r.URL.Query().Get("rating"))
+
+	if restaurant_name == "" || user_name == "" || rating_err != nil {
+		http.Error(w, "Malformed request to `/edit-review` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &review.EditReviewRequest{
+		UserName:       user_name,
+		RestaurantName: restaurant_name,
+		Review:         restaurant_review,
+		Rating:         int32(restaurant_rating),
+	}
+	reply, err := s.reviewClient.EditReview(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// deleteReviewHandler handles DELETE requests for deleting a review.
+func (s *Frontend) deleteReviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		http.Error(w, "Method not allowed at `/delete-review` endpoint!", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	user_name := r.URL.Query().Get("user_name")
+	restaurant_name := r.URL.Query().Get("restaurant_name")
+
+	if restaurant_name == "" || user_name == "" {
+		http.Error(w, "Malformed request to `/delete-review` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &review.DeleteReviewRequest{UserName: user_name, RestaurantName: restaurant_name}
+	reply, err := s.reviewClient.DeleteReview(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// reviewHistoryHandler handles requests for every revision of a review.
+func (s *Frontend) reviewHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user_name := r.URL.Query().Get("user_name")
+	restaurant_name := r.URL.Query().Get("restaurant_name")
+
+	if restaurant_name == "" || user_name == "" {
+		http.Error(w, "Malformed request to `/review-history` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &review.GetReviewHistoryRequest{UserName: user_name, RestaurantName: restaurant_name}
+	reply, err := s.reviewClient.GetReviewHistory(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// voteReviewHandler handles POST requests for marking a review as helpful,
+// with `retract` taking the vote back.
+func (s *Frontend) voteReviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed at `/vote-review` endpoint!", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	user_name := r.URL.Query().Get("user_name")
+	restaurant_name := r.URL.Query().Get("restaurant_name")
+	voter_name := r.URL.Query().Get("voter_name")
+	retract, retract_err := optionalBool(r.URL.Query().Get("retract"))
+
+	if restaurant_name == "" || user_name == "" || voter_name == "" || retract_err != nil {
+		http.Error(w, "Malformed request to `/vote-review` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &review.VoteReviewRequest{UserName: user_name, RestaurantName: restaurant_name, VoterName: voter_name, Retract: retract}
+	reply, err := s.reviewClient.VoteReview(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// pendingReviewsHandler handles admin requests for the reviews held for
+// moderation, optionally only those of a `restaurant_name`.
+func (s *Frontend) pendingReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	restaurant_name := r.URL.Query().Get("restaurant_name")
+
+	req := &review.ListPendingReviewsRequest{RestaurantName: restaurant_name}
+	reply, err := s.reviewClient.ListPendingReviews(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// approveReviewHandler handles admin POST requests for publishing a review
+// held for moderation.
+func (s *Frontend) approveReviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed at `/approve-review` endpoint!", http.StatusMethodNotAllowed)
+		return
+	}
 	ctx := r.Context()
+	user_name := r.URL.Query().Get("user_name")
 	restaurant_name := r.URL.Query().Get("restaurant_name")
-	if restaurant_name == "" {
+
+	if restaurant_name == "" || user_name == "" {
+		http.Error(w, "Malformed request to `/approve-review` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &review.ApproveReviewRequest{UserName: user_name, RestaurantName: restaurant_name}
+	reply, err := s.reviewClient.ApproveReview(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// rejectReviewHandler handles admin POST requests for discarding a review
+// held for moderation.
+func (s *Frontend) rejectReviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed at `/reject-review` endpoint!", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	user_name := r.URL.Query().Get("user_name")
+	restaurant_name := r.URL.Query().Get("restaurant_name")
+
+	if restaurant_name == "" || user_name == "" {
+		http.Error(w, "Malformed request to `/reject-review` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &review.RejectReviewRequest{UserName: user_name, RestaurantName: restaurant_name}
+	reply, err := s.reviewClient.RejectReview(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// searchReviewHandler handles requests for searching reviews, with
+// `page_size` a page of them in the order given by `sort`.
+func (s *Frontend) searchReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	restaurant_name := query.Get("restaurant_name")
+	page_size, page_size_err := optionalInt(query.Get("page_size"))
+	sort_order, sort_ok := review.ReviewSortOrder_value[strings.ToUpper(query.Get("sort"))]
+	if query.Get("sort") == "" {
+		sort_ok = true
+	}
+	if restaurant_name == "" || page_size_err != nil || !sort_ok {
 		http.Error(w, "Malformed request to `/search-reviews` endpoint!", http.StatusBadRequest)
 		return
 	}
 
 	req := &review.SearchReviewsRequest{
 		RestaurantName: restaurant_name,
+		PageSize:       int32(page_size),
+		PageToken:      query.Get("page_token"),
+		SortOrder:      review.ReviewSortOrder(sort_order),
 	}
 	reply, err := s.reviewClient.SearchReviews(ctx, req)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// searchReviewTextHandler handles requests for searching the text of reviews.
+func (s *Frontend) searchReviewTextHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	text_query := query.Get("query")
+	min_rating, min_rating_err := optionalInt(query.Get("min_rating"))
+	max_rating, max_rating_err := optionalInt(query.Get("max_rating"))
+	limit, limit_err := optionalInt(query.Get("limit"))
+	if text_query == "" || min_rating_err != nil || max_rating_err != nil || limit_err != nil {
+		http.Error(w, "Malformed request to `/search-review-text` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &review.SearchReviewTextRequest{
+		Query:          text_query,
+		RestaurantName: query.Get("restaurant_name"),
+		MinRating:      int32(min_rating),
+		MaxRating:      int32(max_rating),
+		Limit:          int32(limit),
+	}
+	reply, err := s.reviewClient.SearchReviewText(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -224,8 +639,9 @@ func (s *Frontend) getReservationHandler(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	user_name := r.URL.Query().Get("user_name")
 	restaurant_name := r.URL.Query().Get("restaurant_name")
+	reservation_id := r.URL.Query().Get("reservation_id")
 
-	if user_name == "" || restaurant_name == "" {
+	if reservation_id == "" && (user_name == "" || restaurant_name == "") {
 		http.Error(w, "Malformed request to `/get-reservation` endpoint!", http.StatusBadRequest)
 		return
 	}
@@ -233,40 +649,183 @@ func (s *Frontend) getReservationHandler(w http.ResponseWriter, r *http.Request)
 	req := &reservation.GetReservationRequest{
 		UserName:       user_name,
 		RestaurantName: restaurant_name,
+		ReservationId:  reservation_id,
 	}
 	reply, err := s.reservationClient.GetReservation(ctx, req)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	_ = json.NewEncoder(w).Encode(reply)
 }
 
-// makeReservationHandler handles requests for making reservations.
+// defaultReservationHour is the hour reservations start at when a request
+// gives only a date, as requests did before reservations had a time.
+const defaultReservationHour = 12
+
+// makeReservationHandler handles requests for making reservations starting
+// at `hour`:`minute`, noon if no hour is given, optionally for `duration`
+// minutes and a `party_size`.
+// With `waitlist=true`, a booking at a full restaurant joins the waitlist. An
+// `Idempotency-Key` header makes retries of the request safe.
 func (s *Frontend) makeReservationHandler(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := withIdempotencyKey(r)
 	user_name := r.URL.Query().Get("user_name")
 	restaurant_name := r.URL.Query().Get("restaurant_name")
 	year, year_err := strconv.Atoi(r.URL.Query().Get("year"))
 	month, month_err := strconv.Atoi(r.URL.Query().Get("month"))
 	day, day_err := strconv.Atoi(r.URL.Query().Get("day"))
+	hour, hour_err := optionalInt(r.URL.Query().Get("hour"))
+	if !r.URL.Query().Has("hour") {
+		hour = defaultReservationHour
+	}
+	minute, minute_err := optionalInt(r.URL.Query().Get("minute"))
+	duration, duration_err := optionalInt(r.URL.Query().Get("duration"))
+	party_size, party_size_err := optionalInt(r.URL.Query().Get("party_size"))
+	waitlist, waitlist_err := optionalBool(r.URL.Query().Get("waitlist"))
 
-	if restaurant_name == "" || user_name == "" || year_err != nil || month_err != nil || day_err != nil {
+	if restaurant_name == "" || user_name == "" || year_err != nil || month_err != nil || day_err != nil ||
+		hour_err != nil || minute_err != nil || duration_err != nil || party_size_err != nil || waitlist_err != nil {
 		http.Error(w, "Malformed request to `/make-reservation` endpoint!", http.StatusBadRequest)
 		return
 	}
 
 	req := &reservation.MakeReservationRequest{
+		UserName:        user_name,
+		RestaurantName:  restaurant_name,
+		Time:            &reservation.Date{Year: int32(year), Month: int32(month), Day: int32(day)},
+		StartTime:       &reservation.TimeOfDay{Hour: int32(hour), Minute: int32(minute)},
+		DurationMinutes: int32(duration),
+		PartySize:       int32(party_size),
+		JoinWaitlist:    waitlist,
+	}
+	reply, err := s.reservationClient.MakeReservation(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// cancelReservationHandler handles requests for cancelling reservations.
+func (s *Frontend) cancelReservationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	reservation_id := r.URL.Query().Get("reservation_id")
+
+	if reservation_id == "" {
+		http.Error(w, "Malformed request to `/cancel-reservation` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &reservation.CancelReservationRequest{ReservationId: reservation_id}
+	reply, err := s.reservationClient.CancelReservation(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// modifyReservationHandler handles requests for changing a reservation's
+// `date` (YYYY-MM-DD), start `hour`:`minute`, `duration` or `party_size`.
+// Parameters left out keep their current value.
+func (s *Frontend) modifyReservationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	reservation_id := query.Get("reservation_id")
+	date, date_err := optionalDate(query.Get("date"))
+	hour, hour_err := optionalInt(query.Get("hour"))
+	minute, minute_err := optionalInt(query.Get("minute"))
+	duration, duration_err := optionalInt(query.Get("duration"))
+	party_size, party_size_err := optionalInt(query.Get("party_size"))
+
+	if reservation_id == "" || date_err != nil || hour_err != nil || minute_err != nil || duration_err != nil || party_size_err != nil {
+		http.Error(w, "Malformed request to `/modify-reservation` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &reservation.ModifyReservationRequest{
+		ReservationId:   reservation_id,
+		Time:            date,
+		DurationMinutes: int32(duration),
+		PartySize:       int32(party_size),
+	}
+	if query.Has("hour") || query.Has("minute") {
+		req.StartTime = &reservation.TimeOfDay{Hour: int32(hour), Minute: int32(minute)}
+	}
+	reply, err := s.reservationClient.ModifyReservation(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// getAvailabilityHandler handles requests for the slots a restaurant has free
+// from one date through another (YYYY-MM-DD), optionally for a `party_size`
+// and `duration` in minutes.
+func (s *Frontend) getAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	restaurant_name := r.URL.Query().Get("restaurant_name")
+	from, from_err := optionalDate(r.URL.Query().Get("from"))
+	to, to_err := optionalDate(r.URL.Query().Get("to"))
+	duration, duration_err := optionalInt(r.URL.Query().Get("duration"))
+	party_size, party_size_err := optionalInt(r.URL.Query().Get("party_size"))
+
+	if restaurant_name == "" || from == nil || from_err != nil || to_err != nil || duration_err != nil || party_size_err != nil {
+		http.Error(w, "Malformed request to `/get-availability` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &reservation.GetAvailabilityRequest{
+		RestaurantName:  restaurant_name,
+		FromDate:        from,
+		ToDate:          to,
+		PartySize:       int32(party_size),
+		DurationMinutes: int32(duration),
+	}
+	reply, err := s.reservationClient.GetAvailability(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// listReservationsHandler handles requests for listing a user's or a
+// restaurant's reservations, optionally for dates `from` through `to`.
+func (s *Frontend) listReservationsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user_name := r.URL.Query().Get("user_name")
+	restaurant_name := r.URL.Query().Get("restaurant_name")
+	from, from_err := optionalDate(r.URL.Query().Get("from"))
+	to, to_err := optionalDate(r.URL.Query().Get("to"))
+
+	if (user_name == "" && restaurant_name == "") || from_err != nil || to_err != nil {
+		http.Error(w, "Malformed request to `/list-reservations` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &reservation.ListReservationsRequest{
 		UserName:       user_name,
 		RestaurantName: restaurant_name,
-		Time:           &reservation.Date{Year: int32(year), Month: int32(month), Day: int32(day)},
+		FromDate:       from,
+		ToDate:         to,
 	}
-	reply, err := s.reservationClient.MakeReservation(ctx, req)
+	reply, err := s.reservationClient.ListReservations(ctx, req)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -274,24 +833,135 @@ func (s *Frontend) makeReservationHandler(w http.ResponseWriter, r *http.Request
 }
 
 // mostPopularHandler handles requests for retrieving most popular restaurants.
+// Optional parameters restrict the count to reservations made in the last
+// `hours`, or to reservation dates `from` through `to` (YYYY-MM-DD).
 func (s *Frontend) mostPopularHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	topk, err := strconv.Atoi(r.URL.Query().Get("topk"))
+	query := r.URL.Query()
+	topk, err := strconv.Atoi(query.Get("topk"))
+	hours, hours_err := optionalInt(query.Get("hours"))
+	from, from_err := optionalDate(query.Get("from"))
+	to, to_err := optionalDate(query.Get("to"))
 
-	if err != nil {
+	if err != nil || hours_err != nil || from_err != nil || to_err != nil {
 		http.Error(w, "Malformed request to `/most-popular` endpoint!", http.StatusBadRequest)
 		return
 	}
 
 	req := &reservation.MostPopularRequest{
-		TopK: int32(topk),
+		TopK:        int32(topk),
+		WindowHours: int32(hours),
+		FromDate:    from,
+		ToDate:      to,
 	}
 	reply, err := s.reservationClient.MostPopular(ctx, req)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	_ = json.NewEncoder(w).Encode(reply)
 }
+
+// trendingHandler handles requests for retrieving the restaurants whose
+// reservations grew the most over the last `hours` (a week by default).
+func (s *Frontend) trendingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	topk, err := strconv.Atoi(r.URL.Query().Get("topk"))
+	hours, hours_err := optionalInt(r.URL.Query().Get("hours"))
+
+	if err != nil || hours_err != nil {
+		http.Error(w, "Malformed request to `/trending` endpoint!", http.StatusBadRequest)
+		return
+	}
+
+	req := &reservation.TrendingRequest{
+		TopK:        int32(topk),
+		WindowHours: int32(hours),
+	}
+	reply, err := s.reservationClient.Trending(ctx, req)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// optionalInt parses an optional integer query parameter.
+func optionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// optionalBool parses an optional boolean query parameter.
+func optionalBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// optionalCoordinates parses optional `latitude` and `longitude` query
+// parameters, which must be given together.
+func optionalCoordinates(query url.Values) (*detail.Coordinates, error) {
+	if !query.Has("latitude") && !query.Has("longitude") {
+		return nil, nil
+	}
+	latitude, err := strconv.ParseFloat(query.Get("latitude"), 64)
+	if err != nil {
+		return nil, err
+	}
+	longitude, err := strconv.ParseFloat(query.Get("longitude"), 64)
+	if err != nil {
+		return nil, err
+	}
+	return &detail.Coordinates{Latitude: latitude, Longitude: longitude}, nil
+}
+
+// optionalDate parses an optional YYYY-MM-DD query parameter.
+func optionalDate(value string) (*reservation.Date, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation.Date{Year: int32(date.Year()), Month: int32(date.Month()), Day: int32(date.Day())}, nil
+}
+
+// fieldError is a field violation in the JSON body of a 400 response.
+type fieldError struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// writeError writes the error of a call to a service. An invalid request is
+// a 400 with a JSON body giving what is wrong with each field the service
+// listed; anything else is a 500.
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	body := struct {
+		Error           string       `json:"error"`
+		FieldViolations []fieldError `json:"field_violations,omitempty"`
+	}{Error: st.Message()}
+	for _, d := range st.Details() {
+		if badRequest, ok := d.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				body.FieldViolations = append(body.FieldViolations, fieldError{violation.GetField(), violation.GetDescription()})
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cse190-welp/proto/mydatabase"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Clients send an idempotency key with a state-changing request as this gRPC
+// metadata key, or to the frontend as this HTTP header. A request retried
+// with the same key gets the response to the first attempt instead of being
+// applied again.
+const (
+	idempotencyMetadataKey = "idempotency-key"
+	IdempotencyHeader      = "Idempotency-Key"
+)
+
+// idempotencyPrefix prefixes the database key of the stored response to a
+// request, idempotencyPrefix + method + ":" + idempotency key.
+const idempotencyPrefix = internalKeyPrefix + "idempotency:"
+
+const (
+	// idempotencyRetention is how long responses are kept for replay
+	idempotencyRetention = 24 * time.Hour
+	// idempotencyLease is how long a request in progress holds its key
+	// without renewing it, so that a replica failing mid-request doesn't hold
+	// it forever
+	idempotencyLease = time.Minute
+)
+
+// idempotency makes some of a service's methods idempotent for requests
+// that carry a key, by remembering their responses in the service's
+// database. Replicas sharing the database share the responses.
+type idempotency struct {
+	name           string
+	databaseClient mydatabase.DatabaseServiceClient
+	methods        []string
+}
+
+// newIdempotency returns an idempotency for the given full method names.
+func newIdempotency(name string, databaseClient mydatabase.DatabaseServiceClient, methods ...string) *idempotency {
+	return &idempotency{name: name, databaseClient: databaseClient, methods: methods}
+}
+
+// intercept is a unary server interceptor that replays the stored response
+// to a request retried with the same key. A retry arriving while the first
+// attempt is in progress fails with Aborted, and a key reused for a
+// different request with InvalidArgument. Failed requests aren't stored, so
+// retrying them applies them again. The key's lease is renewed while the
+// request runs. If the response can't be stored, the request fails with
+// Unavailable and its key stays in progress until the lease expires.
+func (s *idempotency) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	key := incomingIdempotencyKey(ctx)
+	if key == "" || !containsString(s.methods, info.FullMethod) {
+		return handler(ctx, req)
+	}
+	recordKey := idempotencyPrefix + info.FullMethod + ":" + key
+	hash, err := requestHash(req)
+	if err != nil {
+		return nil, err
+	}
+	replay, version, err := s.claim(ctx, recordKey, key, hash)
+	if err != nil || replay != nil {
+		return replay, err
+	}
+
+	// Hold the key for as long as the handler runs
+	stop := make(chan struct{})
+	renewed := s.holdLease(context.WithoutCancel(ctx), recordKey, hash, version, stop)
+	resp, err := handler(ctx, req)
+	close(stop)
+	version = <-renewed
+
+	// Record the outcome even if the client gave up, as it will likely retry
+	ctx = context.WithoutCancel(ctx)
+	message, ok := resp.(proto.Message)
+	if err != nil || !ok {
+		// Release the key for a retry
+		record := &mydatabase.DatabaseRecord{Key: recordKey, Deleted: true}
+		if _, releaseErr := s.write(ctx, record, version); releaseErr != nil {
+			log.Printf("idempotency <%s> failed to release key %s: %v", s.name, key, releaseErr)
+		}
+		return resp, err
+	}
+	stored := &mydatabase.IdempotentResponse{
+		RequestHash:  hash,
+		ResponseType: string(message.ProtoReflect().Descriptor().FullName()),
+		ExpiresAt:    time.Now().Add(idempotencyRetention).UnixNano(),
+	}
+	stored.Response, _ = proto.Marshal(message)
+	data, _ := proto.Marshal(stored)
+	if _, err := s.write(ctx, &mydatabase.DatabaseRecord{Key: recordKey, Value: data}, version); err != nil {
+		log.Printf("idempotency <%s> failed to store the response for key %s: %v", s.name, key, err)
+		return nil, status.Errorf(codes.Unavailable, "The request with idempotency key %s was applied, but its response could not be stored: %v", key, err)
+	}
+	return resp, nil
+}
+
+// holdLease renews the lease on a key every third of idempotencyLease until
+// stop is closed, so that a request running longer than the lease keeps its
+// key. It then sends the version of the key's record.
+func (s *idempotency) holdLease(ctx context.Context, recordKey string, hash []byte, version uint64, stop <-chan struct{}) <-chan uint64 {
+	renewed := make(chan uint64, 1)
+	go func() {
+		ticker := time.NewTicker(idempotencyLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				renewed <- version
+				return
+			case <-ticker.C:
+			}
+			next, err := s.write(ctx, &mydatabase.DatabaseRecord{Key: recordKey, Value: leaseValue(hash)}, version)
+			if err != nil {
+				log.Printf("idempotency <%s> failed to renew the lease on %s: %v", s.name, recordKey, err)
+				continue
+			}
+			version = next
+		}
+	}()
+	return renewed
+}
+
+// leaseValue encodes a lease on a key for a request in progress.
+func leaseValue(hash []byte) []byte {
+	data, _ := proto.Marshal(&mydatabase.IdempotentResponse{RequestHash: hash, ExpiresAt: time.Now().Add(idempotencyLease).UnixNano()})
+	return data
+}
+
+// claim marks a key as in progress and returns the version of its record,
+// or returns the stored response if the request was already made.
+func (s *idempotency) claim(ctx context.Context, recordKey string, key string, hash []byte) (proto.Message, uint64, error) {
+	reply, err := s.databaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: recordKey})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return nil, 0, err
+	}
+	current := reply.GetRecord()
+	stored := &mydatabase.IdempotentResponse{}
+	if err := proto.Unmarshal(current.GetValue(), stored); err != nil {
+		return nil, 0, status.Errorf(codes.DataLoss, "Idempotency key %s could not be decoded: %v", key, err)
+	}
+
+	now := time.Now()
+	if current != nil && now.UnixNano() < stored.GetExpiresAt() {
+		switch {
+		case !bytes.Equal(stored.GetRequestHash(), hash):
+			return nil, 0, status.Errorf(codes.InvalidArgument, "Idempotency key %s was already used for a different request", key)
+		case stored.GetResponseType() == "":
+			return nil, 0, status.Errorf(codes.Aborted, "A request with idempotency key %s is in progress", key)
+		}
+		messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(stored.GetResponseType()))
+		if err != nil {
+			return nil, 0, status.Errorf(codes.DataLoss, "Idempotency key %s stored an unknown response: %v", key, err)
+		}
+		resp := messageType.New().Interface()
+		if err := proto.Unmarshal(stored.GetResponse(), resp); err != nil {
+			return nil, 0, status.Errorf(codes.DataLoss, "Idempotency key %s stored a response that could not be decoded: %v", key, err)
+		}
+		return resp, 0, nil
+	}
+
+	version, err := s.write(ctx, &mydatabase.DatabaseRecord{Key: recordKey, Value: leaseValue(hash)}, current.GetVersion())
+	if status.Code(err) == codes.Aborted {
+		return nil, 0, status.Errorf(codes.Aborted, "A request with idempotency key %s is in progress", key)
+	}
+	return nil, version, err
+}
+
+// write writes a record provided it is still at version, and returns its new
+// version.
+func (s *idempotency) write(ctx context.Context, record *mydatabase.DatabaseRecord, version uint64) (uint64, error) {
+	op := &mydatabase.WriteOperation{Record: record, ExpectedVersion: proto.Uint64(version)}
+	reply, err := s.databaseClient.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{op}})
+	return reply.GetVersion(), err
+}
+
+// expireForever removes expired keys every idempotencyLease.
+func (s *idempotency) expireForever() {
+	ctx := context.Background()
+	for range time.Tick(idempotencyLease) {
+		if err := s.removeExpired(ctx); err != nil {
+			log.Printf("idempotency <%s> failed to remove expired keys: %v", s.name, err)
+		}
+	}
+}
+
+// removeExpired deletes the records of expired keys that haven't been
+// reused since they were read.
+func (s *idempotency) removeExpired(ctx context.Context) error {
+	now := time.Now().UnixNano()
+	return scanAll(ctx, s.databaseClient, idempotencyPrefix, func(record *mydatabase.DatabaseRecord) error {
+		stored := &mydatabase.IdempotentResponse{}
+		if err := proto.Unmarshal(record.GetValue(), stored); err == nil && stored.GetExpiresAt() > now {
+			return nil
+		}
+		_, err := s.write(ctx, &mydatabase.DatabaseRecord{Key: record.GetKey(), Deleted: true}, record.GetVersion())
+		if status.Code(err) == codes.Aborted {
+			return nil
+		}
+		return err
+	})
+}
+
+// incomingIdempotencyKey returns the idempotency key a request carries, if
+// any.
+func incomingIdempotencyKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(idempotencyMetadataKey); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// withIdempotencyKey forwards the idempotency key of an HTTP request, if it
+// has one, to the gRPC requests made with the returned context.
+func withIdempotencyKey(r *http.Request) context.Context {
+	key := strings.TrimSpace(r.Header.Get(IdempotencyHeader))
+	if key == "" {
+		return r.Context()
+	}
+	return metadata.AppendToOutgoingContext(r.Context(), idempotencyMetadataKey, key)
+}
+
+// requestHash returns the SHA-256 of a request's deterministic encoding.
+func requestHash(req interface{}) ([]byte, error) {
+	message, ok := req.(proto.Message)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "Request %T is not a protobuf message", req)
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Request could not be encoded: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
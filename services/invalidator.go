@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+
+	"cse190-welp/proto/mycache"
+	"cse190-welp/proto/mydatabase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Backoff bounds between attempts to reopen a change stream.
+const (
+	minWatchBackoff = 100 * time.Millisecond
+	maxWatchBackoff = 5 * time.Second
+)
+
+// Invalidator keeps a cache consistent with its database by following the
+// database's change stream. Every committed put or delete removes the key
+// from the cache, or in refresh mode replaces a cached value with the new one.
+//
+// Whenever the invalidator may have missed changes, when it starts or when
+// the database no longer retains the changes it needs to resume from, it
+// clears the whole cache instead.
+type Invalidator struct {
+	name           string
+	cacheClient    mycache.CacheServiceClient
+	databaseClient mydatabase.DatabaseServiceClient
+	refresh        bool
+}
+
+// NewInvalidator creates a new instance of Invalidator.
+// name: The name of the invalidator.
+// cacheAddr: The address of the cache to keep up to date.
+// databaseAddr: The address of the database to follow; a single shard.
+// refresh: Whether to refresh cached keys instead of deleting them.
+func NewInvalidator(name string, cacheAddr string, databaseAddr string, refresh bool) *Invalidator {
+	return &Invalidator{
+		name:           name,
+		cacheClient:    mycache.NewCacheServiceClient(dial(cacheAddr)),
+		databaseClient: newDatabaseClient(databaseAddr),
+		refresh:        refresh,
+	}
+}
+
+// Run follows the change stream forever, reconnecting after errors.
+func (s *Invalidator) Run() error {
+	ctx := context.Background()
+	log.Printf("invalidator <%s> running", s.name)
+
+	var next uint64 // sequence of the next change to apply, 0 until known
+	backoff := minWatchBackoff
+	for {
+		if next == 0 {
+			// Anything could have changed since the cache was filled.
+			version, err := s.resync(ctx)
+			if err != nil {
+				log.Printf("invalidator <%s> failed to resync cache: %v", s.name, err)
+				backoff = watchBackoff(backoff)
+				continue
+			}
+			next = version + 1
+		}
+
+		applied, err := s.follow(ctx, &next)
+		if applied {
+			backoff = minWatchBackoff
+		}
+		switch status.Code(err) {
+		case codes.OutOfRange:
+			log.Printf("invalidator <%s> missed changes from sequence %d, clearing cache", s.name, next)
+			next = 0
+			continue
+		case codes.Unimplemented:
+			// e.g. a sharded database address, retrying won't help
+			return err
+		}
+		log.Printf("invalidator <%s> change stream ended: %v", s.name, err)
+		backoff = watchBackoff(backoff)
+	}
+}
+
+// follow opens a change stream resuming at *next and applies changes until
+// the stream fails. It reports whether any change was applied.
+func (s *Invalidator) follow(ctx context.Context, next *uint64) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := s.databaseClient.WatchChanges(ctx, &mydatabase.WatchChangesRequest{StartSequence: *next})
+	if err != nil {
+		return false, err
+	}
+	applied := false
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return applied, status.Error(codes.Unavailable, "change stream closed by server")
+		}
+		if err != nil {
+			return applied, err
+		}
+		if err := s.apply(ctx, event); err != nil {
+			return applied, err
+		}
+		*next = event.GetSequence() + 1
+		applied = true
+	}
+}
+
+// apply brings the cache in line with one change.
+func (s *Invalidator) apply(ctx context.Context, event *mydatabase.ChangeEvent) error {
+	key := event.GetRecord().GetKey()
+	if s.refresh && event.GetOperation() == mydatabase.ChangeEvent_PUT {
+		// Only refresh keys already cached so writes don't evict hot keys.
+		_, err := s.cacheClient.GetItem(ctx, &mycache.GetItemRequest{Key: key})
+		switch status.Code(err) {
+		case codes.OK:
+			item := &mycache.CacheItem{Key: key, Value: event.GetRecord().GetValue()}
+			_, err = s.cacheClient.SetItem(ctx, &mycache.SetItemRequest{Item: item})
+			return err
+		case codes.NotFound:
+			return nil
+		default:
+			return err
+		}
+	}
+
+	_, err := s.cacheClient.DeleteItem(ctx, &mycache.DeleteItemRequest{Key: key})
+	if status.Code(err) == codes.NotFound {
+		return nil // not cached
+	}
+	return err
+}
+
+// resync clears the cache and returns the database version it is now
+// consistent with. The version is read first, so no later change is skipped.
+func (s *Invalidator) resync(ctx context.Context) (uint64, error) {
+	reply, err := s.databaseClient.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.cacheClient.ClearItems(ctx, &mycache.ClearItemsRequest{}); err != nil {
+		return 0, err
+	}
+	return reply.GetSnapshotVersion(), nil
+}
+
+// watchBackoff sleeps for backoff and returns the next, longer backoff.
+func watchBackoff(backoff time.Duration) time.Duration {
+	time.Sleep(backoff)
+	if backoff *= 2; backoff > maxWatchBackoff {
+		backoff = maxWatchBackoff
+	}
+	return backoff
+}
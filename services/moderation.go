@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Database keys of moderation: reviews held for a moderator, under
+// pendingReviewsPrefix and keyed by review ID, and when each user recently
+// submitted reviews, under postingTimesPrefix and keyed by user name.
+const (
+	pendingReviewsPrefix = internalKeyPrefix + "pending-reviews:"
+	postingTimesPrefix   = internalKeyPrefix + "posting-times:"
+)
+
+const (
+	// maxReviewLength is the most characters a review is published with
+	// without moderation.
+	maxReviewLength = 5000
+	// maxPostsPerWindow is the most reviews a user submits within
+	// postingWindow before the rest are held for moderation.
+	maxPostsPerWindow = 5
+	postingWindow     = time.Hour
+)
+
+// bannedWords hold a review for moderation if it uses them, in any
+// inflection: profanity and words common in spam.
+var bannedWords = []string{"asshole", "bastard", "bitch", "casino", "cialis", "cunt", "fuck", "porn", "shit", "viagra"}
+
+// bannedTerms are the stems of bannedWords, as review text is compared.
+var bannedTerms = func() map[string]bool {
+	terms := make(map[string]bool)
+	for _, word := range bannedWords {
+		terms[stem(word)] = true
+	}
+	return terms
+}()
+
+// A moderationCheck looks at a review submitted for posting and returns why
+// it should be held for a moderator, or "" to let it be published.
+type moderationCheck func(s *Review, ctx context.Context, reviewID string, r *review.GetReviewResponse) (string, error)
+
+// moderationChecks are the checks a review service can run on submitted
+// reviews, by the name they are configured with.
+var moderationChecks = map[string]moderationCheck{
+	"length":         (*Review).checkLength,
+	"banned-words":   (*Review).checkBannedWords,
+	"rate-limit":     (*Review).checkPostingRate,
+	"near-duplicate": (*Review).checkNearDuplicate,
+}
+
+// parseModeration returns the checks named in a comma-separated list, in
+// order.
+func parseModeration(names string) ([]moderationCheck, error) {
+	var checks []moderationCheck
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		check, ok := moderationChecks[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown moderation check %q", name)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// moderate runs every configured check on a submitted review and returns
+// why it should be held, none if it can be published. Every check runs, so
+// that the posting rate counts every submission.
+// Caller must hold s.lock.
+func (s *Review) moderate(ctx context.Context, reviewID string, r *review.GetReviewResponse) ([]string, error) {
+	var flags []string
+	for _, check := range s.moderation {
+		flag, err := check(s, ctx, reviewID, r)
+		if err != nil {
+			return nil, err
+		}
+		if flag != "" {
+			flags = append(flags, flag)
+		}
+	}
+	return flags, nil
+}
+
+// checkLength holds reviews without words or too long to be real reviews.
+func (s *Review) checkLength(ctx context.Context, reviewID string, r *review.GetReviewResponse) (string, error) {
+	switch {
+	case len(textWords(r.GetReview())) == 0:
+		return "length: the review has no words", nil
+	case utf8.RuneCountInString(r.GetReview()) > maxReviewLength:
+		return fmt.Sprintf("length: the review is over %d characters", maxReviewLength), nil
+	}
+	return "", nil
+}
+
+// checkBannedWords holds reviews that use banned words.
+func (s *Review) checkBannedWords(ctx context.Context, reviewID string, r *review.GetReviewResponse) (string, error) {
+	var used []string
+	for _, word := range textWords(r.GetReview()) {
+		if text := r.GetReview()[word.start:word.end]; bannedTerms[word.term] && !containsString(used, text) {
+			used = append(used, text)
+		}
+	}
+	if len(used) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("banned-words: the review uses %s", strings.Join(used, ", ")), nil
+}
+
+// checkPostingRate counts a review against its user's posting rate and
+// holds it if the user has already submitted maxPostsPerWindow reviews
+// within postingWindow.
+func (s *Review) checkPostingRate(ctx context.Context, reviewID string, r *review.GetReviewResponse) (string, error) {
+	now := time.Now()
+	recent := 0
+	_, err := writeWithUpdates(ctx, s.reviewDatabaseClient, nil, []recordUpdate{{key: postingTimesPrefix + r.GetUserName(), apply: func(current []byte) ([]byte, error) {
+		times := &review.PostingTimes{}
+		if err := proto.Unmarshal(current, times); err != nil {
+			return nil, status.Errorf(codes.DataLoss, "Posting times of %s could not be decoded: %v", r.GetUserName(), err)
+		}
+		var kept []int64
+		for _, postedAt := range times.GetPostedAt() {
+			if postedAt > now.Add(-postingWindow).UnixNano() {
+				kept = append(kept, postedAt)
+			}
+		}
+		recent = len(kept)
+		// Only the latest maxPostsPerWindow are needed to tell whether the
+		// next submission is over the limit.
+		kept = append(kept, now.UnixNano())
+		kept = kept[max(len(kept)-maxPostsPerWindow, 0):]
+		data, _ := proto.Marshal(&review.PostingTimes{PostedAt: kept})
+		return data, nil
+	}}})
+	if err != nil {
+		return "", err
+	}
+	if recent < maxPostsPerWindow {
+		return "", nil
+	}
+	return fmt.Sprintf("rate-limit: %s submitted over %d reviews within %v", r.GetUserName(), maxPostsPerWindow, postingWindow), nil
+}
+
+// checkNearDuplicate holds reviews whose text is mostly that of another
+// published review.
+func (s *Review) checkNearDuplicate(ctx context.Context, reviewID string, r *review.GetReviewResponse) (string, error) {
+	other, similarity, err := s.nearDuplicate(ctx, reviewID, r)
+	if err != nil || other == nil {
+		return "", err
+	}
+	return fmt.Sprintf("near-duplicate: %.0f%% the same as the review of %s by %s", similarity*100, other.GetRestaurantName(), other.GetUserName()), nil
+}
+
+// holdReview stores a review for a moderator to approve or reject, replacing
+// any the user submitted earlier that is still held.
+func (s *Review) holdReview(ctx context.Context, reviewID string, r *review.GetReviewResponse) error {
+	data, err := proto.Marshal(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = s.reviewDatabaseClient.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: pendingReviewsPrefix + reviewID, Value: data}})
+	return err
+}
+
+// pendingReview reads a review held for moderation and the version of its
+// record.
+func (s *Review) pendingReview(ctx context.Context, reviewID string) (*review.GetReviewResponse, uint64, error) {
+	reply, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: pendingReviewsPrefix + reviewID})
+	if status.Code(err) == codes.NotFound {
+		return nil, 0, status.Error(codes.NotFound, "No review is awaiting moderation")
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	r := &review.GetReviewResponse{}
+	if err := proto.Unmarshal(reply.GetRecord().GetValue(), r); err != nil {
+		return nil, 0, status.Errorf(codes.DataLoss, "Review %s awaiting moderation could not be decoded: %v", reviewID, err)
+	}
+	return r, reply.GetRecord().GetVersion(), nil
+}
+
+// discardHeld returns the write that discards the review held for
+// moderation under reviewID, provided it isn't replaced meanwhile, or none if
+// there is none.
+func (s *Review) discardHeld(ctx context.Context, reviewID string) ([]*mydatabase.WriteOperation, error) {
+	_, version, err := s.pendingReview(ctx, reviewID)
+	switch status.Code(err) {
+	case codes.OK:
+		return []*mydatabase.WriteOperation{{Record: &mydatabase.DatabaseRecord{Key: pendingReviewsPrefix + reviewID, Deleted: true}, ExpectedVersion: proto.Uint64(version)}}, nil
+	case codes.NotFound:
+		return nil, nil
+	}
+	return nil, err
+}
+
+// discardRestaurantHeld discards every review of a restaurant held for
+// moderation.
+func (s *Review) discardRestaurantHeld(ctx context.Context, restaurantName string) error {
+	held, err := s.pendingReviews(ctx, restaurantName)
+	if err != nil {
+		return err
+	}
+	for _, r := range held {
+		reviewID, _ := GetQueryUUID(r.GetRestaurantName(), r.GetUserName())
+		_, err := s.reviewDatabaseClient.DeleteRecord(ctx, &mydatabase.DeleteRecordRequest{Key: pendingReviewsPrefix + reviewID})
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingReviews returns the reviews held for moderation, oldest first,
+// only those of a restaurant if restaurantName is set.
+func (s *Review) pendingReviews(ctx context.Context, restaurantName string) ([]*review.GetReviewResponse, error) {
+	var reviews []*review.GetReviewResponse
+	err := scanAll(ctx, s.reviewDatabaseClient, pendingReviewsPrefix, func(record *mydatabase.DatabaseRecord) error {
+		r := &review.GetReviewResponse{}
+		if err := proto.Unmarshal(record.GetValue(), r); err != nil {
+			return status.Errorf(codes.DataLoss, "Review %s awaiting moderation could not be decoded: %v", record.GetKey(), err)
+		}
+		if restaurantName == "" || r.GetRestaurantName() == restaurantName {
+			reviews = append(reviews, r)
+		}
+		return nil
+	})
+	sort.SliceStable(reviews, func(i, j int) bool { return reviews[i].GetPostedAt() < reviews[j].GetPostedAt() })
+	return reviews, err
+}
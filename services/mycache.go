@@ -38,7 +38,7 @@ func NewMyCache(serverName string, cachePort int, capacity int) *MyCache {
 // It returns an error if the server fails to start or encounters an error.
 func (s *MyCache) Run() error {
 	// Create a new gRPC server instance.
-	srv := grpc.NewServer()
+	srv := grpc.NewServer(grpc.UnaryInterceptor(validateRequest))
 
 	// Register the Cache server implementation with the gRPC server.
 	mycache.RegisterCacheServiceServer(srv, s)
@@ -88,3 +88,9 @@ func (s *MyCache) DeleteItem(ctx context.Context, req *mycache.DeleteItemRequest
 		return &mycache.DeleteItemResponse{}, status.Errorf(codes.OK, "Item delete successfully")
 	}
 }
+
+// ClearItems removes every item from the cache.
+func (s *MyCache) ClearItems(ctx context.Context, req *mycache.ClearItemsRequest) (*mycache.ClearItemsResponse, error) {
+	s.app.Clear()
+	return &mycache.ClearItemsResponse{Success: true}, status.Errorf(codes.OK, "Cache cleared successfully")
+}
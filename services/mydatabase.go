@@ -5,36 +5,74 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"strings"
+	"time"
 
 	apps "cse190-welp/applications"
 	"cse190-welp/proto/mydatabase"
+	raftpb "cse190-welp/proto/raft"
+	"cse190-welp/raft"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	defaultScanLimit = 100  // records per ScanRecords page when the client doesn't say
+	maxScanLimit     = 1000 // upper bound on records per ScanRecords page
+)
+
 // MyDatabase represents a gRPC service for interacting with a database.
 type MyDatabase struct {
 	name string
 	port int
 	mydatabase.DatabaseServiceServer
-	app *apps.EmulatedStorageApp
+	app       *apps.EmulatedStorageApp
+	retention time.Duration
+	node      *raft.Node // nil unless the database is replicated
 }
 
 // NewMyDatabase creates a new instance of MyDatabase.
 // serverName: The name of the database server.
 // databasePort: The port on which the server should listen.
 // deviceType: The type of storage device to use. (ssd, disk, or cloud)
-func NewMyDatabase(serverName string, databasePort int, deviceType string) *MyDatabase {
+// historyLimit: The maximum number of versions kept per key.
+// retention: How long superseded versions are kept before garbage collection.
+func NewMyDatabase(serverName string, databasePort int, deviceType string, historyLimit int, retention time.Duration) *MyDatabase {
 	// Initialize and return a new MyDatabase instance.
 	app, err := apps.NewEmulatedStorageApp(deviceType)
 	if err != nil {
 		log.Fatalf("failed to initialize application: %v", err)
 	}
+	app.SetHistoryLimit(historyLimit)
 	return &MyDatabase{
-		name: serverName,
-		port: databasePort,
-		app:  app,
+		name:      serverName,
+		port:      databasePort,
+		app:       app,
+		retention: retention,
+	}
+}
+
+// collectGarbage periodically drops versions older than the retention window.
+func (s *MyDatabase) collectGarbage() {
+	interval := s.retention
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		// Replicas collect garbage when the leader's command is applied.
+		if s.node != nil {
+			if _, role := s.node.State(); role != raft.Leader {
+				continue
+			}
+		}
+		cutoff := time.Now().Add(-s.retention).UnixNano()
+		cmd := &mydatabase.DatabaseCommand{Op: &mydatabase.DatabaseCommand_CollectGarbageBefore{CollectGarbageBefore: cutoff}}
+		if _, err := s.execute(context.Background(), cmd); err != nil {
+			log.Printf("storage server <%s> failed to collect garbage: %v", s.name, err)
+		}
 	}
 }
 
@@ -42,11 +80,17 @@ func NewMyDatabase(serverName string, databasePort int, deviceType string) *MyDa
 // It returns an error if the server fails to start or encounters an error.
 func (s *MyDatabase) Run() error {
 	// Create a new gRPC server instance.
-	srv := grpc.NewServer()
+	srv := grpc.NewServer(grpc.UnaryInterceptor(validateRequest))
 
 	// Register the Database server implementation with the gRPC server.
 	mydatabase.RegisterDatabaseServiceServer(srv, s)
 
+	// Replicas also serve Raft traffic on the same port.
+	if s.node != nil {
+		raftpb.RegisterRaftServiceServer(srv, raft.NewServer(s.node))
+		s.node.Start()
+	}
+
 	// Create a TCP listener that listens for incoming requests on the specified port.
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
 	if err != nil {
@@ -55,6 +99,11 @@ func (s *MyDatabase) Run() error {
 
 	// (Optional) Log a message indicating that the server is running and listening on the specified port.
 	log.Printf("storage server <%s> running at port: %d", s.name, s.port)
+
+	// Garbage collect old versions in the background.
+	if s.retention > 0 {
+		go s.collectGarbage()
+	}
 	return srv.Serve(lis)
 }
 
@@ -63,16 +112,26 @@ func (s *MyDatabase) GetRecord(ctx context.Context, req *mydatabase.GetRecordReq
 	// Get the name of the requested item
 	key := req.GetKey()
 
-	// Retrieve record from the database application
-	record, ok := s.app.Get(key)
+	// Make sure this replica is up to date before reading
+	if err := s.readBarrier(ctx); err != nil {
+		return &mydatabase.GetRecordResponse{}, err
+	}
+
+	// Retrieve record from the database application, as of the requested snapshot
+	record, snapshot, err := s.app.GetAt(key, req.GetSnapshotVersion())
 	msg := &mydatabase.GetRecordResponse{
-		Record: record, // will be nil if an error occurs
+		Record:          record, // will be nil if an error occurs
+		SnapshotVersion: snapshot,
 	}
-	var err error
-	if !ok {
-		err = status.Errorf(codes.NotFound, "Record not found in storage!")
-	} else {
+	switch err {
+	case nil:
 		err = status.Error(codes.OK, "Record found in storage!")
+	case apps.ErrSnapshotTooOld:
+		err = status.Errorf(codes.FailedPrecondition, "Snapshot version %d is no longer retained!", req.GetSnapshotVersion())
+	case apps.ErrRecordCorrupted:
+		err = status.Errorf(codes.DataLoss, "Record %s is corrupt in storage!", key)
+	default:
+		err = status.Errorf(codes.NotFound, "Record not found in storage!")
 	}
 	return msg, err
 }
@@ -80,11 +139,22 @@ func (s *MyDatabase) GetRecord(ctx context.Context, req *mydatabase.GetRecordReq
 // SetRecord sets a record in the database.
 func (s *MyDatabase) SetRecord(ctx context.Context, req *mydatabase.SetRecordRequest) (*mydatabase.SetRecordResponse, error) {
 	record := req.GetRecord()
+	if record.GetChecksum() != 0 && apps.VerifyChecksum(record) != nil {
+		return &mydatabase.SetRecordResponse{}, status.Errorf(codes.DataLoss, "Record %s was corrupted in transit!", record.GetKey())
+	}
 
+	cmd := &mydatabase.DatabaseCommand{Op: &mydatabase.DatabaseCommand_Set{Set: record}}
+	if req.ExpectedVersion != nil {
+		cmd.Op = batchOf(&mydatabase.WriteOperation{Record: record, ExpectedVersion: req.ExpectedVersion})
+	}
+	version, err := s.execute(ctx, cmd)
+	if err != nil {
+		return &mydatabase.SetRecordResponse{}, err
+	}
 	msg := &mydatabase.SetRecordResponse{
 		Success: true,
+		Version: version,
 	}
-	s.app.Set(record)
 	return msg, status.Error(codes.OK, "Record placed in storage!")
 }
 
@@ -92,10 +162,132 @@ func (s *MyDatabase) SetRecord(ctx context.Context, req *mydatabase.SetRecordReq
 func (s *MyDatabase) DeleteRecord(ctx context.Context, req *mydatabase.DeleteRecordRequest) (*mydatabase.DeleteRecordResponse, error) {
 	key := req.GetKey()
 	log.Printf("DeleteKey: %s", key)
+
+	cmd := &mydatabase.DatabaseCommand{Op: &mydatabase.DatabaseCommand_Delete{Delete: key}}
+	if req.ExpectedVersion != nil {
+		tombstone := &mydatabase.DatabaseRecord{Key: key, Deleted: true}
+		cmd.Op = batchOf(&mydatabase.WriteOperation{Record: tombstone, ExpectedVersion: req.ExpectedVersion})
+	}
+	version, err := s.execute(ctx, cmd)
+	if err != nil {
+		return &mydatabase.DeleteRecordResponse{}, err
+	}
 	msg := &mydatabase.DeleteRecordResponse{
 		Success: true,
+		Version: version,
 	}
-
-	s.app.Delete(key)
 	return msg, status.Error(codes.OK, "Record deleted from database!")
 }
+
+// GetRecordHistory returns the retained versions of a record, newest first.
+func (s *MyDatabase) GetRecordHistory(ctx context.Context, req *mydatabase.GetRecordHistoryRequest) (*mydatabase.GetRecordHistoryResponse, error) {
+	if err := s.readBarrier(ctx); err != nil {
+		return &mydatabase.GetRecordHistoryResponse{}, err
+	}
+
+	history := s.app.History(req.GetKey(), int(req.GetLimit()))
+	msg := &mydatabase.GetRecordHistoryResponse{
+		Records: history,
+	}
+	if len(history) == 0 {
+		return msg, status.Errorf(codes.NotFound, "No history for record in storage!")
+	}
+	return msg, status.Error(codes.OK, "Record history found in storage!")
+}
+
+// ScanRecords returns one page of records in key order.
+func (s *MyDatabase) ScanRecords(ctx context.Context, req *mydatabase.ScanRecordsRequest) (*mydatabase.ScanRecordsResponse, error) {
+	if err := s.readBarrier(ctx); err != nil {
+		return &mydatabase.ScanRecordsResponse{}, err
+	}
+
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultScanLimit
+	} else if limit > maxScanLimit {
+		limit = maxScanLimit
+	}
+	records, next, snapshot, err := s.app.Scan(req.GetPrefix(), req.GetStartAfter(), limit, req.GetSnapshotVersion())
+	if err != nil {
+		return &mydatabase.ScanRecordsResponse{}, status.Errorf(codes.FailedPrecondition, "Snapshot version %d is no longer retained!", req.GetSnapshotVersion())
+	}
+	msg := &mydatabase.ScanRecordsResponse{
+		Records:         records,
+		NextStartAfter:  next,
+		SnapshotVersion: snapshot,
+	}
+	return msg, status.Error(codes.OK, "Records scanned from storage!")
+}
+
+// WatchChanges streams committed writes in commit order, starting at the
+// requested sequence number, until the client goes away.
+func (s *MyDatabase) WatchChanges(req *mydatabase.WatchChangesRequest, stream grpc.ServerStreamingServer[mydatabase.ChangeEvent]) error {
+	next := req.GetStartSequence()
+	if next == 0 {
+		next = s.app.Version() + 1
+	}
+	for {
+		events, wait, err := s.app.Changes(next, maxScanLimit)
+		if err == apps.ErrChangesTruncated {
+			return status.Errorf(codes.OutOfRange, "Changes from sequence %d are no longer retained!", next)
+		}
+		for _, event := range events {
+			next = event.Sequence + 1
+			if !strings.HasPrefix(event.GetRecord().GetKey(), req.GetPrefix()) {
+				continue
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+		if len(events) > 0 {
+			continue
+		}
+		select {
+		case <-wait:
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+// ScrubRecords verifies every version stored on this replica and quarantines
+// corrupt ones. Corruption is local to a replica, so this is not replicated.
+func (s *MyDatabase) ScrubRecords(ctx context.Context, req *mydatabase.ScrubRecordsRequest) (*mydatabase.ScrubRecordsResponse, error) {
+	checked, quarantined, damaged := s.app.Scrub()
+	if len(quarantined) > 0 {
+		log.Printf("storage server <%s> quarantined %d corrupt versions", s.name, len(quarantined))
+	}
+	msg := &mydatabase.ScrubRecordsResponse{
+		Checked:     uint64(checked),
+		Quarantined: quarantined,
+		DamagedKeys: damaged,
+	}
+	return msg, status.Error(codes.OK, "Records scrubbed!")
+}
+
+// WriteBatch applies several writes atomically.
+func (s *MyDatabase) WriteBatch(ctx context.Context, req *mydatabase.WriteBatchRequest) (*mydatabase.WriteBatchResponse, error) {
+	for _, op := range req.GetOperations() {
+		record := op.GetRecord()
+		if record.GetChecksum() != 0 && apps.VerifyChecksum(record) != nil {
+			return &mydatabase.WriteBatchResponse{}, status.Errorf(codes.DataLoss, "Record %s was corrupted in transit!", record.GetKey())
+		}
+	}
+
+	cmd := &mydatabase.DatabaseCommand{Op: &mydatabase.DatabaseCommand_Batch{Batch: req}}
+	version, err := s.execute(ctx, cmd)
+	if err != nil {
+		return &mydatabase.WriteBatchResponse{}, err
+	}
+	msg := &mydatabase.WriteBatchResponse{
+		Success: true,
+		Version: version,
+	}
+	return msg, status.Error(codes.OK, "Batch written to storage!")
+}
+
+// batchOf wraps writes in a batch command.
+func batchOf(ops ...*mydatabase.WriteOperation) *mydatabase.DatabaseCommand_Batch {
+	return &mydatabase.DatabaseCommand_Batch{Batch: &mydatabase.WriteBatchRequest{Operations: ops}}
+}
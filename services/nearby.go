@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"cse190-welp/proto/detail"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// geohashDetailsPrefix prefixes the database keys of the index of where
+// restaurants are, keyed by the geohash of their coordinates and then their
+// name. A geohash names a cell of the earth; each character added divides it
+// into 32, so cells that share a prefix lie within the cell it names.
+const geohashDetailsPrefix = detailIndexPrefix + "geohash:"
+
+const (
+	geohashAlphabet   = "0123456789bcdefghjkmnpqrstuvwxyz"
+	geohashPrecision  = 7 // characters indexed, cells of about 150m
+	earthRadiusKm     = 6371.0
+	kmPerDegree       = earthRadiusKm * math.Pi / 180
+	maxNearbyRadiusKm = 500
+)
+
+// geohash returns the geohash of the cell of the given precision that holds a
+// point.
+func geohash(c *detail.Coordinates, precision int) string {
+	lat, lon := [2]float64{-90, 90}, [2]float64{-180, 180}
+	var hash strings.Builder
+	bits, cell := 0, 0
+	for even := true; hash.Len() < precision; even = !even {
+		// Bits alternate between halving the longitude and the latitude
+		value, bounds := c.GetLatitude(), &lat
+		if even {
+			value, bounds = c.GetLongitude(), &lon
+		}
+		mid := (bounds[0] + bounds[1]) / 2
+		cell <<= 1
+		if value >= mid {
+			cell |= 1
+			bounds[0] = mid
+		} else {
+			bounds[1] = mid
+		}
+		if bits++; bits == 5 {
+			hash.WriteByte(geohashAlphabet[cell])
+			bits, cell = 0, 0
+		}
+	}
+	return hash.String()
+}
+
+// geohashSpan returns the height and width in degrees of the cells of a
+// precision.
+func geohashSpan(precision int) (float64, float64) {
+	bits := 5 * precision
+	return 180 / math.Exp2(float64(bits/2)), 360 / math.Exp2(float64((bits+1)/2))
+}
+
+// coveringCells returns geohashes of cells that together hold every point
+// within radiusKm of center: the cell of center and its neighbors, at the
+// finest precision where cells are at least radiusKm across. It returns nil
+// if no precision has cells that large, as near the poles.
+func coveringCells(center *detail.Coordinates, radiusKm float64) []string {
+	// Cells narrow towards the poles, so measure them where they are
+	// narrowest within the radius.
+	farthest := math.Min(90, math.Abs(center.GetLatitude())+radiusKm/kmPerDegree)
+	for precision := geohashPrecision; precision > 0; precision-- {
+		height, width := geohashSpan(precision)
+		if height*kmPerDegree < radiusKm || width*kmPerDegree*math.Cos(farthest*math.Pi/180) < radiusKm {
+			continue
+		}
+		var cells []string
+		for _, dlat := range []float64{-1, 0, 1} {
+			for _, dlon := range []float64{-1, 0, 1} {
+				lat := math.Max(-90, math.Min(90, center.GetLatitude()+dlat*height))
+				lon := math.Mod(center.GetLongitude()+dlon*width+540, 360) - 180
+				if cell := geohash(&detail.Coordinates{Latitude: lat, Longitude: lon}, precision); !containsString(cells, cell) {
+					cells = append(cells, cell)
+				}
+			}
+		}
+		return cells
+	}
+	return nil
+}
+
+// distanceKm returns the great-circle distance between two points.
+func distanceKm(a *detail.Coordinates, b *detail.Coordinates) float64 {
+	radians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dlat := radians(b.GetLatitude() - a.GetLatitude())
+	dlon := radians(b.GetLongitude() - a.GetLongitude())
+	h := math.Pow(math.Sin(dlat/2), 2) + math.Cos(radians(a.GetLatitude()))*math.Cos(radians(b.GetLatitude()))*math.Pow(math.Sin(dlon/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// validateCoordinates checks that coordinates, if given, are on the earth.
+func validateCoordinates(c *detail.Coordinates) error {
+	if c == nil {
+		return nil
+	}
+	if !(c.GetLatitude() >= -90 && c.GetLatitude() <= 90 && c.GetLongitude() >= -180 && c.GetLongitude() <= 180) {
+		return status.Errorf(codes.InvalidArgument, "Invalid coordinates: %v", c)
+	}
+	return nil
+}
+
+// nearbyRestaurants returns the restaurants within a request's radius of its
+// center, nearest first, found through the geohash index. Caller must hold
+// s.lock.
+func (s *Detail) nearbyRestaurants(ctx context.Context, req *detail.NearbyRestaurantsRequest) ([]*detail.NearbyRestaurant, error) {
+	center, radius := req.GetCenter(), req.GetRadiusKm()
+	if center == nil {
+		return nil, status.Error(codes.InvalidArgument, "A center is required")
+	}
+	if err := validateCoordinates(center); err != nil {
+		return nil, err
+	}
+	if !(radius > 0 && radius <= maxNearbyRadiusKm) {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid radius_km: %v; it must be more than 0 and at most %d", radius, maxNearbyRadiusKm)
+	}
+	limit := int(req.GetLimit())
+	switch {
+	case limit < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid limit: %d", limit)
+	case limit == 0:
+		limit = defaultSearchPageSize
+	case limit > maxSearchPageSize:
+		limit = maxSearchPageSize
+	}
+	if err := s.ensureDetailIndex(ctx); err != nil {
+		return nil, err
+	}
+
+	ranges := []scanRange{{prefix: geohashDetailsPrefix}}
+	if cells := coveringCells(center, radius); cells != nil {
+		ranges = nil
+		for _, cell := range cells {
+			ranges = append(ranges, scanRange{prefix: geohashDetailsPrefix + cell})
+		}
+	}
+	candidates, err := s.indexedNames(ctx, ranges)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetStyle() != "" {
+		styled, err := s.indexedNames(ctx, []scanRange{{prefix: textIndexPrefix(styleDetailsPrefix, req.GetStyle())}})
+		if err != nil {
+			return nil, err
+		}
+		for name := range candidates {
+			if !styled[name] {
+				delete(candidates, name)
+			}
+		}
+	}
+
+	var nearby []*detail.NearbyRestaurant
+	for name := range candidates {
+		d, _, err := s.readDetail(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		// Skip index entries that are out of date, and points in the cells
+		// but outside the radius.
+		if d == nil || d.GetCoordinates() == nil || (req.GetStyle() != "" && !strings.EqualFold(d.GetStyle(), req.GetStyle())) {
+			continue
+		}
+		if distance := distanceKm(center, d.GetCoordinates()); distance <= radius {
+			nearby = append(nearby, &detail.NearbyRestaurant{Detail: d, DistanceKm: distance})
+		}
+	}
+	sort.Slice(nearby, func(i, j int) bool {
+		if nearby[i].DistanceKm != nearby[j].DistanceKm {
+			return nearby[i].DistanceKm < nearby[j].DistanceKm
+		}
+		return nearby[i].Detail.GetRestaurantName() < nearby[j].Detail.GetRestaurantName()
+	})
+	if len(nearby) > limit {
+		nearby = nearby[:limit]
+	}
+	return nearby, nil
+}
@@ -0,0 +1,574 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apps "cse190-welp/applications"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Prefixes of the database keys of the popularity counters, each holding a
+// number of reservations at one restaurant:
+//   - popularityPrefix + restaurant counts every reservation.
+//   - hourlyPopularityPrefix + hour + ":" + restaurant counts the
+//     reservations made in one hour, numbered from the Unix epoch.
+//   - dailyPopularityPrefix + date + ":" + restaurant counts the reservations
+//     for one date, formatted YYYY-MM-DD.
+const (
+	popularityPrefix       = internalKeyPrefix + "popularity:"
+	hourlyPopularityPrefix = internalKeyPrefix + "popularity-hour:"
+	dailyPopularityPrefix  = internalKeyPrefix + "popularity-day:"
+)
+
+// hourlyPopularityRetention is how long hourly counters are kept, and so the
+// longest window they count. Older ones are pruned once an hour as counters
+// are written, and when the counters are rebuilt.
+const hourlyPopularityRetention = 90 * 24 * time.Hour
+
+// maxPopularityWindowHours is the longest window, in hours, that hourly
+// counters are kept for.
+const maxPopularityWindowHours = int32(hourlyPopularityRetention / time.Hour)
+
+// errStopScan stops a scan early without failing it.
+var errStopScan = errors.New("scan stopped")
+
+// defaultTrendingWindow is the window Trending compares when none is given.
+const defaultTrendingWindow = 7 * 24
+
+func hourBucket(t time.Time) string {
+	return fmt.Sprintf("%010d", t.Unix()/3600)
+}
+
+// hoursBefore returns the bucket hours before the bucket of t.
+func hoursBefore(t time.Time, hours int) string {
+	return hourBucket(t.Add(-time.Duration(hours) * time.Hour))
+}
+
+func dateBucket(date *reservation.Date) string {
+	return fmt.Sprintf("%04d-%02d-%02d", date.GetYear(), date.GetMonth(), date.GetDay())
+}
+
+// reservationCounters returns the keys of the counters that count a
+// reservation, in the hour it was made.
+func reservationCounters(r *reservation.GetReservationResponse) []string {
+	keys := []string{
+		popularityPrefix + r.GetRestaurantName(),
+		hourlyPopularityPrefix + hourBucket(time.Unix(r.GetMadeAt(), 0)) + ":" + r.GetRestaurantName(),
+	}
+	if r.GetTime() != nil {
+		keys = append(keys, dailyPopularityPrefix+dateBucket(r.GetTime())+":"+r.GetRestaurantName())
+	}
+	return keys
+}
+
+// isPopularityCounter reports whether key is the key of any popularity
+// counter.
+func isPopularityCounter(key string) bool {
+	return hasAnyPrefix(key, popularityPrefix, hourlyPopularityPrefix, dailyPopularityPrefix)
+}
+
+// decodePopularity decodes a popularity counter record.
+func decodePopularity(record *mydatabase.DatabaseRecord) (int64, error) {
+	count := &reservation.PopularityCount{}
+	if err := proto.Unmarshal(record.GetValue(), count); err != nil {
+		return 0, status.Errorf(codes.DataLoss, "Popularity counter %s could not be decoded: %v", record.GetKey(), err)
+	}
+	return count.GetCount(), nil
+}
+
+// encodePopularity encodes a popularity counter, or returns nil if it is zero.
+func encodePopularity(count int64) []byte {
+	if count <= 0 {
+		return nil
+	}
+	data, _ := proto.Marshal(&reservation.PopularityCount{Count: count})
+	return data
+}
+
+// countReservation returns the update that adds delta to a counter.
+func countReservation(key string, delta int64) recordUpdate {
+	return recordUpdate{key: key, apply: func(current []byte) ([]byte, error) {
+		count, err := decodePopularity(&mydatabase.DatabaseRecord{Key: key, Value: current})
+		return encodePopularity(count + delta), err
+	}}
+}
+
+// countWindow sums, per restaurant, the counters under prefix whose bucket
+// lies between from and to inclusive.
+func (s *Reservation) countWindow(ctx context.Context, prefix string, from string, to string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	_, err := scanAt(ctx, s.reservationDatabaseClient, prefix, prefix+from, 0, func(record *mydatabase.DatabaseRecord) error {
+		bucket, name, _ := strings.Cut(strings.TrimPrefix(record.GetKey(), prefix), ":")
+		if bucket > to {
+			return errStopScan
+		}
+		count, err := decodePopularity(record)
+		counts[name] += count
+		return err
+	})
+	return counts, err
+}
+
+// windowCounts sums each restaurant's counters within the window a
+// MostPopular request asks for: the last window_hours hours, counting the
+// current one, or the reservation dates from from_date through to_date.
+func (s *Reservation) windowCounts(ctx context.Context, req *reservation.MostPopularRequest) (map[string]int64, error) {
+	byDate := req.GetFromDate() != nil || req.GetToDate() != nil
+	switch {
+	case req.GetWindowHours() < 0:
+		return nil, status.Error(codes.InvalidArgument, "window_hours must not be negative")
+	case req.GetWindowHours() > 0 && byDate:
+		return nil, status.Error(codes.InvalidArgument, "Specify either window_hours or a date range, not both")
+	case req.GetWindowHours() > 0:
+		now := time.Now()
+		return s.countWindow(ctx, hourlyPopularityPrefix, hoursBefore(now, int(req.GetWindowHours())-1), hourBucket(now))
+	}
+	from, to := "", "9999-99-99"
+	if req.GetFromDate() != nil {
+		from = dateBucket(req.GetFromDate())
+	}
+	if req.GetToDate() != nil {
+		to = dateBucket(req.GetToDate())
+	}
+	return s.countWindow(ctx, dailyPopularityPrefix, from, to)
+}
+
+// pruneHourlyCounters deletes the hourly counters older than
+// hourlyPopularityRetention, at most once per hour. A counter written
+// meanwhile is kept until the next hour. Failures are logged, and pruning is
+// tried again on the next write.
+// Caller must hold s.lock.
+func (s *Reservation) pruneHourlyCounters(ctx context.Context) {
+	now := hourBucket(time.Now())
+	if s.prunedHour == now {
+		return
+	}
+	oldest := hourBucket(time.Now().Add(-hourlyPopularityRetention))
+	var expired []*mydatabase.WriteOperation
+	err := scanAll(ctx, s.reservationDatabaseClient, hourlyPopularityPrefix, func(record *mydatabase.DatabaseRecord) error {
+		if strings.TrimPrefix(record.GetKey(), hourlyPopularityPrefix) >= oldest {
+			return errStopScan
+		}
+		expired = append(expired, &mydatabase.WriteOperation{
+			Record:          &mydatabase.DatabaseRecord{Key: record.GetKey(), Deleted: true},
+			ExpectedVersion: proto.Uint64(record.GetVersion()),
+		})
+		return nil
+	})
+	for _, op := range expired {
+		if err != nil {
+			break
+		}
+		_, err = s.reservationDatabaseClient.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{op}})
+		if status.Code(err) == codes.Aborted {
+			err = nil
+		}
+	}
+	if err != nil {
+		log.Printf("reservation server <%s> failed to prune hourly popularity counters: %v", s.name, err)
+		return
+	}
+	s.prunedHour = now
+}
+
+// trending ranks restaurants by the growth of their reservations in the last
+// hours hours over the hours before them.
+func (s *Reservation) trending(ctx context.Context, topK int, hours int) ([]*reservation.TrendingRestaurant, error) {
+	now := time.Now()
+	previousEnd := hoursBefore(now, hours)
+	counts := make(map[string]*reservation.TrendingRestaurant)
+	_, err := scanAt(ctx, s.reservationDatabaseClient, hourlyPopularityPrefix, hourlyPopularityPrefix+hoursBefore(now, 2*hours-1), 0, func(record *mydatabase.DatabaseRecord) error {
+		bucket, name, _ := strings.Cut(strings.TrimPrefix(record.GetKey(), hourlyPopularityPrefix), ":")
+		if bucket > hourBucket(now) {
+			return errStopScan
+		}
+		count, err := decodePopularity(record)
+		if err != nil {
+			return err
+		}
+		if counts[name] == nil {
+			counts[name] = &reservation.TrendingRestaurant{RestaurantName: name}
+		}
+		if bucket > previousEnd {
+			counts[name].Count += count
+		} else {
+			counts[name].PreviousCount += count
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ranked []*reservation.TrendingRestaurant
+	for _, r := range counts {
+		if r.Count > 0 {
+			r.Growth = float64(r.Count+1)/float64(r.PreviousCount+1) - 1
+			ranked = append(ranked, r)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Growth != b.Growth {
+			return a.Growth > b.Growth
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.RestaurantName < b.RestaurantName
+	})
+	if topK < len(ranked) {
+		ranked = ranked[:max(topK, 0)]
+	}
+	return ranked, nil
+}
+
+// rankCounts returns up to topK restaurants by descending count. Ties are
+// broken by name.
+func rankCounts(counts map[string]int64, topK int) []string {
+	names := make([]string, 0, len(counts))
+	for name, count := range counts {
+		if count > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if topK < len(names) {
+		names = names[:max(topK, 0)]
+	}
+	return names
+}
+
+// scanPopularRestaurants returns up to topK restaurants by descending number
+// of reservations, read directly from the counters.
+func (s *Reservation) scanPopularRestaurants(ctx context.Context, topK int) ([]string, error) {
+	counts := make(map[string]int64)
+	err := scanAll(ctx, s.reservationDatabaseClient, popularityPrefix, func(record *mydatabase.DatabaseRecord) error {
+		count, err := decodePopularity(record)
+		counts[strings.TrimPrefix(record.GetKey(), popularityPrefix)] = count
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rankCounts(counts, topK), nil
+}
+
+// ensureDerivedRecords rebuilds the popularity counters, booked seats,
+// waitlists and reservation indexes the first time they are needed if the
+// database holds reservations but no counters, as when the reservations were
+// made before counters were stored. Caller must hold s.lock.
+func (s *Reservation) ensureDerivedRecords(ctx context.Context) error {
+	if s.popularityChecked {
+		return nil
+	}
+	reply, err := s.reservationDatabaseClient.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{Prefix: popularityPrefix, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(reply.GetRecords()) == 0 {
+		if _, err := s.rebuildDerivedRecords(ctx); err != nil {
+			return err
+		}
+	}
+	s.popularityChecked = true
+	go s.followPopularity()
+	return nil
+}
+
+// rebuildDerivedRecords rewrites every popularity counter, booked seat count,
+// waitlist and reservation index from a scan of the reservations and returns
+// the number of confirmed reservations found. Each record is written only if
+// it hasn't changed since the scan, so a reservation made meanwhile restarts
+// the rebuild instead of being lost.
+func (s *Reservation) rebuildDerivedRecords(ctx context.Context) (int, error) {
+	for conflicts := 0; ; conflicts++ {
+		count, ops, err := s.deriveRecords(ctx)
+		if err != nil || len(ops) == 0 {
+			return count, err
+		}
+		reply, err := s.reservationDatabaseClient.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: ops})
+		if status.Code(err) == codes.Unimplemented {
+			for _, op := range ops {
+				reply, err = s.reservationDatabaseClient.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{op}})
+				if err != nil {
+					break
+				}
+			}
+		}
+		if err == nil {
+			s.lastCounted = reply.GetVersion()
+		}
+		if status.Code(err) != codes.Aborted || conflicts == maxWriteConflicts {
+			return count, err
+		}
+	}
+}
+
+// deriveRecords scans the database and returns the number of confirmed
+// reservations and the writes that bring each counter, booked seat count,
+// waitlist and index in line with the reservations. Booked seats are
+// recounted even if that exceeds capacity, and waitlists are ordered by when
+// their reservations were made. Hourly counters past
+// hourlyPopularityRetention are removed.
+func (s *Reservation) deriveRecords(ctx context.Context) (int, []*mydatabase.WriteOperation, error) {
+	counted := make(map[string]int64)
+	indexed := make(map[string][]string)
+	waiting := make(map[string][]*reservation.GetReservationResponse)
+	stored := make(map[string][]byte)
+	versions := make(map[string]uint64)
+	oldest := hourlyPopularityPrefix + hourBucket(time.Now().Add(-hourlyPopularityRetention))
+	count := 0
+	err := scanAll(ctx, s.reservationDatabaseClient, "", func(record *mydatabase.DatabaseRecord) error {
+		if isPopularityCounter(record.GetKey()) || isReservationIndex(record.GetKey()) || hasAnyPrefix(record.GetKey(), bookedPrefix, waitlistPrefix) {
+			stored[record.GetKey()], versions[record.GetKey()] = record.GetValue(), record.GetVersion()
+			return nil
+		}
+		if strings.HasPrefix(record.GetKey(), internalKeyPrefix) {
+			return nil
+		}
+		r, err := decodeReservation(record)
+		if err != nil {
+			return err
+		}
+		for _, key := range reservationIndexes(r) {
+			indexed[key] = append(indexed[key], r.GetReservationId())
+		}
+		switch r.GetStatus() {
+		case reservation.ReservationStatus_CONFIRMED:
+			for _, key := range reservationCounters(r) {
+				if !strings.HasPrefix(key, hourlyPopularityPrefix) || key >= oldest {
+					counted[key]++
+				}
+			}
+			for _, key := range bookedKeys(r) {
+				counted[key] += int64(max(r.GetPartySize(), 1))
+			}
+			count++
+		case reservation.ReservationStatus_WAITLISTED:
+			waiting[waitlistKey(r)] = append(waiting[waitlistKey(r)], r)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	derived := make(map[string][]byte)
+	for key := range stored {
+		derived[key] = nil
+	}
+	for key, n := range counted {
+		derived[key] = encodePopularity(n)
+	}
+	for key, ids := range indexed {
+		derived[key] = encodeReservationIndex(ids)
+	}
+	for key, reservations := range waiting {
+		sort.Slice(reservations, func(i, j int) bool {
+			if reservations[i].GetMadeAt() != reservations[j].GetMadeAt() {
+				return reservations[i].GetMadeAt() < reservations[j].GetMadeAt()
+			}
+			return reservations[i].GetReservationId() < reservations[j].GetReservationId()
+		})
+		ids := make([]string, len(reservations))
+		for i, r := range reservations {
+			ids[i] = r.GetReservationId()
+		}
+		derived[key] = encodeWaitlist(ids)
+	}
+	var ops []*mydatabase.WriteOperation
+	for key, value := range derived {
+		if current, ok := stored[key]; ok && bytes.Equal(current, value) {
+			continue
+		}
+		record := &mydatabase.DatabaseRecord{Key: key, Value: value, Deleted: value == nil}
+		ops = append(ops, &mydatabase.WriteOperation{Record: record, ExpectedVersion: proto.Uint64(versions[key])})
+	}
+	return count, ops, nil
+}
+
+// popularityWaitLimit bounds how long MostPopular waits for this replica's
+// ranking to catch up with its own writes before reading the counters
+// directly.
+const popularityWaitLimit = time.Second
+
+// popularityRanking is a replica's in-memory ranking of the popularity
+// counters, kept up to date by following the database's change stream so
+// that every replica converges on the same ranking.
+type popularityRanking struct {
+	counts  apps.TopK
+	lock    sync.Mutex
+	ready   bool          // counts reflect the counters as of applied
+	stopped bool          // counts won't be kept up to date
+	applied uint64        // sequence of the last change reflected in counts
+	changed chan struct{} // closed and replaced whenever applied advances
+}
+
+func newPopularityRanking(capacity int) *popularityRanking {
+	return &popularityRanking{counts: apps.NewTopKApp(capacity), changed: make(chan struct{})}
+}
+
+// load replaces the ranking with counters read at snapshot version.
+func (r *popularityRanking) load(counters map[string]int64, version uint64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.counts.Reset()
+	for name, count := range counters {
+		r.counts.Add(name, count)
+	}
+	r.ready = true
+	r.advance(version)
+}
+
+// invalidate marks the ranking out of date until it is loaded again, or for
+// good if stop is set.
+func (r *popularityRanking) invalidate(stop bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.ready = false
+	r.stopped = stop
+}
+
+// apply adds the difference made by one change to a counter.
+func (r *popularityRanking) apply(event *mydatabase.ChangeEvent) error {
+	var count, previous int64
+	var err error
+	if event.GetOperation() == mydatabase.ChangeEvent_PUT {
+		if count, err = decodePopularity(event.GetRecord()); err != nil {
+			return err
+		}
+	}
+	if event.GetPrevious() != nil {
+		if previous, err = decodePopularity(event.GetPrevious()); err != nil {
+			return err
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.counts.Add(strings.TrimPrefix(event.GetRecord().GetKey(), popularityPrefix), count-previous)
+	r.advance(event.GetSequence())
+	return nil
+}
+
+// advance records that changes up to sequence are reflected. Caller must hold
+// r.lock.
+func (r *popularityRanking) advance(sequence uint64) {
+	r.applied = sequence
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// top returns the ranking once it reflects every change up to sequence, or
+// false if it doesn't within popularityWaitLimit.
+func (r *popularityRanking) top(ctx context.Context, k int, sequence uint64) ([]apps.RankedCount, int64, bool) {
+	timeout := time.NewTimer(popularityWaitLimit)
+	defer timeout.Stop()
+	for {
+		r.lock.Lock()
+		if r.ready && r.applied >= sequence {
+			defer r.lock.Unlock()
+			return r.counts.Top(k), r.counts.ErrorBound(), true
+		}
+		if r.stopped {
+			r.lock.Unlock()
+			return nil, 0, false
+		}
+		changed := r.changed
+		r.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout.C:
+			return nil, 0, false
+		case <-ctx.Done():
+			return nil, 0, false
+		}
+	}
+}
+
+// followPopularity keeps s.popularity up to date with the counters forever,
+// reloading them whenever changes may have been missed. It gives up if the
+// database can't stream changes, as when it is sharded, leaving MostPopular
+// to read the counters directly.
+func (s *Reservation) followPopularity() {
+	ctx := context.Background()
+	var next uint64 // sequence of the next change to apply, 0 until loaded
+	backoff := minWatchBackoff
+	for {
+		if next == 0 {
+			counters := make(map[string]int64)
+			version, err := scanAt(ctx, s.reservationDatabaseClient, popularityPrefix, "", 0, func(record *mydatabase.DatabaseRecord) error {
+				count, err := decodePopularity(record)
+				counters[strings.TrimPrefix(record.GetKey(), popularityPrefix)] = count
+				return err
+			})
+			if err != nil {
+				log.Printf("reservation server <%s> failed to load popularity counters: %v", s.name, err)
+				backoff = watchBackoff(backoff)
+				continue
+			}
+			s.popularity.load(counters, version)
+			next = version + 1
+		}
+
+		err := s.followPopularityChanges(ctx, &next)
+		switch status.Code(err) {
+		case codes.OutOfRange:
+			log.Printf("reservation server <%s> missed popularity changes from sequence %d, reloading", s.name, next)
+			s.popularity.invalidate(false)
+			next = 0
+			continue
+		case codes.Unimplemented:
+			log.Printf("reservation server <%s> can't follow popularity changes: %v", s.name, err)
+			s.popularity.invalidate(true)
+			return
+		}
+		log.Printf("reservation server <%s> popularity change stream ended: %v", s.name, err)
+		backoff = watchBackoff(backoff)
+	}
+}
+
+// followPopularityChanges applies counter changes from *next until the
+// stream fails.
+func (s *Reservation) followPopularityChanges(ctx context.Context, next *uint64) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := s.reservationDatabaseClient.WatchChanges(ctx, &mydatabase.WatchChangesRequest{StartSequence: *next, Prefix: popularityPrefix})
+	if err != nil {
+		return err
+	}
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return status.Error(codes.Unavailable, "change stream closed by server")
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.popularity.apply(event); err != nil {
+			return err
+		}
+		*next = event.GetSequence() + 1
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Keys of the stored rating aggregates: one per restaurant, under
+// ratingsPrefix, and that of every review, split into aggregateShards
+// aggregates under allRatingsPrefix.
+const (
+	ratingsPrefix    = internalKeyPrefix + "ratings:"
+	allRatingsPrefix = internalKeyPrefix + "ratings-all:"
+)
+
+// aggregateShards is how many records the aggregates over every review, of
+// ratings and of the text index, are split into. A restaurant's reviews are
+// counted in one of them, so reviews of different restaurants rarely update
+// the same record.
+const aggregateShards = 16
+
+// aggregateShard returns the shard of the aggregates over every review that
+// counts a restaurant's reviews, as a key suffix.
+func aggregateShard(restaurantName string) string {
+	h := fnv.New32a()
+	h.Write([]byte(restaurantName))
+	return fmt.Sprintf("%02d", h.Sum32()%aggregateShards)
+}
+
+const (
+	minRating = 1
+	maxRating = 5
+	// ratingPriorWeight is how many reviews the mean of every review counts
+	// as in a restaurant's Bayesian score.
+	ratingPriorWeight = 5
+	// neutralRatingPrior stands in for the mean of every review while none
+	// are counted, as before the aggregates are rebuilt.
+	neutralRatingPrior = float64(minRating+maxRating) / 2
+)
+
+// validateRating checks that a rating is a number of stars.
+func validateRating(rating int32) error {
+	if rating < minRating || rating > maxRating {
+		return status.Errorf(codes.InvalidArgument, "Invalid rating: %d; it must be %d to %d", rating, minRating, maxRating)
+	}
+	return nil
+}
+
+// decodeRatings decodes a rating aggregate, which is empty if value is nil.
+func decodeRatings(key string, value []byte) (*review.RatingAggregate, error) {
+	ratings := &review.RatingAggregate{}
+	if err := proto.Unmarshal(value, ratings); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Rating aggregate %s could not be decoded: %v", key, err)
+	}
+	for len(ratings.Histogram) < maxRating {
+		ratings.Histogram = append(ratings.Histogram, 0)
+	}
+	return ratings, nil
+}
+
+// encodeRatings encodes a rating aggregate, or returns nil if it is empty.
+func encodeRatings(ratings *review.RatingAggregate) []byte {
+	if ratings.GetCount() <= 0 {
+		return nil
+	}
+	data, _ := proto.Marshal(ratings)
+	return data
+}
+
+// addRating adds sign times a review's rating to an aggregate, unless the
+// review is deleted.
+func addRating(ratings *review.RatingAggregate, r *review.GetReviewResponse, sign int64) {
+	if r == nil || r.GetDeleted() || validateRating(r.GetRating()) != nil {
+		return
+	}
+	ratings.Count += sign
+	ratings.Total += sign * int64(r.GetRating())
+	ratings.Histogram[r.GetRating()-minRating] += sign
+}
+
+// addRatings adds delta to a decoded aggregate.
+func addRatings(ratings *review.RatingAggregate, delta *review.RatingAggregate) {
+	ratings.Count += delta.GetCount()
+	ratings.Total += delta.GetTotal()
+	for i, n := range delta.GetHistogram() {
+		ratings.Histogram[i] += n
+	}
+}
+
+// mergeRatings returns the update that adds delta to an aggregate.
+func mergeRatings(key string, delta *review.RatingAggregate) recordUpdate {
+	return recordUpdate{key: key, apply: func(current []byte) ([]byte, error) {
+		ratings, err := decodeRatings(key, current)
+		if err != nil {
+			return nil, err
+		}
+		addRatings(ratings, delta)
+		return encodeRatings(ratings), nil
+	}}
+}
+
+// ratingUpdates returns the updates that bring the rating aggregates in line
+// with a review changing from before to after, either of which may be nil.
+func ratingUpdates(before *review.GetReviewResponse, after *review.GetReviewResponse) []recordUpdate {
+	delta, _ := decodeRatings("", nil)
+	addRating(delta, before, -1)
+	addRating(delta, after, 1)
+	if delta.Count == 0 && delta.Total == 0 {
+		return nil
+	}
+	restaurantName := after.GetRestaurantName()
+	if after == nil {
+		restaurantName = before.GetRestaurantName()
+	}
+	return []recordUpdate{mergeRatings(ratingsPrefix+restaurantName, delta), mergeRatings(allRatingsPrefix+aggregateShard(restaurantName), delta)}
+}
+
+// negateRatings returns an aggregate that cancels ratings out.
+func negateRatings(ratings *review.RatingAggregate) *review.RatingAggregate {
+	negated := &review.RatingAggregate{Count: -ratings.GetCount(), Total: -ratings.GetTotal()}
+	for _, n := range ratings.GetHistogram() {
+		negated.Histogram = append(negated.Histogram, -n)
+	}
+	return negated
+}
+
+// readRatings reads a rating aggregate as of snapshot (zero reads the
+// latest). It returns the aggregate, the version of its record (zero if there
+// is none) and the snapshot the read was served at.
+func (s *Review) readRatings(ctx context.Context, key string, snapshot uint64) (*review.RatingAggregate, uint64, uint64, error) {
+	reply, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: key, SnapshotVersion: snapshot})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return nil, 0, 0, err
+	}
+	ratings, err := decodeRatings(key, reply.GetRecord().GetValue())
+	return ratings, reply.GetRecord().GetVersion(), reply.GetSnapshotVersion(), err
+}
+
+// readAllRatings reads the aggregate of every review as of snapshot (zero
+// reads the latest), summing its shards.
+func (s *Review) readAllRatings(ctx context.Context, snapshot uint64) (*review.RatingAggregate, error) {
+	all, _ := decodeRatings(allRatingsPrefix, nil)
+	_, err := scanAt(ctx, s.reviewDatabaseClient, allRatingsPrefix, "", snapshot, func(record *mydatabase.DatabaseRecord) error {
+		shard, err := decodeRatings(record.GetKey(), record.GetValue())
+		if err != nil {
+			return err
+		}
+		addRatings(all, shard)
+		return nil
+	})
+	return all, err
+}
+
+// ratingSummary summarizes the ratings of a restaurant's reviews, reading its
+// aggregate and that of every review at the same snapshot.
+func (s *Review) ratingSummary(ctx context.Context, restaurantName string) (*review.GetRatingSummaryResponse, error) {
+	ratings, _, snapshot, err := s.readRatings(ctx, ratingsPrefix+restaurantName, 0)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.readAllRatings(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &review.GetRatingSummaryResponse{RestaurantName: restaurantName, Count: ratings.GetCount(), Histogram: ratings.GetHistogram()}
+	if ratings.GetCount() > 0 {
+		summary.Mean = float64(ratings.GetTotal()) / float64(ratings.GetCount())
+		prior := neutralRatingPrior
+		if all.GetCount() > 0 {
+			prior = float64(all.GetTotal()) / float64(all.GetCount())
+		}
+		summary.BayesianScore = (ratingPriorWeight*prior + float64(ratings.GetTotal())) / float64(ratingPriorWeight+ratings.GetCount())
+	}
+	return summary, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	apps "cse190-welp/applications"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/raft"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// leaderMetadataKey is the trailer a follower uses to tell clients where the leader is.
+const leaderMetadataKey = "raft-leader"
+
+// NewReplicatedMyDatabase creates a MyDatabase that is one replica of a Raft cluster.
+// selfAddr: The address other replicas use to reach this one; it is also the replica's ID.
+// peers: The addresses of every replica in the initial cluster, including selfAddr.
+// Leave peers empty for a replica that will join an existing cluster through AddServer.
+// raftDir: The directory the replica keeps its Raft term, vote and log in, so that
+// it can restart without forgetting its votes or committed entries. Empty keeps them
+// in memory, which is only safe if the replica never restarts.
+func NewReplicatedMyDatabase(serverName string, databasePort int, deviceType string, historyLimit int, retention time.Duration, selfAddr string, peers []string, raftDir string) *MyDatabase {
+	s := NewMyDatabase(serverName, databasePort, deviceType, historyLimit, retention)
+	storage := raft.NewStorage()
+	if raftDir != "" {
+		var err error
+		if storage, err = raft.OpenStorage(raftDir); err != nil {
+			log.Fatalf("failed to open Raft storage: %v", err)
+		}
+	}
+	transport := raft.NewGRPCTransport(func(addr string) *grpc.ClientConn {
+		// Raft traffic bypasses the logging interceptor, heartbeats would flood the logs.
+		conn, err := grpc.Dial(addr, grpc.WithInsecure())
+		if err != nil {
+			panic(fmt.Sprintf("ERROR: dial error: %v", err))
+		}
+		return conn
+	})
+	s.node = raft.NewNode(selfAddr, peers, &databaseStateMachine{app: s.app}, transport, storage, raft.DefaultConfig())
+	return s
+}
+
+// execute applies a write locally, or replicates it through Raft when the
+// database runs as a cluster. It returns the commit version of the write.
+func (s *MyDatabase) execute(ctx context.Context, cmd *mydatabase.DatabaseCommand) (uint64, error) {
+	cmd.Timestamp = time.Now().UnixNano()
+	var result commandResult
+	if s.node == nil {
+		result = applyCommand(s.app, cmd)
+	} else {
+		data, err := proto.Marshal(cmd)
+		if err != nil {
+			return 0, status.Errorf(codes.Internal, "Failed to encode command: %v", err)
+		}
+		applied, err := s.node.Propose(ctx, data)
+		if err != nil {
+			return 0, s.replicationError(ctx, err)
+		}
+		result = applied.(commandResult)
+	}
+
+	if errors.Is(result.err, apps.ErrVersionConflict) {
+		return result.version, status.Errorf(codes.Aborted, "Write conflict: %v", result.err)
+	}
+	return result.version, result.err
+}
+
+// readBarrier waits until a linearizable read can be served locally. Reads on
+// a standalone database are always local.
+func (s *MyDatabase) readBarrier(ctx context.Context) error {
+	if s.node == nil {
+		return nil
+	}
+	if err := s.node.ReadIndex(ctx); err != nil {
+		return s.replicationError(ctx, err)
+	}
+	return nil
+}
+
+// replicationError converts a Raft error into a gRPC status. Followers attach
+// the leader's address, empty if they don't know it, so clients can tell the
+// request was never proposed and retry at the leader.
+func (s *MyDatabase) replicationError(ctx context.Context, err error) error {
+	switch err {
+	case raft.ErrNotLeader:
+		grpc.SetTrailer(ctx, metadata.Pairs(leaderMetadataKey, s.node.Leader()))
+		return status.Errorf(codes.Unavailable, "Database server <%s> is not the leader!", s.name)
+	case context.Canceled, context.DeadlineExceeded:
+		return status.FromContextError(err).Err()
+	default:
+		return status.Errorf(codes.Unavailable, "Database server <%s> failed to replicate: %v", s.name, err)
+	}
+}
+
+// commandResult is the outcome of applying a command, the same on every replica.
+type commandResult struct {
+	version uint64
+	err     error
+}
+
+// applyCommand applies a write to the storage application and returns the
+// commit version.
+func applyCommand(app *apps.EmulatedStorageApp, cmd *mydatabase.DatabaseCommand) commandResult {
+	timestamp := time.Unix(0, cmd.GetTimestamp())
+	switch op := cmd.Op.(type) {
+	case *mydatabase.DatabaseCommand_Set:
+		return commandResult{version: app.SetAt(op.Set, timestamp)}
+	case *mydatabase.DatabaseCommand_Delete:
+		return commandResult{version: app.DeleteAt(op.Delete, timestamp)}
+	case *mydatabase.DatabaseCommand_Batch:
+		version, err := app.Batch(op.Batch.GetOperations(), timestamp)
+		return commandResult{version: version, err: err}
+	case *mydatabase.DatabaseCommand_CollectGarbageBefore:
+		if dropped := app.CollectGarbage(time.Unix(0, op.CollectGarbageBefore)); dropped > 0 {
+			log.Printf("garbage collected %d versions", dropped)
+		}
+	}
+	return commandResult{version: app.Version()}
+}
+
+// databaseStateMachine replicates an EmulatedStorageApp through Raft.
+type databaseStateMachine struct {
+	app *apps.EmulatedStorageApp
+}
+
+func (m *databaseStateMachine) Apply(command []byte) interface{} {
+	cmd := &mydatabase.DatabaseCommand{}
+	if err := proto.Unmarshal(command, cmd); err != nil {
+		log.Fatalf("corrupt replicated command: %v", err)
+	}
+	return applyCommand(m.app, cmd)
+}
+
+func (m *databaseStateMachine) Snapshot() ([]byte, error) {
+	return proto.Marshal(m.app.Dump())
+}
+
+func (m *databaseStateMachine) Restore(snapshot []byte) error {
+	dump := &mydatabase.StorageSnapshot{}
+	if err := proto.Unmarshal(snapshot, dump); err != nil {
+		return err
+	}
+	m.app.Load(dump)
+	return nil
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"log"
 	"net"
-	"sort"
 	"sync"
+	"time"
 
+	"cse190-welp/proto/detail"
 	"cse190-welp/proto/mycache"
 	"cse190-welp/proto/mydatabase"
 	"cse190-welp/proto/reservation"
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -24,26 +26,35 @@ type Reservation struct {
 	reservation.ReservationServiceServer
 	reservationCacheClient    mycache.CacheServiceClient
 	reservationDatabaseClient mydatabase.DatabaseServiceClient
-	popularityTable           map[string]int
-	lock                      sync.Mutex // Mutex to synchronize access to popularityTable
+	detailClient              detail.DetailServiceClient
+	popularity                *popularityRanking
+	popularityChecked         bool       // whether stored popularity counters are known to exist
+	lastCounted               uint64     // version of this replica's last write to a popularity counter
+	prunedHour                string     // hour bucket expired hourly counters were last pruned in
+	lock                      sync.Mutex // Mutex to synchronize access to popularityChecked, lastCounted and prunedHour
 }
 
-// NewReservation returns a new server
-func NewReservation(name string, reservationPort int, reservationCacheAddr string, reservationDatabaseAddr string) *Reservation {
+// NewReservation returns a new server. Restaurant capacities are read from the
+// detail service at detailAddr. popularityCapacity bounds the number of
+// restaurants MostPopular ranks exactly; 0 ranks every restaurant exactly.
+func NewReservation(name string, reservationPort int, reservationCacheAddr string, reservationDatabaseAddr string, detailAddr string, popularityCapacity int) *Reservation {
 	return &Reservation{
 		name:                      name,
 		port:                      reservationPort,
 		reservationCacheClient:    mycache.NewCacheServiceClient(dial(reservationCacheAddr)),
-		reservationDatabaseClient: mydatabase.NewDatabaseServiceClient(dial(reservationDatabaseAddr)),
-		popularityTable:           make(map[string]int),
+		reservationDatabaseClient: newDatabaseClient(reservationDatabaseAddr),
+		detailClient:              detail.NewDetailServiceClient(dial(detailAddr)),
+		popularity:                newPopularityRanking(popularityCapacity),
 	}
 }
 
 // Run starts the Reservation gRPC server and listens for incoming requests.
 // It returns an error if the server fails to start or encounters an error.
 func (s *Reservation) Run() error {
-	// Create a new gRPC server instance.
-	srv := grpc.NewServer()
+	// Create a new gRPC server instance, replaying the responses to retried writes.
+	keys := newIdempotency(s.name, s.reservationDatabaseClient, reservation.ReservationService_MakeReservation_FullMethodName)
+	go keys.expireForever()
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(validateRequest, keys.intercept))
 
 	// Register the Reservation server implementation with the gRPC server.
 	reservation.RegisterReservationServiceServer(srv, s)
@@ -65,12 +76,13 @@ func (s *Reservation) GetReservation(ctx context.Context, req *reservation.GetRe
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	// Get the restaurant and user names
-	restaurantName := req.GetRestaurantName()
-	userName := req.GetUserName()
+	// Without an ID, fetch the user's latest reservation at the restaurant
+	reservationID := req.GetReservationId()
+	if reservationID == "" {
+		return s.latestReservation(ctx, req.GetUserName(), req.GetRestaurantName())
+	}
 
 	// Check if the data is cached in mycache
-	reservationID, _ := GetQueryUUID(restaurantName, userName)
 
 	cacheRequest := &mycache.GetItemRequest{Key: reservationID}
 	cacheReply, err := s.reservationCacheClient.GetItem(ctx, cacheRequest)
@@ -120,28 +132,118 @@ func (s *Reservation) GetReservation(ctx context.Context, req *reservation.GetRe
 		log.Fatalf("Unexpected error getting item: %v", err)
 	}
 
+	if reservationResponse.ReservationId == "" {
+		reservationResponse.ReservationId = reservationID // stored before reservations had IDs
+	}
 	return reservationResponse, err
 }
 
+// latestReservation returns the user's reservation at the restaurant with the
+// latest date. Caller must hold s.lock.
+func (s *Reservation) latestReservation(ctx context.Context, userName string, restaurantName string) (*reservation.GetReservationResponse, error) {
+	if userName == "" || restaurantName == "" {
+		return &reservation.GetReservationResponse{}, status.Error(codes.InvalidArgument, "Specify a reservation_id, or a user_name and restaurant_name")
+	}
+	if err := s.ensureDerivedRecords(ctx); err != nil {
+		return &reservation.GetReservationResponse{}, err
+	}
+	reservations, err := s.listReservations(ctx, &reservation.ListReservationsRequest{UserName: userName, RestaurantName: restaurantName})
+	if err != nil {
+		return &reservation.GetReservationResponse{}, err
+	}
+	if len(reservations) == 0 {
+		return &reservation.GetReservationResponse{}, status.Error(codes.NotFound, "Item does not exist in cache or database")
+	}
+	return reservations[len(reservations)-1], nil
+}
+
+// GetAvailability returns the slots in which a party can book a restaurant
+// over a range of dates, taking its opening hours and capacity into account.
+func (s *Reservation) GetAvailability(ctx context.Context, req *reservation.GetAvailabilityRequest) (*reservation.GetAvailabilityResponse, error) {
+	s.lock.Lock()
+	err := s.ensureDerivedRecords(ctx)
+	s.lock.Unlock()
+	if err != nil {
+		return &reservation.GetAvailabilityResponse{}, err
+	}
+
+	restaurant, err := s.restaurantDetail(ctx, req.GetRestaurantName())
+	if err != nil {
+		return &reservation.GetAvailabilityResponse{}, err
+	}
+	slots, err := s.availability(ctx, req, restaurant, time.Now())
+	if err != nil {
+		return &reservation.GetAvailabilityResponse{}, err
+	}
+	return &reservation.GetAvailabilityResponse{Slots: slots, Unlimited: restaurant.GetCapacity() <= 0}, nil
+}
+
+// ListReservations returns a user's or a restaurant's reservations, ordered
+// by date and optionally restricted to a range of dates.
+func (s *Reservation) ListReservations(ctx context.Context, req *reservation.ListReservationsRequest) (*reservation.ListReservationsResponse, error) {
+	s.lock.Lock()
+	err := s.ensureDerivedRecords(ctx)
+	s.lock.Unlock()
+	if err != nil {
+		return &reservation.ListReservationsResponse{}, err
+	}
+
+	reservations, err := s.listReservations(ctx, req)
+	if err != nil {
+		return &reservation.ListReservationsResponse{}, err
+	}
+	return &reservation.ListReservationsResponse{Reservations: reservations}, nil
+}
+
 // This function takes a MakeReservationRequest message, saves the reservation to the database and caches it in mycache.
+// When the restaurant is full, the reservation joins the waitlist of its slot if the request asks to.
 func (s *Reservation) MakeReservation(ctx context.Context, req *reservation.MakeReservationRequest) (*reservation.MakeReservationResponse, error) {
+	// Check the booking against the restaurant's details, read before taking
+	// the lock, which the detail service's cascading deletes wait on
+	restaurant, err := s.restaurantDetail(ctx, req.GetRestaurantName())
+	if err != nil {
+		return &reservation.MakeReservationResponse{Status: false}, err
+	}
+	msg, err := validateBooking(req, restaurant, time.Now())
+	if err != nil {
+		return &reservation.MakeReservationResponse{Status: false}, err
+	}
+
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	// Retrieve the user's name, restaurant name, and time from the request message
-	userName := req.GetUserName()
-	restaurantName := req.GetRestaurantName()
-	time := req.GetTime()
+	// Every booking is a new reservation with its own ID
+	reservationID := uuid.NewString()
+	msg.ReservationId = reservationID
+	msg.MadeAt = time.Now().Unix()
 
-	// Create a GetReservationResponse message containing the reservation information
-	msg := &reservation.GetReservationResponse{
-		UserName:       userName,
-		RestaurantName: restaurantName,
-		Time:           time,
+	// Save the reservation if seats are left, counting and indexing it
+	capacity := int64(restaurant.GetCapacity())
+	err = s.ensureDerivedRecords(ctx)
+	if err == nil {
+		err = s.saveReservation(ctx, nil, msg, 0, capacity)
+	}
+	if status.Code(err) == codes.ResourceExhausted && req.GetJoinWaitlist() {
+		msg.Status = reservation.ReservationStatus_WAITLISTED
+		err = s.saveReservation(ctx, nil, msg, 0, capacity)
+		if err == nil {
+			// Seats freed since the booking failed would otherwise go unclaimed
+			_, err = s.promoteWaitlist(ctx, msg.GetRestaurantName(), msg.GetTime(), capacity)
+		}
+		if err == nil {
+			msg, _, err = s.readReservation(ctx, reservationID)
+		}
+	}
+	if err != nil {
+		return &reservation.MakeReservationResponse{Status: false}, err
 	}
 
-	// Safely update Restaurant popularity using a mutex
-	s.popularityTable[restaurantName]++
+	// Create a protobuf response indicating whether the reservation was successfully posted
+	reservationResponse := &reservation.MakeReservationResponse{
+		Status:        true,
+		ReservationId: reservationID,
+		Waitlisted:    msg.GetStatus() == reservation.ReservationStatus_WAITLISTED,
+	}
 
 	// Marshal the message to binary data for storage
 	data, err := proto.Marshal(msg)
@@ -150,56 +252,211 @@ func (s *Reservation) MakeReservation(ctx context.Context, req *reservation.Make
 	}
 
 	// Cache the data in mycache
-	reservationID, _ := GetQueryUUID(restaurantName, userName)
-
 	item := &mycache.CacheItem{
 		Key:   reservationID,
 		Value: data,
 	}
-	record := &mydatabase.DatabaseRecord{
-		Key:   reservationID,
-		Value: data,
+	err = cacheSetHelper(s.reservationCacheClient, ctx, item, s.name)
+	if err != nil {
+		reservationResponse.Status = false
 	}
 
-	// Create a protobuf response indicating whether the reservation was successfully posted
-	reservationResponse := &reservation.MakeReservationResponse{Status: true}
+	return reservationResponse, status.Errorf(codes.OK, "Successfully placed in database: %s", s.name)
+}
 
-	err = cacheSetHelper(s.reservationCacheClient, ctx, item, s.name)
+// CancelReservation cancels a reservation, releasing its seats or leaving
+// the waitlist, and confirms waitlisted reservations into the seats it frees.
+func (s *Reservation) CancelReservation(ctx context.Context, req *reservation.CancelReservationRequest) (*reservation.CancelReservationResponse, error) {
+	restaurant, err := s.reservationRestaurant(ctx, req.GetReservationId())
 	if err != nil {
-		reservationResponse.Status = false
+		return &reservation.CancelReservationResponse{}, err
 	}
 
-	err = storageSetHelper(s.reservationDatabaseClient, ctx, record, s.name)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.ensureDerivedRecords(ctx); err != nil {
+		return &reservation.CancelReservationResponse{}, err
+	}
+	before, after, err := s.changeReservation(ctx, req.GetReservationId(), 0, func(r *reservation.GetReservationResponse) error {
+		if r.GetStatus() == reservation.ReservationStatus_CANCELLED {
+			return status.Errorf(codes.FailedPrecondition, "Reservation %s is already cancelled", r.GetReservationId())
+		}
+		r.Status = reservation.ReservationStatus_CANCELLED
+		return nil
+	})
 	if err != nil {
-		reservationResponse.Status = false
+		return &reservation.CancelReservationResponse{}, err
 	}
 
-	return reservationResponse, err
+	resp := &reservation.CancelReservationResponse{Reservation: after}
+	if before.GetStatus() == reservation.ReservationStatus_CONFIRMED {
+		resp.Promoted, err = s.promoteWaitlist(ctx, before.GetRestaurantName(), before.GetTime(), int64(restaurant.GetCapacity()))
+	}
+	return resp, err
 }
 
-func (s *Reservation) MostPopular(ctx context.Context, req *reservation.MostPopularRequest) (*reservation.MostPopularResponse, error) {
+// ModifyReservation changes the date, start time, duration or party size of
+// a confirmed reservation, provided the restaurant is open and has the seats
+// the change adds, and confirms waitlisted reservations into any seats it
+// frees.
+func (s *Reservation) ModifyReservation(ctx context.Context, req *reservation.ModifyReservationRequest) (*reservation.ModifyReservationResponse, error) {
+	restaurant, err := s.reservationRestaurant(ctx, req.GetReservationId())
+	if err != nil {
+		return &reservation.ModifyReservationResponse{}, err
+	}
+
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	topK := int(req.GetTopK())
+	if err := s.ensureDerivedRecords(ctx); err != nil {
+		return &reservation.ModifyReservationResponse{}, err
+	}
 
-	keys := make([]string, 0, len(s.popularityTable))
+	before, after, err := s.changeReservation(ctx, req.GetReservationId(), int64(restaurant.GetCapacity()), func(r *reservation.GetReservationResponse) error {
+		if r.GetStatus() != reservation.ReservationStatus_CONFIRMED {
+			return status.Errorf(codes.FailedPrecondition, "Only confirmed reservations can be modified; %s is %s", r.GetReservationId(), r.GetStatus())
+		}
+		booking := &reservation.MakeReservationRequest{
+			UserName:        r.GetUserName(),
+			RestaurantName:  r.GetRestaurantName(),
+			Time:            r.GetTime(),
+			StartTime:       r.GetStartTime(),
+			DurationMinutes: r.GetDurationMinutes(),
+			PartySize:       r.GetPartySize(),
+		}
+		if req.GetTime() != nil {
+			booking.Time = req.GetTime()
+		}
+		if req.GetStartTime() != nil {
+			booking.StartTime = req.GetStartTime()
+		}
+		if req.GetDurationMinutes() != 0 {
+			booking.DurationMinutes = req.GetDurationMinutes()
+		}
+		if req.GetPartySize() != 0 {
+			booking.PartySize = req.GetPartySize()
+		}
+		modified, err := validateBooking(booking, restaurant, time.Now())
+		if err != nil {
+			return err
+		}
+		r.Time, r.StartTime = modified.GetTime(), modified.GetStartTime()
+		r.DurationMinutes, r.PartySize = modified.GetDurationMinutes(), modified.GetPartySize()
+		return nil
+	})
+	if err != nil {
+		return &reservation.ModifyReservationResponse{}, err
+	}
+
+	resp := &reservation.ModifyReservationResponse{Reservation: after}
+	resp.Promoted, err = s.promoteWaitlist(ctx, before.GetRestaurantName(), before.GetTime(), int64(restaurant.GetCapacity()))
+	return resp, err
+}
+
+// DeleteRestaurantReservations deletes every reservation at a restaurant, as
+// when the restaurant itself is deleted, so that none of its seats, waitlists
+// or popularity counters are left behind.
+func (s *Reservation) DeleteRestaurantReservations(ctx context.Context, req *reservation.DeleteRestaurantReservationsRequest) (*reservation.DeleteRestaurantReservationsResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-	for key := range s.popularityTable {
-		keys = append(keys, key)
+	if req.GetRestaurantName() == "" {
+		return &reservation.DeleteRestaurantReservationsResponse{}, status.Error(codes.InvalidArgument, "A restaurant_name is required")
 	}
+	if err := s.ensureDerivedRecords(ctx); err != nil {
+		return &reservation.DeleteRestaurantReservationsResponse{}, err
+	}
+	deleted, err := s.deleteRestaurantReservations(ctx, req.GetRestaurantName())
+	return &reservation.DeleteRestaurantReservationsResponse{Reservations: int32(deleted)}, err
+}
 
-	sort.SliceStable(keys, func(i, j int) bool {
-		return s.popularityTable[keys[i]] > s.popularityTable[keys[j]]
-	})
+// reservationRestaurant returns the details of the restaurant a reservation
+// is at. Reservations never change restaurant, so it is read before s.lock is
+// taken: the detail service holds its own lock while it deletes a
+// restaurant's reservations through this service.
+func (s *Reservation) reservationRestaurant(ctx context.Context, id string) (*detail.GetDetailResponse, error) {
+	r, _, err := s.readReservation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.restaurantDetail(ctx, r.GetRestaurantName())
+}
+
+func (s *Reservation) MostPopular(ctx context.Context, req *reservation.MostPopularRequest) (*reservation.MostPopularResponse, error) {
+	s.lock.Lock()
+	err := s.ensureDerivedRecords(ctx)
+	lastCounted := s.lastCounted
+	s.lock.Unlock()
+	if err != nil {
+		return &reservation.MostPopularResponse{}, err
+	}
 
-	topKeys := make([]string, 0, topK)
-	for i := 0; i < topK && i < len(keys); i++ {
-		topKeys = append(topKeys, keys[i])
+	topK := int(req.GetTopK())
+	resp := &reservation.MostPopularResponse{}
+
+	// Windows are counted from the time-bucketed counters
+	if req.GetWindowHours() != 0 || req.GetFromDate() != nil || req.GetToDate() != nil {
+		counts, err := s.windowCounts(ctx, req)
+		if err != nil {
+			return &reservation.MostPopularResponse{}, err
+		}
+		resp.TopKRestaurants = rankCounts(counts, topK)
+		return resp, nil
 	}
 
-	resp := &reservation.MostPopularResponse{
-		TopKRestaurants: topKeys,
+	// Answer from the ranking once it includes this replica's own writes
+	if top, bound, ok := s.popularity.top(ctx, topK, lastCounted); ok {
+		for _, entry := range top {
+			resp.TopKRestaurants = append(resp.TopKRestaurants, entry.Name)
+		}
+		resp.ErrorBound = bound
+		return resp, nil
+	}
+
+	resp.TopKRestaurants, err = s.scanPopularRestaurants(ctx, topK)
+	if err != nil {
+		return &reservation.MostPopularResponse{}, err
 	}
 	return resp, nil
 }
+
+// Trending returns the restaurants whose reservations grew the most in the
+// last window compared with the window before it.
+func (s *Reservation) Trending(ctx context.Context, req *reservation.TrendingRequest) (*reservation.TrendingResponse, error) {
+	s.lock.Lock()
+	err := s.ensureDerivedRecords(ctx)
+	s.lock.Unlock()
+	if err != nil {
+		return &reservation.TrendingResponse{}, err
+	}
+
+	hours := int(req.GetWindowHours())
+	switch {
+	case hours < 0:
+		return &reservation.TrendingResponse{}, status.Error(codes.InvalidArgument, "window_hours must not be negative")
+	case hours == 0:
+		hours = defaultTrendingWindow
+	}
+	ranked, err := s.trending(ctx, int(req.GetTopK()), hours)
+	if err != nil {
+		return &reservation.TrendingResponse{}, err
+	}
+	return &reservation.TrendingResponse{Restaurants: ranked}, nil
+}
+
+// RebuildPopularityTable recounts the stored popularity counters, and
+// rebuilds the reservation indexes, from every reservation in the database.
+func (s *Reservation) RebuildPopularityTable(ctx context.Context, req *reservation.RebuildPopularityTableRequest) (*reservation.RebuildPopularityTableResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.ensureDerivedRecords(ctx); err != nil {
+		return &reservation.RebuildPopularityTableResponse{}, err
+	}
+	count, err := s.rebuildDerivedRecords(ctx)
+	if err != nil {
+		return &reservation.RebuildPopularityTableResponse{}, err
+	}
+	return &reservation.RebuildPopularityTableResponse{Reservations: int32(count)}, status.Error(codes.OK, "Popularity table rebuilt!")
+}
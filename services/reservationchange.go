@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"cse190-welp/proto/mycache"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// reservationUpdates returns the updates that bring the booked seats,
+// popularity counters, waitlists and indexes in line with a reservation
+// changing from before to after. A nil before is a new reservation and a nil
+// after a deleted one. Only confirmed reservations hold seats and are
+// counted, so a change claims only the seats it adds, within capacity (0 is
+// unlimited).
+func reservationUpdates(before *reservation.GetReservationResponse, after *reservation.GetReservationResponse, capacity int64) []recordUpdate {
+	r := after
+	if r == nil {
+		r = before
+	}
+	seats := make(map[string]int64)
+	slots := make(map[string]time.Time)
+	counts := make(map[string]int64)
+	waitlists := make(map[string]int64)
+	add := func(r *reservation.GetReservationResponse, sign int64) {
+		switch r.GetStatus() {
+		case reservation.ReservationStatus_CONFIRMED:
+			if start, ok := reservationStart(r); ok {
+				for _, slot := range slotsOf(start, r.GetDurationMinutes()) {
+					key := slotKey(r.GetRestaurantName(), slot)
+					seats[key] += sign * int64(max(r.GetPartySize(), 1))
+					slots[key] = slot
+				}
+			}
+			for _, key := range reservationCounters(r) {
+				counts[key] += sign
+			}
+		case reservation.ReservationStatus_WAITLISTED:
+			waitlists[waitlistKey(r)] += sign
+		}
+	}
+	if before != nil {
+		add(before, -1)
+	}
+	if after != nil {
+		add(after, 1)
+	}
+
+	var updates []recordUpdate
+	for _, key := range sortedKeys(seats) {
+		switch n := seats[key]; {
+		case n > 0:
+			updates = append(updates, claimSeats(r.GetRestaurantName(), slots[key], n, capacity))
+		case n < 0:
+			updates = append(updates, countReservation(key, n))
+		}
+	}
+	for _, key := range sortedKeys(waitlists) {
+		switch n := waitlists[key]; {
+		case n > 0:
+			updates = append(updates, joinWaitlist(key, r.GetReservationId()))
+		case n < 0:
+			updates = append(updates, leaveWaitlist(key, r.GetReservationId()))
+		}
+	}
+	switch {
+	case before == nil:
+		for _, key := range reservationIndexes(r) {
+			updates = append(updates, indexReservation(key, r.GetReservationId()))
+		}
+	case after == nil:
+		for _, key := range reservationIndexes(r) {
+			updates = append(updates, unindexReservation(key, r.GetReservationId()))
+		}
+	}
+	// The counters come last, the total last of all, so the version of the
+	// write is the version of the total counter MostPopular follows.
+	for _, key := range sortedKeys(counts) {
+		if n := counts[key]; n != 0 {
+			updates = append(updates, countReservation(key, n))
+		}
+	}
+	return updates
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// saveReservation writes a reservation as it is after a change, or deletes
+// it if after is nil, provided its record is still at version (0 for a new
+// reservation), and atomically with it updates every record derived from it.
+// The reservation's cached copy, if it had one, is invalidated, and when it
+// was counted expired hourly counters are pruned. Caller must hold s.lock.
+func (s *Reservation) saveReservation(ctx context.Context, before *reservation.GetReservationResponse, after *reservation.GetReservationResponse, version uint64, capacity int64) error {
+	record := &mydatabase.DatabaseRecord{Key: before.GetReservationId(), Deleted: true}
+	if after != nil {
+		data, err := proto.Marshal(after)
+		if err != nil {
+			log.Fatal(err)
+		}
+		record = &mydatabase.DatabaseRecord{Key: after.GetReservationId(), Value: data}
+	}
+	writes := []*mydatabase.WriteOperation{{Record: record, ExpectedVersion: proto.Uint64(version)}}
+	updates := reservationUpdates(before, after, capacity)
+	version, err := writeWithUpdates(ctx, s.reservationDatabaseClient, writes, updates)
+	if err != nil {
+		return err
+	}
+	// MostPopular waits for the total counters to be followed up to
+	// lastCounted, so it only moves when one of them was written.
+	for _, update := range updates {
+		if strings.HasPrefix(update.key, popularityPrefix) {
+			s.lastCounted = version
+			s.pruneHourlyCounters(ctx)
+			break
+		}
+	}
+
+	if before != nil {
+		_, err := s.reservationCacheClient.DeleteItem(ctx, &mycache.DeleteItemRequest{Key: record.GetKey()})
+		if err != nil && status.Code(err) != codes.NotFound {
+			log.Printf("reservation server <%s> failed to invalidate cached reservation %s: %v", s.name, record.GetKey(), err)
+		}
+	}
+	return nil
+}
+
+// readReservation returns a stored reservation and the version of its
+// record.
+func (s *Reservation) readReservation(ctx context.Context, id string) (*reservation.GetReservationResponse, uint64, error) {
+	if id == "" {
+		return nil, 0, status.Error(codes.InvalidArgument, "A reservation_id is required")
+	}
+	reply, err := s.reservationDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: id})
+	if status.Code(err) == codes.NotFound {
+		return nil, 0, status.Errorf(codes.NotFound, "Reservation %s does not exist", id)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	r, err := decodeReservation(reply.GetRecord())
+	return r, reply.GetRecord().GetVersion(), err
+}
+
+// changeReservation applies change to a copy of a stored reservation and
+// saves it, rereading the reservation and trying again if another writer
+// changes it first. It returns the reservation before and after the change.
+// Caller must hold s.lock.
+func (s *Reservation) changeReservation(ctx context.Context, id string, capacity int64, change func(r *reservation.GetReservationResponse) error) (*reservation.GetReservationResponse, *reservation.GetReservationResponse, error) {
+	for conflicts := 0; ; conflicts++ {
+		before, version, err := s.readReservation(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		after := proto.Clone(before).(*reservation.GetReservationResponse)
+		if err := change(after); err != nil {
+			return nil, nil, err
+		}
+		err = s.saveReservation(ctx, before, after, version, capacity)
+		if status.Code(err) != codes.Aborted || conflicts == maxWriteConflicts {
+			return before, after, err
+		}
+	}
+}
+
+// deleteRestaurantReservations deletes every reservation at a restaurant, one
+// at a time, with the records derived from them, and returns how many were
+// deleted. Caller must hold s.lock.
+func (s *Reservation) deleteRestaurantReservations(ctx context.Context, restaurantName string) (int, error) {
+	reservations, err := s.listReservations(ctx, &reservation.ListReservationsRequest{RestaurantName: restaurantName})
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, r := range reservations {
+		for conflicts := 0; ; conflicts++ {
+			before, version, err := s.readReservation(ctx, r.GetReservationId())
+			if status.Code(err) == codes.NotFound {
+				break
+			}
+			if err == nil {
+				err = s.saveReservation(ctx, before, nil, version, 0)
+			}
+			if err == nil {
+				deleted++
+				break
+			}
+			if status.Code(err) != codes.Aborted || conflicts == maxWriteConflicts {
+				return deleted, err
+			}
+		}
+	}
+	return deleted, nil
+}
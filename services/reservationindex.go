@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Prefixes of the database keys of the reservation indexes, which list the
+// IDs of each user's and each restaurant's reservations.
+const (
+	userReservationsPrefix       = internalKeyPrefix + "reservations-by-user:"
+	restaurantReservationsPrefix = internalKeyPrefix + "reservations-by-restaurant:"
+)
+
+// reservationIndexes returns the keys of the indexes that list a reservation.
+func reservationIndexes(r *reservation.GetReservationResponse) []string {
+	return []string{
+		userReservationsPrefix + r.GetUserName(),
+		restaurantReservationsPrefix + r.GetRestaurantName(),
+	}
+}
+
+// isReservationIndex reports whether key is the key of a reservation index.
+func isReservationIndex(key string) bool {
+	return hasAnyPrefix(key, userReservationsPrefix, restaurantReservationsPrefix)
+}
+
+// decodeReservationIndex decodes a reservation index, which may be empty.
+func decodeReservationIndex(key string, value []byte) ([]string, error) {
+	index := &reservation.ReservationIndex{}
+	if err := proto.Unmarshal(value, index); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Reservation index %s could not be decoded: %v", key, err)
+	}
+	return index.GetReservationIds(), nil
+}
+
+// encodeReservationIndex encodes a reservation index, or returns nil if it
+// is empty.
+func encodeReservationIndex(ids []string) []byte {
+	if len(ids) == 0 {
+		return nil
+	}
+	sort.Strings(ids)
+	data, _ := proto.Marshal(&reservation.ReservationIndex{ReservationIds: ids})
+	return data
+}
+
+// indexReservation returns the update that adds a reservation to an index.
+func indexReservation(key string, id string) recordUpdate {
+	return recordUpdate{key: key, apply: func(current []byte) ([]byte, error) {
+		ids, err := decodeReservationIndex(key, current)
+		if err != nil || containsString(ids, id) {
+			return current, err
+		}
+		return encodeReservationIndex(append(ids, id)), nil
+	}}
+}
+
+// unindexReservation returns the update that removes a reservation from an
+// index.
+func unindexReservation(key string, id string) recordUpdate {
+	return recordUpdate{key: key, apply: func(current []byte) ([]byte, error) {
+		ids, err := decodeReservationIndex(key, current)
+		if err != nil {
+			return nil, err
+		}
+		var kept []string
+		for _, indexed := range ids {
+			if indexed != id {
+				kept = append(kept, indexed)
+			}
+		}
+		return encodeReservationIndex(kept), nil
+	}}
+}
+
+// decodeReservation decodes a reservation record. Reservations stored before
+// they had IDs are identified by their key, and those stored before they
+// recorded when they were made were made when last written.
+func decodeReservation(record *mydatabase.DatabaseRecord) (*reservation.GetReservationResponse, error) {
+	r := &reservation.GetReservationResponse{}
+	if err := proto.Unmarshal(record.GetValue(), r); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Reservation %s could not be decoded: %v", record.GetKey(), err)
+	}
+	if r.ReservationId == "" {
+		r.ReservationId = record.GetKey()
+	}
+	if r.MadeAt == 0 {
+		r.MadeAt = time.Unix(0, record.GetTimestamp()).Unix()
+	}
+	return r, nil
+}
+
+// listReservations returns the reservations a request asks for, ordered by
+// start and then ID. Every reservation is read at the snapshot of the index.
+func (s *Reservation) listReservations(ctx context.Context, req *reservation.ListReservationsRequest) ([]*reservation.GetReservationResponse, error) {
+	key := restaurantReservationsPrefix + req.GetRestaurantName()
+	switch {
+	case req.GetUserName() != "":
+		key = userReservationsPrefix + req.GetUserName()
+	case req.GetRestaurantName() == "":
+		return nil, status.Error(codes.InvalidArgument, "Specify a user_name or a restaurant_name")
+	}
+
+	reply, err := s.reservationDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: key})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids, err := decodeReservationIndex(key, reply.GetRecord().GetValue())
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := "", "9999-99-99"
+	if req.GetFromDate() != nil {
+		from = dateBucket(req.GetFromDate())
+	}
+	if req.GetToDate() != nil {
+		to = dateBucket(req.GetToDate())
+	}
+	var reservations []*reservation.GetReservationResponse
+	for _, id := range ids {
+		record, err := s.reservationDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: id, SnapshotVersion: reply.GetSnapshotVersion()})
+		if status.Code(err) == codes.NotFound {
+			continue // indexed before it was written
+		}
+		if err != nil {
+			return nil, err
+		}
+		r, err := decodeReservation(record.GetRecord())
+		if err != nil {
+			return nil, err
+		}
+		if req.GetRestaurantName() != "" && r.GetRestaurantName() != req.GetRestaurantName() {
+			continue
+		}
+		if date := dateBucket(r.GetTime()); date < from || date > to {
+			continue
+		}
+		reservations = append(reservations, r)
+	}
+	sort.SliceStable(reservations, func(i, j int) bool {
+		a, b := startBucket(reservations[i]), startBucket(reservations[j])
+		if a != b {
+			return a < b
+		}
+		return reservations[i].GetReservationId() < reservations[j].GetReservationId()
+	})
+	return reservations, nil
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"slices"
 	"sync"
+	"time"
 
 	"cse190-welp/proto/mycache"
 	"cse190-welp/proto/mydatabase"
@@ -23,42 +25,25 @@ type Review struct {
 	review.ReviewServiceServer
 	reviewCacheClient    mycache.CacheServiceClient
 	reviewDatabaseClient mydatabase.DatabaseServiceClient
-	idLookupTable        map[string]map[string]struct{} // Map of restaurant names to sets of review IDs
+	moderation           []moderationCheck // checks run on submitted reviews
+	indexChecked         bool              // whether ensureIndex has run
 	lock                 sync.Mutex
 }
 
-// NewReview returns a new server
-func NewReview(name string, reviewPort int, reviewCacheAddr string, reviewDatabaseAddr string) *Review {
+// NewReview returns a new server that holds submitted reviews for moderation
+// if any of the comma-separated moderation checks flags them.
+func NewReview(name string, reviewPort int, reviewCacheAddr string, reviewDatabaseAddr string, moderation string) *Review {
+	checks, err := parseModeration(moderation)
+	if err != nil {
+		log.Fatalf("failed to initialize moderation: %v", err)
+	}
 	return &Review{
 		name:                 name,
 		port:                 reviewPort,
 		reviewCacheClient:    mycache.NewCacheServiceClient(dial(reviewCacheAddr)),
-		reviewDatabaseClient: mydatabase.NewDatabaseServiceClient(dial(reviewDatabaseAddr)),
-		idLookupTable:        make(map[string]map[string]struct{}),
-	}
-}
-
-func (s *Review) updateLookupTable(restaurantName string, reviewID string) error {
-	set, exists := s.idLookupTable[restaurantName]
-	if !exists {
-		set = make(map[string]struct{})
-		s.idLookupTable[restaurantName] = set
-	}
-	set[reviewID] = struct{}{}
-	return nil
-}
-
-func (s *Review) getFromLookupTable(restaurantName string) ([]string, error) {
-	set, exists := s.idLookupTable[restaurantName]
-	if !exists {
-		// no IDs associated with restaurantName
-		return nil, nil
-	}
-	result := make([]string, 0, len(set))
-	for value := range set {
-		result = append(result, value)
+		reviewDatabaseClient: newDatabaseClient(reviewDatabaseAddr),
+		moderation:           checks,
 	}
-	return result, nil
 }
 
 func (s *Review) getResponseHelper(ctx context.Context, reviewID string) (*review.GetReviewResponse, error) {
@@ -76,6 +61,9 @@ func (s *Review) getResponseHelper(ctx context.Context, reviewID string) (*revie
 		if err != nil {
 			log.Fatal(err)
 		}
+		if reviewResponse.GetDeleted() {
+			return &review.GetReviewResponse{}, status.Error(codes.NotFound, "Review was deleted")
+		}
 		err = status.Error(codes.OK, "Cache hit while reading from service: mycache-review")
 	case codes.NotFound:
 		err = nil
@@ -93,6 +81,9 @@ func (s *Review) getResponseHelper(ctx context.Context, reviewID string) (*revie
 		if err != nil { // err if bytes don't unmarshal
 			log.Fatal(err)
 		}
+		if reviewResponse.GetDeleted() {
+			return &review.GetReviewResponse{}, status.Error(codes.NotFound, "Review was deleted")
+		}
 
 		// Populate cache with item
 		item := &mycache.CacheItem{
@@ -113,11 +104,39 @@ func (s *Review) getResponseHelper(ctx context.Context, reviewID string) (*revie
 	return reviewResponse, err
 }
 
+// getSnapshotHelper reads a review straight from the database as of the given
+// snapshot version. It bypasses the cache, which only holds latest versions, and
+// returns the snapshot the read was served at so later reads can reuse it.
+func (s *Review) getSnapshotHelper(ctx context.Context, reviewID string, snapshot uint64) (*review.GetReviewResponse, uint64, error) {
+	reviewResponse := &review.GetReviewResponse{}
+
+	databaseRequest := &mydatabase.GetRecordRequest{Key: reviewID, SnapshotVersion: snapshot}
+	databaseReply, err := s.reviewDatabaseClient.GetRecord(ctx, databaseRequest)
+	databaseReplyStatus, _ := status.FromError(err)
+
+	switch databaseReplyStatus.Code() {
+	case codes.OK:
+		err = proto.Unmarshal(databaseReply.GetRecord().GetValue(), reviewResponse)
+		if err != nil { // err if bytes don't unmarshal
+			log.Fatal(err)
+		}
+	case codes.NotFound:
+		err = status.Error(codes.NotFound, "Item does not exist in database")
+	case codes.FailedPrecondition, codes.Canceled:
+		// snapshot was garbage collected or the caller gave up
+	default:
+		log.Fatalf("Unexpected error getting item: %v", err)
+	}
+	return reviewResponse, databaseReply.GetSnapshotVersion(), err
+}
+
 // Run starts the Review gRPC server and listens for incoming requests.
 // It returns an error if the server fails to start or encounters an error.
 func (s *Review) Run() error {
-	// Create a new gRPC server instance.
-	srv := grpc.NewServer()
+	// Create a new gRPC server instance, replaying the responses to retried writes.
+	keys := newIdempotency(s.name, s.reviewDatabaseClient, review.ReviewService_PostReview_FullMethodName)
+	go keys.expireForever()
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(validateRequest, keys.intercept))
 
 	// Register the Review server implementation with the gRPC server.
 	review.RegisterReviewServiceServer(srv, s)
@@ -148,29 +167,53 @@ func (s *Review) GetReview(ctx context.Context, req *review.GetReviewRequest) (*
 	return s.getResponseHelper(ctx, reviewID)
 }
 
+// SearchReviews returns a page of a restaurant's reviews in the requested
+// order, or, if no page size is given, every review keyed by user name.
 func (s *Review) SearchReviews(ctx context.Context, req *review.SearchReviewsRequest) (*review.SearchReviewsResponse, error) {
 	s.lock.Lock()
-	defer s.lock.Unlock()
+	err := s.ensureIndex(ctx)
+	s.lock.Unlock()
+	if err != nil {
+		return &review.SearchReviewsResponse{}, err
+	}
+
+	if req.GetPageSize() != 0 {
+		page, next, err := s.reviewPage(ctx, req)
+		if err != nil {
+			return &review.SearchReviewsResponse{}, err
+		}
+		return &review.SearchReviewsResponse{Reviews: page, NextPageToken: next}, nil
+	}
 
 	restaurantName := req.GetRestaurantName()
 
 	// maps usernames to review responses
 	userReviews := make(map[string]*review.GetReviewResponse)
 
-	var reviewIDs []string
-	reviewIDs, _ = s.getFromLookupTable(restaurantName)
-
+	// Reading the index pins a snapshot version and every later read uses it,
+	// so all reviews are seen as of the same point in time.
+	reviewIDs, _, snapshot, err := s.readIndex(ctx, restaurantName, 0)
+	if err != nil {
+		return &review.SearchReviewsResponse{}, err
+	}
 	for _, reviewID := range reviewIDs {
-		r, err := s.getResponseHelper(ctx, reviewID)
+		r, _, err := s.getSnapshotHelper(ctx, reviewID, snapshot)
+		if status.Code(err) == codes.NotFound {
+			continue // indexed before it was written, only possible across shards
+		}
 		if err != nil {
 			return &review.SearchReviewsResponse{}, err
 		}
+		if r.GetDeleted() {
+			continue
+		}
 		userReviews[r.UserName] = r
 	}
 	return &review.SearchReviewsResponse{ReviewsMap: userReviews}, nil
 }
 
-// PostReview posts a review of a restaurant
+// PostReview posts a review of a restaurant, or holds it for a moderator to
+// approve if the moderation checks flag it.
 func (s *Review) PostReview(ctx context.Context, req *review.PostReviewRequest) (*review.PostReviewResponse, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -181,47 +224,427 @@ func (s *Review) PostReview(ctx context.Context, req *review.PostReviewRequest)
 	restaurantReview := req.GetReview()
 	restaurantRating := req.GetRating()
 
+	if err := validateRating(restaurantRating); err != nil {
+		return &review.PostReviewResponse{Status: false}, err
+	}
+
 	// Create a protobuf message containing the review data
 	msg := &review.GetReviewResponse{
 		UserName:       userName,
 		RestaurantName: restaurantName,
 		Review:         restaurantReview,
 		Rating:         restaurantRating,
+		PostedAt:       time.Now().UnixNano(),
 	}
 
-	data, err := proto.Marshal(msg)
+	reviewID, _ := GetQueryUUID(restaurantName, userName)
+
+	// Create a protobuf response indicating whether the review was successfully posted
+	reviewResponse := &review.PostReviewResponse{
+		Status: true,
+	}
+
+	if err := s.ensureIndex(ctx); err != nil {
+		reviewResponse.Status = false
+		return reviewResponse, err
+	}
+	flags, err := s.moderate(ctx, reviewID, msg)
 	if err != nil {
-		log.Fatal(err)
+		reviewResponse.Status = false
+		return reviewResponse, err
+	}
+	if len(flags) > 0 {
+		msg.ModerationFlags = flags
+		if err := s.holdReview(ctx, reviewID, msg); err != nil {
+			reviewResponse.Status = false
+			return reviewResponse, err
+		}
+		reviewResponse.Pending, reviewResponse.ModerationFlags = true, flags
+		return reviewResponse, nil
 	}
 
-	reviewID, _ := GetQueryUUID(restaurantName, userName)
-	s.updateLookupTable(restaurantName, reviewID)
+	// Store the review, add it to the restaurant's index and count its rating
+	// in one batch, discarding any earlier review of the user still held for
+	// moderation, which it replaces
+	discard, err := s.discardHeld(ctx, reviewID)
+	if err == nil {
+		_, err = s.writeReview(ctx, reviewID, func(before *review.GetReviewResponse) (*review.GetReviewResponse, error) {
+			// A review posted again keeps the votes it had
+			msg.HelpfulCount = before.GetHelpfulCount()
+			return msg, nil
+		}, discard...)
+	}
+	if err != nil {
+		reviewResponse.Status = false
+		return reviewResponse, err
+	}
 
-	// Cache the data in mycache
+	// Cache the review as stored in mycache
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		log.Fatal(err)
+	}
 	item := &mycache.CacheItem{
 		Key:   reviewID,
 		Value: data,
 	}
+	err = cacheSetHelper(s.reviewCacheClient, ctx, item, s.name)
+	if err != nil {
+		reviewResponse.Status = false
+	}
 
-	record := &mydatabase.DatabaseRecord{
-		Key:   reviewID,
-		Value: data,
+	return reviewResponse, nil
+}
+
+// writeReview replaces a review with what change returns for the current
+// one, nil if there is none, together with the changes to its restaurant's
+// index, orderings and rating aggregates and to the text and shingle
+// indexes, keeps the review it replaces as a revision unless only its votes
+// change, and applies the writes in also with them. If another writer
+// changes the review first, it rereads it and tries again. It returns the
+// review as written.
+// Caller must hold s.lock.
+func (s *Review) writeReview(ctx context.Context, reviewID string, change func(before *review.GetReviewResponse) (*review.GetReviewResponse, error), also ...*mydatabase.WriteOperation) (*review.GetReviewResponse, error) {
+	for conflicts := 0; ; conflicts++ {
+		reply, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: reviewID})
+		if err != nil && status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+		var before *review.GetReviewResponse
+		if reply.GetRecord() != nil {
+			if before, err = decodeReview(reply.GetRecord()); err != nil {
+				return nil, err
+			}
+		}
+		r, err := change(before)
+		if err != nil {
+			return nil, err
+		}
+		var revisions []*mydatabase.WriteOperation
+		if onlyVotesChanged(before, r) {
+			r.Revision = before.GetRevision()
+		} else {
+			r.Revision = before.GetRevision() + 1
+			revisions = revisionWrites(reviewID, before)
+		}
+		data, err := proto.Marshal(r)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		record := &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: reviewID, Value: data}, ExpectedVersion: proto.Uint64(reply.GetRecord().GetVersion())}
+		postings, stale := reviewTextWrites(reviewID, before, r)
+		shingles, staleShingles := reviewShingleWrites(reviewID, before, r)
+		writes := append(revisions, postings...)
+		writes = append(writes, shingles...)
+		writes = append(writes, reviewOrderWrites(reviewID, before, r, record)...)
+		writes = append(writes, stale...)
+		writes = append(writes, staleShingles...)
+		writes = append(writes, also...)
+		updates := append(ratingUpdates(before, r), indexReview(r.GetRestaurantName(), reviewID))
+		updates = append(updates, textStatsUpdates(before, r)...)
+		_, err = writeWithUpdates(ctx, s.reviewDatabaseClient, writes, updates)
+		if status.Code(err) != codes.Aborted || conflicts == maxWriteConflicts {
+			return r, err
+		}
 	}
+}
 
-	// Create a protobuf response indicating whether the review was successfully posted
-	reviewResponse := &review.PostReviewResponse{
-		Status: true,
+// EditReview changes the text or rating of a review, keeping the earlier
+// version in its history, and returns the review as edited.
+func (s *Review) EditReview(ctx context.Context, req *review.EditReviewRequest) (*review.EditReviewResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if req.GetReview() == "" && req.GetRating() == 0 {
+		return &review.EditReviewResponse{}, status.Error(codes.InvalidArgument, "Nothing to edit; set the review or the rating")
+	}
+	if req.GetRating() != 0 {
+		if err := validateRating(req.GetRating()); err != nil {
+			return &review.EditReviewResponse{}, err
+		}
+	}
+	if err := s.ensureIndex(ctx); err != nil {
+		return &review.EditReviewResponse{}, err
 	}
 
-	err = cacheSetHelper(s.reviewCacheClient, ctx, item, s.name)
+	reviewID, _ := GetQueryUUID(req.GetRestaurantName(), req.GetUserName())
+	edited, err := s.writeReview(ctx, reviewID, func(before *review.GetReviewResponse) (*review.GetReviewResponse, error) {
+		if before == nil || before.GetDeleted() {
+			return nil, status.Error(codes.NotFound, "Review does not exist")
+		}
+		after := proto.Clone(before).(*review.GetReviewResponse)
+		if req.GetReview() != "" {
+			after.Review = req.GetReview()
+		}
+		if req.GetRating() != 0 {
+			after.Rating = req.GetRating()
+		}
+		after.EditedAt = time.Now().UnixNano()
+		return after, nil
+	})
 	if err != nil {
-		reviewResponse.Status = false
+		return &review.EditReviewResponse{}, err
+	}
+	s.invalidate(ctx, reviewID)
+	return &review.EditReviewResponse{Review: edited}, nil
+}
+
+// DeleteReview deletes a review and its votes, keeping it and its earlier
+// versions in its history. Posting the review again restores it without the
+// votes.
+func (s *Review) DeleteReview(ctx context.Context, req *review.DeleteReviewRequest) (*review.DeleteReviewResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.ensureIndex(ctx); err != nil {
+		return &review.DeleteReviewResponse{}, err
 	}
 
-	err = storageSetHelper(s.reviewDatabaseClient, ctx, record, s.name)
+	reviewID, _ := GetQueryUUID(req.GetRestaurantName(), req.GetUserName())
+	for conflicts := 0; ; conflicts++ {
+		// The votes go with the review, so a review posted again starts
+		// without them. Every vote changes the review, so the votes read
+		// with it are those deleted.
+		votes, err := s.readVotes(ctx, reviewID)
+		if err != nil {
+			return &review.DeleteReviewResponse{}, err
+		}
+		var clear []*mydatabase.WriteOperation
+		for _, key := range votes {
+			clear = append(clear, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Deleted: true}})
+		}
+		_, err = s.writeReview(ctx, reviewID, func(before *review.GetReviewResponse) (*review.GetReviewResponse, error) {
+			if before == nil || before.GetDeleted() {
+				return nil, status.Error(codes.NotFound, "Review does not exist")
+			}
+			current, err := s.readVotes(ctx, reviewID)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Equal(current, votes) {
+				return nil, status.Error(codes.Aborted, "The review's votes changed while it was deleted")
+			}
+			after := proto.Clone(before).(*review.GetReviewResponse)
+			after.Deleted = true
+			after.DeletedAt = time.Now().UnixNano()
+			after.HelpfulCount = 0
+			return after, nil
+		}, clear...)
+		if err == nil {
+			break
+		}
+		if status.Code(err) != codes.Aborted || conflicts == maxWriteConflicts {
+			return &review.DeleteReviewResponse{}, err
+		}
+	}
+	s.invalidate(ctx, reviewID)
+	return &review.DeleteReviewResponse{}, nil
+}
+
+// VoteReview records that a user found a review helpful, or with retract
+// takes their vote back, and returns the review's count of helpful votes.
+// Each user votes once for a review, and not for their own.
+func (s *Review) VoteReview(ctx context.Context, req *review.VoteReviewRequest) (*review.VoteReviewResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if req.GetVoterName() == req.GetUserName() {
+		return &review.VoteReviewResponse{}, status.Error(codes.InvalidArgument, "Users can't vote for their own reviews")
+	}
+	if err := s.ensureIndex(ctx); err != nil {
+		return &review.VoteReviewResponse{}, err
+	}
+
+	reviewID, _ := GetQueryUUID(req.GetRestaurantName(), req.GetUserName())
+	// A review awaiting moderation can't be voted for, even if an earlier
+	// one it replaces is published
+	if _, _, err := s.pendingReview(ctx, reviewID); err == nil {
+		return &review.VoteReviewResponse{}, status.Error(codes.NotFound, "Review is awaiting moderation")
+	} else if status.Code(err) != codes.NotFound {
+		return &review.VoteReviewResponse{}, err
+	}
+	version, err := s.readVote(ctx, reviewID, req.GetVoterName())
 	if err != nil {
-		reviewResponse.Status = false
+		return &review.VoteReviewResponse{}, err
 	}
+	// The vote is written with the count, so concurrent votes of one user
+	// conflict and are rechecked.
+	vote := voteOperation(reviewID, req.GetVoterName(), req.GetRetract(), version)
+	voted, err := s.writeReview(ctx, reviewID, func(before *review.GetReviewResponse) (*review.GetReviewResponse, error) {
+		if before == nil || before.GetDeleted() {
+			return nil, status.Error(codes.NotFound, "Review does not exist")
+		}
+		version, err := s.readVote(ctx, reviewID, req.GetVoterName())
+		switch {
+		case err != nil:
+			return nil, err
+		case version != 0 && !req.GetRetract():
+			return nil, status.Errorf(codes.FailedPrecondition, "%s already voted for this review", req.GetVoterName())
+		case version == 0 && req.GetRetract():
+			return nil, status.Errorf(codes.FailedPrecondition, "%s has not voted for this review", req.GetVoterName())
+		}
+		after := proto.Clone(before).(*review.GetReviewResponse)
+		if req.GetRetract() {
+			after.HelpfulCount--
+		} else {
+			after.HelpfulCount++
+		}
+		return after, nil
+	}, vote)
+	if err != nil {
+		return &review.VoteReviewResponse{}, err
+	}
+	s.invalidate(ctx, reviewID)
+	return &review.VoteReviewResponse{HelpfulCount: voted.GetHelpfulCount()}, nil
+}
 
-	return reviewResponse, err
+// GetReviewHistory returns every revision of a review, oldest first, ending
+// with the review as it is, which may be deleted.
+func (s *Review) GetReviewHistory(ctx context.Context, req *review.GetReviewHistoryRequest) (*review.GetReviewHistoryResponse, error) {
+	reviewID, _ := GetQueryUUID(req.GetRestaurantName(), req.GetUserName())
+	revisions, err := s.reviewHistory(ctx, reviewID)
+	if err != nil {
+		return &review.GetReviewHistoryResponse{}, err
+	}
+	return &review.GetReviewHistoryResponse{Revisions: revisions}, nil
+}
+
+// ListPendingReviews returns the reviews held for moderation, oldest first,
+// for an admin to approve or reject.
+func (s *Review) ListPendingReviews(ctx context.Context, req *review.ListPendingReviewsRequest) (*review.ListPendingReviewsResponse, error) {
+	reviews, err := s.pendingReviews(ctx, req.GetRestaurantName())
+	if err != nil {
+		return &review.ListPendingReviewsResponse{}, err
+	}
+	return &review.ListPendingReviewsResponse{Reviews: reviews}, nil
+}
+
+// ApproveReview publishes a review held for moderation, as if it had just
+// been posted, and returns it as published.
+func (s *Review) ApproveReview(ctx context.Context, req *review.ApproveReviewRequest) (*review.ApproveReviewResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.ensureIndex(ctx); err != nil {
+		return &review.ApproveReviewResponse{}, err
+	}
+	reviewID, _ := GetQueryUUID(req.GetRestaurantName(), req.GetUserName())
+	held, version, err := s.pendingReview(ctx, reviewID)
+	if err != nil {
+		return &review.ApproveReviewResponse{}, err
+	}
+
+	// The held review is discarded as it is published, unless the user
+	// submits another meanwhile
+	discard := &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: pendingReviewsPrefix + reviewID, Deleted: true}, ExpectedVersion: proto.Uint64(version)}
+	published, err := s.writeReview(ctx, reviewID, func(before *review.GetReviewResponse) (*review.GetReviewResponse, error) {
+		r := proto.Clone(held).(*review.GetReviewResponse)
+		r.ModerationFlags = nil
+		r.HelpfulCount = before.GetHelpfulCount()
+		return r, nil
+	}, discard)
+	if err != nil {
+		return &review.ApproveReviewResponse{}, err
+	}
+	s.invalidate(ctx, reviewID)
+	return &review.ApproveReviewResponse{Review: published}, nil
+}
+
+// RejectReview discards a review held for moderation.
+func (s *Review) RejectReview(ctx context.Context, req *review.RejectReviewRequest) (*review.RejectReviewResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	reviewID, _ := GetQueryUUID(req.GetRestaurantName(), req.GetUserName())
+	discard, err := s.discardHeld(ctx, reviewID)
+	if err == nil && len(discard) == 0 {
+		err = status.Error(codes.NotFound, "No review is awaiting moderation")
+	}
+	if err == nil {
+		_, err = s.reviewDatabaseClient.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: discard})
+	}
+	if err != nil {
+		return &review.RejectReviewResponse{}, err
+	}
+	return &review.RejectReviewResponse{}, nil
+}
+
+// invalidate removes a review from the cache after it changes.
+func (s *Review) invalidate(ctx context.Context, reviewID string) {
+	_, err := s.reviewCacheClient.DeleteItem(ctx, &mycache.DeleteItemRequest{Key: reviewID})
+	if err != nil && status.Code(err) != codes.NotFound {
+		log.Printf("review server <%s> failed to invalidate cached review %s: %v", s.name, reviewID, err)
+	}
+}
+
+// GetRatingSummary returns the number of reviews of a restaurant, their mean
+// rating, how many gave each rating, and a Bayesian score for ranking.
+func (s *Review) GetRatingSummary(ctx context.Context, req *review.GetRatingSummaryRequest) (*review.GetRatingSummaryResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.ensureIndex(ctx); err != nil {
+		return &review.GetRatingSummaryResponse{}, err
+	}
+	summary, err := s.ratingSummary(ctx, req.GetRestaurantName())
+	if err != nil {
+		return &review.GetRatingSummaryResponse{}, err
+	}
+	return summary, nil
+}
+
+// SearchReviewText returns the reviews whose text best matches a query,
+// optionally only those of a restaurant or within a range of ratings, with
+// snippets of the text around the matches.
+func (s *Review) SearchReviewText(ctx context.Context, req *review.SearchReviewTextRequest) (*review.SearchReviewTextResponse, error) {
+	s.lock.Lock()
+	err := s.ensureIndex(ctx)
+	s.lock.Unlock()
+	if err != nil {
+		return &review.SearchReviewTextResponse{}, err
+	}
+
+	matches, err := s.searchReviewText(ctx, req)
+	if err != nil {
+		return &review.SearchReviewTextResponse{}, err
+	}
+	return &review.SearchReviewTextResponse{Matches: matches}, nil
+}
+
+// DeleteRestaurantReviews deletes every review of a restaurant, and those
+// held for moderation, as when the restaurant itself is deleted, and removes
+// them from the cache.
+func (s *Review) DeleteRestaurantReviews(ctx context.Context, req *review.DeleteRestaurantReviewsRequest) (*review.DeleteRestaurantReviewsResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.ensureIndex(ctx); err != nil {
+		return &review.DeleteRestaurantReviewsResponse{}, err
+	}
+	ids, err := s.deleteIndexed(ctx, req.GetRestaurantName())
+	if err == nil {
+		err = s.discardRestaurantHeld(ctx, req.GetRestaurantName())
+	}
+	if err != nil {
+		return &review.DeleteRestaurantReviewsResponse{}, err
+	}
+	for _, id := range ids {
+		s.invalidate(ctx, id)
+	}
+	return &review.DeleteRestaurantReviewsResponse{Reviews: int32(len(ids))}, nil
+}
+
+// RebuildLookupTable rewrites the stored restaurant to review indexes from
+// every review in the database.
+func (s *Review) RebuildLookupTable(ctx context.Context, req *review.RebuildLookupTableRequest) (*review.RebuildLookupTableResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	count, err := s.rebuildIndex(ctx)
+	if err != nil {
+		return &review.RebuildLookupTableResponse{}, err
+	}
+	s.indexChecked = true
+	return &review.RebuildLookupTableResponse{Reviews: int32(count)}, status.Error(codes.OK, "Lookup table rebuilt!")
 }
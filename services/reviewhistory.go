@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// revisionsPrefix prefixes the database keys of the earlier revisions of
+// reviews, keyed by the review's ID and then the revision number, padded so
+// that revisions sort in order.
+const revisionsPrefix = internalKeyPrefix + "revisions:"
+
+// revisionPrefix returns the prefix of the keys of a review's revisions.
+func revisionPrefix(reviewID string) string {
+	return revisionsPrefix + reviewID + ":"
+}
+
+// revisionWrites returns the write that keeps a review as a revision before
+// it is replaced, none if there is no review.
+func revisionWrites(reviewID string, before *review.GetReviewResponse) []*mydatabase.WriteOperation {
+	if before == nil {
+		return nil
+	}
+	data, _ := proto.Marshal(before)
+	key := revisionPrefix(reviewID) + fmt.Sprintf("%010d", before.GetRevision())
+	return []*mydatabase.WriteOperation{{Record: &mydatabase.DatabaseRecord{Key: key, Value: data}}}
+}
+
+// reviewHistory returns every revision of a review, oldest first and ending
+// with the review as it is, all read at one snapshot.
+func (s *Review) reviewHistory(ctx context.Context, reviewID string) ([]*review.GetReviewResponse, error) {
+	reply, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: reviewID})
+	if status.Code(err) == codes.NotFound {
+		return nil, status.Error(codes.NotFound, "Review does not exist")
+	}
+	if err != nil {
+		return nil, err
+	}
+	current, err := decodeReview(reply.GetRecord())
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []*review.GetReviewResponse
+	_, err = scanAt(ctx, s.reviewDatabaseClient, revisionPrefix(reviewID), "", reply.GetSnapshotVersion(), func(record *mydatabase.DatabaseRecord) error {
+		r, err := decodeReview(record)
+		if err != nil {
+			return err
+		}
+		revisions = append(revisions, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(revisions, current), nil
+}
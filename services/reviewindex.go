@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// reviewIndexPrefix prefixes the database key of each restaurant's review
+// index, which lists the IDs of the restaurant's reviews.
+const reviewIndexPrefix = internalKeyPrefix + "reviewindex:"
+
+// maxWriteConflicts bounds how often a read-modify-write is retried when
+// another writer gets in first.
+const maxWriteConflicts = 10
+
+// readIndex reads a restaurant's review index as of snapshot (zero reads the
+// latest). It returns the IDs, the version of the index record (zero if there
+// is none), and the snapshot the read was served at.
+func (s *Review) readIndex(ctx context.Context, restaurantName string, snapshot uint64) ([]string, uint64, uint64, error) {
+	reply, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: reviewIndexPrefix + restaurantName, SnapshotVersion: snapshot})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound:
+		return nil, 0, reply.GetSnapshotVersion(), nil
+	default:
+		return nil, 0, 0, err
+	}
+	index := &review.ReviewIndex{}
+	if err := proto.Unmarshal(reply.GetRecord().GetValue(), index); err != nil {
+		return nil, 0, 0, status.Errorf(codes.DataLoss, "Review index of %s could not be decoded: %v", restaurantName, err)
+	}
+	return index.GetReviewIds(), reply.GetRecord().GetVersion(), reply.GetSnapshotVersion(), nil
+}
+
+// indexOperation returns the write that replaces a restaurant's index with
+// ids, provided the index is still at version.
+func indexOperation(restaurantName string, ids []string, version uint64) *mydatabase.WriteOperation {
+	record := &mydatabase.DatabaseRecord{Key: reviewIndexPrefix + restaurantName, Deleted: len(ids) == 0}
+	if len(ids) > 0 {
+		sort.Strings(ids)
+		record.Value, _ = proto.Marshal(&review.ReviewIndex{ReviewIds: ids})
+	}
+	return &mydatabase.WriteOperation{Record: record, ExpectedVersion: proto.Uint64(version)}
+}
+
+// indexReview returns the update that adds a review to its restaurant's
+// index. Where the database can't apply it atomically with the review, it is
+// applied after; a review missing from the index would be lost to searches,
+// while an indexed review that doesn't exist yet is skipped by them.
+func indexReview(restaurantName string, reviewID string) recordUpdate {
+	key := reviewIndexPrefix + restaurantName
+	return recordUpdate{key: key, apply: func(current []byte) ([]byte, error) {
+		index := &review.ReviewIndex{}
+		if err := proto.Unmarshal(current, index); err != nil {
+			return nil, status.Errorf(codes.DataLoss, "Review index of %s could not be decoded: %v", restaurantName, err)
+		}
+		if containsString(index.GetReviewIds(), reviewID) {
+			return current, nil
+		}
+		return indexOperation(restaurantName, append(index.GetReviewIds(), reviewID), 0).GetRecord().GetValue(), nil
+	}}
+}
+
+// deleteIndexed deletes every review in a restaurant's index together with
+// its revisions, votes, postings and shingle keys, the index, the
+// restaurant's orderings and its ratings, atomically, taking the reviews out
+// of the text index's counts and the ratings out of those of every review,
+// and returns the deleted review IDs. A review posted meanwhile changes the index or the review and restarts
+// the deletion. Where the database can't apply the deletes atomically, the
+// reviews are deleted before the index.
+func (s *Review) deleteIndexed(ctx context.Context, restaurantName string) ([]string, error) {
+	for conflicts := 0; ; conflicts++ {
+		ids, version, _, err := s.readIndex(ctx, restaurantName, 0)
+		if err != nil || version == 0 {
+			return nil, err
+		}
+		ratings, ratingsVersion, _, err := s.readRatings(ctx, ratingsPrefix+restaurantName, 0)
+		if err != nil {
+			return nil, err
+		}
+		var ops, postings []*mydatabase.WriteOperation
+		text := &review.TextStats{}
+		for _, id := range ids {
+			reply, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: id})
+			if status.Code(err) == codes.NotFound {
+				ops = append(ops, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: id, Deleted: true}})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			r, err := decodeReview(reply.GetRecord())
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: id, Deleted: true}, ExpectedVersion: proto.Uint64(reply.GetRecord().GetVersion())})
+			for _, prefix := range []string{revisionPrefix(id), votePrefix(id)} {
+				err = scanAll(ctx, s.reviewDatabaseClient, prefix, func(record *mydatabase.DatabaseRecord) error {
+					postings = append(postings, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: record.GetKey(), Deleted: true}})
+					return nil
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+			_, stale := reviewTextWrites(id, r, nil)
+			_, staleShingles := reviewShingleWrites(id, r, nil)
+			postings = append(postings, stale...)
+			postings = append(postings, staleShingles...)
+			textStatsDelta(text, r, -1)
+		}
+		ops = append(ops, postings...)
+		for _, update := range mergeTextStats(restaurantName, text) {
+			op, err := updateOperation(ctx, s.reviewDatabaseClient, update)
+			if err != nil {
+				return nil, err
+			}
+			if op != nil {
+				ops = append(ops, op)
+			}
+		}
+		for order := range review.ReviewSortOrder_name {
+			err := scanAll(ctx, s.reviewDatabaseClient, reviewOrderPrefix(review.ReviewSortOrder(order), restaurantName), func(record *mydatabase.DatabaseRecord) error {
+				ops = append(ops, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: record.GetKey(), Deleted: true}})
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		ops = append(ops, indexOperation(restaurantName, nil, version))
+		if ratingsVersion != 0 {
+			all, err := updateOperation(ctx, s.reviewDatabaseClient, mergeRatings(allRatingsPrefix+aggregateShard(restaurantName), negateRatings(ratings)))
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: ratingsPrefix + restaurantName, Deleted: true}, ExpectedVersion: proto.Uint64(ratingsVersion)}, all)
+		}
+		_, err = s.reviewDatabaseClient.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: ops})
+		if status.Code(err) == codes.Unimplemented {
+			for _, op := range ops {
+				_, err = s.reviewDatabaseClient.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{op}})
+				if err != nil {
+					break
+				}
+			}
+		}
+		switch status.Code(err) {
+		case codes.OK:
+			return ids, nil
+		case codes.Aborted:
+			if conflicts == maxWriteConflicts {
+				return nil, err
+			}
+		default:
+			return nil, err
+		}
+	}
+}
+
+// ensureIndex rebuilds the review indexes, orderings, rating aggregates and
+// text index the first time they are needed if the database holds reviews but
+// is missing any of them, as when the reviews were written before they were
+// stored.
+// Caller must hold s.lock.
+func (s *Review) ensureIndex(ctx context.Context) error {
+	if s.indexChecked {
+		return nil
+	}
+	missing := false
+	for _, prefix := range []string{reviewIndexPrefix, ratingsPrefix, reviewOrderPrefixes[review.ReviewSortOrder_NEWEST], textTermsPrefix} {
+		reply, err := s.reviewDatabaseClient.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{Prefix: prefix, Limit: 1})
+		if err != nil {
+			return err
+		}
+		missing = missing || len(reply.GetRecords()) == 0
+	}
+	if missing {
+		if _, err := s.rebuildIndex(ctx); err != nil {
+			return err
+		}
+	}
+	s.indexChecked = true
+	return nil
+}
+
+// rebuildIndex rewrites every restaurant's index, orderings and rating
+// aggregate, and the text and shingle indexes, from a scan of the reviews and
+// returns the number of reviews indexed. Indexes written after the scan are
+// merged rather than replaced, so concurrent posts aren't lost; aggregates and
+// counts written after it are kept. Ordering entries, postings and shingle
+// keys are only added, as searches skip those that are out of date.
+func (s *Review) rebuildIndex(ctx context.Context) (int, error) {
+	restaurants := make(map[string][]string)
+	ratings := make(map[string]*review.RatingAggregate)
+	text := make(map[string]*review.TextStats)
+	var entries []*mydatabase.WriteOperation
+	var scanned uint64
+	count := 0
+	err := scanAll(ctx, s.reviewDatabaseClient, "", func(record *mydatabase.DatabaseRecord) error {
+		if scanned == 0 || record.GetVersion() > scanned {
+			scanned = record.GetVersion() // newest write seen by the scan
+		}
+		if strings.HasPrefix(record.GetKey(), internalKeyPrefix) {
+			if name, ok := strings.CutPrefix(record.GetKey(), reviewIndexPrefix); ok {
+				if _, seen := restaurants[name]; !seen {
+					restaurants[name] = nil // stale unless reviews are found
+				}
+			}
+			// Aggregates are stale unless reviews are found
+			if hasAnyPrefix(record.GetKey(), ratingsPrefix, allRatingsPrefix) && ratings[record.GetKey()] == nil {
+				ratings[record.GetKey()], _ = decodeRatings(record.GetKey(), nil)
+			}
+			if strings.HasPrefix(record.GetKey(), textStatsPrefix) && text[record.GetKey()] == nil {
+				text[record.GetKey()] = &review.TextStats{}
+			}
+			return nil
+		}
+		r, err := decodeReview(record)
+		if err != nil {
+			return err
+		}
+		for _, key := range reviewOrderKeys(record.GetKey(), r) {
+			entries = append(entries, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Value: []byte(record.GetKey())}})
+		}
+		postings, _ := reviewTextWrites(record.GetKey(), nil, r)
+		shingles, _ := reviewShingleWrites(record.GetKey(), nil, r)
+		entries = append(entries, postings...)
+		entries = append(entries, shingles...)
+		restaurants[r.GetRestaurantName()] = append(restaurants[r.GetRestaurantName()], record.GetKey())
+		shard := aggregateShard(r.GetRestaurantName())
+		if text[textStatsPrefix+shard] == nil {
+			text[textStatsPrefix+shard] = &review.TextStats{}
+		}
+		textStatsDelta(text[textStatsPrefix+shard], r, 1)
+		for _, key := range []string{ratingsPrefix + r.GetRestaurantName(), allRatingsPrefix + shard} {
+			if ratings[key] == nil {
+				ratings[key], _ = decodeRatings(key, nil)
+			}
+			addRating(ratings[key], r, 1)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := writeWithUpdates(ctx, s.reviewDatabaseClient, entries, nil); err != nil {
+		return 0, err
+	}
+	if err := s.rebuildTextStats(ctx, text, scanned); err != nil {
+		return 0, err
+	}
+
+	for key, aggregate := range ratings {
+		for conflicts := 0; ; conflicts++ {
+			_, version, _, err := s.readRatings(ctx, key, 0)
+			if err != nil {
+				return 0, err
+			}
+			if version > scanned || (version == 0 && aggregate.GetCount() == 0) {
+				break // changed since the scan, or nothing to write
+			}
+			value := encodeRatings(aggregate)
+			record := &mydatabase.DatabaseRecord{Key: key, Value: value, Deleted: value == nil}
+			_, err = s.reviewDatabaseClient.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{{Record: record, ExpectedVersion: proto.Uint64(version)}}})
+			if err == nil {
+				break
+			}
+			if status.Code(err) != codes.Aborted || conflicts == maxWriteConflicts {
+				return 0, err
+			}
+		}
+	}
+
+	for name, ids := range restaurants {
+		for conflicts := 0; ; conflicts++ {
+			current, version, _, err := s.readIndex(ctx, name, 0)
+			if err != nil {
+				return 0, err
+			}
+			rebuilt := ids
+			if version > scanned {
+				// changed since the scan, keep what was added
+				rebuilt = mergeStrings(ids, current)
+			}
+			if version == 0 && len(rebuilt) == 0 {
+				break
+			}
+			_, err = s.reviewDatabaseClient.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{indexOperation(name, rebuilt, version)}})
+			if err == nil {
+				break
+			}
+			if status.Code(err) != codes.Aborted || conflicts == maxWriteConflicts {
+				return 0, err
+			}
+		}
+	}
+	return count, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeStrings returns the union of two lists without duplicates.
+func mergeStrings(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var merged []string
+	for _, value := range append(append([]string(nil), a...), b...) {
+		if !seen[value] {
+			seen[value] = true
+			merged = append(merged, value)
+		}
+	}
+	return merged
+}
+
+func hasAnyPrefix(value string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// shinglesPrefix prefixes the database keys that find published reviews
+// alike in text: one per band of a review's MinHash signature, keyed by the
+// band, its hash and the review's ID, with the review's ID as the value.
+const shinglesPrefix = internalKeyPrefix + "review-shingles:"
+
+const (
+	// shingleWords is the number of consecutive words in a shingle, the
+	// pieces of text that reviews are compared by.
+	shingleWords = 2
+	// minShingledWords is the fewest words a review is compared with others
+	// in; short reviews are often alike without being copied.
+	minShingledWords = 8
+	// minHashes is the length of a review's MinHash signature, compared
+	// minHashRows at a time: reviews with a band of the signature in common
+	// are likely to share many shingles.
+	minHashes   = 16
+	minHashRows = 2
+	// nearDuplicateSimilarity is the least fraction of their shingles two
+	// reviews have in common to be near duplicates.
+	nearDuplicateSimilarity = 0.5
+)
+
+// shingles returns the set of shingles of a review's text, ignoring stop
+// words, or none if it has fewer than minShingledWords words.
+func shingles(text string) map[string]bool {
+	var terms []string
+	for _, word := range textWords(text) {
+		if word.term != "" {
+			terms = append(terms, word.term)
+		}
+	}
+	if len(terms) < minShingledWords {
+		return nil
+	}
+	set := make(map[string]bool)
+	for i := 0; i+shingleWords <= len(terms); i++ {
+		set[strings.Join(terms[i:i+shingleWords], " ")] = true
+	}
+	return set
+}
+
+// mixHash scrambles the bits of a hash, deriving an independent one.
+func mixHash(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// shingleKeys returns the keys that find a review by its shingles, none if
+// the review is nil, deleted or too short to compare.
+func shingleKeys(reviewID string, r *review.GetReviewResponse) []string {
+	if r == nil || r.GetDeleted() {
+		return nil
+	}
+	set := shingles(r.GetReview())
+	if len(set) == 0 {
+		return nil
+	}
+	var signature [minHashes]uint64
+	for i := range signature {
+		signature[i] = ^uint64(0)
+	}
+	for shingle := range set {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		hash := h.Sum64()
+		for i := range signature {
+			signature[i] = min(signature[i], mixHash(hash^uint64(i+1)*0x9e3779b97f4a7c15))
+		}
+	}
+	var keys []string
+	for band := 0; band < minHashes/minHashRows; band++ {
+		var rows []byte
+		for _, value := range signature[band*minHashRows : (band+1)*minHashRows] {
+			rows = binary.BigEndian.AppendUint64(rows, value)
+		}
+		h := fnv.New64a()
+		h.Write(rows)
+		keys = append(keys, fmt.Sprintf("%s%d:%016x:%s", shinglesPrefix, band, h.Sum64(), reviewID))
+	}
+	return keys
+}
+
+// bandPrefix returns the prefix of a shingle key that reviews with the same
+// band of their signature share.
+func bandPrefix(key string) string {
+	return key[:strings.LastIndex(key, ":")+1]
+}
+
+// reviewShingleWrites returns the writes that bring a review's shingle keys
+// in line with it changing from before to after, either of which may be
+// nil: the keys to write, which should precede the review, and the stale
+// ones to delete, which should follow it.
+func reviewShingleWrites(reviewID string, before *review.GetReviewResponse, after *review.GetReviewResponse) ([]*mydatabase.WriteOperation, []*mydatabase.WriteOperation) {
+	stale, current := shingleKeys(reviewID, before), shingleKeys(reviewID, after)
+	var puts, deletes []*mydatabase.WriteOperation
+	for _, key := range current {
+		if !containsString(stale, key) {
+			puts = append(puts, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Value: []byte(reviewID)}})
+		}
+	}
+	for _, key := range stale {
+		if !containsString(current, key) {
+			deletes = append(deletes, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Deleted: true}})
+		}
+	}
+	return puts, deletes
+}
+
+// shingleSimilarity returns the fraction of the shingles of two texts that
+// they have in common.
+func shingleSimilarity(a map[string]bool, b map[string]bool) float64 {
+	shared := 0
+	for shingle := range a {
+		if b[shingle] {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return 0
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// nearDuplicate returns the published review, other than reviewID, that
+// is most alike in text to r if any is a near duplicate of it, and how alike
+// they are. Reviews are compared as they are now, so keys left by reviews
+// that have since changed only find them needlessly.
+func (s *Review) nearDuplicate(ctx context.Context, reviewID string, r *review.GetReviewResponse) (*review.GetReviewResponse, float64, error) {
+	set := shingles(r.GetReview())
+	candidates := make(map[string]bool)
+	for _, key := range shingleKeys(reviewID, r) {
+		err := scanAll(ctx, s.reviewDatabaseClient, bandPrefix(key), func(record *mydatabase.DatabaseRecord) error {
+			if id := string(record.GetValue()); id != reviewID {
+				candidates[id] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	var closest *review.GetReviewResponse
+	similarity := 0.0
+	for id := range candidates {
+		reply, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: id})
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		other, err := decodeReview(reply.GetRecord())
+		if err != nil {
+			return nil, 0, err
+		}
+		if other.GetDeleted() {
+			continue
+		}
+		if alike := shingleSimilarity(set, shingles(other.GetReview())); alike >= nearDuplicateSimilarity && alike > similarity {
+			closest, similarity = other, alike
+		}
+	}
+	return closest, similarity, nil
+}
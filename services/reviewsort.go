@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Prefixes of the database keys of the review orderings. Each review has one
+// entry in each, keyed by its restaurant, then by what it is ordered by, then
+// its ID, which is the entry's value. Keys sort in the order reviews are
+// listed in, so a page is a scan.
+var reviewOrderPrefixes = map[review.ReviewSortOrder]string{
+	review.ReviewSortOrder_NEWEST:        internalKeyPrefix + "reviews-newest:",
+	review.ReviewSortOrder_HIGHEST_RATED: internalKeyPrefix + "reviews-highest:",
+	review.ReviewSortOrder_LOWEST_RATED:  internalKeyPrefix + "reviews-lowest:",
+	review.ReviewSortOrder_MOST_HELPFUL:  internalKeyPrefix + "reviews-helpful:",
+}
+
+// reviewOrderPrefix returns the prefix of the entries ordering a restaurant's
+// reviews.
+func reviewOrderPrefix(order review.ReviewSortOrder, restaurantName string) string {
+	return reviewOrderPrefixes[order] + url.QueryEscape(restaurantName) + ":"
+}
+
+// reviewOrderKey returns the key of a review's entry in an ordering.
+func reviewOrderKey(order review.ReviewSortOrder, reviewID string, r *review.GetReviewResponse) string {
+	newest := fmt.Sprintf("%019d", math.MaxInt64-r.GetPostedAt())
+	var sortBy string
+	switch order {
+	case review.ReviewSortOrder_HIGHEST_RATED:
+		sortBy = fmt.Sprintf("%d:", maxRating-r.GetRating())
+	case review.ReviewSortOrder_LOWEST_RATED:
+		sortBy = fmt.Sprintf("%d:", r.GetRating())
+	case review.ReviewSortOrder_MOST_HELPFUL:
+		sortBy = fmt.Sprintf("%019d:", math.MaxInt64-r.GetHelpfulCount())
+	}
+	return reviewOrderPrefix(order, r.GetRestaurantName()) + sortBy + newest + ":" + reviewID
+}
+
+// reviewOrderKeys returns the keys of a review's entries, none if it is nil
+// or deleted.
+func reviewOrderKeys(reviewID string, r *review.GetReviewResponse) []string {
+	if r == nil || r.GetDeleted() {
+		return nil
+	}
+	var keys []string
+	for order := range review.ReviewSortOrder_name {
+		keys = append(keys, reviewOrderKey(review.ReviewSortOrder(order), reviewID, r))
+	}
+	return keys
+}
+
+// reviewOrderWrites returns the writes that move a review's entries from
+// where they were before a change to where they are after, either of which
+// may be nil. The entries added come first and those removed last, so that
+// where they can't be applied atomically a review is never missing from an
+// ordering; searches skip entries that are out of date.
+func reviewOrderWrites(reviewID string, before *review.GetReviewResponse, after *review.GetReviewResponse, record *mydatabase.WriteOperation) []*mydatabase.WriteOperation {
+	stale, current := reviewOrderKeys(reviewID, before), reviewOrderKeys(reviewID, after)
+	var writes []*mydatabase.WriteOperation
+	for _, key := range current {
+		if !containsString(stale, key) {
+			writes = append(writes, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Value: []byte(reviewID)}})
+		}
+	}
+	writes = append(writes, record)
+	for _, key := range stale {
+		if !containsString(current, key) {
+			writes = append(writes, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Deleted: true}})
+		}
+	}
+	return writes
+}
+
+// decodeReview decodes a stored review. Reviews stored before they had a
+// posting time or revisions are taken to be posted when they were written,
+// and not to have changed since.
+func decodeReview(record *mydatabase.DatabaseRecord) (*review.GetReviewResponse, error) {
+	r := &review.GetReviewResponse{}
+	if err := proto.Unmarshal(record.GetValue(), r); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Review %s could not be decoded: %v", record.GetKey(), err)
+	}
+	if r.PostedAt == 0 {
+		r.PostedAt = record.GetTimestamp()
+	}
+	if r.Revision == 0 {
+		r.Revision = 1
+	}
+	return r, nil
+}
+
+// reviewPage returns a page of a restaurant's reviews in the requested
+// order, all read at one snapshot, and the token of the next page. Entries
+// that are out of date are skipped, so a page may be short.
+func (s *Review) reviewPage(ctx context.Context, req *review.SearchReviewsRequest) ([]*review.GetReviewResponse, string, error) {
+	if _, ok := reviewOrderPrefixes[req.GetSortOrder()]; !ok {
+		return nil, "", status.Errorf(codes.InvalidArgument, "Unknown sort_order: %v", req.GetSortOrder())
+	}
+	pageSize := req.GetPageSize()
+	switch {
+	case pageSize < 0:
+		return nil, "", status.Errorf(codes.InvalidArgument, "Invalid page_size: %d", pageSize)
+	case pageSize > maxSearchPageSize:
+		pageSize = maxSearchPageSize
+	}
+	prefix := reviewOrderPrefix(req.GetSortOrder(), req.GetRestaurantName())
+	if req.GetPageToken() != "" && !strings.HasPrefix(req.GetPageToken(), prefix) {
+		return nil, "", status.Error(codes.InvalidArgument, "The page_token is not from this search")
+	}
+
+	reply, err := s.reviewDatabaseClient.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{Prefix: prefix, StartAfter: req.GetPageToken(), Limit: pageSize})
+	if err != nil {
+		return nil, "", err
+	}
+	var page []*review.GetReviewResponse
+	for _, entry := range reply.GetRecords() {
+		reviewID := string(entry.GetValue())
+		record, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: reviewID, SnapshotVersion: reply.GetSnapshotVersion()})
+		if status.Code(err) == codes.NotFound {
+			continue // written before the review, only possible across shards
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		r, err := decodeReview(record.GetRecord())
+		if err != nil {
+			return nil, "", err
+		}
+		if !r.GetDeleted() && reviewOrderKey(req.GetSortOrder(), reviewID, r) == entry.GetKey() {
+			page = append(page, r)
+		}
+	}
+	return page, reply.GetNextStartAfter(), nil
+}
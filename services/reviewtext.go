@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"html"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Keys of the text index of reviews. Each review has a posting for each term
+// of its text, keyed by the term and then the review's ID, and the index
+// keeps a count of its reviews and their terms for ranking, split into
+// aggregateShards counts under textStatsPrefix.
+const (
+	textTermsPrefix = internalKeyPrefix + "reviewterms:"
+	textStatsPrefix = internalKeyPrefix + "reviewtext-stats:"
+)
+
+const (
+	// BM25 parameters: how quickly repeating a term stops adding to a
+	// review's score, and how much longer reviews are penalized for it.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// snippetWords is how many words a snippet holds, of which
+	// snippetContext come before the first match.
+	snippetWords   = 20
+	snippetContext = 5
+)
+
+// stopWords are words too common to be worth indexing.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true,
+	"by": true, "for": true, "if": true, "in": true, "into": true, "is": true, "it": true, "no": true,
+	"not": true, "of": true, "on": true, "or": true, "such": true, "that": true, "the": true,
+	"their": true, "then": true, "there": true, "these": true, "they": true, "this": true, "to": true,
+	"was": true, "will": true, "with": true, "i": true, "we": true, "you": true, "my": true,
+	"our": true, "its": true, "were": true, "has": true, "have": true, "had": true, "so": true,
+}
+
+// textWord is a word of a text: its position as text[start:end], and the
+// term it is indexed as, empty for a stop word.
+type textWord struct {
+	start, end int
+	term       string
+}
+
+// textWords splits a text into words: runs of letters and digits, which may
+// hold apostrophes ("don't"). Terms are in lower case without apostrophes,
+// and stemmed.
+func textWords(text string) []textWord {
+	isWordRune := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	var words []textWord
+	start, end := -1, 0
+	emit := func() {
+		term := strings.ToLower(strings.NewReplacer("'", "", "’", "").Replace(text[start:end]))
+		if stopWords[term] {
+			term = ""
+		}
+		words = append(words, textWord{start: start, end: end, term: stem(term)})
+		start = -1
+	}
+	for i, r := range text {
+		switch {
+		case isWordRune(r):
+			if start < 0 {
+				start = i
+			}
+			end = i + utf8.RuneLen(r)
+		case start >= 0 && (r == '\'' || r == '’'):
+			if next, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(r):]); !isWordRune(next) {
+				emit()
+			}
+		case start >= 0:
+			emit()
+		}
+	}
+	if start >= 0 {
+		emit()
+	}
+	return words
+}
+
+// textTerms returns how often a text uses each term, and how many terms it
+// has.
+func textTerms(text string) (map[string]int32, int32) {
+	terms := make(map[string]int32)
+	var length int32
+	for _, word := range textWords(text) {
+		if word.term != "" {
+			terms[word.term]++
+			length++
+		}
+	}
+	return terms, length
+}
+
+// postingKey returns the key of a review's posting for a term.
+func postingKey(term string, reviewID string) string {
+	return textTermsPrefix + url.QueryEscape(term) + ":" + reviewID
+}
+
+// textPostings returns a review's postings by key, none if it is nil or
+// deleted.
+func textPostings(reviewID string, r *review.GetReviewResponse) map[string]*review.TextPosting {
+	if r == nil || r.GetDeleted() {
+		return nil
+	}
+	terms, length := textTerms(r.GetReview())
+	postings := make(map[string]*review.TextPosting, len(terms))
+	for term, frequency := range terms {
+		postings[postingKey(term, reviewID)] = &review.TextPosting{TermFrequency: frequency, ReviewLength: length, RestaurantName: r.GetRestaurantName(), Rating: r.GetRating()}
+	}
+	return postings
+}
+
+// reviewTextWrites returns the writes that bring a review's postings in line
+// with it changing from before to after, either of which may be nil: the
+// postings to write, which should precede the review, and the stale ones to
+// delete, which should follow it.
+func reviewTextWrites(reviewID string, before *review.GetReviewResponse, after *review.GetReviewResponse) ([]*mydatabase.WriteOperation, []*mydatabase.WriteOperation) {
+	stale, current := textPostings(reviewID, before), textPostings(reviewID, after)
+	var puts, deletes []*mydatabase.WriteOperation
+	for key, posting := range current {
+		if !proto.Equal(posting, stale[key]) {
+			value, _ := proto.Marshal(posting)
+			puts = append(puts, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Value: value}})
+		}
+	}
+	for key := range stale {
+		if current[key] == nil {
+			deletes = append(deletes, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: key, Deleted: true}})
+		}
+	}
+	return puts, deletes
+}
+
+// textStatsDelta adds sign times a review to a count of reviews and terms,
+// unless it is deleted.
+func textStatsDelta(stats *review.TextStats, r *review.GetReviewResponse, sign int64) {
+	if r == nil || r.GetDeleted() {
+		return
+	}
+	_, length := textTerms(r.GetReview())
+	stats.Reviews += sign
+	stats.Terms += sign * int64(length)
+}
+
+// decodeTextStats decodes a shard of the text index's counts, which is empty
+// if value is nil.
+func decodeTextStats(value []byte) (*review.TextStats, error) {
+	stats := &review.TextStats{}
+	if err := proto.Unmarshal(value, stats); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Text index counts could not be decoded: %v", err)
+	}
+	return stats, nil
+}
+
+// mergeTextStats returns the update that adds delta to the shard of the text
+// index's counts that counts a restaurant's reviews, or none if delta is
+// empty.
+func mergeTextStats(restaurantName string, delta *review.TextStats) []recordUpdate {
+	if delta.GetReviews() == 0 && delta.GetTerms() == 0 {
+		return nil
+	}
+	return []recordUpdate{{key: textStatsPrefix + aggregateShard(restaurantName), apply: func(current []byte) ([]byte, error) {
+		stats, err := decodeTextStats(current)
+		if err != nil {
+			return nil, err
+		}
+		stats.Reviews += delta.GetReviews()
+		stats.Terms += delta.GetTerms()
+		if stats.Reviews <= 0 {
+			return nil, nil
+		}
+		return proto.Marshal(stats)
+	}}}
+}
+
+// textStatsUpdates returns the updates that bring the text index's counts in
+// line with a review changing from before to after, either of which may be
+// nil.
+func textStatsUpdates(before *review.GetReviewResponse, after *review.GetReviewResponse) []recordUpdate {
+	delta := &review.TextStats{}
+	textStatsDelta(delta, before, -1)
+	textStatsDelta(delta, after, 1)
+	restaurantName := after.GetRestaurantName()
+	if after == nil {
+		restaurantName = before.GetRestaurantName()
+	}
+	return mergeTextStats(restaurantName, delta)
+}
+
+// readTextStats reads the text index's counts, summing their shards, and
+// returns them with the snapshot the read was served at.
+func (s *Review) readTextStats(ctx context.Context) (*review.TextStats, uint64, error) {
+	stats := &review.TextStats{}
+	snapshot, err := scanAt(ctx, s.reviewDatabaseClient, textStatsPrefix, "", 0, func(record *mydatabase.DatabaseRecord) error {
+		shard, err := decodeTextStats(record.GetValue())
+		if err != nil {
+			return err
+		}
+		stats.Reviews += shard.GetReviews()
+		stats.Terms += shard.GetTerms()
+		return nil
+	})
+	return stats, snapshot, err
+}
+
+// rebuildTextStats replaces each shard of the text index's counts, keyed by
+// its key, with the counts of a scan, unless it was written after it.
+func (s *Review) rebuildTextStats(ctx context.Context, shards map[string]*review.TextStats, scanned uint64) error {
+	for key, stats := range shards {
+		for conflicts := 0; ; conflicts++ {
+			reply, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: key})
+			if err != nil && status.Code(err) != codes.NotFound {
+				return err
+			}
+			version := reply.GetRecord().GetVersion()
+			if version > scanned || (version == 0 && stats.GetReviews() == 0) {
+				break // changed since the scan, or nothing to write
+			}
+			record := &mydatabase.DatabaseRecord{Key: key, Deleted: stats.GetReviews() == 0}
+			if !record.Deleted {
+				record.Value, _ = proto.Marshal(stats)
+			}
+			_, err = s.reviewDatabaseClient.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{{Record: record, ExpectedVersion: proto.Uint64(version)}}})
+			if err == nil {
+				break
+			}
+			if status.Code(err) != codes.Aborted || conflicts == maxWriteConflicts {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// searchReviewText returns the reviews in a search's scope that best match
+// its query by BM25, most relevant first, all read at one snapshot.
+func (s *Review) searchReviewText(ctx context.Context, req *review.SearchReviewTextRequest) ([]*review.ReviewTextMatch, error) {
+	limit := int(req.GetLimit())
+	switch {
+	case limit < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid limit: %d", limit)
+	case limit == 0:
+		limit = defaultSearchPageSize
+	case limit > maxSearchPageSize:
+		limit = maxSearchPageSize
+	}
+	minRating, maxRating := req.GetMinRating(), req.GetMaxRating()
+	for _, rating := range []int32{minRating, maxRating} {
+		if rating != 0 {
+			if err := validateRating(rating); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if maxRating == 0 {
+		maxRating = math.MaxInt32
+	}
+	if minRating > maxRating {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid rating range: %d to %d", minRating, maxRating)
+	}
+	query, _ := textTerms(req.GetQuery())
+	if len(query) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "The query has no words to search for")
+	}
+
+	stats, snapshot, err := s.readTextStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	averageLength := 1.0
+	if stats.GetReviews() > 0 && stats.GetTerms() > 0 {
+		averageLength = float64(stats.GetTerms()) / float64(stats.GetReviews())
+	}
+
+	// Score every review in scope using a term of the query. How many
+	// reviews use a term counts those out of scope too.
+	scores := make(map[string]float64)
+	postings := make(map[string]*review.TextPosting)
+	for term := range query {
+		prefix := textTermsPrefix + url.QueryEscape(term) + ":"
+		matched := make(map[string]*review.TextPosting)
+		_, err := scanAt(ctx, s.reviewDatabaseClient, prefix, "", snapshot, func(record *mydatabase.DatabaseRecord) error {
+			posting := &review.TextPosting{}
+			if err := proto.Unmarshal(record.GetValue(), posting); err != nil {
+				return status.Errorf(codes.DataLoss, "Posting %s could not be decoded: %v", record.GetKey(), err)
+			}
+			matched[strings.TrimPrefix(record.GetKey(), prefix)] = posting
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		n, df := float64(max(stats.GetReviews(), int64(len(matched)))), float64(len(matched))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for reviewID, posting := range matched {
+			if (req.GetRestaurantName() != "" && posting.GetRestaurantName() != req.GetRestaurantName()) || posting.GetRating() < minRating || posting.GetRating() > maxRating {
+				continue
+			}
+			tf := float64(posting.GetTermFrequency())
+			scores[reviewID] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(posting.GetReviewLength())/averageLength))
+			postings[postingKey(term, reviewID)] = posting
+		}
+	}
+	ranked := make([]string, 0, len(scores))
+	for reviewID := range scores {
+		ranked = append(ranked, reviewID)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+
+	var matches []*review.ReviewTextMatch
+	for _, reviewID := range ranked {
+		if len(matches) == limit {
+			break
+		}
+		record, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: reviewID, SnapshotVersion: snapshot})
+		if status.Code(err) == codes.NotFound {
+			continue // indexed before it was written, only possible across shards
+		}
+		if err != nil {
+			return nil, err
+		}
+		r, err := decodeReview(record.GetRecord())
+		if err != nil {
+			return nil, err
+		}
+		if !postingsCurrent(reviewID, r, query, postings) {
+			continue
+		}
+		matches = append(matches, &review.ReviewTextMatch{Review: r, Score: scores[reviewID], Snippet: snippet(r.GetReview(), query)})
+	}
+	return matches, nil
+}
+
+// postingsCurrent reports whether the postings a review was scored by for
+// the terms of a query are those of the review as it is, rather than out of
+// date.
+func postingsCurrent(reviewID string, r *review.GetReviewResponse, query map[string]int32, scored map[string]*review.TextPosting) bool {
+	current := textPostings(reviewID, r)
+	for term := range query {
+		key := postingKey(term, reviewID)
+		if !proto.Equal(current[key], scored[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// snippet returns the words of a text around the first that matches a term
+// of a query, HTML escaped, with those that match in <em> tags.
+func snippet(text string, query map[string]int32) string {
+	words := textWords(text)
+	first := 0
+	for i, word := range words {
+		if query[word.term] > 0 {
+			first = i
+			break
+		}
+	}
+	start := max(0, first-snippetContext)
+	end := min(len(words), start+snippetWords)
+	if start == end {
+		return ""
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	next := words[start].start
+	for _, word := range words[start:end] {
+		b.WriteString(html.EscapeString(text[next:word.start]))
+		if query[word.term] > 0 {
+			b.WriteString("<em>" + html.EscapeString(text[word.start:word.end]) + "</em>")
+		} else {
+			b.WriteString(html.EscapeString(text[word.start:word.end]))
+		}
+		next = word.end
+	}
+	if end < len(words) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// votesPrefix prefixes the database keys of the users who found reviews
+// helpful, keyed by the review's ID and then the voter's name. A review's
+// count of them is kept in the review itself.
+const votesPrefix = internalKeyPrefix + "review-votes:"
+
+// votePrefix returns the prefix of the keys of a review's votes.
+func votePrefix(reviewID string) string {
+	return votesPrefix + reviewID + ":"
+}
+
+// readVote returns the version of a user's vote for a review, zero if they
+// haven't voted for it.
+func (s *Review) readVote(ctx context.Context, reviewID string, voterName string) (uint64, error) {
+	reply, err := s.reviewDatabaseClient.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: votePrefix(reviewID) + voterName})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return 0, err
+	}
+	return reply.GetRecord().GetVersion(), nil
+}
+
+// readVotes returns the keys of a review's votes, in order.
+func (s *Review) readVotes(ctx context.Context, reviewID string) ([]string, error) {
+	var keys []string
+	err := scanAll(ctx, s.reviewDatabaseClient, votePrefix(reviewID), func(record *mydatabase.DatabaseRecord) error {
+		keys = append(keys, record.GetKey())
+		return nil
+	})
+	return keys, err
+}
+
+// voteOperation returns the write that records a user's vote for a review,
+// provided they haven't voted, or with retract the one that takes it back,
+// provided the vote is still at version.
+func voteOperation(reviewID string, voterName string, retract bool, version uint64) *mydatabase.WriteOperation {
+	record := &mydatabase.DatabaseRecord{Key: votePrefix(reviewID) + voterName, Deleted: retract}
+	if !retract {
+		version = 0
+	}
+	return &mydatabase.WriteOperation{Record: record, ExpectedVersion: proto.Uint64(version)}
+}
+
+// onlyVotesChanged reports whether a review differs from before only in its
+// votes, a change that isn't kept as a revision.
+func onlyVotesChanged(before *review.GetReviewResponse, after *review.GetReviewResponse) bool {
+	if before == nil || after == nil {
+		return false
+	}
+	before, after = proto.Clone(before).(*review.GetReviewResponse), proto.Clone(after).(*review.GetReviewResponse)
+	before.HelpfulCount, after.HelpfulCount = 0, 0
+	return proto.Equal(before, after)
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	apps "cse190-welp/applications"
+	"cse190-welp/proto/mydatabase"
+)
+
+// Scrubber checks every replica of a database for corrupt records, and can
+// repair the damaged keys by writing back a good copy from another source.
+type Scrubber struct {
+	name         string
+	databaseAddr string
+	repairFrom   string
+}
+
+// NewScrubber creates a new instance of Scrubber.
+// name: The name of the scrubber.
+// databaseAddr: The database to scrub, in any form accepted by the services.
+// repairFrom: Where to read good copies of damaged keys from: a backup file
+// written by PersistentStorageApp, or the address of another database or
+// replica. Empty only reports damage.
+func NewScrubber(name string, databaseAddr string, repairFrom string) *Scrubber {
+	return &Scrubber{
+		name:         name,
+		databaseAddr: databaseAddr,
+		repairFrom:   repairFrom,
+	}
+}
+
+// Run scrubs every replica once and repairs what it can. It returns an error
+// if any key is left damaged.
+func (s *Scrubber) Run() error {
+	ctx := context.Background()
+
+	// Scrub each replica of each shard on its own, corruption is local.
+	damaged := make(map[string]bool)
+	for _, shard := range strings.Split(s.databaseAddr, ";") {
+		for _, addr := range strings.Split(shard, ",") {
+			client := mydatabase.NewDatabaseServiceClient(dial(addr))
+			reply, err := client.ScrubRecords(ctx, &mydatabase.ScrubRecordsRequest{})
+			if err != nil {
+				return fmt.Errorf("scrubbing %s: %w", addr, err)
+			}
+			log.Printf("scrubber <%s> checked %d versions on %s, quarantined %d", s.name, reply.GetChecked(), addr, len(reply.GetQuarantined()))
+			for _, record := range reply.GetQuarantined() {
+				log.Printf("scrubber <%s> quarantined %s version %d on %s", s.name, record.GetKey(), record.GetVersion(), addr)
+			}
+			for _, key := range reply.GetDamagedKeys() {
+				damaged[key] = true
+			}
+		}
+	}
+	if len(damaged) == 0 {
+		return nil
+	}
+	if s.repairFrom == "" {
+		return fmt.Errorf("%d damaged keys, rerun with a repair source to fix them", len(damaged))
+	}
+
+	source, err := s.repairSource()
+	if err != nil {
+		return err
+	}
+	database := newDatabaseClient(s.databaseAddr)
+	unrepaired := 0
+	for key := range damaged {
+		record, err := source(ctx, key)
+		if err != nil {
+			log.Printf("scrubber <%s> can't repair %s: %v", s.name, key, err)
+			unrepaired++
+			continue
+		}
+		// Writing a new version makes the key readable on every replica again.
+		good := &mydatabase.DatabaseRecord{Key: key, Value: record.GetValue()}
+		good.Checksum = apps.Checksum(good)
+		if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: good}); err != nil {
+			log.Printf("scrubber <%s> failed to repair %s: %v", s.name, key, err)
+			unrepaired++
+			continue
+		}
+		log.Printf("scrubber <%s> repaired %s", s.name, key)
+	}
+	if unrepaired > 0 {
+		return fmt.Errorf("%d of %d damaged keys could not be repaired", unrepaired, len(damaged))
+	}
+	return nil
+}
+
+// repairSource returns a function that looks up a good copy of a key.
+func (s *Scrubber) repairSource() (func(context.Context, string) (*mydatabase.DatabaseRecord, error), error) {
+	if _, err := os.Stat(s.repairFrom); err == nil {
+		backup, err := apps.NewPersistentStorageApp(s.repairFrom)
+		if err != nil {
+			return nil, fmt.Errorf("opening backup %s: %w", s.repairFrom, err)
+		}
+		return func(_ context.Context, key string) (*mydatabase.DatabaseRecord, error) {
+			if record, ok := backup.Get(key); ok {
+				return record, nil
+			}
+			return nil, fmt.Errorf("not in backup %s", s.repairFrom)
+		}, nil
+	}
+
+	replica := newDatabaseClient(s.repairFrom)
+	return func(ctx context.Context, key string) (*mydatabase.DatabaseRecord, error) {
+		reply, err := replica.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: key})
+		if err != nil {
+			return nil, err
+		}
+		if err := apps.VerifyChecksum(reply.GetRecord()); err != nil {
+			return nil, err
+		}
+		return reply.GetRecord(), nil
+	}, nil
+}
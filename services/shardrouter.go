@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	apps "cse190-welp/applications"
+	"cse190-welp/proto/mydatabase"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultVirtualNodes is the number of ring points given to each database shard.
+const DefaultVirtualNodes = 128
+
+// ShardedDatabaseClient splits keys across several databases by consistent
+// hashing. It satisfies mydatabase.DatabaseServiceClient, so services can use
+// it in place of a single database client.
+//
+// The shards are fixed when the client is created. Every replica of a service
+// derives the same layout from the same shard list, so they agree on each
+// key's owner without coordinating. Changing the shards of a deployment moves
+// keys between databases and is not supported while services are running.
+//
+// Commit versions are per shard, so snapshot reads are not supported across
+// shards: every read is served at the latest version and responses carry a
+// zero snapshot version.
+type ShardedDatabaseClient struct {
+	ring   *apps.HashRing // owners of every key
+	shards map[string]mydatabase.DatabaseServiceClient
+}
+
+// NewShardedDatabaseClient creates a router over the given shards, keyed by shard name.
+func NewShardedDatabaseClient(shards map[string]mydatabase.DatabaseServiceClient, virtualNodes int) *ShardedDatabaseClient {
+	c := &ShardedDatabaseClient{
+		ring:   apps.NewHashRing(virtualNodes),
+		shards: make(map[string]mydatabase.DatabaseServiceClient),
+	}
+	for name, client := range shards {
+		c.ring.Add(name)
+		c.shards[name] = client
+	}
+	return c
+}
+
+// Shards returns the names of the shards.
+func (c *ShardedDatabaseClient) Shards() []string {
+	return c.ring.Nodes()
+}
+
+// route returns the client of the shard that owns key.
+func (c *ShardedDatabaseClient) route(key string) mydatabase.DatabaseServiceClient {
+	return c.shards[c.ring.Get(key)]
+}
+
+func (c *ShardedDatabaseClient) SetRecord(ctx context.Context, in *mydatabase.SetRecordRequest, opts ...grpc.CallOption) (*mydatabase.SetRecordResponse, error) {
+	return c.route(in.GetRecord().GetKey()).SetRecord(ctx, in, opts...)
+}
+
+func (c *ShardedDatabaseClient) GetRecord(ctx context.Context, in *mydatabase.GetRecordRequest, opts ...grpc.CallOption) (*mydatabase.GetRecordResponse, error) {
+	req := &mydatabase.GetRecordRequest{Key: in.GetKey()} // latest version only, see the type comment
+	reply, err := c.route(in.GetKey()).GetRecord(ctx, req, opts...)
+	if reply != nil {
+		reply.SnapshotVersion = 0
+	}
+	return reply, err
+}
+
+func (c *ShardedDatabaseClient) DeleteRecord(ctx context.Context, in *mydatabase.DeleteRecordRequest, opts ...grpc.CallOption) (*mydatabase.DeleteRecordResponse, error) {
+	return c.route(in.GetKey()).DeleteRecord(ctx, in, opts...)
+}
+
+func (c *ShardedDatabaseClient) GetRecordHistory(ctx context.Context, in *mydatabase.GetRecordHistoryRequest, opts ...grpc.CallOption) (*mydatabase.GetRecordHistoryResponse, error) {
+	return c.route(in.GetKey()).GetRecordHistory(ctx, in, opts...)
+}
+
+// ScanRecords merges one page from every shard in key order.
+func (c *ShardedDatabaseClient) ScanRecords(ctx context.Context, in *mydatabase.ScanRecordsRequest, opts ...grpc.CallOption) (*mydatabase.ScanRecordsResponse, error) {
+	limit := int(in.GetLimit())
+	if limit <= 0 {
+		limit = defaultScanLimit
+	} else if limit > maxScanLimit {
+		limit = maxScanLimit
+	}
+	req := &mydatabase.ScanRecordsRequest{Prefix: in.GetPrefix(), StartAfter: in.GetStartAfter(), Limit: int32(limit)}
+
+	merged := make(map[string]*mydatabase.DatabaseRecord)
+	truncated := false
+	for _, client := range c.shards {
+		reply, err := client.ScanRecords(ctx, req, opts...)
+		if err != nil {
+			return &mydatabase.ScanRecordsResponse{}, err
+		}
+		for _, record := range reply.GetRecords() {
+			merged[record.Key] = record
+		}
+		truncated = truncated || reply.GetNextStartAfter() != ""
+	}
+
+	// Every shard returned its first `limit` keys, so the first `limit` keys
+	// of the union are complete even where a shard had more.
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+		truncated = true
+	}
+	reply := &mydatabase.ScanRecordsResponse{}
+	for _, key := range keys {
+		reply.Records = append(reply.Records, merged[key])
+	}
+	if truncated && len(keys) > 0 {
+		reply.NextStartAfter = keys[len(keys)-1]
+	}
+	return reply, nil
+}
+
+// WriteBatch applies a batch whose keys all belong to one shard. Batches that
+// span shards can't be atomic and fail with Unimplemented.
+func (c *ShardedDatabaseClient) WriteBatch(ctx context.Context, in *mydatabase.WriteBatchRequest, opts ...grpc.CallOption) (*mydatabase.WriteBatchResponse, error) {
+	var owner mydatabase.DatabaseServiceClient
+	for _, op := range in.GetOperations() {
+		next := c.route(op.GetRecord().GetKey())
+		if owner != nil && next != owner {
+			return &mydatabase.WriteBatchResponse{}, status.Error(codes.Unimplemented, "Batch spans database shards!")
+		}
+		owner = next
+	}
+	if owner == nil {
+		return &mydatabase.WriteBatchResponse{Success: true}, nil
+	}
+	return owner.WriteBatch(ctx, in, opts...)
+}
+
+// ScrubRecords scrubs every shard and combines the results.
+func (c *ShardedDatabaseClient) ScrubRecords(ctx context.Context, in *mydatabase.ScrubRecordsRequest, opts ...grpc.CallOption) (*mydatabase.ScrubRecordsResponse, error) {
+	merged := &mydatabase.ScrubRecordsResponse{}
+	for _, client := range c.shards {
+		reply, err := client.ScrubRecords(ctx, in, opts...)
+		if err != nil {
+			return merged, err
+		}
+		merged.Checked += reply.GetChecked()
+		merged.Quarantined = append(merged.Quarantined, reply.GetQuarantined()...)
+		merged.DamagedKeys = append(merged.DamagedKeys, reply.GetDamagedKeys()...)
+	}
+	sort.Strings(merged.DamagedKeys)
+	return merged, nil
+}
+
+// WatchChanges is not supported across shards because sequence numbers are
+// per shard. Watch each shard's database directly instead.
+func (c *ShardedDatabaseClient) WatchChanges(ctx context.Context, in *mydatabase.WatchChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[mydatabase.ChangeEvent], error) {
+	return nil, status.Error(codes.Unimplemented, "Change streams are per shard, watch each shard instead!")
+}
@@ -0,0 +1,250 @@
+package services
+
+// stem returns the stem of a lower case English word by the Porter stemming
+// algorithm, so that inflections of a word ("rated", "rating", "rates") index
+// as one term ("rate"). Words that aren't plain ASCII letters are returned as
+// they are.
+func stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+	p := &porterStemmer{b: []byte(word), k: len(word) - 1}
+	p.step1ab()
+	if p.k > 0 {
+		p.step1c()
+		p.step2()
+		p.step3()
+		p.step4()
+	}
+	p.step5()
+	return string(p.b[:p.k+1])
+}
+
+// porterStemmer holds a word being stemmed: b[:k+1] is what is left of it,
+// and b[:j+1] what is left before a suffix that matched.
+type porterStemmer struct {
+	b    []byte
+	k, j int
+}
+
+// cons reports whether b[i] is a consonant.
+func (p *porterStemmer) cons(i int) bool {
+	switch p.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !p.cons(i-1)
+	}
+	return true
+}
+
+// m counts the vowel-consonant sequences in b[:j+1].
+func (p *porterStemmer) m() int {
+	n, i := 0, 0
+	for ; i <= p.j && p.cons(i); i++ {
+	}
+	for i <= p.j {
+		for ; i <= p.j && !p.cons(i); i++ {
+		}
+		if i > p.j {
+			break
+		}
+		n++
+		for ; i <= p.j && p.cons(i); i++ {
+		}
+	}
+	return n
+}
+
+// vowelInStem reports whether b[:j+1] has a vowel.
+func (p *porterStemmer) vowelInStem() bool {
+	for i := 0; i <= p.j; i++ {
+		if !p.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doublec reports whether b[i-1:i+1] is a double consonant.
+func (p *porterStemmer) doublec(i int) bool {
+	return i >= 1 && p.b[i] == p.b[i-1] && p.cons(i)
+}
+
+// cvc reports whether b[i-2:i+1] is consonant-vowel-consonant with the last
+// consonant not w, x or y, as at the end of "hop" but not "snow".
+func (p *porterStemmer) cvc(i int) bool {
+	if i < 2 || !p.cons(i) || p.cons(i-1) || !p.cons(i-2) {
+		return false
+	}
+	c := p.b[i]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+// ends reports whether b[:k+1] ends with suffix, setting j to just before it
+// if so.
+func (p *porterStemmer) ends(suffix string) bool {
+	n := len(suffix)
+	if n > p.k+1 || string(p.b[p.k+1-n:p.k+1]) != suffix {
+		return false
+	}
+	p.j = p.k - n
+	return true
+}
+
+// setTo replaces what follows b[:j+1] with s.
+func (p *porterStemmer) setTo(s string) {
+	p.b = append(p.b[:p.j+1], s...)
+	p.k = p.j + len(s)
+}
+
+// replace replaces the suffix ends matched with s if b[:j+1] has a
+// vowel-consonant sequence.
+func (p *porterStemmer) replace(s string) {
+	if p.m() > 0 {
+		p.setTo(s)
+	}
+}
+
+// step1ab removes plurals and -ed or -ing.
+func (p *porterStemmer) step1ab() {
+	if p.b[p.k] == 's' {
+		switch {
+		case p.ends("sses"):
+			p.k -= 2
+		case p.ends("ies"):
+			p.setTo("i")
+		case p.b[p.k-1] != 's':
+			p.k--
+		}
+	}
+	if p.ends("eed") {
+		if p.m() > 0 {
+			p.k--
+		}
+	} else if (p.ends("ed") || p.ends("ing")) && p.vowelInStem() {
+		p.k = p.j
+		switch {
+		case p.ends("at"):
+			p.setTo("ate")
+		case p.ends("bl"):
+			p.setTo("ble")
+		case p.ends("iz"):
+			p.setTo("ize")
+		case p.doublec(p.k):
+			if c := p.b[p.k]; c != 'l' && c != 's' && c != 'z' {
+				p.k--
+			}
+		default:
+			p.j = p.k
+			if p.m() == 1 && p.cvc(p.k) {
+				p.setTo("e")
+			}
+		}
+	}
+}
+
+// step1c turns a final y to i when there is another vowel in the stem.
+func (p *porterStemmer) step1c() {
+	if p.ends("y") && p.vowelInStem() {
+		p.b[p.k] = 'i'
+	}
+}
+
+// porterSuffixes lists a step's suffixes, by a letter of them, and what each
+// is replaced with.
+type porterSuffixes map[byte][][2]string
+
+// step2Suffixes are the double suffixes step 2 shortens, by their second to
+// last letter.
+var step2Suffixes = porterSuffixes{
+	'a': {{"ational", "ate"}, {"tional", "tion"}},
+	'c': {{"enci", "ence"}, {"anci", "ance"}},
+	'e': {{"izer", "ize"}},
+	'l': {{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"}},
+	'o': {{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}},
+	's': {{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"}},
+	't': {{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"}},
+	'g': {{"logi", "log"}},
+}
+
+// step3Suffixes are the suffixes step 3 shortens, by their last letter.
+var step3Suffixes = porterSuffixes{
+	'e': {{"icate", "ic"}, {"ative", ""}, {"alize", "al"}},
+	'i': {{"iciti", "ic"}},
+	'l': {{"ical", "ic"}, {"ful", ""}},
+	's': {{"ness", ""}},
+}
+
+// replaceSuffix replaces the first of the suffixes that b[:k+1] ends with.
+func (p *porterStemmer) replaceSuffix(suffixes [][2]string) {
+	for _, suffix := range suffixes {
+		if p.ends(suffix[0]) {
+			p.replace(suffix[1])
+			return
+		}
+	}
+}
+
+// step2 shortens double suffixes, as -ization to -ize.
+func (p *porterStemmer) step2() {
+	p.replaceSuffix(step2Suffixes[p.b[p.k-1]])
+}
+
+// step3 shortens -ic-, -full and -ness suffixes.
+func (p *porterStemmer) step3() {
+	p.replaceSuffix(step3Suffixes[p.b[p.k]])
+}
+
+// step4Suffixes are the suffixes step 4 removes, by their second to last
+// letter.
+var step4Suffixes = map[byte][]string{
+	'a': {"al"},
+	'c': {"ance", "ence"},
+	'e': {"er"},
+	'i': {"ic"},
+	'l': {"able", "ible"},
+	'n': {"ant", "ement", "ment", "ent"},
+	's': {"ism"},
+	't': {"ate", "iti"},
+	'u': {"ous"},
+	'v': {"ive"},
+	'z': {"ize"},
+}
+
+// step4 removes suffixes such as -ant and -ence from stems long enough to
+// keep their meaning without them.
+func (p *porterStemmer) step4() {
+	matched := false
+	if p.b[p.k-1] == 'o' {
+		matched = (p.ends("ion") && p.j >= 0 && (p.b[p.j] == 's' || p.b[p.j] == 't')) || p.ends("ou")
+	} else {
+		for _, suffix := range step4Suffixes[p.b[p.k-1]] {
+			if matched = p.ends(suffix); matched {
+				break
+			}
+		}
+	}
+	if matched && p.m() > 1 {
+		p.k = p.j
+	}
+}
+
+// step5 removes a final -e and shortens a final -ll where the stem is long
+// enough.
+func (p *porterStemmer) step5() {
+	p.j = p.k
+	if p.b[p.k] == 'e' {
+		if m := p.m(); m > 1 || (m == 1 && !p.cvc(p.k-1)) {
+			p.k--
+		}
+	}
+	if p.b[p.k] == 'l' && p.doublec(p.k) && p.m() > 1 {
+		p.k--
+	}
+}
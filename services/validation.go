@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cse190-welp/proto/detail"
+	"cse190-welp/proto/mycache"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"cse190-welp/proto/review"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validateRequest is a unary server interceptor that checks the fields of a
+// request before it reaches the service, failing with InvalidArgument and a
+// google.rpc.BadRequest detail listing what is wrong with each field.
+// Services still check what depends on what they store.
+func validateRequest(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if violations := requestViolations(req); len(violations) > 0 {
+		return nil, badRequest(violations)
+	}
+	return handler(ctx, req)
+}
+
+// badRequest returns the InvalidArgument error for field violations.
+func badRequest(violations fieldViolations) error {
+	var messages []string
+	for _, violation := range violations {
+		messages = append(messages, violation.GetField()+": "+violation.GetDescription())
+	}
+	st := status.New(codes.InvalidArgument, "Invalid request: "+strings.Join(messages, "; "))
+	if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// fieldViolations collects what is wrong with the fields of a request, each
+// named by its path in the request message.
+type fieldViolations []*errdetails.BadRequest_FieldViolation
+
+// check records a violation of a field unless ok.
+func (v *fieldViolations) check(ok bool, field string, format string, args ...any) {
+	if !ok {
+		*v = append(*v, &errdetails.BadRequest_FieldViolation{Field: field, Description: fmt.Sprintf(format, args...)})
+	}
+}
+
+// require records a violation of a required text field left empty.
+func (v *fieldViolations) require(field string, value string) {
+	v.check(value != "", field, "A %s is required", field)
+}
+
+// checkError records a violation of a field a validation function failed
+// for, described by its error.
+func (v *fieldViolations) checkError(field string, err error) {
+	if err != nil {
+		v.check(false, field, "%s", status.Convert(err).Message())
+	}
+}
+
+// checkRating records a violation of a rating field unless it is a number
+// of stars, or unset where that is allowed.
+func (v *fieldViolations) checkRating(field string, rating int32, optional bool) {
+	if !optional || rating != 0 {
+		v.checkError(field, validateRating(rating))
+	}
+}
+
+// checkDate records a violation of a date field unless it is a calendar
+// date, or unset where that is allowed.
+func (v *fieldViolations) checkDate(field string, date *reservation.Date, optional bool) {
+	if date == nil && optional {
+		return
+	}
+	if _, err := calendarDate(date); err != nil {
+		v.check(false, field, "%s", strings.Replace(status.Convert(err).Message(), "A date", "A "+field, 1))
+	}
+}
+
+// checkTimeOfDay records a violation of a time of day field unless it is
+// between 00:00 and 23:59, or unset where that is allowed.
+func (v *fieldViolations) checkTimeOfDay(field string, t *reservation.TimeOfDay, optional bool) {
+	if t == nil && optional {
+		return
+	}
+	v.check(t != nil && t.GetHour() >= 0 && t.GetHour() <= 23 && t.GetMinute() >= 0 && t.GetMinute() <= 59, field, "A %s between 00:00 and 23:59 is required", field)
+}
+
+// checkDetail records violations of the fields of a restaurant's details,
+// under prefix.
+func (v *fieldViolations) checkDetail(prefix string, capacity int32, openingHours []*detail.OpeningHours, coordinates *detail.Coordinates) {
+	v.check(capacity >= 0, prefix+"capacity", "capacity must not be negative; 0 is unlimited")
+	v.checkError(prefix+"opening_hours", validateOpeningHours(openingHours))
+	v.checkError(prefix+"coordinates", validateCoordinates(coordinates))
+}
+
+// requestViolations returns what is wrong with the fields of a request to
+// any of the services, none if it is valid or has nothing to check.
+func requestViolations(req any) fieldViolations {
+	var v fieldViolations
+	nonNegative := func(field string, value int64) {
+		v.check(value >= 0, field, "%s must not be negative", field)
+	}
+	duration := func(minutes int32) {
+		v.check(minutes >= 0 && minutes <= 24*60, "duration_minutes", "duration_minutes must be between 1 and 1440, or 0 for the default")
+	}
+
+	switch req := req.(type) {
+	// Details
+	case *detail.PostDetailRequest:
+		v.require("restaurant_name", req.GetRestaurantName())
+		v.checkDetail("", req.GetCapacity(), req.GetOpeningHours(), req.GetCoordinates())
+	case *detail.GetDetailRequest:
+		v.require("restaurant_name", req.GetRestaurantName())
+	case *detail.UpdateDetailRequest:
+		d := req.GetDetail()
+		v.require("detail.restaurant_name", d.GetRestaurantName())
+		v.checkDetail("detail.", d.GetCapacity(), d.GetOpeningHours(), d.GetCoordinates())
+	case *detail.DeleteDetailRequest:
+		v.require("restaurant_name", req.GetRestaurantName())
+	case *detail.SearchDetailsRequest:
+		nonNegative("min_capacity", int64(req.GetMinCapacity()))
+		nonNegative("page_size", int64(req.GetPageSize()))
+	case *detail.NearbyRestaurantsRequest:
+		v.check(req.GetCenter() != nil, "center", "A center is required")
+		v.checkError("center", validateCoordinates(req.GetCenter()))
+		v.check(req.GetRadiusKm() > 0 && req.GetRadiusKm() <= maxNearbyRadiusKm, "radius_km", "radius_km must be more than 0 and at most %d", maxNearbyRadiusKm)
+		nonNegative("limit", int64(req.GetLimit()))
+
+	// Reviews
+	case *review.PostReviewRequest:
+		v.require("user_name", req.GetUserName())
+		v.require("restaurant_name", req.GetRestaurantName())
+		v.require("review", req.GetReview())
+		v.checkRating("rating", req.GetRating(), false)
+	case *review.GetReviewRequest:
+		v.require("user_name", req.GetUserName())
+		v.require("restaurant_name", req.GetRestaurantName())
+	case *review.EditReviewRequest:
+		v.require("user_name", req.GetUserName())
+		v.require("restaurant_name", req.GetRestaurantName())
+		v.check(req.GetReview() != "" || req.GetRating() != 0, "review", "Set the review or the rating to edit")
+		v.checkRating("rating", req.GetRating(), true)
+	case *review.DeleteReviewRequest:
+		v.require("user_name", req.GetUserName())
+		v.require("restaurant_name", req.GetRestaurantName())
+	case *review.GetReviewHistoryRequest:
+		v.require("user_name", req.GetUserName())
+		v.require("restaurant_name", req.GetRestaurantName())
+	case *review.VoteReviewRequest:
+		v.require("user_name", req.GetUserName())
+		v.require("restaurant_name", req.GetRestaurantName())
+		v.require("voter_name", req.GetVoterName())
+		v.check(req.GetVoterName() != req.GetUserName(), "voter_name", "Users can't vote for their own reviews")
+	case *review.ApproveReviewRequest:
+		v.require("user_name", req.GetUserName())
+		v.require("restaurant_name", req.GetRestaurantName())
+	case *review.RejectReviewRequest:
+		v.require("user_name", req.GetUserName())
+		v.require("restaurant_name", req.GetRestaurantName())
+	case *review.SearchReviewsRequest:
+		v.require("restaurant_name", req.GetRestaurantName())
+		nonNegative("page_size", int64(req.GetPageSize()))
+		_, known := review.ReviewSortOrder_name[int32(req.GetSortOrder())]
+		v.check(known, "sort_order", "Unknown sort_order: %v", req.GetSortOrder())
+	case *review.SearchReviewTextRequest:
+		v.require("query", strings.TrimSpace(req.GetQuery()))
+		v.checkRating("min_rating", req.GetMinRating(), true)
+		v.checkRating("max_rating", req.GetMaxRating(), true)
+		v.check(req.GetMaxRating() == 0 || req.GetMinRating() <= req.GetMaxRating(), "max_rating", "max_rating must not be below min_rating")
+		nonNegative("limit", int64(req.GetLimit()))
+	case *review.GetRatingSummaryRequest:
+		v.require("restaurant_name", req.GetRestaurantName())
+	case *review.DeleteRestaurantReviewsRequest:
+		v.require("restaurant_name", req.GetRestaurantName())
+
+	// Reservations
+	case *reservation.MakeReservationRequest:
+		v.require("user_name", req.GetUserName())
+		v.require("restaurant_name", req.GetRestaurantName())
+		v.checkDate("time", req.GetTime(), false)
+		v.checkTimeOfDay("start_time", req.GetStartTime(), false)
+		duration(req.GetDurationMinutes())
+		nonNegative("party_size", int64(req.GetPartySize()))
+	case *reservation.GetReservationRequest:
+		v.check(req.GetReservationId() != "" || (req.GetUserName() != "" && req.GetRestaurantName() != ""), "reservation_id", "Specify a reservation_id, or a user_name and restaurant_name")
+	case *reservation.CancelReservationRequest:
+		v.require("reservation_id", req.GetReservationId())
+	case *reservation.ModifyReservationRequest:
+		v.require("reservation_id", req.GetReservationId())
+		v.checkDate("time", req.GetTime(), true)
+		v.checkTimeOfDay("start_time", req.GetStartTime(), true)
+		duration(req.GetDurationMinutes())
+		nonNegative("party_size", int64(req.GetPartySize()))
+	case *reservation.GetAvailabilityRequest:
+		v.require("restaurant_name", req.GetRestaurantName())
+		v.checkDate("from_date", req.GetFromDate(), false)
+		v.checkDate("to_date", req.GetToDate(), true)
+		duration(req.GetDurationMinutes())
+		nonNegative("party_size", int64(req.GetPartySize()))
+	case *reservation.ListReservationsRequest:
+		v.check(req.GetUserName() != "" || req.GetRestaurantName() != "", "user_name", "Specify a user_name or a restaurant_name")
+		v.checkDate("from_date", req.GetFromDate(), true)
+		v.checkDate("to_date", req.GetToDate(), true)
+	case *reservation.MostPopularRequest:
+		nonNegative("topK", int64(req.GetTopK()))
+		nonNegative("window_hours", int64(req.GetWindowHours()))
+		v.check(req.GetWindowHours() <= maxPopularityWindowHours, "window_hours", "window_hours must be at most %d", maxPopularityWindowHours)
+		v.check(req.GetWindowHours() == 0 || (req.GetFromDate() == nil && req.GetToDate() == nil), "window_hours", "Specify either window_hours or a date range, not both")
+		v.checkDate("from_date", req.GetFromDate(), true)
+		v.checkDate("to_date", req.GetToDate(), true)
+	case *reservation.TrendingRequest:
+		nonNegative("topK", int64(req.GetTopK()))
+		nonNegative("window_hours", int64(req.GetWindowHours()))
+		// Trending compares the window with the one before it.
+		v.check(req.GetWindowHours() <= maxPopularityWindowHours/2, "window_hours", "window_hours must be at most %d", maxPopularityWindowHours/2)
+	case *reservation.DeleteRestaurantReservationsRequest:
+		v.require("restaurant_name", req.GetRestaurantName())
+
+	// Storage
+	case *mydatabase.SetRecordRequest:
+		v.require("record.key", req.GetRecord().GetKey())
+	case *mydatabase.GetRecordRequest:
+		v.require("key", req.GetKey())
+	case *mydatabase.DeleteRecordRequest:
+		v.require("key", req.GetKey())
+	case *mydatabase.GetRecordHistoryRequest:
+		v.require("key", req.GetKey())
+	case *mydatabase.WriteBatchRequest:
+		for i, op := range req.GetOperations() {
+			v.require(fmt.Sprintf("operations[%d].record.key", i), op.GetRecord().GetKey())
+		}
+	case *mycache.GetItemRequest:
+		v.require("key", req.GetKey())
+	case *mycache.SetItemRequest:
+		v.require("item.key", req.GetItem().GetKey())
+	case *mycache.DeleteItemRequest:
+		v.require("key", req.GetKey())
+	}
+	return v
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// waitlistPrefix prefixes the database key of the waitlist of a slot,
+// waitlistPrefix + restaurant + ":" + slot, which lists the reservations
+// waiting for seats that start in the slot.
+const waitlistPrefix = internalKeyPrefix + "waitlist:"
+
+// waitlistKey returns the key of the waitlist a reservation waits in.
+func waitlistKey(r *reservation.GetReservationResponse) string {
+	start, _ := reservationStart(r)
+	return waitlistPrefix + r.GetRestaurantName() + ":" + slotOf(start).Format(slotLayout)
+}
+
+// decodeWaitlist decodes a waitlist, which may be empty.
+func decodeWaitlist(key string, value []byte) ([]string, error) {
+	waitlist := &reservation.Waitlist{}
+	if err := proto.Unmarshal(value, waitlist); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "Waitlist %s could not be decoded: %v", key, err)
+	}
+	return waitlist.GetReservationIds(), nil
+}
+
+// encodeWaitlist encodes a waitlist, keeping its order, or returns nil if it
+// is empty.
+func encodeWaitlist(ids []string) []byte {
+	if len(ids) == 0 {
+		return nil
+	}
+	data, _ := proto.Marshal(&reservation.Waitlist{ReservationIds: ids})
+	return data
+}
+
+// joinWaitlist returns the update that adds a reservation to the end of a
+// waitlist.
+func joinWaitlist(key string, id string) recordUpdate {
+	return recordUpdate{key: key, apply: func(current []byte) ([]byte, error) {
+		ids, err := decodeWaitlist(key, current)
+		if err != nil || containsString(ids, id) {
+			return current, err
+		}
+		return encodeWaitlist(append(ids, id)), nil
+	}}
+}
+
+// leaveWaitlist returns the update that removes a reservation from a
+// waitlist.
+func leaveWaitlist(key string, id string) recordUpdate {
+	return recordUpdate{key: key, apply: func(current []byte) ([]byte, error) {
+		ids, err := decodeWaitlist(key, current)
+		if err != nil {
+			return nil, err
+		}
+		var kept []string
+		for _, waiting := range ids {
+			if waiting != id {
+				kept = append(kept, waiting)
+			}
+		}
+		return encodeWaitlist(kept), nil
+	}}
+}
+
+// promoteWaitlist confirms the reservations waiting at a restaurant on a date
+// that now fit within capacity, slot by slot and in the order they joined,
+// and returns them. A party too large for the seats left is passed over for
+// the next one. Caller must hold s.lock.
+func (s *Reservation) promoteWaitlist(ctx context.Context, restaurantName string, date *reservation.Date, capacity int64) ([]*reservation.GetReservationResponse, error) {
+	prefix := waitlistPrefix + restaurantName + ":"
+	var waiting []string
+	err := scanAll(ctx, s.reservationDatabaseClient, prefix+dateBucket(date), func(record *mydatabase.DatabaseRecord) error {
+		if len(strings.TrimPrefix(record.GetKey(), prefix)) != len(slotLayout) {
+			return nil // another restaurant whose name starts with this one's
+		}
+		ids, err := decodeWaitlist(record.GetKey(), record.GetValue())
+		waiting = append(waiting, ids...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var promoted []*reservation.GetReservationResponse
+	for _, id := range waiting {
+		_, after, err := s.changeReservation(ctx, id, capacity, func(r *reservation.GetReservationResponse) error {
+			if r.GetStatus() != reservation.ReservationStatus_WAITLISTED {
+				return status.Errorf(codes.FailedPrecondition, "Reservation %s is no longer waitlisted", id)
+			}
+			r.Status = reservation.ReservationStatus_CONFIRMED
+			r.PromotedAt = time.Now().Unix()
+			return nil
+		})
+		switch status.Code(err) {
+		case codes.OK:
+			log.Printf("reservation server <%s> promoted reservation %s from the waitlist", s.name, id)
+			promoted = append(promoted, after)
+		case codes.ResourceExhausted, codes.FailedPrecondition, codes.NotFound:
+		default:
+			return promoted, err
+		}
+	}
+	return promoted, nil
+}
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 
 	"cse190-welp/proto/detail"
 	"cse190-welp/proto/reservation"
@@ -49,6 +50,33 @@ func loadCSV(csvFilePath string) ([][]string, error) {
 	return records, nil
 }
 
+// shiftSampleDates moves the Year, Month and Day columns of the sample
+// reservations after the header row to start tomorrow, keeping the days
+// between them, as reservations can't be made in the past.
+func shiftSampleDates(t *testing.T, records [][]string) {
+	dates := make([]time.Time, len(records))
+	var earliest time.Time
+	for i := 1; i < len(records); i++ {
+		year, yearErr := strconv.Atoi(records[i][2])
+		month, monthErr := strconv.Atoi(records[i][3])
+		day, dayErr := strconv.Atoi(records[i][4])
+		if yearErr != nil || monthErr != nil || dayErr != nil {
+			t.Fatalf("Malformed sample date in %v", records[i])
+		}
+		dates[i] = time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		if earliest.IsZero() || dates[i].Before(earliest) {
+			earliest = dates[i]
+		}
+	}
+	now := time.Now()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	days := int(tomorrow.Sub(earliest) / (24 * time.Hour))
+	for i := 1; i < len(records); i++ {
+		date := dates[i].AddDate(0, 0, days)
+		records[i][2], records[i][3], records[i][4] = strconv.Itoa(date.Year()), strconv.Itoa(int(date.Month())), strconv.Itoa(date.Day())
+	}
+}
+
 func testDetailHelper(t *testing.T, expectedData *url.Values) {
 	clusterFrontendIP := "10.96.88.88"
 	port := 8080
@@ -415,6 +443,7 @@ func TestReservation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error: %v", err)
 	}
+	shiftSampleDates(t, records)
 
 	// Process post-get
 	for row, record := range records {
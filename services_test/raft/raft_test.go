@@ -0,0 +1,332 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cse190-welp/raft"
+)
+
+// counterMachine is a replicated map of counters; commands are key names to increment.
+type counterMachine struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newCounterMachine() *counterMachine {
+	return &counterMachine{counts: make(map[string]int)}
+}
+
+func (m *counterMachine) Apply(command []byte) interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[string(command)]++
+	return m.counts[string(command)]
+}
+
+func (m *counterMachine) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return json.Marshal(m.counts)
+}
+
+func (m *counterMachine) Restore(snapshot []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts = make(map[string]int)
+	return json.Unmarshal(snapshot, &m.counts)
+}
+
+func (m *counterMachine) get(key string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[key]
+}
+
+type cluster struct {
+	t        *testing.T
+	network  *raft.SimNetwork
+	config   raft.Config
+	nodes    map[string]*raft.Node
+	machines map[string]*counterMachine
+	storage  map[string]*raft.Storage
+	dir      string // if set, each node keeps its storage in a subdirectory
+}
+
+func testConfig() raft.Config {
+	return raft.Config{
+		ElectionTimeout:     100 * time.Millisecond,
+		HeartbeatInterval:   20 * time.Millisecond,
+		SnapshotThreshold:   0,
+		MaxEntriesPerAppend: 16,
+	}
+}
+
+func newCluster(t *testing.T, size int, config raft.Config) *cluster {
+	return newClusterIn(t, size, config, "")
+}
+
+// newClusterIn starts a cluster whose nodes keep their storage on disk in
+// dir, or in memory if dir is empty.
+func newClusterIn(t *testing.T, size int, config raft.Config, dir string) *cluster {
+	c := &cluster{
+		t:        t,
+		network:  raft.NewSimNetwork(),
+		config:   config,
+		nodes:    make(map[string]*raft.Node),
+		machines: make(map[string]*counterMachine),
+		storage:  make(map[string]*raft.Storage),
+		dir:      dir,
+	}
+	var peers []string
+	for i := 0; i < size; i++ {
+		peers = append(peers, fmt.Sprintf("node%d", i))
+	}
+	for _, id := range peers {
+		c.start(id, peers)
+	}
+	t.Cleanup(func() {
+		for _, n := range c.nodes {
+			n.Stop()
+		}
+	})
+	return c
+}
+
+// start boots a node, reusing its storage if it ran before. Storage on disk
+// is read back from its files.
+func (c *cluster) start(id string, peers []string) {
+	if c.dir != "" {
+		storage, err := raft.OpenStorage(filepath.Join(c.dir, id))
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		c.storage[id] = storage
+	} else if _, ok := c.storage[id]; !ok {
+		c.storage[id] = raft.NewStorage()
+	}
+	c.machines[id] = newCounterMachine()
+	n := raft.NewNode(id, peers, c.machines[id], c.network.Transport(id), c.storage[id], c.config)
+	c.nodes[id] = n
+	c.network.Register(n)
+	n.Start()
+}
+
+// leader waits for exactly one leader among the given nodes.
+func (c *cluster) leader(among ...string) *raft.Node {
+	if len(among) == 0 {
+		for id := range c.nodes {
+			among = append(among, id)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var leaders []*raft.Node
+		for _, id := range among {
+			if _, role := c.nodes[id].State(); role == raft.Leader {
+				leaders = append(leaders, c.nodes[id])
+			}
+		}
+		if len(leaders) == 1 {
+			return leaders[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.t.Fatalf("No single leader elected among %v", among)
+	return nil
+}
+
+// propose retries a command against the current leader until it commits.
+func (c *cluster) propose(command string, among ...string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		_, err := c.leader(among...).Propose(ctx, []byte(command))
+		cancel()
+		if err == nil {
+			return
+		}
+	}
+	c.t.Fatalf("Command %q never committed", command)
+}
+
+// waitFor waits until every named node has applied count increments of key.
+func (c *cluster) waitFor(key string, count int, ids ...string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		done := true
+		for _, id := range ids {
+			if c.machines[id].get(key) != count {
+				done = false
+			}
+		}
+		if done {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, id := range ids {
+		c.t.Errorf("Node %s has %s=%d, expected %d", id, key, c.machines[id].get(key), count)
+	}
+	c.t.FailNow()
+}
+
+func others(all []string, exclude string) []string {
+	var rest []string
+	for _, id := range all {
+		if id != exclude {
+			rest = append(rest, id)
+		}
+	}
+	return rest
+}
+
+func TestElectionAndReplication(t *testing.T) {
+	c := newCluster(t, 3, testConfig())
+	for i := 0; i < 5; i++ {
+		c.propose("x")
+	}
+	c.waitFor("x", 5, "node0", "node1", "node2")
+}
+
+func TestLeaderFailover(t *testing.T) {
+	c := newCluster(t, 3, testConfig())
+	c.propose("x")
+	old := c.leader()
+	c.network.Disconnect(old.ID())
+
+	rest := others([]string{"node0", "node1", "node2"}, old.ID())
+	c.propose("x", rest...)
+	c.waitFor("x", 2, rest...)
+
+	// The old leader can't commit on its own and rejoins as a follower.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := old.Propose(ctx, []byte("lost")); err == nil {
+		t.Errorf("Expected proposal on an isolated leader to fail")
+	}
+	c.network.Reconnect(old.ID())
+	c.propose("x")
+	c.waitFor("x", 3, "node0", "node1", "node2")
+	if c.machines[old.ID()].get("lost") != 0 {
+		t.Errorf("Uncommitted entry from the isolated leader was applied")
+	}
+}
+
+func TestPartitionAndDelay(t *testing.T) {
+	c := newCluster(t, 5, testConfig())
+	c.propose("x")
+	old := c.leader()
+	majority := others([]string{"node0", "node1", "node2", "node3", "node4"}, old.ID())[:3]
+	minority := others([]string{"node0", "node1", "node2", "node3", "node4"}, old.ID())[3:]
+	c.network.Partition(majority, append(minority, old.ID()))
+
+	c.propose("x", majority...)
+	c.waitFor("x", 2, majority...)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	if err := old.ReadIndex(ctx); err == nil {
+		t.Errorf("Expected a read on the minority side to fail")
+	}
+	cancel()
+
+	c.network.Heal()
+	c.network.SetDelay(minority[0], 15*time.Millisecond)
+	c.propose("x")
+	c.waitFor("x", 3, "node0", "node1", "node2", "node3", "node4")
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.leader().ReadIndex(ctx); err != nil {
+		t.Errorf("Expected read index on the leader to succeed, got %v", err)
+	}
+}
+
+func TestSnapshotCatchUp(t *testing.T) {
+	config := testConfig()
+	config.SnapshotThreshold = 5
+	c := newCluster(t, 3, config)
+	c.propose("x")
+	lagging := others([]string{"node0", "node1", "node2"}, c.leader().ID())[0]
+	c.network.Disconnect(lagging)
+
+	rest := others([]string{"node0", "node1", "node2"}, lagging)
+	for i := 0; i < 20; i++ {
+		c.propose("x", rest...)
+	}
+	c.network.Reconnect(lagging)
+	c.waitFor("x", 21, "node0", "node1", "node2")
+
+	// A restarted node recovers from its own snapshot and log.
+	c.nodes[lagging].Stop()
+	c.start(lagging, nil)
+	c.propose("x")
+	c.waitFor("x", 22, "node0", "node1", "node2")
+}
+
+func TestMembershipChange(t *testing.T) {
+	c := newCluster(t, 3, testConfig())
+	c.propose("x")
+
+	c.start("node3", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := c.leader().AddServer(ctx, "node3"); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	c.propose("x")
+	c.waitFor("x", 2, "node0", "node1", "node2", "node3")
+
+	removed := c.leader().ID()
+	if err := c.nodes[removed].RemoveServer(ctx, removed); err != nil {
+		t.Fatalf("RemoveServer failed: %v", err)
+	}
+	rest := others([]string{"node0", "node1", "node2", "node3"}, removed)
+	c.propose("x", rest...)
+	c.waitFor("x", 3, rest...)
+	if members := strings.Join(c.leader(rest...).Members(), ","); strings.Contains(members, removed) {
+		t.Errorf("Expected %s to be removed, members are %s", removed, members)
+	}
+}
+
+func TestRestartFromDisk(t *testing.T) {
+	config := testConfig()
+	config.SnapshotThreshold = 5
+	dir := t.TempDir()
+	c := newClusterIn(t, 3, config, dir)
+	all := []string{"node0", "node1", "node2"}
+	for i := 0; i < 8; i++ {
+		c.propose("x")
+	}
+	c.waitFor("x", 8, all...)
+
+	// Every node stops at once, so what they recover can only come from disk.
+	terms := make(map[string]uint64)
+	for _, id := range all {
+		c.nodes[id].Stop()
+		terms[id], _ = c.nodes[id].State()
+	}
+	// A record torn by a crash is dropped.
+	file, err := os.OpenFile(filepath.Join(dir, "node0", "raft.log"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte{0, 0, 0, 9, 1, 2})
+	file.Close()
+
+	for _, id := range all {
+		c.start(id, nil)
+		if term, _ := c.nodes[id].State(); term < terms[id] {
+			t.Errorf("Node %s restarted in term %d, expected at least %d", id, term, terms[id])
+		}
+	}
+	c.waitFor("x", 8, all...)
+	c.propose("x")
+	c.waitFor("x", 9, all...)
+}
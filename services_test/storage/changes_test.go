@@ -0,0 +1,153 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	apps "cse190-welp/applications"
+	"cse190-welp/proto/mycache"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// freePort returns a TCP port that is not in use.
+func freePort(t *testing.T) int {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
+
+func connect(t *testing.T, port int) *grpc.ClientConn {
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// eventually retries check until it succeeds or a few seconds have passed.
+func eventually(t *testing.T, what string, check func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if check() {
+			return
+		}
+	}
+	t.Fatalf("Timed out waiting for %s", what)
+}
+
+func TestChangeLog(t *testing.T) {
+	s := newStorage(t)
+
+	v1 := s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("a1")})
+	s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("a2")})
+	s.Delete("a")
+
+	changes, _, err := s.Changes(v1, 0)
+	if err != nil || len(changes) != 3 {
+		t.Fatalf("Expected 3 changes from %d, got %d (err %v)", v1, len(changes), err)
+	}
+	if changes[1].Operation != mydatabase.ChangeEvent_PUT || string(changes[1].Previous.GetValue()) != "a1" {
+		t.Errorf("Expected a put replacing a1, got %v", changes[1])
+	}
+	if changes[2].Operation != mydatabase.ChangeEvent_DELETE || string(changes[2].Previous.GetValue()) != "a2" {
+		t.Errorf("Expected a delete of a2, got %v", changes[2])
+	}
+
+	// Readers that are caught up wait for the next commit.
+	changes, wait, err := s.Changes(s.Version()+1, 0)
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("Expected no changes yet, got %d (err %v)", len(changes), err)
+	}
+	s.Set(&mydatabase.DatabaseRecord{Key: "b", Value: []byte("b1")})
+	select {
+	case <-wait:
+	case <-time.After(time.Second):
+		t.Fatal("Expected waiters to be woken by a commit")
+	}
+
+	for i := 0; i < apps.ChangeLogLimit; i++ {
+		s.Set(&mydatabase.DatabaseRecord{Key: "c", Value: []byte("c")})
+	}
+	if _, _, err := s.Changes(v1, 0); err != apps.ErrChangesTruncated {
+		t.Errorf("Expected truncated changes, got %v", err)
+	}
+}
+
+func TestInvalidatorFollowsChanges(t *testing.T) {
+	databasePort, cachePort := freePort(t), freePort(t)
+	go services.NewMyDatabase("database", databasePort, "none", 10, 0).Run()
+	go services.NewMyCache("cache", cachePort, 100).Run()
+	database := mydatabase.NewDatabaseServiceClient(connect(t, databasePort))
+	cache := mycache.NewCacheServiceClient(connect(t, cachePort))
+	ctx := context.Background()
+
+	cached := func(key string) (string, bool) {
+		reply, err := cache.GetItem(ctx, &mycache.GetItemRequest{Key: key})
+		return string(reply.GetItem().GetValue()), err == nil
+	}
+	set := func(key, value string) {
+		record := &mydatabase.DatabaseRecord{Key: key, Value: []byte(value)}
+		if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: record}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A stale entry cached before the invalidator starts is cleared.
+	eventually(t, "cache to start", func() bool {
+		item := &mycache.CacheItem{Key: "stale", Value: []byte("old")}
+		_, err := cache.SetItem(ctx, &mycache.SetItemRequest{Item: item})
+		return err == nil
+	})
+	eventually(t, "database to start", func() bool {
+		_, err := database.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{})
+		return err == nil
+	})
+	go services.NewInvalidator("invalidator", fmt.Sprintf("localhost:%d", cachePort), fmt.Sprintf("localhost:%d", databasePort), true).Run()
+	eventually(t, "stale entry to be cleared", func() bool {
+		_, ok := cached("stale")
+		return !ok
+	})
+
+	// Cached keys are refreshed, other keys are left out of the cache.
+	item := &mycache.CacheItem{Key: "a", Value: []byte("a1")}
+	if _, err := cache.SetItem(ctx, &mycache.SetItemRequest{Item: item}); err != nil {
+		t.Fatal(err)
+	}
+	set("a", "a2")
+	set("b", "b1")
+	eventually(t, "cached key to be refreshed", func() bool {
+		value, _ := cached("a")
+		return value == "a2"
+	})
+	if _, ok := cached("b"); ok {
+		t.Error("Expected uncached key to stay out of the cache")
+	}
+
+	if _, err := database.DeleteRecord(ctx, &mydatabase.DeleteRecordRequest{Key: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "deleted key to be invalidated", func() bool {
+		_, ok := cached("a")
+		return !ok
+	})
+
+	// Watchers can resume from an earlier sequence and filter by prefix.
+	stream, err := database.WatchChanges(ctx, &mydatabase.WatchChangesRequest{StartSequence: 1, Prefix: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := stream.Recv()
+	if err != nil || event.GetRecord().GetKey() != "b" {
+		t.Errorf("Expected the change to b, got %v (err %v)", event, err)
+	}
+}
@@ -0,0 +1,336 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"cse190-welp/proto/detail"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"cse190-welp/proto/review"
+	"cse190-welp/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// startCascading runs detail, review and reservation services that know each
+// other, so deletes of details cascade, and returns their clients.
+func startCascading(t *testing.T) (detail.DetailServiceClient, review.ReviewServiceClient, reservation.ReservationServiceClient) {
+	ctx := context.Background()
+	detailPort, reviewPort, reservationPort := freePort(t), freePort(t), freePort(t)
+	detailAddr := fmt.Sprintf("localhost:%d", detailPort)
+	reviewAddr := fmt.Sprintf("localhost:%d", reviewPort)
+	reservationAddr := fmt.Sprintf("localhost:%d", reservationPort)
+	detailDatabaseAddr, _ := startDatabase(t)
+	reviewDatabaseAddr, _ := startDatabase(t)
+	reservationDatabaseAddr, _ := startDatabase(t)
+	go services.NewDetail("detail", detailPort, startCache(t), detailDatabaseAddr, reviewAddr, reservationAddr).Run()
+	go services.NewReview("review", reviewPort, startCache(t), reviewDatabaseAddr, "").Run()
+	go services.NewReservation("reservation", reservationPort, startCache(t), reservationDatabaseAddr, detailAddr, 0).Run()
+	details := detail.NewDetailServiceClient(connect(t, detailPort))
+	reviews := review.NewReviewServiceClient(connect(t, reviewPort))
+	reservations := reservation.NewReservationServiceClient(connect(t, reservationPort))
+	eventually(t, "services to start", func() bool {
+		_, detailErr := details.GetDetail(ctx, &detail.GetDetailRequest{RestaurantName: "nowhere"})
+		_, reviewErr := reviews.SearchReviews(ctx, &review.SearchReviewsRequest{RestaurantName: "nowhere"})
+		_, reservationErr := reservations.ListReservations(ctx, &reservation.ListReservationsRequest{UserName: "nobody"})
+		return status.Code(detailErr) == codes.NotFound && reviewErr == nil && reservationErr == nil
+	})
+	return details, reviews, reservations
+}
+
+func TestUpdateAndDeleteDetail(t *testing.T) {
+	ctx := context.Background()
+	details, reviews, reservations := startCascading(t)
+
+	for _, name := range []string{"Chick-fil-A", "Chipotle"} {
+		if _, err := details.PostDetail(ctx, &detail.PostDetailRequest{RestaurantName: name, Location: "San Diego", Style: "Fast Food", Capacity: 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Cache the details, so a stale copy would show.
+	if _, err := details.GetDetail(ctx, &detail.GetDetailRequest{RestaurantName: "Chick-fil-A"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// An update changes only the fields in its mask.
+	_, err := details.UpdateDetail(ctx, &detail.UpdateDetailRequest{
+		Detail:     &detail.GetDetailResponse{RestaurantName: "Chick-fil-A", Location: "La Jolla", Style: "ignored"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"location"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := details.GetDetail(ctx, &detail.GetDetailRequest{RestaurantName: "Chick-fil-A"})
+	if err != nil || got.Location != "La Jolla" || got.Style != "Fast Food" || got.Capacity != 10 {
+		t.Errorf("Expected only the location updated, got %v (err %v)", got, err)
+	}
+
+	// Without a mask, the fields set are updated.
+	updated, err := details.UpdateDetail(ctx, &detail.UpdateDetailRequest{Detail: &detail.GetDetailResponse{RestaurantName: "Chick-fil-A", Capacity: 20}})
+	if err != nil || updated.Detail.Location != "La Jolla" || updated.Detail.Capacity != 20 {
+		t.Errorf("Expected the capacity updated, got %v (err %v)", updated, err)
+	}
+
+	for _, paths := range [][]string{{"restaurant_name"}, {"rating"}} {
+		_, err := details.UpdateDetail(ctx, &detail.UpdateDetailRequest{
+			Detail:     &detail.GetDetailResponse{RestaurantName: "Chick-fil-A"},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument updating %v, got %v", paths, err)
+		}
+	}
+	if _, err := details.UpdateDetail(ctx, &detail.UpdateDetailRequest{Detail: &detail.GetDetailResponse{RestaurantName: "Panda Express", Capacity: 5}}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound updating a missing restaurant, got %v", err)
+	}
+
+	// A cascading delete takes the reviews, reservations and popularity with it.
+	for _, user := range []string{"Michael Jordan", "LeBron James"} {
+		if _, err := reviews.PostReview(ctx, &review.PostReviewRequest{UserName: user, RestaurantName: "Chick-fil-A", Review: "Great", Rating: 5}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, name := range []string{"Chick-fil-A", "Chick-fil-A", "Chipotle"} {
+		booking := &reservation.MakeReservationRequest{UserName: "Michael Jordan", RestaurantName: name, Time: future(int32(i + 1)), StartTime: noon}
+		if _, err := reservations.MakeReservation(ctx, booking); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The detail service's own connections come up in the background.
+	var deleted *detail.DeleteDetailResponse
+	eventually(t, "detail service to reach the others", func() bool {
+		deleted, err = details.DeleteDetail(ctx, &detail.DeleteDetailRequest{RestaurantName: "Chick-fil-A", Cascade: true})
+		return status.Code(err) != codes.Unavailable
+	})
+	if err != nil || !deleted.Status || deleted.ReviewsDeleted != 2 || deleted.ReservationsDeleted != 2 {
+		t.Fatalf("Expected 2 reviews and 2 reservations deleted, got %v (err %v)", deleted, err)
+	}
+	if _, err := details.GetDetail(ctx, &detail.GetDetailRequest{RestaurantName: "Chick-fil-A"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound after delete, got %v", err)
+	}
+	found, err := reviews.SearchReviews(ctx, &review.SearchReviewsRequest{RestaurantName: "Chick-fil-A"})
+	if err != nil || len(found.ReviewsMap) != 0 {
+		t.Errorf("Expected no reviews left, got %v (err %v)", found, err)
+	}
+	listed, err := reservations.ListReservations(ctx, &reservation.ListReservationsRequest{UserName: "Michael Jordan"})
+	if err != nil || len(listed.Reservations) != 1 || listed.Reservations[0].RestaurantName != "Chipotle" {
+		t.Errorf("Expected only the Chipotle reservation left, got %v (err %v)", listed, err)
+	}
+	popular, err := reservations.MostPopular(ctx, &reservation.MostPopularRequest{TopK: 10})
+	if err != nil || len(popular.TopKRestaurants) != 1 || popular.TopKRestaurants[0] != "Chipotle" {
+		t.Errorf("Expected only Chipotle ranked, got %v (err %v)", popular, err)
+	}
+
+	if _, err := details.DeleteDetail(ctx, &detail.DeleteDetailRequest{RestaurantName: "Chick-fil-A"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound deleting twice, got %v", err)
+	}
+}
+
+// TestDeleteDetailWhileBooking checks that cascading deletes and bookings,
+// which each call the other's service, don't wait on each other for good.
+func TestDeleteDetailWhileBooking(t *testing.T) {
+	ctx := context.Background()
+	details, _, reservations := startCascading(t)
+	eventually(t, "detail service to reach the others", func() bool {
+		_, err := details.DeleteDetail(ctx, &detail.DeleteDetailRequest{RestaurantName: "nowhere", Cascade: true})
+		return status.Code(err) != codes.Unavailable
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+	for round := 0; round < 5; round++ {
+		if _, err := details.PostDetail(ctx, &detail.PostDetailRequest{RestaurantName: "Chick-fil-A", Capacity: 100}); err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				booking := &reservation.MakeReservationRequest{UserName: fmt.Sprintf("User %d", i), RestaurantName: "Chick-fil-A", Time: future(int32(i + 1)), StartTime: noon}
+				if _, err := reservations.MakeReservation(ctx, booking); err != nil {
+					t.Error(err)
+				}
+			}(i)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := details.DeleteDetail(ctx, &detail.DeleteDetailRequest{RestaurantName: "Chick-fil-A", Cascade: true}); err != nil {
+				t.Error(err)
+			}
+		}()
+		wg.Wait()
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+func TestSearchDetails(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, database := startDatabase(t)
+
+	// Details stored before they were indexed are indexed on first search.
+	legacy, _ := proto.Marshal(&detail.GetDetailResponse{RestaurantName: "Panda Express", Location: "La Jolla", Style: "Chinese", Capacity: 30})
+	if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: "Panda Express", Value: legacy}}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := services.NewDetail("detail", 0, startCache(t), databaseAddr, "", "")
+	for _, d := range []*detail.PostDetailRequest{
+		{RestaurantName: "Chick-fil-A", Location: "San Diego", Style: "Fast Food", Capacity: 40},
+		{RestaurantName: "Chipotle", Location: "san diego", Style: "Mexican", Capacity: 20},
+		{RestaurantName: "In-N-Out Burger", Location: "San Diego", Style: "fast food", Capacity: 10},
+		{RestaurantName: "Taco Stand", Location: "La Jolla", Style: "Mexican"}, // unlimited capacity
+	} {
+		if _, err := srv.PostDetail(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(req *detail.SearchDetailsRequest) ([]string, string) {
+		t.Helper()
+		reply, err := srv.SearchDetails(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, d := range reply.Details {
+			names = append(names, d.RestaurantName)
+		}
+		return names, reply.NextPageToken
+	}
+
+	for _, tc := range []struct {
+		req  *detail.SearchDetailsRequest
+		want []string
+	}{
+		{&detail.SearchDetailsRequest{}, []string{"Chick-fil-A", "Chipotle", "In-N-Out Burger", "Panda Express", "Taco Stand"}},
+		{&detail.SearchDetailsRequest{Location: "SAN DIEGO"}, []string{"Chick-fil-A", "Chipotle", "In-N-Out Burger"}},
+		{&detail.SearchDetailsRequest{Location: "San Diego", Style: "Fast Food"}, []string{"Chick-fil-A", "In-N-Out Burger"}},
+		{&detail.SearchDetailsRequest{MinCapacity: 20}, []string{"Chick-fil-A", "Chipotle", "Panda Express", "Taco Stand"}},
+		{&detail.SearchDetailsRequest{Style: "mexican", MinCapacity: 21}, []string{"Taco Stand"}},
+		{&detail.SearchDetailsRequest{Location: "Los Angeles"}, nil},
+	} {
+		if got, _ := search(tc.req); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Expected %v searching %v, got %v", tc.want, tc.req, got)
+		}
+	}
+
+	// Updates and deletes move restaurants between the indexes.
+	if _, err := srv.UpdateDetail(ctx, &detail.UpdateDetailRequest{Detail: &detail.GetDetailResponse{RestaurantName: "Chipotle", Location: "La Jolla"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.DeleteDetail(ctx, &detail.DeleteDetailRequest{RestaurantName: "In-N-Out Burger"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := func() []string { names, _ := search(&detail.SearchDetailsRequest{Location: "la jolla"}); return names }(), []string{"Chipotle", "Panda Express", "Taco Stand"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v in La Jolla after the update, got %v", want, got)
+	}
+	if got, _ := search(&detail.SearchDetailsRequest{Style: "Fast Food"}); !reflect.DeepEqual(got, []string{"Chick-fil-A"}) {
+		t.Errorf("Expected only Chick-fil-A after the delete, got %v", got)
+	}
+
+	// Pages follow on from each other.
+	var pages [][]string
+	token := ""
+	for {
+		names, next := search(&detail.SearchDetailsRequest{PageSize: 2, PageToken: token})
+		pages = append(pages, names)
+		if token = next; token == "" {
+			break
+		}
+	}
+	if want := [][]string{{"Chick-fil-A", "Chipotle"}, {"Panda Express", "Taco Stand"}}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("Expected pages %v, got %v", want, pages)
+	}
+	if _, err := srv.SearchDetails(ctx, &detail.SearchDetailsRequest{PageSize: -1}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a negative page size, got %v", err)
+	}
+}
+
+func TestNearbyRestaurants(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, _ := startDatabase(t)
+	srv := services.NewDetail("detail", 0, startCache(t), databaseAddr, "", "")
+	ucsd := &detail.Coordinates{Latitude: 32.8801, Longitude: -117.2340}
+	for _, d := range []*detail.PostDetailRequest{
+		{RestaurantName: "Chick-fil-A", Style: "Fast Food", Coordinates: ucsd},
+		{RestaurantName: "Chipotle", Style: "Mexican", Coordinates: &detail.Coordinates{Latitude: 32.8328, Longitude: -117.2713}},
+		{RestaurantName: "In-N-Out Burger", Style: "Fast Food", Coordinates: &detail.Coordinates{Latitude: 32.7157, Longitude: -117.1611}},
+		{RestaurantName: "Panda Express", Style: "Chinese", Coordinates: &detail.Coordinates{Latitude: 34.0522, Longitude: -118.2437}},
+		{RestaurantName: "Taco Stand", Style: "Mexican"}, // nowhere in particular
+		{RestaurantName: "East", Coordinates: &detail.Coordinates{Latitude: 0, Longitude: 179.99}},
+		{RestaurantName: "West", Coordinates: &detail.Coordinates{Latitude: 0, Longitude: -179.99}},
+	} {
+		if _, err := srv.PostDetail(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nearby := func(req *detail.NearbyRestaurantsRequest) []string {
+		t.Helper()
+		reply, err := srv.NearbyRestaurants(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for i, r := range reply.Restaurants {
+			if i > 0 && r.DistanceKm < reply.Restaurants[i-1].DistanceKm {
+				t.Errorf("Expected the nearest first, got %v", reply.Restaurants)
+			}
+			names = append(names, r.Detail.RestaurantName)
+		}
+		return names
+	}
+
+	for _, tc := range []struct {
+		req  *detail.NearbyRestaurantsRequest
+		want []string
+	}{
+		{&detail.NearbyRestaurantsRequest{Center: ucsd, RadiusKm: 10}, []string{"Chick-fil-A", "Chipotle"}},
+		{&detail.NearbyRestaurantsRequest{Center: ucsd, RadiusKm: 25}, []string{"Chick-fil-A", "Chipotle", "In-N-Out Burger"}},
+		{&detail.NearbyRestaurantsRequest{Center: ucsd, RadiusKm: 25, Style: "fast food"}, []string{"Chick-fil-A", "In-N-Out Burger"}},
+		{&detail.NearbyRestaurantsRequest{Center: ucsd, RadiusKm: 200}, []string{"Chick-fil-A", "Chipotle", "In-N-Out Burger", "Panda Express"}},
+		{&detail.NearbyRestaurantsRequest{Center: ucsd, RadiusKm: 200, Limit: 2}, []string{"Chick-fil-A", "Chipotle"}},
+		{&detail.NearbyRestaurantsRequest{Center: &detail.Coordinates{Latitude: 0, Longitude: 179.999}, RadiusKm: 5}, []string{"East", "West"}},
+		{&detail.NearbyRestaurantsRequest{Center: &detail.Coordinates{Latitude: 89.9, Longitude: 0}, RadiusKm: 100}, nil},
+	} {
+		if got := nearby(tc.req); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Expected %v near %v, got %v", tc.want, tc.req, got)
+		}
+	}
+
+	// Moving a restaurant moves it in the index.
+	_, err := srv.UpdateDetail(ctx, &detail.UpdateDetailRequest{
+		Detail:     &detail.GetDetailResponse{RestaurantName: "In-N-Out Burger", Coordinates: &detail.Coordinates{Latitude: 34.06, Longitude: -118.25}},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"coordinates"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := nearby(&detail.NearbyRestaurantsRequest{Center: ucsd, RadiusKm: 25}), []string{"Chick-fil-A", "Chipotle"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v after the move, got %v", want, got)
+	}
+
+	for _, req := range []*detail.NearbyRestaurantsRequest{
+		{RadiusKm: 10},
+		{Center: ucsd},
+		{Center: ucsd, RadiusKm: 1000},
+		{Center: &detail.Coordinates{Latitude: 91}, RadiusKm: 10},
+	} {
+		if _, err := srv.NearbyRestaurants(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", req, err)
+		}
+	}
+	if _, err := srv.PostDetail(ctx, &detail.PostDetailRequest{RestaurantName: "Nowhere", Coordinates: &detail.Coordinates{Latitude: 100}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument posting invalid coordinates, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package services_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"cse190-welp/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	databaseAddr, _ := startDatabase(t)
+	detailAddr, _ := startDetail(t)
+	port := freePort(t)
+	go services.NewReservation("reservation", port, startCache(t), databaseAddr, detailAddr, 0).Run()
+	client := reservation.NewReservationServiceClient(connect(t, port))
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "idempotency-key", key)
+	}
+	booking := func(user string, day int32) *reservation.MakeReservationRequest {
+		return &reservation.MakeReservationRequest{UserName: user, RestaurantName: "Chick-fil-A", Time: future(day), StartTime: noon}
+	}
+	eventually(t, "reservation to start", func() bool {
+		_, err := client.ListReservations(context.Background(), &reservation.ListReservationsRequest{UserName: "nobody"})
+		return err == nil
+	})
+	count := func(user string) int {
+		t.Helper()
+		reply, err := client.ListReservations(context.Background(), &reservation.ListReservationsRequest{UserName: user})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(reply.Reservations)
+	}
+
+	// A retry gets the first response and books nothing more.
+	first, err := client.MakeReservation(withKey("jordan-1"), booking("Michael Jordan", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	retry, err := client.MakeReservation(withKey("jordan-1"), booking("Michael Jordan", 1))
+	if err != nil || retry.ReservationId != first.ReservationId {
+		t.Errorf("Expected the retry to replay %s, got %v (err %v)", first.ReservationId, retry, err)
+	}
+	if n := count("Michael Jordan"); n != 1 {
+		t.Errorf("Expected 1 reservation after a retry, got %d", n)
+	}
+	if _, err := client.MakeReservation(withKey("jordan-1"), booking("Michael Jordan", 2)); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument reusing a key for another request, got %v", err)
+	}
+
+	// Without a key, or with another one, every request is applied.
+	for _, ctx := range []context.Context{context.Background(), context.Background(), withKey("jordan-2")} {
+		if _, err := client.MakeReservation(ctx, booking("Michael Jordan", 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := count("Michael Jordan"); n != 4 {
+		t.Errorf("Expected 4 reservations, got %d", n)
+	}
+
+	// A failed request releases its key for a corrected retry.
+	if _, err := client.MakeReservation(withKey("bird-1"), booking("Larry Bird", -1)); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument booking in the past, got %v", err)
+	}
+	if _, err := client.MakeReservation(withKey("bird-1"), booking("Larry Bird", 1)); err != nil {
+		t.Errorf("Expected the key to be released after a failure, got %v", err)
+	}
+
+	// Concurrent duplicates are applied once; the rest replay it or are
+	// told to retry.
+	var wg sync.WaitGroup
+	ids := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reply, err := client.MakeReservation(withKey("james-1"), booking("LeBron James", 1))
+			switch status.Code(err) {
+			case codes.OK:
+				ids <- reply.ReservationId
+			case codes.Aborted:
+			default:
+				t.Errorf("Unexpected error for a concurrent duplicate: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	distinct := make(map[string]bool)
+	for id := range ids {
+		distinct[id] = true
+	}
+	if len(distinct) != 1 || count("LeBron James") != 1 {
+		t.Errorf("Expected concurrent duplicates to book once, got IDs %v and %d reservations", distinct, count("LeBron James"))
+	}
+}
+
+// forgetfulDatabase is a database that fails to store idempotent responses.
+type forgetfulDatabase struct {
+	*services.MyDatabase
+}
+
+func (d forgetfulDatabase) WriteBatch(ctx context.Context, in *mydatabase.WriteBatchRequest) (*mydatabase.WriteBatchResponse, error) {
+	for _, op := range in.Operations {
+		stored := &mydatabase.IdempotentResponse{}
+		if strings.HasPrefix(op.GetRecord().GetKey(), "__idempotency:") && proto.Unmarshal(op.GetRecord().GetValue(), stored) == nil && stored.ResponseType != "" {
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		}
+	}
+	return d.MyDatabase.WriteBatch(ctx, in)
+}
+
+func TestIdempotencyStoreFailure(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	mydatabase.RegisterDatabaseServiceServer(server, forgetfulDatabase{services.NewMyDatabase("forgetful", 0, "none", 10, 0)})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	detailAddr, _ := startDetail(t)
+	port := freePort(t)
+	go services.NewReservation("reservation", port, startCache(t), lis.Addr().String(), detailAddr, 0).Run()
+	client := reservation.NewReservationServiceClient(connect(t, port))
+	eventually(t, "reservation to start", func() bool {
+		_, err := client.ListReservations(context.Background(), &reservation.ListReservationsRequest{UserName: "nobody"})
+		return err == nil
+	})
+
+	// A request whose response can't be stored fails, and a retry is told the
+	// first attempt still holds the key rather than being applied again.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "idempotency-key", "jordan-1")
+	booking := &reservation.MakeReservationRequest{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Time: future(1), StartTime: noon}
+	if _, err := client.MakeReservation(ctx, booking); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable when the response can't be stored, got %v", err)
+	}
+	if _, err := client.MakeReservation(ctx, booking); status.Code(err) != codes.Aborted {
+		t.Errorf("Expected Aborted retrying while the key is held, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"cse190-welp/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// unavailableDatabase is a replica that fails every write, either refused as
+// by a follower that doesn't know the leader, or after it may have been
+// proposed.
+type unavailableDatabase struct {
+	mydatabase.UnimplementedDatabaseServiceServer
+	refuse bool
+	writes atomic.Int32
+}
+
+func (d *unavailableDatabase) SetRecord(ctx context.Context, in *mydatabase.SetRecordRequest) (*mydatabase.SetRecordResponse, error) {
+	d.writes.Add(1)
+	if d.refuse {
+		grpc.SetTrailer(ctx, metadata.Pairs("raft-leader", ""))
+	}
+	return nil, status.Error(codes.Unavailable, "unavailable")
+}
+
+// startUnavailable runs an unavailableDatabase on a free port and returns its
+// address.
+func startUnavailable(t *testing.T, d *unavailableDatabase) string {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	mydatabase.RegisterDatabaseServiceServer(server, d)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestLeaderClientRetries(t *testing.T) {
+	ctx := context.Background()
+	sourceAddr, source := startDatabase(t)
+	id, _ := services.GetQueryUUID("Chick-fil-A", "Michael Jordan")
+	data, _ := proto.Marshal(&review.GetReviewResponse{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Rating: 5})
+	if _, err := source.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: id, Value: data}}); err != nil {
+		t.Fatal(err)
+	}
+	var backup bytes.Buffer
+	if _, err := services.ExportRecords(ctx, sourceAddr, "review", "jsonl", &backup); err != nil {
+		t.Fatal(err)
+	}
+
+	// Imports write unconditionally, so they are only retried on another
+	// replica when they were refused without being proposed.
+	for _, refuse := range []bool{true, false} {
+		replicas := []*unavailableDatabase{{refuse: refuse}, {refuse: refuse}}
+		addrs := fmt.Sprintf("%s,%s", startUnavailable(t, replicas[0]), startUnavailable(t, replicas[1]))
+		if _, err := services.ImportRecords(ctx, addrs, "review", "jsonl", bytes.NewReader(backup.Bytes()), 1); status.Code(err) != codes.Unavailable {
+			t.Errorf("Expected the import to fail with Unavailable, got %v", err)
+		}
+		writes := replicas[0].writes.Load() + replicas[1].writes.Load()
+		if refuse && (replicas[0].writes.Load() == 0 || replicas[1].writes.Load() == 0) {
+			t.Errorf("Expected a refused write to be retried on every replica, got %d and %d", replicas[0].writes.Load(), replicas[1].writes.Load())
+		}
+		if !refuse && writes != 1 {
+			t.Errorf("Expected a write that may have been proposed to be tried once, got %d", writes)
+		}
+	}
+}
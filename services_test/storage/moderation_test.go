@@ -0,0 +1,136 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"cse190-welp/proto/review"
+	"cse190-welp/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestModerateReviews(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, _ := startDatabase(t)
+	srv := services.NewReview("review", 0, startCache(t), databaseAddr, "length,banned-words,rate-limit,near-duplicate")
+
+	post := func(user, restaurant, text string) *review.PostReviewResponse {
+		t.Helper()
+		reply, err := srv.PostReview(ctx, &review.PostReviewRequest{UserName: user, RestaurantName: restaurant, Review: text, Rating: 4})
+		if err != nil || !reply.Status {
+			t.Fatalf("Expected the review to be submitted, got %v, %v", reply, err)
+		}
+		return reply
+	}
+	flagged := func(reply *review.PostReviewResponse, check string) bool {
+		for _, flag := range reply.ModerationFlags {
+			if strings.HasPrefix(flag, check+":") {
+				return reply.Pending
+			}
+		}
+		return false
+	}
+	published := func(user, restaurant string) bool {
+		_, err := srv.GetReview(ctx, &review.GetReviewRequest{UserName: user, RestaurantName: restaurant})
+		return err == nil
+	}
+	pending := func(restaurant string) []string {
+		t.Helper()
+		reply, err := srv.ListPendingReviews(ctx, &review.ListPendingReviewsRequest{RestaurantName: restaurant})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var users []string
+		for _, r := range reply.Reviews {
+			users = append(users, r.UserName)
+		}
+		return users
+	}
+
+	original := "The spicy chicken sandwich was crispy and juicy, the waffle fries were hot, and the lemonade was fresh and sweet."
+	if reply := post("Michael Jordan", "Chick-fil-A", original); reply.Pending {
+		t.Fatalf("Expected a clean review to be published, got %v", reply)
+	}
+	if !published("Michael Jordan", "Chick-fil-A") {
+		t.Error("Expected the clean review to be readable")
+	}
+
+	// Flagged reviews are held, unreadable until approved.
+	if reply := post("LeBron James", "Chick-fil-A", "This place is SHIT"); !flagged(reply, "banned-words") {
+		t.Errorf("Expected a banned word to be flagged, got %v", reply)
+	}
+	if reply := post("Kobe Bryant", "Chick-fil-A", "!!!"); !flagged(reply, "length") {
+		t.Errorf("Expected a review without words to be flagged, got %v", reply)
+	}
+	copied := strings.Replace(original, "lemonade", "iced tea", 1)
+	if reply := post("Larry Bird", "In-N-Out Burger", copied); !flagged(reply, "near-duplicate") {
+		t.Errorf("Expected a copied review to be flagged, got %v", reply)
+	}
+	if published("LeBron James", "Chick-fil-A") || published("Larry Bird", "In-N-Out Burger") {
+		t.Error("Expected held reviews not to be readable")
+	}
+	if users := pending("Chick-fil-A"); len(users) != 2 || users[0] != "LeBron James" || users[1] != "Kobe Bryant" {
+		t.Errorf("Expected the held reviews of Chick-fil-A oldest first, got %v", users)
+	}
+
+	// Reposting one's own review isn't a duplicate.
+	if reply := post("Michael Jordan", "Chick-fil-A", original+" Again!"); reply.Pending {
+		t.Errorf("Expected a reposted review to be published, got %v", reply)
+	}
+
+	// Approving publishes a held review; rejecting discards it.
+	approved, err := srv.ApproveReview(ctx, &review.ApproveReviewRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A"})
+	if err != nil || approved.Review.Review != "This place is SHIT" || len(approved.Review.ModerationFlags) != 0 {
+		t.Fatalf("Expected the review to be published, got %v, %v", approved, err)
+	}
+	if !published("LeBron James", "Chick-fil-A") {
+		t.Error("Expected the approved review to be readable")
+	}
+	if _, err := srv.RejectReview(ctx, &review.RejectReviewRequest{UserName: "Larry Bird", RestaurantName: "In-N-Out Burger"}); err != nil {
+		t.Fatal(err)
+	}
+	if published("Larry Bird", "In-N-Out Burger") {
+		t.Error("Expected the rejected review not to be readable")
+	}
+	for _, err := range []error{
+		func() error {
+			_, err := srv.ApproveReview(ctx, &review.ApproveReviewRequest{UserName: "Larry Bird", RestaurantName: "In-N-Out Burger"})
+			return err
+		}(),
+		func() error {
+			_, err := srv.RejectReview(ctx, &review.RejectReviewRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A"})
+			return err
+		}(),
+	} {
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("Expected NotFound once moderated, got %v", err)
+		}
+	}
+
+	// A clean review replaces one still held.
+	post("Kobe Bryant", "Chick-fil-A", "Good nuggets")
+	if users := pending(""); len(users) != 0 {
+		t.Errorf("Expected no reviews left to moderate, got %v", users)
+	}
+
+	// Users submitting too often are held.
+	for i := 0; i < 5; i++ {
+		if reply := post("Magic Johnson", fmt.Sprintf("Restaurant %d", i), "Fine"); reply.Pending {
+			t.Fatalf("Expected review %d to be published, got %v", i, reply)
+		}
+	}
+	if reply := post("Magic Johnson", "Restaurant 5", "Fine"); !flagged(reply, "rate-limit") {
+		t.Errorf("Expected a sixth review within the hour to be flagged, got %v", reply)
+	}
+
+	// Deleting a restaurant's reviews deletes those held too.
+	if _, err := srv.DeleteRestaurantReviews(ctx, &review.DeleteRestaurantReviewsRequest{RestaurantName: "Restaurant 5"}); err != nil {
+		t.Fatal(err)
+	}
+	if users := pending(""); len(users) != 0 {
+		t.Errorf("Expected the restaurant's held reviews to be deleted, got %v", users)
+	}
+}
@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	apps "cse190-welp/applications"
+	"cse190-welp/proto/mydatabase"
+)
+
+func newStorage(t *testing.T) *apps.EmulatedStorageApp {
+	s, err := apps.NewEmulatedStorageApp("none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSnapshotReads(t *testing.T) {
+	s := newStorage(t)
+
+	v1 := s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("a1")})
+	s.Set(&mydatabase.DatabaseRecord{Key: "b", Value: []byte("b1")})
+	v3 := s.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("a2")})
+	v4 := s.Delete("b")
+
+	if v1 >= v3 || v3 >= v4 {
+		t.Fatalf("Expected increasing versions, got %d, %d, %d", v1, v3, v4)
+	}
+
+	record, snapshot, err := s.GetAt("a", v1)
+	if err != nil || string(record.Value) != "a1" || snapshot != v1 {
+		t.Errorf("Expected a1 at snapshot %d, got %v (snapshot %d, err %v)", v1, record, snapshot, err)
+	}
+	record, _, err = s.GetAt("b", v3)
+	if err != nil || string(record.Value) != "b1" {
+		t.Errorf("Expected b1 at snapshot %d, got %v (err %v)", v3, record, err)
+	}
+	if _, _, err = s.GetAt("b", 0); err != apps.ErrRecordNotFound {
+		t.Errorf("Expected deleted record to be missing at latest snapshot, got %v", err)
+	}
+	if _, _, err = s.GetAt("b", v1); err != apps.ErrRecordNotFound {
+		t.Errorf("Expected record to be missing before its first write, got %v", err)
+	}
+	if _, ok := s.Get("b"); ok {
+		t.Errorf("Expected Get to skip tombstones")
+	}
+
+	history := s.History("b", 0)
+	if len(history) != 2 || !history[0].Deleted || string(history[1].Value) != "b1" {
+		t.Errorf("Unexpected history for b: %v", history)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	s := newStorage(t)
+	s.SetHistoryLimit(2)
+
+	first := s.Set(&mydatabase.DatabaseRecord{Key: "k", Value: []byte("1")})
+	s.Set(&mydatabase.DatabaseRecord{Key: "k", Value: []byte("2")})
+	s.Set(&mydatabase.DatabaseRecord{Key: "k", Value: []byte("3")})
+
+	if history := s.History("k", 0); len(history) != 2 || string(history[0].Value) != "3" {
+		t.Errorf("Expected two newest versions, got %v", history)
+	}
+	if history := s.History("k", 1); len(history) != 1 {
+		t.Errorf("Expected limit to cap history, got %d versions", len(history))
+	}
+	if _, _, err := s.GetAt("k", first); err != apps.ErrSnapshotTooOld {
+		t.Errorf("Expected trimmed version to be too old, got %v", err)
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	s := newStorage(t)
+
+	s.Set(&mydatabase.DatabaseRecord{Key: "kept", Value: []byte("old")})
+	s.Set(&mydatabase.DatabaseRecord{Key: "kept", Value: []byte("base")})
+	s.Set(&mydatabase.DatabaseRecord{Key: "gone", Value: []byte("x")})
+	s.Delete("gone")
+	cutoff := time.Now()
+	time.Sleep(time.Millisecond)
+	latest := s.Set(&mydatabase.DatabaseRecord{Key: "kept", Value: []byte("new")})
+
+	if dropped := s.CollectGarbage(cutoff); dropped != 3 {
+		t.Errorf("Expected 3 versions dropped, got %d", dropped)
+	}
+	if history := s.History("gone", 0); len(history) != 0 {
+		t.Errorf("Expected deleted key to be collected, got %v", history)
+	}
+	record, _, err := s.GetAt("kept", latest-1)
+	if err != nil || string(record.Value) != "base" {
+		t.Errorf("Expected base version to survive garbage collection, got %v (err %v)", record, err)
+	}
+	if _, _, err = s.GetAt("kept", 1); err != apps.ErrSnapshotTooOld {
+		t.Errorf("Expected snapshot before the horizon to be rejected, got %v", err)
+	}
+}
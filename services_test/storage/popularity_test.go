@@ -0,0 +1,263 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	apps "cse190-welp/applications"
+	"cse190-welp/proto/detail"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"cse190-welp/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPopularityCountersAreShared(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, database := startDatabase(t)
+	cacheAddr := startCache(t)
+	detailAddr, _ := startDetail(t)
+
+	// Reservations made before counters were stored are counted on first use.
+	legacy := &reservation.GetReservationResponse{UserName: "Larry Bird", RestaurantName: "In-N-Out Burger", Time: &reservation.Date{Year: 2024, Month: 5, Day: 1}}
+	id, _ := services.GetQueryUUID(legacy.RestaurantName, legacy.UserName)
+	data, _ := proto.Marshal(legacy)
+	if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: id, Value: data}}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := services.NewReservation("reservation-0", 0, cacheAddr, databaseAddr, detailAddr, 0)
+	second := services.NewReservation("reservation-1", 0, cacheAddr, databaseAddr, detailAddr, 0)
+	bookings := []*reservation.MakeReservationRequest{
+		{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Time: future(2), StartTime: noon},
+		{UserName: "LeBron James", RestaurantName: "Chick-fil-A", Time: future(3), StartTime: noon},
+		{UserName: "Kobe Bryant", RestaurantName: "Chipotle", Time: future(4), StartTime: noon},
+		{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Time: future(5), StartTime: noon},
+	}
+	for i, booking := range bookings {
+		replica := first
+		if i%2 == 1 {
+			replica = second
+		}
+		if reply, err := replica.MakeReservation(ctx, booking); err != nil || !reply.Status {
+			t.Fatalf("Expected reservation to succeed, got %v (err %v)", reply, err)
+		}
+	}
+
+	// Every replica converges on the same ranking.
+	want := []string{"Chick-fil-A", "Chipotle", "In-N-Out Burger"}
+	restarted := services.NewReservation("reservation-2", 0, cacheAddr, databaseAddr, detailAddr, 0)
+	for _, replica := range []*services.Reservation{first, second, restarted} {
+		eventually(t, "replica to rank every reservation", func() bool {
+			popular, err := replica.MostPopular(ctx, &reservation.MostPopularRequest{TopK: 3})
+			return err == nil && reflect.DeepEqual(popular.TopKRestaurants, want)
+		})
+	}
+
+	// A rebuild counts the same reservations and leaves the ranking unchanged.
+	rebuilt, err := restarted.RebuildPopularityTable(ctx, &reservation.RebuildPopularityTableRequest{})
+	if err != nil || rebuilt.Reservations != 5 {
+		t.Fatalf("Expected 5 reservations counted, got %v (err %v)", rebuilt, err)
+	}
+	popular, err := restarted.MostPopular(ctx, &reservation.MostPopularRequest{TopK: 1})
+	if err != nil || !reflect.DeepEqual(popular.TopKRestaurants, want[:1]) {
+		t.Errorf("Expected %v after rebuild, got %v (err %v)", want[:1], popular.GetTopKRestaurants(), err)
+	}
+
+	// A replica sees its own reservations in the very next ranking.
+	for _, user := range []string{"Kobe Bryant", "Shaquille O'Neal", "Tim Duncan", "Kobe Bryant"} {
+		booking := &reservation.MakeReservationRequest{UserName: user, RestaurantName: "In-N-Out Burger", Time: future(40), StartTime: noon}
+		if _, err := second.MakeReservation(ctx, booking); err != nil {
+			t.Fatal(err)
+		}
+	}
+	popular, err = second.MostPopular(ctx, &reservation.MostPopularRequest{TopK: 1})
+	if err != nil || !reflect.DeepEqual(popular.TopKRestaurants, []string{"In-N-Out Burger"}) {
+		t.Errorf("Expected In-N-Out Burger to lead, got %v (err %v)", popular.GetTopKRestaurants(), err)
+	}
+}
+
+// TestMostPopularAfterWaitlisting checks that a booking that only joins a
+// waitlist, and so isn't counted, doesn't hold up the next ranking.
+func TestMostPopularAfterWaitlisting(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, _ := startDatabase(t)
+	detailAddr, details := startDetail(t)
+	if _, err := details.PostDetail(ctx, &detail.PostDetailRequest{RestaurantName: "Chick-fil-A", Capacity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	srv := services.NewReservation("reservation", 0, startCache(t), databaseAddr, detailAddr, 0)
+	for _, user := range []string{"Michael Jordan", "LeBron James"} {
+		booking := &reservation.MakeReservationRequest{UserName: user, RestaurantName: "Chick-fil-A", Time: future(2), StartTime: noon, JoinWaitlist: true}
+		if _, err := srv.MakeReservation(ctx, booking); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now()
+	popular, err := srv.MostPopular(ctx, &reservation.MostPopularRequest{TopK: 1})
+	if err != nil || !reflect.DeepEqual(popular.TopKRestaurants, []string{"Chick-fil-A"}) {
+		t.Fatalf("Expected Chick-fil-A to lead, got %v (err %v)", popular.GetTopKRestaurants(), err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the ranking without waiting for the waitlisted booking, took %v", elapsed)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	names := func(ranked []apps.RankedCount) []string {
+		var result []string
+		for _, entry := range ranked {
+			result = append(result, entry.Name)
+		}
+		return result
+	}
+
+	exact := apps.NewExactTopKApp()
+	for _, name := range []string{"c", "b", "a", "b", "c", "d"} {
+		exact.Add(name, 1)
+	}
+	if got, want := names(exact.Top(3)), []string{"b", "c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected ties broken by name %v, got %v", want, got)
+	}
+	exact.Add("b", -2)
+	if got, want := names(exact.Top(10)), []string{"c", "a", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected b forgotten at zero %v, got %v", want, got)
+	}
+
+	// Space-Saving keeps heavy hitters and bounds the error of the rest.
+	approx := apps.NewSpaceSavingTopKApp(3)
+	total := 0
+	for i := 0; i < 100; i++ {
+		approx.Add("heavy", 1)
+		approx.Add(fmt.Sprintf("light-%d", i), 1)
+		total += 2
+	}
+	top := approx.Top(1)
+	if len(top) != 1 || top[0].Name != "heavy" || top[0].Count != 100 || top[0].Error != 0 {
+		t.Errorf("Expected heavy counted exactly, got %+v", top)
+	}
+	if bound := approx.ErrorBound(); bound <= 0 || bound > int64(total/3) {
+		t.Errorf("Expected an error bound within N/capacity = %d, got %d", total/3, bound)
+	}
+	for _, entry := range approx.Top(3)[1:] {
+		if entry.Count-entry.Error > 1 || entry.Count < 1 {
+			t.Errorf("Expected %s's true count 1 within [%d, %d]", entry.Name, entry.Count-entry.Error, entry.Count)
+		}
+	}
+
+	// Decrementing a name to zero frees a slot, but a name evicted earlier
+	// may still have a true count up to the error it was replaced with.
+	evicting := apps.NewSpaceSavingTopKApp(2)
+	for _, name := range []string{"a", "b", "c"} {
+		evicting.Add(name, 1)
+	}
+	evicting.Add("c", -2)
+	if tracked := len(evicting.Top(10)); tracked != 1 {
+		t.Errorf("Expected 1 name tracked after c dropped to zero, got %d", tracked)
+	}
+	if bound := evicting.ErrorBound(); bound != 1 {
+		t.Errorf("Expected the error given out, 1, to bound a tracker with a free slot, got %d", bound)
+	}
+
+	// Under many ties and decrements, both agree with sorting every count,
+	// as Space-Saving is exact while it has room for every name.
+	rng := rand.New(rand.NewSource(1))
+	exact, roomy := apps.NewExactTopKApp(), apps.NewSpaceSavingTopKApp(50)
+	counts := make(map[string]int64)
+	for i := 0; i < 5000; i++ {
+		name, delta := fmt.Sprintf("r%02d", rng.Intn(50)), int64(rng.Intn(3)-1)
+		exact.Add(name, delta)
+		roomy.Add(name, delta)
+		if counts[name]+delta > 0 {
+			counts[name] += delta
+		} else {
+			delete(counts, name)
+		}
+		if i%100 != 0 {
+			continue
+		}
+		var want []string
+		for name := range counts {
+			want = append(want, name)
+		}
+		sort.Slice(want, func(i, j int) bool {
+			if counts[want[i]] != counts[want[j]] {
+				return counts[want[i]] > counts[want[j]]
+			}
+			return want[i] < want[j]
+		})
+		want = want[:min(10, len(want))]
+		if got := names(exact.Top(10)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Expected exact top 10 %v, got %v", want, got)
+		}
+		if got := names(roomy.Top(10)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Expected space-saving top 10 %v, got %v", want, got)
+		}
+	}
+}
+
+func TestPopularityWindows(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, database := startDatabase(t)
+	detailAddr, _ := startDetail(t)
+	srv := services.NewReservation("reservation", 0, startCache(t), databaseAddr, detailAddr, 0)
+	book := func(user, restaurant string, day int32) {
+		booking := &reservation.MakeReservationRequest{UserName: user, RestaurantName: restaurant, Time: future(day), StartTime: noon}
+		if _, err := srv.MakeReservation(ctx, booking); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Hourly counters past their retention are pruned as counters are written.
+	expired := fmt.Sprintf("__popularity-hour:%010d:Chipotle", time.Now().Add(-100*24*time.Hour).Unix()/3600)
+	data, _ := proto.Marshal(&reservation.PopularityCount{Count: 7})
+	if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: expired, Value: data}}); err != nil {
+		t.Fatal(err)
+	}
+	book("Michael Jordan", "Chick-fil-A", 1)
+	if _, err := database.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: expired}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected the expired hourly counter to be pruned, got %v", err)
+	}
+	book("LeBron James", "Chick-fil-A", 2)
+	book("Kobe Bryant", "Chipotle", 10)
+	book("Larry Bird", "Chipotle", 11)
+	book("Tim Duncan", "Chipotle", 12)
+
+	check := func(req *reservation.MostPopularRequest, want []string) {
+		t.Helper()
+		popular, err := srv.MostPopular(ctx, req)
+		if err != nil || !reflect.DeepEqual(popular.TopKRestaurants, want) {
+			t.Errorf("Expected %v for %v, got %v (err %v)", want, req, popular.GetTopKRestaurants(), err)
+		}
+	}
+	check(&reservation.MostPopularRequest{TopK: 5, FromDate: future(1), ToDate: future(9)}, []string{"Chick-fil-A"})
+	check(&reservation.MostPopularRequest{TopK: 5, FromDate: future(2), ToDate: future(10)}, []string{"Chick-fil-A", "Chipotle"})
+	check(&reservation.MostPopularRequest{TopK: 5, WindowHours: 2}, []string{"Chipotle", "Chick-fil-A"})
+	if _, err := srv.MostPopular(ctx, &reservation.MostPopularRequest{TopK: 5, WindowHours: 1, FromDate: future(1)}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument mixing windows, got %v", err)
+	}
+
+	// Nothing was booked in the previous week, so the busiest grew the most.
+	trending, err := srv.Trending(ctx, &reservation.TrendingRequest{TopK: 5})
+	if err != nil || len(trending.Restaurants) != 2 {
+		t.Fatalf("Expected 2 trending restaurants, got %v (err %v)", trending, err)
+	}
+	if first := trending.Restaurants[0]; first.RestaurantName != "Chipotle" || first.Count != 3 || first.PreviousCount != 0 || first.Growth != 3 {
+		t.Errorf("Expected Chipotle to trend with growth 3, got %v", first)
+	}
+
+	// A rebuild arrives at the same windowed counts.
+	if _, err := srv.RebuildPopularityTable(ctx, &reservation.RebuildPopularityTableRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	check(&reservation.MostPopularRequest{TopK: 5, FromDate: future(11)}, []string{"Chipotle"})
+	check(&reservation.MostPopularRequest{TopK: 5, WindowHours: 24}, []string{"Chipotle", "Chick-fil-A"})
+}
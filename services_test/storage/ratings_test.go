@@ -0,0 +1,94 @@
+package services_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"cse190-welp/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRatingSummary(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, database := startDatabase(t)
+
+	// Reviews written before ratings were aggregated are counted on first use.
+	legacy := &review.GetReviewResponse{UserName: "Larry Bird", RestaurantName: "Chick-fil-A", Review: "Fine", Rating: 3}
+	id, _ := services.GetQueryUUID(legacy.RestaurantName, legacy.UserName)
+	data, _ := proto.Marshal(legacy)
+	if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: id, Value: data}}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := services.NewReview("review", 0, startCache(t), databaseAddr, "")
+	post := func(user, restaurant string, rating int32) error {
+		_, err := srv.PostReview(ctx, &review.PostReviewRequest{UserName: user, RestaurantName: restaurant, Review: "Review", Rating: rating})
+		return err
+	}
+	for _, r := range []struct {
+		user, restaurant string
+		rating           int32
+	}{
+		{"Michael Jordan", "Chick-fil-A", 5},
+		{"LeBron James", "Chick-fil-A", 4},
+		{"Kobe Bryant", "Chipotle", 1},
+	} {
+		if err := post(r.user, r.restaurant, r.rating); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(restaurant string, want *review.GetRatingSummaryResponse) {
+		t.Helper()
+		got, err := srv.GetRatingSummary(ctx, &review.GetRatingSummaryRequest{RestaurantName: restaurant})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Count != want.Count || got.Mean != want.Mean || !reflect.DeepEqual(got.Histogram, want.Histogram) || got.BayesianScore != want.BayesianScore {
+			t.Errorf("Expected %v for %s, got %v", want, restaurant, got)
+		}
+	}
+
+	// Every review has a mean of 13/4, weighted in as 5 reviews.
+	check("Chick-fil-A", &review.GetRatingSummaryResponse{Count: 3, Mean: 4, Histogram: []int64{0, 0, 1, 1, 1}, BayesianScore: (5*13.0/4 + 12) / 8})
+	check("Chipotle", &review.GetRatingSummaryResponse{Count: 1, Mean: 1, Histogram: []int64{1, 0, 0, 0, 0}, BayesianScore: (5*13.0/4 + 1) / 6})
+	check("Panda Express", &review.GetRatingSummaryResponse{Histogram: []int64{0, 0, 0, 0, 0}})
+
+	// Reposting replaces the earlier rating.
+	if err := post("Michael Jordan", "Chick-fil-A", 2); err != nil {
+		t.Fatal(err)
+	}
+	check("Chick-fil-A", &review.GetRatingSummaryResponse{Count: 3, Mean: 3, Histogram: []int64{0, 1, 1, 1, 0}, BayesianScore: (5*10.0/4 + 9) / 8})
+	if err := post("Michael Jordan", "Chick-fil-A", 6); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a rating of 6, got %v", err)
+	}
+
+	// Deleting a restaurant's reviews takes them out of every aggregate, and a
+	// rebuild agrees.
+	if _, err := srv.DeleteRestaurantReviews(ctx, &review.DeleteRestaurantReviewsRequest{RestaurantName: "Chipotle"}); err != nil {
+		t.Fatal(err)
+	}
+	want := &review.GetRatingSummaryResponse{Count: 3, Mean: 3, Histogram: []int64{0, 1, 1, 1, 0}, BayesianScore: 3}
+	check("Chick-fil-A", want)
+	check("Chipotle", &review.GetRatingSummaryResponse{Histogram: []int64{0, 0, 0, 0, 0}})
+	if _, err := srv.RebuildLookupTable(ctx, &review.RebuildLookupTableRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	check("Chick-fil-A", want)
+
+	// Without an aggregate of every review, the prior is a neutral 3 stars.
+	shards, err := database.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{Prefix: "__ratings-all:"})
+	if err != nil || len(shards.Records) == 0 {
+		t.Fatalf("Expected the aggregate of every review stored, got %v (err %v)", shards, err)
+	}
+	for _, shard := range shards.Records {
+		if _, err := database.DeleteRecord(ctx, &mydatabase.DeleteRecordRequest{Key: shard.Key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check("Chick-fil-A", &review.GetRatingSummaryResponse{Count: 3, Mean: 3, Histogram: []int64{0, 1, 1, 1, 0}, BayesianScore: (5*3.0 + 9) / 8})
+}
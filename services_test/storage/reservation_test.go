@@ -0,0 +1,444 @@
+package services_test
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"cse190-welp/proto/detail"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"cse190-welp/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// startDetail runs a detail service, with its own cache and database, on a
+// free port and returns its address.
+func startDetail(t *testing.T) (string, detail.DetailServiceClient) {
+	databaseAddr, _ := startDatabase(t)
+	port := freePort(t)
+	go services.NewDetail("detail", port, startCache(t), databaseAddr, "", "").Run()
+	client := detail.NewDetailServiceClient(connect(t, port))
+	eventually(t, "detail to start", func() bool {
+		_, err := client.GetDetail(context.Background(), &detail.GetDetailRequest{RestaurantName: "nowhere"})
+		return status.Code(err) == codes.NotFound
+	})
+	return fmt.Sprintf("localhost:%d", port), client
+}
+
+// noon is the start time of bookings whose time doesn't matter.
+var noon = &reservation.TimeOfDay{Hour: 12}
+
+// future returns the date days from today, so bookings are never in the past.
+func future(days int32) *reservation.Date {
+	day := time.Now().AddDate(0, 0, int(days))
+	return &reservation.Date{Year: int32(day.Year()), Month: int32(day.Month()), Day: int32(day.Day())}
+}
+
+func TestReservationIDs(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, database := startDatabase(t)
+	cacheAddr := startCache(t)
+	detailAddr, _ := startDetail(t)
+
+	// Reservations stored before they had IDs are identified by their key.
+	legacy := &reservation.GetReservationResponse{UserName: "Michael Jordan", RestaurantName: "Chipotle", Time: future(1)}
+	legacyID, _ := services.GetQueryUUID(legacy.RestaurantName, legacy.UserName)
+	data, _ := proto.Marshal(legacy)
+	if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: legacyID, Value: data}}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := services.NewReservation("reservation", 0, cacheAddr, databaseAddr, detailAddr, 0)
+	var ids []string
+	for _, booking := range []*reservation.MakeReservationRequest{
+		{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Time: future(20), StartTime: noon},
+		{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Time: future(10), StartTime: noon},
+		{UserName: "LeBron James", RestaurantName: "Chick-fil-A", Time: future(15), StartTime: noon},
+	} {
+		reply, err := srv.MakeReservation(ctx, booking)
+		if err != nil || reply.ReservationId == "" {
+			t.Fatalf("Expected a reservation ID, got %v (err %v)", reply, err)
+		}
+		ids = append(ids, reply.ReservationId)
+	}
+	if ids[0] == ids[1] {
+		t.Fatalf("Expected a second booking to get its own ID, got %s twice", ids[0])
+	}
+
+	// Both of Jordan's bookings at Chick-fil-A are kept.
+	for i, id := range ids[:2] {
+		got, err := srv.GetReservation(ctx, &reservation.GetReservationRequest{ReservationId: id})
+		if err != nil || got.ReservationId != id || !proto.Equal(got.Time, future([]int32{20, 10}[i])) {
+			t.Errorf("Expected reservation %s, got %v (err %v)", id, got, err)
+		}
+	}
+	latest, err := srv.GetReservation(ctx, &reservation.GetReservationRequest{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A"})
+	if err != nil || latest.ReservationId != ids[0] {
+		t.Errorf("Expected the latest reservation %s, got %v (err %v)", ids[0], latest, err)
+	}
+
+	list := func(req *reservation.ListReservationsRequest) []string {
+		t.Helper()
+		reply, err := srv.ListReservations(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range reply.Reservations {
+			got = append(got, r.ReservationId)
+		}
+		return got
+	}
+	cases := []struct {
+		req  *reservation.ListReservationsRequest
+		want []string
+	}{
+		{&reservation.ListReservationsRequest{UserName: "Michael Jordan"}, []string{legacyID, ids[1], ids[0]}},
+		{&reservation.ListReservationsRequest{RestaurantName: "Chick-fil-A"}, []string{ids[1], ids[2], ids[0]}},
+		{&reservation.ListReservationsRequest{RestaurantName: "Chick-fil-A", FromDate: future(11), ToDate: future(20)}, []string{ids[2], ids[0]}},
+		{&reservation.ListReservationsRequest{UserName: "Michael Jordan", RestaurantName: "Chipotle"}, []string{legacyID}},
+	}
+	for _, c := range cases {
+		if got := list(c.req); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Expected %v for %v, got %v", c.want, c.req, got)
+		}
+	}
+}
+
+func TestReservationCapacity(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, _ := startDatabase(t)
+	cacheAddr := startCache(t)
+	detailAddr, details := startDetail(t)
+	for name, capacity := range map[string]int32{"Chick-fil-A": 2, "In-N-Out Burger": 1} {
+		if _, err := details.PostDetail(ctx, &detail.PostDetailRequest{RestaurantName: name, Capacity: capacity}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first := services.NewReservation("reservation-0", 0, cacheAddr, databaseAddr, detailAddr, 0)
+	second := services.NewReservation("reservation-1", 0, cacheAddr, databaseAddr, detailAddr, 0)
+	book := func(srv *services.Reservation, user, restaurant string, day int32) error {
+		booking := &reservation.MakeReservationRequest{UserName: user, RestaurantName: restaurant, Time: future(day), StartTime: noon}
+		_, err := srv.MakeReservation(ctx, booking)
+		return err
+	}
+
+	for _, user := range []string{"Michael Jordan", "LeBron James"} {
+		if err := book(first, user, "Chick-fil-A", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := book(second, "Kobe Bryant", "Chick-fil-A", 1); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted overbooking, got %v", err)
+	}
+	if err := book(second, "Kobe Bryant", "Chick-fil-A", 2); err != nil {
+		t.Errorf("Expected another date to have seats, got %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := book(first, "Larry Bird", "Chipotle", 1); err != nil {
+			t.Errorf("Expected a restaurant without details to be unlimited, got %v", err)
+		}
+	}
+
+	// Replicas racing for the last seat can't both get it.
+	var wg sync.WaitGroup
+	results := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			srv := []*services.Reservation{first, second}[i%2]
+			results <- book(srv, fmt.Sprintf("user-%d", i), "In-N-Out Burger", 1)
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+	booked := 0
+	for err := range results {
+		switch status.Code(err) {
+		case codes.OK:
+			booked++
+		case codes.ResourceExhausted, codes.Aborted:
+		default:
+			t.Errorf("Unexpected error racing for the last seat: %v", err)
+		}
+	}
+	if booked != 1 {
+		t.Errorf("Expected exactly 1 booking of the last seat, got %d", booked)
+	}
+	list, err := first.ListReservations(ctx, &reservation.ListReservationsRequest{RestaurantName: "In-N-Out Burger"})
+	if err != nil || len(list.Reservations) != 1 {
+		t.Errorf("Expected 1 In-N-Out Burger reservation stored, got %v (err %v)", list, err)
+	}
+}
+
+func TestReservationSlots(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, _ := startDatabase(t)
+	detailAddr, details := startDetail(t)
+	var hours []*detail.OpeningHours
+	for day := int32(0); day < 7; day++ {
+		hours = append(hours, &detail.OpeningHours{DayOfWeek: day, OpensAt: 17 * 60, ClosesAt: 22 * 60})
+	}
+	if _, err := details.PostDetail(ctx, &detail.PostDetailRequest{RestaurantName: "Chick-fil-A", Capacity: 4, OpeningHours: hours}); err != nil {
+		t.Fatal(err)
+	}
+	srv := services.NewReservation("reservation", 0, startCache(t), databaseAddr, detailAddr, 0)
+	book := func(date *reservation.Date, hour, minute, party int32) error {
+		booking := &reservation.MakeReservationRequest{
+			UserName:       "Michael Jordan",
+			RestaurantName: "Chick-fil-A",
+			Time:           date,
+			StartTime:      &reservation.TimeOfDay{Hour: hour, Minute: minute},
+			PartySize:      party,
+		}
+		_, err := srv.MakeReservation(ctx, booking)
+		return err
+	}
+
+	invalid := []struct {
+		name string
+		date *reservation.Date
+		hour int32
+	}{
+		{"in the past", future(-1), 18},
+		{"not a calendar date", &reservation.Date{Year: 2100, Month: 2, Day: 30}, 18},
+		{"before opening", future(7), 16},
+		{"past closing", future(7), 21},
+	}
+	for _, c := range invalid {
+		if err := book(c.date, c.hour, 0, 1); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument booking %s, got %v", c.name, err)
+		}
+	}
+
+	// Parties take seats in every slot they overlap.
+	day := future(7)
+	if err := book(day, 18, 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := book(day, 19, 0, 2); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted for an overlapping party, got %v", err)
+	}
+	if err := book(day, 19, 0, 1); err != nil {
+		t.Errorf("Expected the last seat at 19:00, got %v", err)
+	}
+	if err := book(day, 19, 30, 2); err != nil {
+		t.Errorf("Expected 2 seats at 19:30, got %v", err)
+	}
+
+	// Booked: 18:00-19:30 3 seats, 19:00-20:30 1 seat, 19:30-21:00 2 seats.
+	// Only 20:30 leaves room for 2 for 90 minutes before closing.
+	reply, err := srv.GetAvailability(ctx, &reservation.GetAvailabilityRequest{RestaurantName: "Chick-fil-A", FromDate: day, PartySize: 2})
+	want := []*reservation.AvailableSlot{{Date: day, StartTime: &reservation.TimeOfDay{Hour: 20, Minute: 30}, AvailableSeats: 2}}
+	if err != nil || reply.Unlimited || len(reply.Slots) != 1 || !proto.Equal(reply.Slots[0], want[0]) {
+		t.Errorf("Expected %v, got %v (err %v)", want, reply, err)
+	}
+	reply, err = srv.GetAvailability(ctx, &reservation.GetAvailabilityRequest{RestaurantName: "Chick-fil-A", FromDate: day, ToDate: future(8), DurationMinutes: 60})
+	if err != nil || len(reply.Slots) != 16 {
+		t.Errorf("Expected 16 one-hour slots over two days, got %v (err %v)", reply, err)
+	}
+	reply, err = srv.GetAvailability(ctx, &reservation.GetAvailabilityRequest{RestaurantName: "Chipotle", FromDate: day})
+	if err != nil || !reply.Unlimited || len(reply.Slots) != 48-2 {
+		t.Errorf("Expected unlimited slots all day at a restaurant without details, got %v (err %v)", reply, err)
+	}
+	if _, err := srv.GetAvailability(ctx, &reservation.GetAvailabilityRequest{RestaurantName: "Chick-fil-A", FromDate: day, ToDate: future(60)}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a range over 31 days, got %v", err)
+	}
+}
+
+func TestCancelModifyAndWaitlist(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, _ := startDatabase(t)
+	detailAddr, details := startDetail(t)
+	if _, err := details.PostDetail(ctx, &detail.PostDetailRequest{RestaurantName: "Chick-fil-A", Capacity: 2}); err != nil {
+		t.Fatal(err)
+	}
+	srv := services.NewReservation("reservation", 0, startCache(t), databaseAddr, detailAddr, 0)
+	day := future(3)
+	book := func(user string, minute, party int32, waitlist bool) (*reservation.MakeReservationResponse, error) {
+		return srv.MakeReservation(ctx, &reservation.MakeReservationRequest{
+			UserName:       user,
+			RestaurantName: "Chick-fil-A",
+			Time:           day,
+			StartTime:      &reservation.TimeOfDay{Hour: 18, Minute: minute},
+			PartySize:      party,
+			JoinWaitlist:   waitlist,
+		})
+	}
+	statusOf := func(id string) reservation.ReservationStatus {
+		t.Helper()
+		r, err := srv.GetReservation(ctx, &reservation.GetReservationRequest{ReservationId: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.Status
+	}
+	ids := func(reservations []*reservation.GetReservationResponse) []string {
+		var ids []string
+		for _, r := range reservations {
+			ids = append(ids, r.ReservationId)
+		}
+		return ids
+	}
+
+	jordan, err := book("Michael Jordan", 0, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := book("LeBron James", 0, 1, false); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted without joining the waitlist, got %v", err)
+	}
+	var waiting []string
+	for _, b := range []struct {
+		user          string
+		minute, party int32
+	}{{"LeBron James", 0, 1}, {"Kobe Bryant", 30, 2}, {"Larry Bird", 0, 1}} {
+		reply, err := book(b.user, b.minute, b.party, true)
+		if err != nil || !reply.Waitlisted {
+			t.Fatalf("Expected %s to be waitlisted, got %v (err %v)", b.user, reply, err)
+		}
+		waiting = append(waiting, reply.ReservationId)
+	}
+	if got := statusOf(waiting[0]); got != reservation.ReservationStatus_WAITLISTED {
+		t.Errorf("Expected WAITLISTED, got %v", got)
+	}
+	if statusOf(jordan.ReservationId) != reservation.ReservationStatus_CONFIRMED {
+		t.Errorf("Expected the first booking to be confirmed")
+	}
+
+	// Shrinking the party frees a seat for the first in line.
+	modified, err := srv.ModifyReservation(ctx, &reservation.ModifyReservationRequest{ReservationId: jordan.ReservationId, PartySize: 1})
+	if err != nil || modified.Reservation.PartySize != 1 || !reflect.DeepEqual(ids(modified.Promoted), waiting[:1]) {
+		t.Fatalf("Expected %v promoted, got %v (err %v)", waiting[:1], modified, err)
+	}
+	if got := statusOf(waiting[0]); got != reservation.ReservationStatus_CONFIRMED {
+		t.Errorf("Expected the promoted reservation to be confirmed, got %v", got)
+	}
+	if _, err := srv.ModifyReservation(ctx, &reservation.ModifyReservationRequest{ReservationId: jordan.ReservationId, PartySize: 3}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted growing the party, got %v", err)
+	}
+	if _, err := srv.ModifyReservation(ctx, &reservation.ModifyReservationRequest{ReservationId: waiting[1], PartySize: 1}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition modifying a waitlisted reservation, got %v", err)
+	}
+
+	// Cancelling frees another seat, enough for Bird's party of 1 but not
+	// for Bryant's party of 2.
+	cancelled, err := srv.CancelReservation(ctx, &reservation.CancelReservationRequest{ReservationId: jordan.ReservationId})
+	if err != nil || !reflect.DeepEqual(ids(cancelled.Promoted), waiting[2:]) {
+		t.Fatalf("Expected %v promoted, got %v (err %v)", waiting[2:], cancelled, err)
+	}
+	if got := statusOf(jordan.ReservationId); got != reservation.ReservationStatus_CANCELLED {
+		t.Errorf("Expected the cached reservation to be invalidated and CANCELLED, got %v", got)
+	}
+	if _, err := srv.CancelReservation(ctx, &reservation.CancelReservationRequest{ReservationId: jordan.ReservationId}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition cancelling twice, got %v", err)
+	}
+	if _, err := srv.CancelReservation(ctx, &reservation.CancelReservationRequest{ReservationId: "nowhere"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+	if reply, err := srv.CancelReservation(ctx, &reservation.CancelReservationRequest{ReservationId: waiting[1]}); err != nil || len(reply.Promoted) != 0 {
+		t.Errorf("Expected a waitlisted reservation to cancel without promotions, got %v (err %v)", reply, err)
+	}
+
+	// Moving a reservation frees its old seats and takes new ones.
+	moved, err := srv.ModifyReservation(ctx, &reservation.ModifyReservationRequest{ReservationId: waiting[0], Time: future(4)})
+	if err != nil || !proto.Equal(moved.Reservation.Time, future(4)) {
+		t.Fatalf("Expected the reservation moved, got %v (err %v)", moved, err)
+	}
+
+	// The derived records a rebuild arrives at match those kept up to date.
+	availability := func() *reservation.GetAvailabilityResponse {
+		t.Helper()
+		reply, err := srv.GetAvailability(ctx, &reservation.GetAvailabilityRequest{RestaurantName: "Chick-fil-A", FromDate: day, ToDate: future(4)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply
+	}
+	want := availability()
+	rebuilt, err := srv.RebuildPopularityTable(ctx, &reservation.RebuildPopularityTableRequest{})
+	if err != nil || rebuilt.Reservations != 2 {
+		t.Errorf("Expected 2 confirmed reservations counted, got %v (err %v)", rebuilt, err)
+	}
+	if got := availability(); !proto.Equal(got, want) {
+		t.Errorf("Expected availability unchanged by a rebuild")
+	}
+	popular, err := srv.MostPopular(ctx, &reservation.MostPopularRequest{TopK: 5, FromDate: day, ToDate: day})
+	if err != nil || !reflect.DeepEqual(popular.TopKRestaurants, []string{"Chick-fil-A"}) {
+		t.Errorf("Expected Bird's reservation counted on %v, got %v (err %v)", day, popular, err)
+	}
+}
+
+// shiftSampleDates moves the Year, Month and Day columns of the sample
+// reservations after the header row to start tomorrow, keeping the days
+// between them, as reservations can't be made in the past.
+func shiftSampleDates(t *testing.T, records [][]string) {
+	dates := make([]time.Time, len(records))
+	var earliest time.Time
+	for i := 1; i < len(records); i++ {
+		year, yearErr := strconv.Atoi(records[i][2])
+		month, monthErr := strconv.Atoi(records[i][3])
+		day, dayErr := strconv.Atoi(records[i][4])
+		if yearErr != nil || monthErr != nil || dayErr != nil {
+			t.Fatalf("Malformed sample date in %v", records[i])
+		}
+		dates[i] = time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		if earliest.IsZero() || dates[i].Before(earliest) {
+			earliest = dates[i]
+		}
+	}
+	now := time.Now()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	days := int(tomorrow.Sub(earliest) / (24 * time.Hour))
+	for i := 1; i < len(records); i++ {
+		date := dates[i].AddDate(0, 0, days)
+		records[i][2], records[i][3], records[i][4] = strconv.Itoa(date.Year()), strconv.Itoa(int(date.Month())), strconv.Itoa(date.Day())
+	}
+}
+
+func TestSampleReservations(t *testing.T) {
+	databaseAddr, _ := startDatabase(t)
+	detailAddr, _ := startDetail(t)
+	reservationPort := freePort(t)
+	go services.NewReservation("reservation", reservationPort, startCache(t), databaseAddr, detailAddr, 0).Run()
+	frontendPort := freePort(t)
+	go services.NewFrontend(frontendPort, detailAddr, detailAddr, fmt.Sprintf("localhost:%d", reservationPort)).Run()
+
+	// The samples are booked as the load scripts book them, by date alone.
+	file, err := os.Open("../../samples/reservation_samples.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shiftSampleDates(t, rows)
+	for _, row := range rows[1:] {
+		query := url.Values{"user_name": {row[0]}, "restaurant_name": {row[1]}, "year": {row[2]}, "month": {row[3]}, "day": {row[4]}}
+		var resp *http.Response
+		eventually(t, "frontend to start", func() bool {
+			resp, err = http.Get(fmt.Sprintf("http://localhost:%d/make-reservation?%s", frontendPort, query.Encode()))
+			return err == nil
+		})
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected the sample booking %v to succeed, got %d %s", row, resp.StatusCode, body)
+		}
+	}
+}
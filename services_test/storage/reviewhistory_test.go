@@ -0,0 +1,146 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"cse190-welp/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEditAndDeleteReview(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, database := startDatabase(t)
+	srv := services.NewReview("review", 0, startCache(t), databaseAddr, "")
+
+	post := func(user, text string, rating int32) {
+		t.Helper()
+		if _, err := srv.PostReview(ctx, &review.PostReviewRequest{UserName: user, RestaurantName: "Chick-fil-A", Review: text, Rating: rating}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func(user string) (*review.GetReviewResponse, error) {
+		return srv.GetReview(ctx, &review.GetReviewRequest{UserName: user, RestaurantName: "Chick-fil-A"})
+	}
+	count := func() int64 {
+		t.Helper()
+		summary, err := srv.GetRatingSummary(ctx, &review.GetRatingSummaryRequest{RestaurantName: "Chick-fil-A"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return summary.Count
+	}
+	history := func() []*review.GetReviewResponse {
+		t.Helper()
+		reply, err := srv.GetReviewHistory(ctx, &review.GetReviewHistoryRequest{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply.Revisions
+	}
+	post("Michael Jordan", "Great nuggets", 5)
+	post("LeBron James", "Fine", 3)
+	posted, err := get("Michael Jordan") // cached
+	if err != nil || posted.Revision != 1 || posted.PostedAt == 0 {
+		t.Fatalf("Expected the first revision of the review, got %v, %v", posted, err)
+	}
+
+	// Editing changes what is read, through the cache, and keeps the earlier
+	// version.
+	reply, err := srv.EditReview(ctx, &review.EditReviewRequest{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Rating: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := reply.Review
+	if edited.Review != "Great nuggets" || edited.Rating != 2 || edited.Revision != 2 || edited.PostedAt != posted.PostedAt || edited.EditedAt == 0 {
+		t.Errorf("Expected the rating to be edited, got %v", edited)
+	}
+	if got, err := get("Michael Jordan"); err != nil || got.Rating != 2 {
+		t.Errorf("Expected the edited review, got %v, %v", got, err)
+	}
+	summary, _ := srv.GetRatingSummary(ctx, &review.GetRatingSummaryRequest{RestaurantName: "Chick-fil-A"})
+	if summary.GetMean() != 2.5 {
+		t.Errorf("Expected the edited rating in the summary, got %v", summary)
+	}
+	if _, err := srv.EditReview(ctx, &review.EditReviewRequest{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Review: "Cold nuggets"}); err != nil {
+		t.Fatal(err)
+	}
+	text, err := srv.SearchReviewText(ctx, &review.SearchReviewTextRequest{Query: "great"})
+	if err != nil || len(text.Matches) != 0 {
+		t.Errorf("Expected the edited text out of the text index, got %v, %v", text, err)
+	}
+
+	// Deleting hides the review but keeps its history.
+	if _, err := srv.DeleteReview(ctx, &review.DeleteReviewRequest{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get("Michael Jordan"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for a deleted review, got %v", err)
+	}
+	search, err := srv.SearchReviews(ctx, &review.SearchReviewsRequest{RestaurantName: "Chick-fil-A"})
+	if err != nil || len(search.ReviewsMap) != 1 {
+		t.Errorf("Expected only the other review, got %v, %v", search, err)
+	}
+	page, err := srv.SearchReviews(ctx, &review.SearchReviewsRequest{RestaurantName: "Chick-fil-A", PageSize: 10})
+	if err != nil || len(page.Reviews) != 1 {
+		t.Errorf("Expected only the other review, got %v, %v", page, err)
+	}
+	if got := count(); got != 1 {
+		t.Errorf("Expected 1 rating after the delete, got %d", got)
+	}
+	revisions := history()
+	if len(revisions) != 4 || revisions[0].Review != "Great nuggets" || revisions[0].Rating != 5 || revisions[1].Rating != 2 || revisions[2].Review != "Cold nuggets" || !revisions[3].Deleted || revisions[3].Revision != 4 {
+		t.Errorf("Expected every revision, got %v", revisions)
+	}
+
+	for _, err := range []error{
+		func() error {
+			_, err := srv.DeleteReview(ctx, &review.DeleteReviewRequest{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A"})
+			return err
+		}(),
+		func() error {
+			_, err := srv.EditReview(ctx, &review.EditReviewRequest{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Rating: 4})
+			return err
+		}(),
+		func() error {
+			_, err := srv.GetReviewHistory(ctx, &review.GetReviewHistoryRequest{UserName: "Kobe Bryant", RestaurantName: "Chick-fil-A"})
+			return err
+		}(),
+	} {
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("Expected NotFound, got %v", err)
+		}
+	}
+	for _, req := range []*review.EditReviewRequest{
+		{UserName: "LeBron James", RestaurantName: "Chick-fil-A"},
+		{UserName: "LeBron James", RestaurantName: "Chick-fil-A", Rating: 9},
+	} {
+		if _, err := srv.EditReview(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", req, err)
+		}
+	}
+
+	// Posting again restores the review, and a rebuild agrees on what counts.
+	post("Michael Jordan", "Back again", 4)
+	if got, err := get("Michael Jordan"); err != nil || got.Revision != 5 || got.Deleted {
+		t.Errorf("Expected the review restored, got %v, %v", got, err)
+	}
+	if _, err := srv.RebuildLookupTable(ctx, &review.RebuildLookupTableRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(); got != 2 {
+		t.Errorf("Expected 2 ratings after the rebuild, got %d", got)
+	}
+
+	// Deleting the restaurant's reviews deletes their history too.
+	if _, err := srv.DeleteRestaurantReviews(ctx, &review.DeleteRestaurantReviewsRequest{RestaurantName: "Chick-fil-A"}); err != nil {
+		t.Fatal(err)
+	}
+	scan, err := database.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{Prefix: "__revisions:"})
+	if err != nil || len(scan.Records) != 0 {
+		t.Errorf("Expected no revisions left, got %v, %v", scan, err)
+	}
+}
@@ -0,0 +1,260 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"cse190-welp/proto/mycache"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"cse190-welp/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// startCache runs a cache on a free port and returns its address.
+func startCache(t *testing.T) string {
+	port := freePort(t)
+	go services.NewMyCache("cache", port, 100).Run()
+	client := mycache.NewCacheServiceClient(connect(t, port))
+	eventually(t, "cache to start", func() bool {
+		_, err := client.ClearItems(context.Background(), &mycache.ClearItemsRequest{})
+		return err == nil
+	})
+	return fmt.Sprintf("localhost:%d", port)
+}
+
+func TestConditionalWritesAndBatches(t *testing.T) {
+	db := newLocalDatabase("database")
+	ctx := context.Background()
+	record := func(key, value string) *mydatabase.DatabaseRecord {
+		return &mydatabase.DatabaseRecord{Key: key, Value: []byte(value)}
+	}
+
+	// Zero expects the key not to exist.
+	created, err := db.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: record("a", "a1"), ExpectedVersion: proto.Uint64(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: record("a", "a2"), ExpectedVersion: proto.Uint64(0)}); status.Code(err) != codes.Aborted {
+		t.Errorf("Expected Aborted creating an existing key, got %v", err)
+	}
+	if _, err := db.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: record("a", "a2"), ExpectedVersion: proto.Uint64(created.Version)}); err != nil {
+		t.Errorf("Expected write at the current version to succeed, got %v", err)
+	}
+
+	// A batch with one stale expectation writes nothing.
+	batch := &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{
+		{Record: record("b", "b1")},
+		{Record: record("a", "a3"), ExpectedVersion: proto.Uint64(created.Version)},
+	}}
+	if _, err := db.WriteBatch(ctx, batch); status.Code(err) != codes.Aborted {
+		t.Fatalf("Expected Aborted for a stale batch, got %v", err)
+	}
+	if _, err := db.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: "b"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected no write from an aborted batch, got %v", err)
+	}
+
+	current, _ := db.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: "a"})
+	batch.Operations[1].ExpectedVersion = proto.Uint64(current.Record.Version)
+	batch.Operations = append(batch.Operations, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: "a", Deleted: true}})
+	if _, err := db.WriteBatch(ctx, batch); err != nil {
+		t.Fatalf("Expected batch to succeed, got %v", err)
+	}
+	if _, err := db.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: "a"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected a to be deleted by the batch, got %v", err)
+	}
+	if _, err := db.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: "b"}); err != nil {
+		t.Errorf("Expected b to be written by the batch, got %v", err)
+	}
+}
+
+func TestReviewIndexSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, database := startDatabase(t)
+	cacheAddr := startCache(t)
+
+	// Reviews written before indexes were stored are indexed on first use.
+	legacy := &review.GetReviewResponse{UserName: "Larry Bird", RestaurantName: "Chick-fil-A", Rating: 4}
+	id, _ := services.GetQueryUUID(legacy.RestaurantName, legacy.UserName)
+	data, _ := proto.Marshal(legacy)
+	if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: id, Value: data}}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := services.NewReview("review-0", 0, cacheAddr, databaseAddr, "")
+	second := services.NewReview("review-1", 0, cacheAddr, databaseAddr, "")
+	posts := []*review.PostReviewRequest{
+		{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Review: "Great.", Rating: 5},
+		{UserName: "LeBron James", RestaurantName: "Chick-fil-A", Review: "Good.", Rating: 4},
+		{UserName: "Kobe Bryant", RestaurantName: "In-N-Out Burger", Review: "Fine.", Rating: 3},
+	}
+	for i, post := range posts {
+		replica := first
+		if i%2 == 1 {
+			replica = second
+		}
+		if _, err := replica.PostReview(ctx, post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Both replicas, and one that just started, see every review.
+	restarted := services.NewReview("review-2", 0, cacheAddr, databaseAddr, "")
+	for _, replica := range []*services.Review{first, second, restarted} {
+		search, err := replica.SearchReviews(ctx, &review.SearchReviewsRequest{RestaurantName: "Chick-fil-A"})
+		if err != nil || len(search.ReviewsMap) != 3 {
+			t.Errorf("Expected 3 Chick-fil-A reviews, got %v (err %v)", search.GetReviewsMap(), err)
+		}
+	}
+
+	// Index records are bookkeeping, not reviews, so they aren't exported.
+	count, err := services.ExportRecords(ctx, databaseAddr, "review", "jsonl", io.Discard)
+	if err != nil || count != 4 {
+		t.Errorf("Expected 4 reviews exported, got %d (err %v)", count, err)
+	}
+}
+
+func TestSearchReviewsReadsWrites(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, database := startDatabase(t)
+	cacheAddr := startCache(t)
+	first := services.NewReview("review-0", 0, cacheAddr, databaseAddr, "")
+	second := services.NewReview("review-1", 0, cacheAddr, databaseAddr, "")
+
+	if _, err := first.PostReview(ctx, &review.PostReviewRequest{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Review: "Great.", Rating: 5}); err != nil {
+		t.Fatal(err)
+	}
+	search := func() map[string]*review.GetReviewResponse {
+		t.Helper()
+		reply, err := first.SearchReviews(ctx, &review.SearchReviewsRequest{RestaurantName: "Chick-fil-A"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply.ReviewsMap
+	}
+	search()
+
+	// A search sees every write made before it, through another replica or
+	// straight to the database.
+	id, _ := services.GetQueryUUID("Chick-fil-A", "Michael Jordan")
+	data, _ := proto.Marshal(&review.GetReviewResponse{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Review: "Changed.", Rating: 5})
+	if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: id, Value: data}}); err != nil {
+		t.Fatal(err)
+	}
+	if reviews := search(); reviews["Michael Jordan"].GetReview() != "Changed." {
+		t.Errorf("Expected the review as written, got %v", reviews)
+	}
+	if _, err := second.PostReview(ctx, &review.PostReviewRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A", Review: "Good.", Rating: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if reviews := search(); len(reviews) != 2 || reviews["LeBron James"].GetReview() != "Good." {
+		t.Errorf("Expected both reviews as written, got %v", reviews)
+	}
+	if _, err := second.EditReview(ctx, &review.EditReviewRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A", Review: "Very good."}); err != nil {
+		t.Fatal(err)
+	}
+	if reviews := search(); reviews["LeBron James"].GetReview() != "Very good." {
+		t.Errorf("Expected the edited review, got %v", reviews)
+	}
+	if _, err := second.DeleteRestaurantReviews(ctx, &review.DeleteRestaurantReviewsRequest{RestaurantName: "Chick-fil-A"}); err != nil {
+		t.Fatal(err)
+	}
+	if reviews := search(); len(reviews) != 0 {
+		t.Errorf("Expected no reviews once deleted, got %v", reviews)
+	}
+}
+
+func TestSearchReviewsPages(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, database := startDatabase(t)
+
+	// A review written before reviews were ordered counts as posted when it
+	// was written, so it is the oldest.
+	legacy := &review.GetReviewResponse{UserName: "Larry Bird", RestaurantName: "Chick-fil-A", Review: "Fine", Rating: 3}
+	id, _ := services.GetQueryUUID(legacy.RestaurantName, legacy.UserName)
+	data, _ := proto.Marshal(legacy)
+	if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: id, Value: data}}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := services.NewReview("review", 0, startCache(t), databaseAddr, "")
+	post := func(user string, rating int32) {
+		t.Helper()
+		if _, err := srv.PostReview(ctx, &review.PostReviewRequest{UserName: user, RestaurantName: "Chick-fil-A", Review: "Review", Rating: rating}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	post("Michael Jordan", 5)
+	post("LeBron James", 1)
+	post("Kobe Bryant", 5)
+	post("Tim Duncan", 4)
+
+	// list pages through a restaurant's reviews two at a time.
+	list := func(order review.ReviewSortOrder) []string {
+		t.Helper()
+		var users []string
+		req := &review.SearchReviewsRequest{RestaurantName: "Chick-fil-A", PageSize: 2, SortOrder: order}
+		for {
+			reply, err := srv.SearchReviews(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(reply.ReviewsMap) != 0 || len(reply.Reviews) > 2 {
+				t.Fatalf("Expected a page of at most 2 reviews and no map, got %v", reply)
+			}
+			for _, r := range reply.Reviews {
+				users = append(users, r.UserName)
+			}
+			if reply.NextPageToken == "" {
+				return users
+			}
+			req.PageToken = reply.NextPageToken
+		}
+	}
+	check := func(order review.ReviewSortOrder, want ...string) {
+		t.Helper()
+		if got := list(order); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Expected %v reviews in order %v, got %v", order, want, got)
+		}
+	}
+	check(review.ReviewSortOrder_NEWEST, "Tim Duncan", "Kobe Bryant", "LeBron James", "Michael Jordan", "Larry Bird")
+	check(review.ReviewSortOrder_HIGHEST_RATED, "Kobe Bryant", "Michael Jordan", "Tim Duncan", "Larry Bird", "LeBron James")
+	check(review.ReviewSortOrder_LOWEST_RATED, "LeBron James", "Larry Bird", "Tim Duncan", "Kobe Bryant", "Michael Jordan")
+	check(review.ReviewSortOrder_MOST_HELPFUL, "Tim Duncan", "Kobe Bryant", "LeBron James", "Michael Jordan", "Larry Bird")
+
+	// Reposting moves a review rather than listing it twice.
+	post("LeBron James", 5)
+	check(review.ReviewSortOrder_NEWEST, "LeBron James", "Tim Duncan", "Kobe Bryant", "Michael Jordan", "Larry Bird")
+	check(review.ReviewSortOrder_LOWEST_RATED, "Larry Bird", "Tim Duncan", "LeBron James", "Kobe Bryant", "Michael Jordan")
+
+	// Without a page size every review is returned in the map.
+	reply, err := srv.SearchReviews(ctx, &review.SearchReviewsRequest{RestaurantName: "Chick-fil-A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.ReviewsMap) != 5 || len(reply.Reviews) != 0 || reply.ReviewsMap["LeBron James"].GetRating() != 5 {
+		t.Errorf("Expected every review in the map, got %v", reply)
+	}
+
+	bad := []*review.SearchReviewsRequest{
+		{RestaurantName: "Chick-fil-A", PageSize: -1},
+		{RestaurantName: "Chick-fil-A", PageSize: 2, SortOrder: 9},
+		{RestaurantName: "Chipotle", PageSize: 2, PageToken: reply.ReviewsMap["Larry Bird"].GetRestaurantName()},
+	}
+	for _, req := range bad {
+		if _, err := srv.SearchReviews(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", req, err)
+		}
+	}
+
+	// Deleting the restaurant's reviews empties every ordering.
+	if _, err := srv.DeleteRestaurantReviews(ctx, &review.DeleteRestaurantReviewsRequest{RestaurantName: "Chick-fil-A"}); err != nil {
+		t.Fatal(err)
+	}
+	check(review.ReviewSortOrder_NEWEST)
+	check(review.ReviewSortOrder_MOST_HELPFUL)
+}
@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/review"
+	"cse190-welp/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSearchReviewText(t *testing.T) {
+	ctx := context.Background()
+	databaseAddr, database := startDatabase(t)
+
+	// Reviews written before the text index are indexed on first use.
+	legacy := &review.GetReviewResponse{UserName: "Larry Bird", RestaurantName: "Chick-fil-A", Review: "The fries were crispy and delicious", Rating: 3}
+	id, _ := services.GetQueryUUID(legacy.RestaurantName, legacy.UserName)
+	data, _ := proto.Marshal(legacy)
+	if _, err := database.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: &mydatabase.DatabaseRecord{Key: id, Value: data}}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := services.NewReview("review", 0, startCache(t), databaseAddr, "")
+	post := func(user, restaurant, text string, rating int32) {
+		t.Helper()
+		if _, err := srv.PostReview(ctx, &review.PostReviewRequest{UserName: user, RestaurantName: restaurant, Review: text, Rating: rating}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	post("Michael Jordan", "Chick-fil-A", "Delicious chicken, the best chicken sandwich I have eaten", 5)
+	post("LeBron James", "Chick-fil-A", "Slow service and the chicken was cold", 2)
+	post("Kobe Bryant", "Chipotle", "Delicious burritos <3", 4)
+
+	search := func(req *review.SearchReviewTextRequest) []*review.ReviewTextMatch {
+		t.Helper()
+		reply, err := srv.SearchReviewText(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply.Matches
+	}
+	check := func(req *review.SearchReviewTextRequest, want ...string) {
+		t.Helper()
+		var got []string
+		for _, match := range search(req) {
+			got = append(got, match.Review.UserName)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Expected %v searching %v, got %v", want, req, got)
+		}
+	}
+
+	// Words match their inflections, and reviews using them more rank higher.
+	check(&review.SearchReviewTextRequest{Query: "Chickens"}, "Michael Jordan", "LeBron James")
+	check(&review.SearchReviewTextRequest{Query: "delicious fries"}, "Larry Bird", "Kobe Bryant", "Michael Jordan")
+	check(&review.SearchReviewTextRequest{Query: "delicious", RestaurantName: "Chipotle"}, "Kobe Bryant")
+	check(&review.SearchReviewTextRequest{Query: "delicious", MinRating: 4, MaxRating: 4}, "Kobe Bryant")
+	check(&review.SearchReviewTextRequest{Query: "delicious", MinRating: 5}, "Michael Jordan")
+	check(&review.SearchReviewTextRequest{Query: "delicious", Limit: 1}, "Kobe Bryant")
+	check(&review.SearchReviewTextRequest{Query: "pizza"})
+
+	matches := search(&review.SearchReviewTextRequest{Query: "burrito"})
+	if len(matches) != 1 || matches[0].Snippet != "Delicious <em>burritos</em> &lt;3" || matches[0].Score <= 0 {
+		t.Errorf("Expected a highlighted snippet, got %v", matches)
+	}
+
+	for _, req := range []*review.SearchReviewTextRequest{
+		{Query: "the and"},
+		{Query: "chicken", MinRating: 6},
+		{Query: "chicken", MinRating: 4, MaxRating: 2},
+		{Query: "chicken", Limit: -1},
+	} {
+		if _, err := srv.SearchReviewText(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument searching %v, got %v", req, err)
+		}
+	}
+
+	// Reposting replaces a review's text in the index.
+	post("LeBron James", "Chick-fil-A", "Great service", 4)
+	check(&review.SearchReviewTextRequest{Query: "chicken"}, "Michael Jordan")
+	check(&review.SearchReviewTextRequest{Query: "service"}, "LeBron James")
+
+	// Deleting a restaurant's reviews takes them out of the index, and a
+	// rebuild agrees.
+	if _, err := srv.DeleteRestaurantReviews(ctx, &review.DeleteRestaurantReviewsRequest{RestaurantName: "Chick-fil-A"}); err != nil {
+		t.Fatal(err)
+	}
+	check(&review.SearchReviewTextRequest{Query: "delicious chicken service"}, "Kobe Bryant")
+	if _, err := srv.RebuildLookupTable(ctx, &review.RebuildLookupTableRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	check(&review.SearchReviewTextRequest{Query: "delicious chicken service"}, "Kobe Bryant")
+}
@@ -0,0 +1,133 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"cse190-welp/proto/review"
+	"cse190-welp/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestVoteReviews(t *testing.T) {
+	ctx := context.Background()
+	cacheAddr := startCache(t)
+	databaseAddr, _ := startDatabase(t)
+	first := services.NewReview("review-0", 0, cacheAddr, databaseAddr, "")
+	second := services.NewReview("review-1", 0, cacheAddr, databaseAddr, "")
+
+	for _, post := range []*review.PostReviewRequest{
+		{UserName: "Michael Jordan", RestaurantName: "Chick-fil-A", Review: "Great.", Rating: 5},
+		{UserName: "LeBron James", RestaurantName: "Chick-fil-A", Review: "Good.", Rating: 4},
+	} {
+		if _, err := first.PostReview(ctx, post); err != nil {
+			t.Fatal(err)
+		}
+	}
+	helpful := func(user string) int64 {
+		t.Helper()
+		reply, err := second.GetReview(ctx, &review.GetReviewRequest{UserName: user, RestaurantName: "Chick-fil-A"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply.HelpfulCount
+	}
+	vote := func(voter, user string, retract bool) (*review.VoteReviewResponse, error) {
+		return second.VoteReview(ctx, &review.VoteReviewRequest{UserName: user, RestaurantName: "Chick-fil-A", VoterName: voter, Retract: retract})
+	}
+	helpful("LeBron James") // cached before the votes
+
+	// Concurrent voters on both replicas are each counted once.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			replica := first
+			if i%2 == 1 {
+				replica = second
+			}
+			_, err := replica.VoteReview(ctx, &review.VoteReviewRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A", VoterName: fmt.Sprintf("Voter %d", i)})
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if count := helpful("LeBron James"); count != 10 {
+		t.Errorf("Expected 10 helpful votes, got %d", count)
+	}
+
+	// Each user votes once, and not for their own review.
+	if _, err := vote("Voter 0", "LeBron James", false); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition voting twice, got %v", err)
+	}
+	if _, err := vote("Voter 0", "Michael Jordan", true); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition retracting no vote, got %v", err)
+	}
+	if _, err := vote("Michael Jordan", "Michael Jordan", false); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument voting for one's own review, got %v", err)
+	}
+	if _, err := vote("Voter 0", "Larry Bird", false); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound voting for no review, got %v", err)
+	}
+
+	// A retracted vote can be cast again.
+	if reply, err := vote("Voter 0", "LeBron James", true); err != nil || reply.HelpfulCount != 9 {
+		t.Errorf("Expected 9 helpful votes after retracting, got %v, %v", reply, err)
+	}
+	if reply, err := vote("Voter 0", "LeBron James", false); err != nil || reply.HelpfulCount != 10 {
+		t.Errorf("Expected 10 helpful votes after voting again, got %v, %v", reply, err)
+	}
+
+	// The most helpful review comes first.
+	page, err := first.SearchReviews(ctx, &review.SearchReviewsRequest{RestaurantName: "Chick-fil-A", PageSize: 2, SortOrder: review.ReviewSortOrder_MOST_HELPFUL})
+	if err != nil || len(page.Reviews) != 2 || page.Reviews[0].UserName != "LeBron James" || page.Reviews[0].HelpfulCount != 10 {
+		t.Errorf("Expected LeBron James's review first, got %v, %v", page.GetReviews(), err)
+	}
+
+	// Votes aren't revisions, and an edit keeps them.
+	history, err := first.GetReviewHistory(ctx, &review.GetReviewHistoryRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A"})
+	if err != nil || len(history.Revisions) != 1 {
+		t.Errorf("Expected one revision, got %v, %v", history.GetRevisions(), err)
+	}
+	if _, err := first.EditReview(ctx, &review.EditReviewRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A", Review: "Very good."}); err != nil {
+		t.Fatal(err)
+	}
+	if count := helpful("LeBron James"); count != 10 {
+		t.Errorf("Expected 10 helpful votes after an edit, got %d", count)
+	}
+
+	// A review awaiting moderation can't be voted for.
+	moderated := services.NewReview("review-2", 0, cacheAddr, databaseAddr, "banned-words")
+	held, err := moderated.PostReview(ctx, &review.PostReviewRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A", Review: "This place is SHIT", Rating: 1})
+	if err != nil || !held.Pending {
+		t.Fatalf("Expected the review to be held, got %v, %v", held, err)
+	}
+	if _, err := vote("Voter 10", "LeBron James", false); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound voting for a review awaiting moderation, got %v", err)
+	}
+	if _, err := moderated.RejectReview(ctx, &review.RejectReviewRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A deleted review loses its votes, and is posted again without them.
+	if _, err := first.DeleteReview(ctx, &review.DeleteReviewRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vote("Voter 10", "LeBron James", false); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound voting for a deleted review, got %v", err)
+	}
+	if _, err := first.PostReview(ctx, &review.PostReviewRequest{UserName: "LeBron James", RestaurantName: "Chick-fil-A", Review: "Good again.", Rating: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if count := helpful("LeBron James"); count != 0 {
+		t.Errorf("Expected no helpful votes after posting again, got %d", count)
+	}
+	if reply, err := vote("Voter 0", "LeBron James", false); err != nil || reply.HelpfulCount != 1 {
+		t.Errorf("Expected an earlier voter to vote again, got %v, %v", reply, err)
+	}
+}
@@ -0,0 +1,155 @@
+package services_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apps "cse190-welp/applications"
+	"cse190-welp/proto/mydatabase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestScrubQuarantinesCorruptRecords(t *testing.T) {
+	db := newLocalDatabase("database")
+	ctx := context.Background()
+	set := func(key, value string) uint64 {
+		record := &mydatabase.DatabaseRecord{Key: key, Value: []byte(value)}
+		reply, err := db.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: record})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply.Version
+	}
+	v1 := set("a", "a1")
+	set("a", "a2")
+	set("b", "b1")
+
+	// In-process replies share the stored record, so this flips stored bytes.
+	reply, err := db.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply.Record.Value[0] = 'x'
+
+	if _, err := db.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: "a"}); status.Code(err) != codes.DataLoss {
+		t.Fatalf("Expected DataLoss reading a corrupt record, got %v", err)
+	}
+
+	scrub, err := db.ScrubRecords(ctx, &mydatabase.ScrubRecordsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scrub.Checked != 3 || len(scrub.Quarantined) != 1 || len(scrub.DamagedKeys) != 1 || scrub.DamagedKeys[0] != "a" {
+		t.Fatalf("Expected a's latest version to be quarantined, got %v", scrub)
+	}
+
+	// Quarantined versions aren't served, older snapshots still are.
+	if _, err := db.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: "a"}); status.Code(err) != codes.DataLoss {
+		t.Errorf("Expected DataLoss reading a quarantined record, got %v", err)
+	}
+	old, err := db.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: "a", SnapshotVersion: v1})
+	if err != nil || string(old.Record.Value) != "a1" {
+		t.Errorf("Expected a1 at snapshot %d, got %v (err %v)", v1, old, err)
+	}
+	scan, err := db.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{})
+	if err != nil || len(scan.Records) != 1 || scan.Records[0].Key != "b" {
+		t.Errorf("Expected scans to skip the quarantined record, got %v (err %v)", scan, err)
+	}
+
+	// Writes that arrive corrupted are refused.
+	record := &mydatabase.DatabaseRecord{Key: "c", Value: []byte("c1")}
+	record.Checksum = apps.Checksum(record) + 1
+	if _, err := db.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: record}); status.Code(err) != codes.DataLoss {
+		t.Errorf("Expected DataLoss writing a corrupt record, got %v", err)
+	}
+
+	// Rewriting the key repairs it.
+	set("a", "a2")
+	if _, err := db.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: "a"}); err != nil {
+		t.Errorf("Expected repaired record to be readable, got %v", err)
+	}
+	if scrub, _ := db.ScrubRecords(ctx, &mydatabase.ScrubRecordsRequest{}); len(scrub.DamagedKeys) != 0 {
+		t.Errorf("Expected no damaged keys after repair, got %v", scrub.DamagedKeys)
+	}
+}
+
+func TestPersistentStorageQuarantinesBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	store, err := apps.NewPersistentStorageApp(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("a1")})
+	store.Set(&mydatabase.DatabaseRecord{Key: "b", Value: []byte("b1")})
+	store.Set(&mydatabase.DatabaseRecord{Key: "a", Value: []byte("a2")})
+
+	// Cut the last write short, as a crash would.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)-10], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = apps.NewPersistentStorageApp(path)
+	if err != nil {
+		t.Fatalf("Expected the store to load despite a bad line, got %v", err)
+	}
+	if store.Quarantined() != 1 {
+		t.Errorf("Expected 1 quarantined line, got %d", store.Quarantined())
+	}
+	if record, ok := store.Get("a"); !ok || string(record.Value) != "a1" {
+		t.Errorf("Expected the last intact write of a, got %v", record)
+	}
+	if record, ok := store.Get("b"); !ok || string(record.Value) != "b1" {
+		t.Errorf("Expected b1, got %v", record)
+	}
+	if _, err := os.Stat(apps.QuarantinePath(path)); err != nil {
+		t.Errorf("Expected a quarantine file, got %v", err)
+	}
+
+	// The load compacted the file, so it loads cleanly now.
+	if store, err = apps.NewPersistentStorageApp(path); err != nil || store.Quarantined() != 0 || len(store.Records()) != 2 {
+		t.Errorf("Expected a clean reload with 2 records, got err %v", err)
+	}
+}
+
+func TestPersistentStorageMigratesLegacyFormat(t *testing.T) {
+	// Stores used to be a single JSON object mapping keys to records, without
+	// checksums.
+	path := filepath.Join(t.TempDir(), "store.json")
+	legacy := `{"a":{"key":"a","value":"YTE="},"b":{"key":"b","value":"YjE="}}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := apps.NewPersistentStorageApp(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Quarantined() != 0 || len(store.Records()) != 2 {
+		t.Fatalf("Expected 2 migrated records and none quarantined, got %d and %d", len(store.Records()), store.Quarantined())
+	}
+	if record, ok := store.Get("a"); !ok || string(record.Value) != "a1" || apps.VerifyChecksum(record) != nil {
+		t.Errorf("Expected a1 with a checksum, got %v", record)
+	}
+
+	// The migrated store reloads as it is now written.
+	store.Set(&mydatabase.DatabaseRecord{Key: "c", Value: []byte("c1")})
+	if store, err = apps.NewPersistentStorageApp(path); err != nil || store.Quarantined() != 0 || len(store.Records()) != 3 {
+		t.Errorf("Expected a clean reload with 3 records, got err %v", err)
+	}
+
+	// Only the legacy format is given checksums; lines without one, or with
+	// a zero checksum, are quarantined.
+	lines := `{"key":"c","value":"YzE="}` + "\n" + `{"key":"d","value":"ZDE=","checksum":0}` + "\n"
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if store, err = apps.NewPersistentStorageApp(path); err != nil || store.Quarantined() != 2 || len(store.Records()) != 0 {
+		t.Errorf("Expected both lines without a checksum quarantined, got err %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	apps "cse190-welp/applications"
+	"cse190-welp/proto/detail"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"cse190-welp/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// localDatabase calls a MyDatabase server in-process.
+type localDatabase struct {
+	srv *services.MyDatabase
+}
+
+func newLocalDatabase(name string) *localDatabase {
+	return &localDatabase{srv: services.NewMyDatabase(name, 0, "none", 10, 0)}
+}
+
+func (d *localDatabase) SetRecord(ctx context.Context, in *mydatabase.SetRecordRequest, _ ...grpc.CallOption) (*mydatabase.SetRecordResponse, error) {
+	return d.srv.SetRecord(ctx, in)
+}
+
+func (d *localDatabase) GetRecord(ctx context.Context, in *mydatabase.GetRecordRequest, _ ...grpc.CallOption) (*mydatabase.GetRecordResponse, error) {
+	return d.srv.GetRecord(ctx, in)
+}
+
+func (d *localDatabase) DeleteRecord(ctx context.Context, in *mydatabase.DeleteRecordRequest, _ ...grpc.CallOption) (*mydatabase.DeleteRecordResponse, error) {
+	return d.srv.DeleteRecord(ctx, in)
+}
+
+func (d *localDatabase) GetRecordHistory(ctx context.Context, in *mydatabase.GetRecordHistoryRequest, _ ...grpc.CallOption) (*mydatabase.GetRecordHistoryResponse, error) {
+	return d.srv.GetRecordHistory(ctx, in)
+}
+
+func (d *localDatabase) ScanRecords(ctx context.Context, in *mydatabase.ScanRecordsRequest, _ ...grpc.CallOption) (*mydatabase.ScanRecordsResponse, error) {
+	return d.srv.ScanRecords(ctx, in)
+}
+
+func (d *localDatabase) WriteBatch(ctx context.Context, in *mydatabase.WriteBatchRequest, _ ...grpc.CallOption) (*mydatabase.WriteBatchResponse, error) {
+	return d.srv.WriteBatch(ctx, in)
+}
+
+func (d *localDatabase) ScrubRecords(ctx context.Context, in *mydatabase.ScrubRecordsRequest, _ ...grpc.CallOption) (*mydatabase.ScrubRecordsResponse, error) {
+	return d.srv.ScrubRecords(ctx, in)
+}
+
+func (d *localDatabase) WatchChanges(ctx context.Context, in *mydatabase.WatchChangesRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[mydatabase.ChangeEvent], error) {
+	return nil, status.Error(codes.Unimplemented, "not supported in-process")
+}
+
+// countKeys returns the number of keys stored on a shard.
+func countKeys(t *testing.T, d *localDatabase) int {
+	count, startAfter := 0, ""
+	for {
+		reply, err := d.ScanRecords(context.Background(), &mydatabase.ScanRecordsRequest{StartAfter: startAfter})
+		if err != nil {
+			t.Fatal(err)
+		}
+		count += len(reply.Records)
+		if startAfter = reply.NextStartAfter; startAfter == "" {
+			return count
+		}
+	}
+}
+
+func TestHashRingMovesFewKeys(t *testing.T) {
+	ring := apps.NewHashRing(services.DefaultVirtualNodes)
+	for i := 0; i < 4; i++ {
+		ring.Add(fmt.Sprintf("shard%d", i))
+	}
+	before := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		before[key] = ring.Get(key)
+		counts[before[key]]++
+	}
+	for shard, count := range counts {
+		if count < 1500 || count > 3500 {
+			t.Errorf("Shard %s owns %d of 10000 keys, expected about 2500", shard, count)
+		}
+	}
+
+	ring.Add("shard4")
+	moved := 0
+	for key, owner := range before {
+		if now := ring.Get(key); now != owner {
+			if now != "shard4" {
+				t.Fatalf("Key %s moved between existing shards %s and %s", key, owner, now)
+			}
+			moved++
+		}
+	}
+	if moved < 1000 || moved > 3000 {
+		t.Errorf("Expected about a fifth of the keys to move, %d did", moved)
+	}
+}
+
+func TestShardedDatabaseClient(t *testing.T) {
+	ctx := context.Background()
+	shards := map[string]*localDatabase{"a": newLocalDatabase("a"), "b": newLocalDatabase("b"), "c": newLocalDatabase("c")}
+	router := services.NewShardedDatabaseClient(map[string]mydatabase.DatabaseServiceClient{"a": shards["a"], "b": shards["b"], "c": shards["c"]}, 32)
+
+	for i := 0; i < 500; i++ {
+		record := &mydatabase.DatabaseRecord{Key: fmt.Sprintf("key%d", i), Value: []byte("value")}
+		if _, err := router.SetRecord(ctx, &mydatabase.SetRecordRequest{Record: record}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i < 500; i += 10 {
+		if _, err := router.DeleteRecord(ctx, &mydatabase.DeleteRecordRequest{Key: fmt.Sprintf("key%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Every key lives on one shard, and reads find it there.
+	stored := 0
+	for name, shard := range shards {
+		count := countKeys(t, shard)
+		if count == 0 {
+			t.Errorf("Expected shard %s to own keys", name)
+		}
+		stored += count
+	}
+	if stored != 450 {
+		t.Errorf("Expected 450 keys across shards, found %d", stored)
+	}
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key%d", i)
+		_, err := router.GetRecord(ctx, &mydatabase.GetRecordRequest{Key: key})
+		if i%10 == 1 && status.Code(err) != codes.NotFound {
+			t.Errorf("Expected deleted key %s to be gone, got %v", key, err)
+		} else if i%10 != 1 && err != nil {
+			t.Errorf("Lost key %s: %v", key, err)
+		}
+	}
+
+	// Scans page through the keys of every shard in order.
+	total, startAfter := 0, ""
+	for {
+		reply, err := router.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{StartAfter: startAfter, Limit: 37})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, record := range reply.Records {
+			if record.Key <= startAfter {
+				t.Fatalf("Scan returned %s after %s", record.Key, startAfter)
+			}
+			startAfter = record.Key
+		}
+		total += len(reply.Records)
+		if reply.NextStartAfter == "" {
+			break
+		}
+	}
+	if total != 450 {
+		t.Errorf("Expected 450 keys across shards, scanned %d", total)
+	}
+
+	// A batch can't span shards.
+	var ops []*mydatabase.WriteOperation
+	for i := 0; i < 20; i++ {
+		ops = append(ops, &mydatabase.WriteOperation{Record: &mydatabase.DatabaseRecord{Key: fmt.Sprintf("batch%d", i), Value: []byte("value")}})
+	}
+	if _, err := router.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: ops}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Expected Unimplemented for a batch across shards, got %v", err)
+	}
+}
+
+// piecewiseDatabase is a database that, like a sharded one, can't write
+// several records atomically, and fails every write of a record that isn't
+// internal bookkeeping.
+type piecewiseDatabase struct {
+	*services.MyDatabase
+}
+
+func (d piecewiseDatabase) WriteBatch(ctx context.Context, in *mydatabase.WriteBatchRequest) (*mydatabase.WriteBatchResponse, error) {
+	if len(in.Operations) > 1 {
+		return nil, status.Error(codes.Unimplemented, "Batch spans database shards!")
+	}
+	if !strings.HasPrefix(in.Operations[0].GetRecord().GetKey(), "__") {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+	return d.MyDatabase.WriteBatch(ctx, in)
+}
+
+func TestSeparateWritesUndoGuards(t *testing.T) {
+	ctx := context.Background()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := services.NewMyDatabase("piecewise", 0, "none", 10, 0)
+	server := grpc.NewServer()
+	mydatabase.RegisterDatabaseServiceServer(server, piecewiseDatabase{database})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	detailAddr, details := startDetail(t)
+	if _, err := details.PostDetail(ctx, &detail.PostDetailRequest{RestaurantName: "In-N-Out Burger", Capacity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	srv := services.NewReservation("reservation", 0, startCache(t), lis.Addr().String(), detailAddr, 0)
+
+	// The seat is claimed before the reservation is written, and given back
+	// when the write fails.
+	booking := &reservation.MakeReservationRequest{UserName: "Michael Jordan", RestaurantName: "In-N-Out Burger", Time: future(1), StartTime: noon}
+	if _, err := srv.MakeReservation(ctx, booking); status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected the reservation write to fail with Unavailable, got %v", err)
+	}
+	reply, err := database.ScanRecords(ctx, &mydatabase.ScanRecordsRequest{Prefix: "__booked:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Records) != 0 {
+		t.Errorf("Expected the seats claimed by a failed booking to be given back, got %v", reply.Records)
+	}
+}
@@ -0,0 +1,109 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"cse190-welp/proto/detail"
+	"cse190-welp/proto/mydatabase"
+	"cse190-welp/proto/reservation"
+	"cse190-welp/services"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// violatedFields returns the fields an InvalidArgument error says are
+// invalid, in order.
+func violatedFields(t *testing.T, err error) []string {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+	var fields []string
+	for _, d := range st.Details() {
+		if badRequest, ok := d.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fields = append(fields, violation.GetField())
+			}
+		}
+	}
+	return fields
+}
+
+func TestValidateRequests(t *testing.T) {
+	ctx := context.Background()
+	detailAddr, details := startDetail(t)
+	databaseAddr, database := startDatabase(t)
+
+	// Every invalid field is reported at once.
+	_, err := details.PostDetail(ctx, &detail.PostDetailRequest{
+		Capacity:     -5,
+		OpeningHours: []*detail.OpeningHours{{DayOfWeek: 1, OpensAt: 600, ClosesAt: 500}},
+		Coordinates:  &detail.Coordinates{Latitude: 91},
+	})
+	if fields, want := violatedFields(t, err), []string{"restaurant_name", "capacity", "opening_hours", "coordinates"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected violations of %v, got %v", want, fields)
+	}
+	if _, err := details.GetDetail(ctx, &detail.GetDetailRequest{RestaurantName: "Chick-fil-A"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected the invalid restaurant not to be posted, got %v", err)
+	}
+
+	_, err = details.UpdateDetail(ctx, &detail.UpdateDetailRequest{Detail: &detail.GetDetailResponse{RestaurantName: "Chick-fil-A", Capacity: -1}})
+	if fields, want := violatedFields(t, err), []string{"detail.capacity"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected violations of %v, got %v", want, fields)
+	}
+
+	// Storage requests are checked too.
+	_, err = database.WriteBatch(ctx, &mydatabase.WriteBatchRequest{Operations: []*mydatabase.WriteOperation{
+		{Record: &mydatabase.DatabaseRecord{Key: "a"}},
+		{Record: &mydatabase.DatabaseRecord{}},
+	}})
+	if fields, want := violatedFields(t, err), []string{"operations[1].record.key"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected violations of %v, got %v", want, fields)
+	}
+
+	// Reservations for dates that don't exist never reach the service.
+	reservationPort := freePort(t)
+	go services.NewReservation("reservation", reservationPort, startCache(t), databaseAddr, detailAddr, 0).Run()
+	reservations := reservation.NewReservationServiceClient(connect(t, reservationPort))
+	_, err = reservations.MakeReservation(ctx, &reservation.MakeReservationRequest{
+		UserName:       "Michael Jordan",
+		RestaurantName: "Chick-fil-A",
+		Time:           &reservation.Date{Year: 2030, Month: 13, Day: 0},
+		StartTime:      &reservation.TimeOfDay{Hour: 24},
+	})
+	if fields, want := violatedFields(t, err), []string{"time", "start_time"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected violations of %v, got %v", want, fields)
+	}
+
+	// The frontend returns the violations as JSON with a 400.
+	frontendPort := freePort(t)
+	go services.NewFrontend(frontendPort, detailAddr, detailAddr, detailAddr).Run()
+	url := fmt.Sprintf("http://localhost:%d/post-detail?restaurant_name=Chick-fil-A&location=Atlanta&style=Chicken&capacity=-5", frontendPort)
+	var resp *http.Response
+	eventually(t, "frontend to start", func() bool {
+		resp, err = http.Get(url)
+		return err == nil
+	})
+	defer resp.Body.Close()
+	var body struct {
+		Error           string `json:"error"`
+		FieldViolations []struct {
+			Field       string `json:"field"`
+			Description string `json:"description"`
+		} `json:"field_violations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || resp.Header.Get("Content-Type") != "application/json" || body.Error == "" ||
+		len(body.FieldViolations) != 1 || body.FieldViolations[0].Field != "capacity" || body.FieldViolations[0].Description == "" {
+		t.Errorf("Expected a 400 with a capacity violation, got %d %v", resp.StatusCode, body)
+	}
+}
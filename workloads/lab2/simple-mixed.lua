@@ -73,6 +73,25 @@ local function load_reservation_samples(file_path, t)
     file:close()
 end
 
+-- Reservations can't be made in the past, so move the sample dates to start
+-- tomorrow, keeping the days between them
+local function shift_to_future(t)
+    local earliest
+    for _, sample in ipairs(t) do
+        local date = os.time{year = sample.year, month = sample.month, day = sample.day, hour = 12}
+        if not earliest or date < earliest then
+            earliest = date
+        end
+    end
+    local today = os.date("*t")
+    local tomorrow = os.time{year = today.year, month = today.month, day = today.day + 1, hour = 12}
+    local days = math.floor((tomorrow - earliest) / 86400 + 0.5)
+    for _, sample in ipairs(t) do
+        local date = os.date("*t", os.time{year = sample.year, month = sample.month, day = sample.day + days, hour = 12})
+        sample.year, sample.month, sample.day = date.year, date.month, date.day
+    end
+end
+
 local function load_detail_samples(file_path, t)
     local file = io.open(file_path, "r")
 
@@ -91,6 +110,7 @@ end
 
 load_detail_samples("./samples/detail_samples.csv", detail_samples)
 load_reservation_samples("./samples/reservation_samples.csv", reservation_samples)
+shift_to_future(reservation_samples)
 load_review_samples("./samples/review_samples.csv", review_samples)
 
 local function post_detail()
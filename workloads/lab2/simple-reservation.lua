@@ -30,6 +30,25 @@ urldecode = function(url)
 end
 
 
+-- Reservations can't be made in the past, so move the sample dates to start
+-- tomorrow, keeping the days between them
+local function shift_to_future(t)
+    local earliest
+    for _, sample in ipairs(t) do
+        local date = os.time{year = sample.year, month = sample.month, day = sample.day, hour = 12}
+        if not earliest or date < earliest then
+            earliest = date
+        end
+    end
+    local today = os.date("*t")
+    local tomorrow = os.time{year = today.year, month = today.month, day = today.day + 1, hour = 12}
+    local days = math.floor((tomorrow - earliest) / 86400 + 0.5)
+    for _, sample in ipairs(t) do
+        local date = os.date("*t", os.time{year = sample.year, month = sample.month, day = sample.day + days, hour = 12})
+        sample.year, sample.month, sample.day = date.year, date.month, date.day
+    end
+end
+
 -- Load the data from the CSV file into a table
 local samples = {}
 local file = io.open("./samples/reservation_samples.csv", "r")
@@ -53,6 +72,8 @@ for line in file:lines() do
     end
 end
 
+shift_to_future(samples)
+
 local function make_reservation()
     local method = "GET"
 